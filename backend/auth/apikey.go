@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"slices"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// APIKeyPrefix marks bearer tokens that are API keys rather than sessions
+const APIKeyPrefix = "hk_"
+
+// API key scopes. Sessions are not scoped and may do anything.
+const (
+	ScopeRead    = "read"
+	ScopeWrite   = "write"
+	ScopeExtract = "extract"
+)
+
+var Scopes = []string{ScopeRead, ScopeWrite, ScopeExtract}
+
+// displayPrefixLength is how much of a key is kept in clear so users can tell
+// their keys apart
+const displayPrefixLength = len(APIKeyPrefix) + 8
+
+// NewAPIKey returns a new random API key and the short prefix stored in clear
+// to identify it. Only the key's HashToken digest should be stored.
+func NewAPIKey() (key, displayPrefix string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	key = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:displayPrefixLength], nil
+}
+
+// IsAPIKey reports whether a bearer token is an API key
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// ValidScope reports whether scope is a known API key scope
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+type apiKey struct {
+	uuid   uuid.UUID
+	scopes []string
+}
+
+const apiKeyKey contextKey = "api_key"
+
+// WithAPIKey records that the request was authenticated by an API key
+func WithAPIKey(ctx context.Context, keyUUID uuid.UUID, scopes []string) context.Context {
+	return context.WithValue(ctx, apiKeyKey, apiKey{uuid: keyUUID, scopes: scopes})
+}
+
+// APIKeyFromContext returns the UUID of the API key the request was made with
+func APIKeyFromContext(ctx context.Context) (uuid.UUID, bool) {
+	key, ok := ctx.Value(apiKeyKey).(apiKey)
+	return key.uuid, ok
+}
+
+// HasScope reports whether the request may act within scope. Requests not
+// made with an API key are unrestricted.
+func HasScope(ctx context.Context, scope string) bool {
+	key, ok := ctx.Value(apiKeyKey).(apiKey)
+	if !ok {
+		return true
+	}
+	return slices.Contains(key.scopes, scope)
+}
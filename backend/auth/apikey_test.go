@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gofrs/uuid"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	key, prefix, err := NewAPIKey()
+	if err != nil {
+		t.Fatalf("NewAPIKey failed: %v", err)
+	}
+	if !IsAPIKey(key) {
+		t.Errorf("Expected %q to be recognised as an API key", key)
+	}
+	if !strings.HasPrefix(key, prefix) || len(prefix) >= len(key) {
+		t.Errorf("Expected %q to be a short prefix of %q", prefix, key)
+	}
+
+	other, _, _ := NewAPIKey()
+	if key == other {
+		t.Error("Expected unique keys")
+	}
+}
+
+func TestIsAPIKey(t *testing.T) {
+	sessionToken := SessionToken(uuid.Must(uuid.NewV4()))
+	if IsAPIKey(sessionToken) {
+		t.Errorf("Session token %q mistaken for an API key", sessionToken)
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	ctx := context.Background()
+	for _, scope := range Scopes {
+		if !HasScope(ctx, scope) {
+			t.Errorf("Expected session requests to have %q scope", scope)
+		}
+	}
+
+	ctx = WithAPIKey(ctx, uuid.Must(uuid.NewV4()), []string{ScopeRead})
+	tests := []struct {
+		scope string
+		want  bool
+	}{
+		{ScopeRead, true},
+		{ScopeWrite, false},
+		{ScopeExtract, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			if got := HasScope(ctx, tt.scope); got != tt.want {
+				t.Errorf("HasScope(%q) = %v, want %v", tt.scope, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/gofrs/uuid"
+)
+
+// CookieName is the cookie the session token is stored in for browser clients
+const CookieName = "hungr_session"
+
+// SessionTTL is how long a newly issued session stays valid
+const SessionTTL = 30 * 24 * time.Hour
+
+var ErrInvalidToken = errors.New("invalid session token")
+
+var secret []byte
+
+// Init sets the key used to sign session tokens
+func Init(key string) {
+	secret = []byte(key)
+}
+
+// SessionToken returns the signed token handed to the client for a session.
+// The token is "<session uuid>.<signature>" so it can be verified before
+// touching the database.
+func SessionToken(sessionUUID uuid.UUID) string {
+	payload := sessionUUID.String()
+	return payload + "." + sign(payload)
+}
+
+// SessionExpiry returns the expiry time for a session issued now
+func SessionExpiry() time.Time {
+	return time.Now().Add(SessionTTL)
+}
+
+// ParseSessionToken verifies a token's signature and returns its session UUID
+func ParseSessionToken(token string) (uuid.UUID, error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(sig), []byte(sign(payload))) {
+		return uuid.Nil, ErrInvalidToken
+	}
+	sessionUUID, err := uuid.FromString(payload)
+	if err != nil {
+		return uuid.Nil, ErrInvalidToken
+	}
+	return sessionUUID, nil
+}
+
+func sign(payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// TokenFromRequest returns the session token from the Authorization header,
+// falling back to the session cookie
+func TokenFromRequest(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	if cookie, err := r.Cookie(CookieName); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// SetSessionCookie writes the session cookie for browser clients
+func SetSessionCookie(w http.ResponseWriter, token string, expiresAt time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearSessionCookie removes the session cookie
+func ClearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+type contextKey string
+
+const (
+	userKey    contextKey = "user"
+	sessionKey contextKey = "session"
+)
+
+func WithUser(ctx context.Context, user *models.User) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+// UserFromContext returns the authenticated user, or nil for anonymous requests
+func UserFromContext(ctx context.Context) *models.User {
+	if user, ok := ctx.Value(userKey).(*models.User); ok {
+		return user
+	}
+	return nil
+}
+
+func WithSession(ctx context.Context, sessionUUID uuid.UUID) context.Context {
+	return context.WithValue(ctx, sessionKey, sessionUUID)
+}
+
+// SessionFromContext returns the UUID of the session the request was made with
+func SessionFromContext(ctx context.Context) (uuid.UUID, bool) {
+	sessionUUID, ok := ctx.Value(sessionKey).(uuid.UUID)
+	return sessionUUID, ok
+}
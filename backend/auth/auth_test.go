@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/gofrs/uuid"
+)
+
+func init() {
+	Init("test-secret")
+}
+
+func TestSessionTokenRoundTrip(t *testing.T) {
+	sessionUUID := uuid.Must(uuid.NewV4())
+
+	token := SessionToken(sessionUUID)
+	parsed, err := ParseSessionToken(token)
+	if err != nil {
+		t.Fatalf("ParseSessionToken failed: %v", err)
+	}
+	if parsed != sessionUUID {
+		t.Errorf("Expected %v, got %v", sessionUUID, parsed)
+	}
+}
+
+func TestParseSessionToken_Invalid(t *testing.T) {
+	sessionUUID := uuid.Must(uuid.NewV4())
+	otherUUID := uuid.Must(uuid.NewV4())
+	token := SessionToken(sessionUUID)
+
+	tests := []struct {
+		desc  string
+		token string
+	}{
+		{"empty", ""},
+		{"no signature", sessionUUID.String()},
+		{"tampered payload", otherUUID.String() + token[len(sessionUUID.String()):]},
+		{"tampered signature", token[:len(token)-2] + "xx"},
+		{"not a uuid", "abc." + sign("abc")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if _, err := ParseSessionToken(tt.token); err == nil {
+				t.Errorf("ParseSessionToken(%q) expected error, got nil", tt.token)
+			}
+		})
+	}
+}
+
+func TestParseSessionToken_WrongSecret(t *testing.T) {
+	sessionUUID := uuid.Must(uuid.NewV4())
+	token := SessionToken(sessionUUID)
+
+	Init("other-secret")
+	defer Init("test-secret")
+
+	if _, err := ParseSessionToken(token); err == nil {
+		t.Error("Expected token signed with a different secret to be rejected")
+	}
+}
+
+func TestTokenFromRequest(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/recipes", nil)
+	if got := TokenFromRequest(req); got != "" {
+		t.Errorf("Expected empty token, got %q", got)
+	}
+
+	req = httptest.NewRequest("GET", "/api/recipes", nil)
+	req.Header.Set("Authorization", "Bearer header-token")
+	if got := TokenFromRequest(req); got != "header-token" {
+		t.Errorf("Expected header-token, got %q", got)
+	}
+
+	req = httptest.NewRequest("GET", "/api/recipes", nil)
+	w := httptest.NewRecorder()
+	SetSessionCookie(w, "cookie-token", SessionExpiry())
+	for _, c := range w.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	if got := TokenFromRequest(req); got != "cookie-token" {
+		t.Errorf("Expected cookie-token, got %q", got)
+	}
+}
+
+func TestUserContext(t *testing.T) {
+	ctx := context.Background()
+	if UserFromContext(ctx) != nil {
+		t.Error("Expected nil user for empty context")
+	}
+
+	user := &models.User{UUID: uuid.Must(uuid.NewV4()), Email: "test@example.com"}
+	ctx = WithUser(ctx, user)
+	if got := UserFromContext(ctx); got != user {
+		t.Errorf("Expected %v, got %v", user, got)
+	}
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinPasswordLength is the shortest password accepted at registration or reset
+const MinPasswordLength = 8
+
+// MaxFailedLogins is how many wrong passwords in a row lock an account
+const MaxFailedLogins = 5
+
+// LockoutDuration is how long an account stays locked after MaxFailedLogins
+const LockoutDuration = 15 * time.Minute
+
+// Lifetimes of the one-time tokens sent by email
+const (
+	MagicLinkTTL     = 15 * time.Minute
+	PasswordResetTTL = time.Hour
+	VerifyEmailTTL   = 7 * 24 * time.Hour
+)
+
+const (
+	passwordScheme     = "pbkdf2-sha256"
+	passwordIterations = 600_000
+	passwordSaltLength = 16
+	passwordKeyLength  = 32
+)
+
+var ErrPasswordTooShort = fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+
+var errMalformedHash = errors.New("malformed password hash")
+
+// HashPassword returns an encoded PBKDF2 hash of the password in the form
+// "pbkdf2-sha256$<iterations>$<salt>$<key>"
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", ErrPasswordTooShort
+	}
+	salt := make([]byte, passwordSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return hashPassword(password, salt, passwordIterations)
+}
+
+func hashPassword(password string, salt []byte, iterations int) (string, error) {
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, passwordKeyLength)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{
+		passwordScheme,
+		strconv.Itoa(iterations),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	}, "$"), nil
+}
+
+// CheckPassword reports whether password matches an encoded hash from HashPassword
+func CheckPassword(encoded, password string) bool {
+	salt, iterations, err := parsePasswordHash(encoded)
+	if err != nil {
+		return false
+	}
+	candidate, err := hashPassword(password, salt, iterations)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(candidate), []byte(encoded)) == 1
+}
+
+func parsePasswordHash(encoded string) ([]byte, int, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != passwordScheme {
+		return nil, 0, errMalformedHash
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return nil, 0, errMalformedHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, 0, errMalformedHash
+	}
+	return salt, iterations, nil
+}
+
+// NewToken returns a random URL-safe token for emailed links. Only its
+// HashToken digest should be stored.
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the digest stored in place of a one-time token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashPassword_RoundTrip(t *testing.T) {
+	hash, err := HashPassword("correct horse battery")
+	if err != nil {
+		t.Fatalf("HashPassword failed: %v", err)
+	}
+	if !strings.HasPrefix(hash, "pbkdf2-sha256$") {
+		t.Errorf("Unexpected hash format: %q", hash)
+	}
+	if !CheckPassword(hash, "correct horse battery") {
+		t.Error("Expected correct password to match")
+	}
+	if CheckPassword(hash, "wrong horse battery") {
+		t.Error("Expected wrong password not to match")
+	}
+}
+
+func TestHashPassword_Salted(t *testing.T) {
+	a, _ := HashPassword("same password")
+	b, _ := HashPassword("same password")
+	if a == b {
+		t.Error("Expected different hashes for the same password")
+	}
+}
+
+func TestHashPassword_TooShort(t *testing.T) {
+	if _, err := HashPassword("short"); err != ErrPasswordTooShort {
+		t.Errorf("Expected ErrPasswordTooShort, got %v", err)
+	}
+}
+
+func TestCheckPassword_Malformed(t *testing.T) {
+	tests := []string{
+		"",
+		"plaintext",
+		"md5$1$abc$def",
+		"pbkdf2-sha256$zero$abc$def",
+		"pbkdf2-sha256$1000$!!!$def",
+	}
+
+	for _, encoded := range tests {
+		t.Run(encoded, func(t *testing.T) {
+			if CheckPassword(encoded, "anything") {
+				t.Errorf("CheckPassword(%q) expected false", encoded)
+			}
+		})
+	}
+}
+
+func TestNewToken(t *testing.T) {
+	a, err := NewToken()
+	if err != nil {
+		t.Fatalf("NewToken failed: %v", err)
+	}
+	b, _ := NewToken()
+	if a == b {
+		t.Error("Expected unique tokens")
+	}
+	if HashToken(a) == a {
+		t.Error("Expected HashToken to differ from the token")
+	}
+	if HashToken(a) != HashToken(a) {
+		t.Error("Expected HashToken to be deterministic")
+	}
+}
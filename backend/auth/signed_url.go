@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// SignedURLTTL is the minimum lifetime of a signed URL
+const SignedURLTTL = 24 * time.Hour
+
+// SignedURLExpiry returns an expiry at least SignedURLTTL after now, rounded up
+// to a UTC day boundary. Every URL signed during a day shares the same expiry,
+// so a file's signed URL stays stable and browsers can cache it.
+func SignedURLExpiry(now time.Time) time.Time {
+	return now.UTC().Add(SignedURLTTL).Truncate(24 * time.Hour).Add(24 * time.Hour)
+}
+
+// SignURL appends an expiry and signature to path, granting access to it
+// without a session until expiresAt
+func SignURL(path string, expiresAt time.Time) string {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	return path + "?expires=" + expires + "&sig=" + sign(path+"|"+expires)
+}
+
+// VerifySignedURL reports whether query carries a valid, unexpired signature
+// for path as produced by SignURL
+func VerifySignedURL(path string, query url.Values, now time.Time) bool {
+	expires := query.Get("expires")
+	sig := query.Get("sig")
+	if expires == "" || sig == "" {
+		return false
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() >= unix {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(sign(path+"|"+expires)))
+}
+
+// FeedURL appends a user and signature to path, granting that user access to
+// the calendar feed at path without a session. It doesn't expire, since
+// calendar apps poll a feed for as long as it is subscribed to; instead it is
+// signed with the user's feed key, and giving them a new key revokes it. The
+// feed handler still checks the user may see the feed.
+func FeedURL(path string, userUUID, feedKey uuid.UUID) string {
+	return path + "?user=" + userUUID.String() + "&sig=" + signFeed(path, userUUID, feedKey)
+}
+
+// FeedURLUser returns the user a feed URL says it was signed for, whose feed
+// key VerifyFeedURL needs
+func FeedURLUser(query url.Values) (uuid.UUID, bool) {
+	userUUID, err := uuid.FromString(query.Get("user"))
+	return userUUID, err == nil
+}
+
+// VerifyFeedURL reports whether query carries a valid signature for path, as
+// produced by FeedURL for userUUID with their current feed key
+func VerifyFeedURL(path string, query url.Values, userUUID, feedKey uuid.UUID) bool {
+	sig := query.Get("sig")
+	return query.Get("user") == userUUID.String() && sig != "" &&
+		hmac.Equal([]byte(sig), []byte(signFeed(path, userUUID, feedKey)))
+}
+
+func signFeed(path string, userUUID, feedKey uuid.UUID) string {
+	return sign("feed|" + path + "|" + userUUID.String() + "|" + feedKey.String())
+}
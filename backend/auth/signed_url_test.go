@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+func TestSignedURLExpiry(t *testing.T) {
+	morning := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+	evening := time.Date(2026, 3, 10, 23, 59, 0, 0, time.UTC)
+
+	if !SignedURLExpiry(morning).Equal(SignedURLExpiry(evening)) {
+		t.Error("Expected URLs signed on the same day to share an expiry")
+	}
+
+	for _, now := range []time.Time{morning, evening} {
+		if got := SignedURLExpiry(now); got.Sub(now) < SignedURLTTL {
+			t.Errorf("SignedURLExpiry(%v) = %v, less than %v away", now, got, SignedURLTTL)
+		}
+	}
+}
+
+func TestSignedURLRoundTrip(t *testing.T) {
+	now := time.Now()
+	path := "/api/files/0b3a6c2e-1111-4a5b-9c1d-2e3f4a5b6c7d"
+	signed := SignURL(path, now.Add(time.Hour))
+
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("SignURL returned an invalid URL: %v", err)
+	}
+	if u.Path != path {
+		t.Errorf("Expected path %q, got %q", path, u.Path)
+	}
+	if !VerifySignedURL(u.Path, u.Query(), now) {
+		t.Error("Expected signed URL to verify")
+	}
+}
+
+func TestVerifySignedURL_Invalid(t *testing.T) {
+	now := time.Now()
+	path := "/api/files/0b3a6c2e-1111-4a5b-9c1d-2e3f4a5b6c7d"
+	valid, _ := url.Parse(SignURL(path, now.Add(time.Hour)))
+	expired, _ := url.Parse(SignURL(path, now.Add(-time.Second)))
+
+	tampered := valid.Query()
+	tampered.Set("expires", "9999999999")
+
+	badSig := valid.Query()
+	badSig.Set("sig", strings.Repeat("A", len(badSig.Get("sig"))))
+
+	tests := []struct {
+		desc  string
+		path  string
+		query url.Values
+	}{
+		{"unsigned", path, url.Values{}},
+		{"expired", path, expired.Query()},
+		{"extended expiry", path, tampered},
+		{"bad signature", path, badSig},
+		{"other file", "/api/files/7d6c5b4a-3f2e-4d1c-9b5a-4a3b2c1d0e0f", valid.Query()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if VerifySignedURL(tt.path, tt.query, now) {
+				t.Error("Expected URL to be rejected")
+			}
+		})
+	}
+}
+
+func TestFeedURL(t *testing.T) {
+	path := "/api/meal-plans/0b3a6c2e-1111-4a5b-9c1d-2e3f4a5b6c7d/calendar.ics"
+	user, key := uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4())
+	feed, err := url.Parse(FeedURL(path, user, key))
+	if err != nil {
+		t.Fatalf("FeedURL returned an invalid URL: %v", err)
+	}
+	if got, ok := FeedURLUser(feed.Query()); !ok || got != user {
+		t.Errorf("Expected feed URL to be for %s, got %s, %v", user, got, ok)
+	}
+	if !VerifyFeedURL(feed.Path, feed.Query(), user, key) {
+		t.Errorf("Expected feed URL to verify for %s", user)
+	}
+
+	otherUser := uuid.Must(uuid.NewV4())
+	otherUserQuery := feed.Query()
+	otherUserQuery.Set("user", otherUser.String())
+
+	tests := []struct {
+		desc  string
+		path  string
+		query url.Values
+		user  uuid.UUID
+		key   uuid.UUID
+	}{
+		{"unsigned", path, url.Values{"user": {user.String()}}, user, key},
+		{"other user", path, otherUserQuery, otherUser, key},
+		{"other plan", "/api/meal-plans/7d6c5b4a-3f2e-4d1c-9b5a-4a3b2c1d0e0f/calendar.ics", feed.Query(), user, key},
+		{"regenerated key", path, feed.Query(), user, uuid.Must(uuid.NewV4())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if VerifyFeedURL(tt.path, tt.query, tt.user, tt.key) {
+				t.Error("Expected feed URL to be rejected")
+			}
+		})
+	}
+}
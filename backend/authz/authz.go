@@ -0,0 +1,73 @@
+package authz
+
+import (
+	"os"
+	"strings"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+	"github.com/gofrs/uuid"
+)
+
+// connectionExists reports whether owner shares their recipes with viewer.
+// A variable so tests can stub out the database.
+var connectionExists = func(owner, viewer uuid.UUID) (bool, error) {
+	return storage.ConnectionExists(owner, viewer)
+}
+
+// CanEdit reports whether user may change or delete recipe. Only the owner
+// can; connected friends get read access only.
+func CanEdit(user *models.User, recipe *models.Recipe) bool {
+	return user != nil && user.UUID == recipe.User
+}
+
+// CanView reports whether user may read recipe. Public recipes are readable by
+// anyone, private ones by their owner and by users the owner has connected to.
+func CanView(user *models.User, recipe *models.Recipe) (bool, error) {
+	if recipe.IsPublic {
+		return true, nil
+	}
+	if user == nil {
+		return false, nil
+	}
+	if CanEdit(user, recipe) {
+		return true, nil
+	}
+	return connectionExists(recipe.User, user.UUID)
+}
+
+// CanEditMealPlan reports whether user may change or delete plan. Only the
+// owner can.
+func CanEditMealPlan(user *models.User, plan *models.MealPlan) bool {
+	return user != nil && user.UUID == plan.UserUUID
+}
+
+// CanViewMealPlan reports whether user may see plan: its owner can, and so can
+// the users the owner has connected to once the plan is shared
+func CanViewMealPlan(user *models.User, plan *models.MealPlan) (bool, error) {
+	if user == nil {
+		return false, nil
+	}
+	if CanEditMealPlan(user, plan) {
+		return true, nil
+	}
+	if !plan.Shared {
+		return false, nil
+	}
+	return connectionExists(plan.UserUUID, user.UUID)
+}
+
+// IsAdmin reports whether user may maintain data shared by everyone, like the
+// ingredient catalog. Admins are listed by email, separated by commas, in
+// ADMIN_EMAILS, and must have verified that email.
+func IsAdmin(user *models.User) bool {
+	if user == nil || !user.EmailVerified {
+		return false
+	}
+	for _, email := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if email = strings.TrimSpace(email); email != "" && strings.EqualFold(email, user.Email) {
+			return true
+		}
+	}
+	return false
+}
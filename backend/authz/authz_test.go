@@ -0,0 +1,142 @@
+package authz
+
+import (
+	"testing"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/gofrs/uuid"
+)
+
+var (
+	owner    = &models.User{UUID: uuid.Must(uuid.NewV4()), Email: "owner@example.com"}
+	friend   = &models.User{UUID: uuid.Must(uuid.NewV4()), Email: "friend@example.com"}
+	stranger = &models.User{UUID: uuid.Must(uuid.NewV4()), Email: "stranger@example.com"}
+)
+
+func init() {
+	// owner has shared their recipes with friend, and nobody else
+	connectionExists = func(source, target uuid.UUID) (bool, error) {
+		return source == owner.UUID && target == friend.UUID, nil
+	}
+}
+
+func TestCanEdit(t *testing.T) {
+	private := &models.Recipe{UUID: uuid.Must(uuid.NewV4()), User: owner.UUID}
+	public := &models.Recipe{UUID: uuid.Must(uuid.NewV4()), User: owner.UUID, IsPublic: true}
+
+	tests := []struct {
+		desc   string
+		user   *models.User
+		recipe *models.Recipe
+		want   bool
+	}{
+		{"owner", owner, private, true},
+		{"owner of public recipe", owner, public, true},
+		{"connected friend", friend, private, false},
+		{"connected friend on public recipe", friend, public, false},
+		{"stranger", stranger, private, false},
+		{"stranger on public recipe", stranger, public, false},
+		{"anonymous", nil, private, false},
+		{"anonymous on public recipe", nil, public, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := CanEdit(tt.user, tt.recipe); got != tt.want {
+				t.Errorf("CanEdit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanView(t *testing.T) {
+	private := &models.Recipe{UUID: uuid.Must(uuid.NewV4()), User: owner.UUID}
+	public := &models.Recipe{UUID: uuid.Must(uuid.NewV4()), User: owner.UUID, IsPublic: true}
+	friendsRecipe := &models.Recipe{UUID: uuid.Must(uuid.NewV4()), User: friend.UUID}
+
+	tests := []struct {
+		desc   string
+		user   *models.User
+		recipe *models.Recipe
+		want   bool
+	}{
+		{"owner", owner, private, true},
+		{"connected friend", friend, private, true},
+		{"stranger", stranger, private, false},
+		{"anonymous", nil, private, false},
+		{"stranger on public recipe", stranger, public, true},
+		{"anonymous on public recipe", nil, public, true},
+		{"connections are one way", owner, friendsRecipe, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := CanView(tt.user, tt.recipe)
+			if err != nil {
+				t.Fatalf("CanView() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CanView() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanViewMealPlan(t *testing.T) {
+	private := &models.MealPlan{UUID: uuid.Must(uuid.NewV4()), UserUUID: owner.UUID}
+	shared := &models.MealPlan{UUID: uuid.Must(uuid.NewV4()), UserUUID: owner.UUID, Shared: true}
+
+	tests := []struct {
+		desc     string
+		user     *models.User
+		plan     *models.MealPlan
+		wantView bool
+		wantEdit bool
+	}{
+		{"owner", owner, private, true, true},
+		{"owner of shared plan", owner, shared, true, true},
+		{"connected friend", friend, private, false, false},
+		{"connected friend on shared plan", friend, shared, true, false},
+		{"stranger on shared plan", stranger, shared, false, false},
+		{"anonymous on shared plan", nil, shared, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := CanViewMealPlan(tt.user, tt.plan)
+			if err != nil {
+				t.Fatalf("CanViewMealPlan() error: %v", err)
+			}
+			if got != tt.wantView {
+				t.Errorf("CanViewMealPlan() = %v, want %v", got, tt.wantView)
+			}
+			if got := CanEditMealPlan(tt.user, tt.plan); got != tt.wantEdit {
+				t.Errorf("CanEditMealPlan() = %v, want %v", got, tt.wantEdit)
+			}
+		})
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+	t.Setenv("ADMIN_EMAILS", " Owner@Example.com ,, admin@example.com")
+
+	tests := []struct {
+		desc string
+		user *models.User
+		want bool
+	}{
+		{"listed, in another case", &models.User{UUID: owner.UUID, Email: owner.Email, EmailVerified: true}, true},
+		{"listed, unverified", owner, false},
+		{"not listed", &models.User{UUID: friend.UUID, Email: friend.Email, EmailVerified: true}, false},
+		{"anonymous", nil, false},
+		{"no email", &models.User{UUID: uuid.Must(uuid.NewV4()), EmailVerified: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := IsAdmin(tt.user); got != tt.want {
+				t.Errorf("IsAdmin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
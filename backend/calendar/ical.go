@@ -0,0 +1,105 @@
+// Package calendar writes iCalendar (RFC 5545) feeds, so meal plans show up
+// in calendar apps
+package calendar
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the longest a content line may be before it is folded
+const maxLineOctets = 75
+
+const (
+	dateTimeFormat = "20060102T150405"
+	utcFormat      = "20060102T150405Z"
+)
+
+// Event is a calendar event. Start is a floating time: its clock time is
+// shown as-is in the calendar's own time zone, so dinner at 18:00 stays at
+// 18:00 wherever the calendar is.
+type Event struct {
+	UID         string
+	Start       time.Time
+	Duration    time.Duration
+	Summary     string
+	Description string
+	URL         string
+	// Modified is when the event last changed, in any time zone
+	Modified time.Time
+}
+
+// Calendar is a named list of events
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Encode writes the calendar in iCalendar format
+func (c Calendar) Encode() string {
+	var b strings.Builder
+	line := func(name, value string) {
+		writeLine(&b, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//Hungr//Meal Planner//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escapeText(c.Name))
+	}
+	for _, e := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", e.UID)
+		line("DTSTAMP", e.Modified.UTC().Format(utcFormat))
+		line("LAST-MODIFIED", e.Modified.UTC().Format(utcFormat))
+		line("DTSTART", e.Start.Format(dateTimeFormat))
+		line("DTEND", e.Start.Add(e.Duration).Format(dateTimeFormat))
+		line("SUMMARY", escapeText(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escapeText(e.Description))
+		}
+		if e.URL != "" {
+			line("URL", e.URL)
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return b.String()
+}
+
+// escapeText escapes a TEXT value: backslashes, semicolons, commas and
+// newlines
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
+
+// writeLine writes a content line ending in CRLF, folding it onto
+// continuation lines that start with a space so no line is longer than 75
+// octets. Lines are only folded between characters, never inside one.
+func writeLine(b *strings.Builder, s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// The leading space counts towards the continuation line's length
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,107 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEncode(t *testing.T) {
+	cal := Calendar{
+		Name: "Week 42",
+		Events: []Event{{
+			UID:         "entry-1@hungr",
+			Start:       time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC),
+			Duration:    time.Hour,
+			Summary:     "Dinner: Mac, cheese; peas",
+			Description: "Serves 4\nEnjoy",
+			URL:         "https://hungr.dev/recipe/1",
+			Modified:    time.Date(2026, 10, 16, 9, 30, 0, 0, time.FixedZone("EST", -5*3600)),
+		}},
+	}
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Hungr//Meal Planner//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Week 42",
+		"BEGIN:VEVENT",
+		"UID:entry-1@hungr",
+		"DTSTAMP:20261016T143000Z",
+		"LAST-MODIFIED:20261016T143000Z",
+		"DTSTART:20261019T180000",
+		"DTEND:20261019T190000",
+		`SUMMARY:Dinner: Mac\, cheese\; peas`,
+		`DESCRIPTION:Serves 4\nEnjoy`,
+		"URL:https://hungr.dev/recipe/1",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	if got := cal.Encode(); got != want {
+		t.Errorf("Encode() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{`back\slash`, `back\\slash`},
+		{"a;b,c", `a\;b\,c`},
+		{"one\r\ntwo\nthree", `one\ntwo\nthree`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeText(tt.in); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteLine_Folds(t *testing.T) {
+	tests := []struct {
+		desc string
+		line string
+	}{
+		{"short", "SUMMARY:Dinner"},
+		{"exactly 75 octets", "SUMMARY:" + strings.Repeat("a", 67)},
+		{"long ascii", "DESCRIPTION:" + strings.Repeat("abcdefghij", 20)},
+		{"multibyte", "SUMMARY:" + strings.Repeat("crème brûlée ", 12)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var b strings.Builder
+			writeLine(&b, tt.line)
+			out := b.String()
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("Expected a CRLF line ending, got %q", out)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			for i, l := range lines {
+				if len(l) > maxLineOctets {
+					t.Errorf("Line %d is %d octets: %q", i, len(l), l)
+				}
+				if i > 0 && !strings.HasPrefix(l, " ") {
+					t.Errorf("Continuation line %d doesn't start with a space: %q", i, l)
+				}
+				if !utf8.ValidString(l) {
+					t.Errorf("Line %d splits a character: %q", i, l)
+				}
+			}
+
+			// Unfolding gives back the original line
+			if unfolded := strings.ReplaceAll(strings.TrimSuffix(out, "\r\n"), "\r\n ", ""); unfolded != tt.line {
+				t.Errorf("Unfolded to %q, want %q", unfolded, tt.line)
+			}
+		})
+	}
+}
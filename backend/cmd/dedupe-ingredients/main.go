@@ -0,0 +1,112 @@
+// Command dedupe-ingredients backfills the ingredient catalog: names that are
+// variants of one ingredient ("Eggs", "egg ", "large eggs") are merged into
+// one, which is given its canonical name, and every ingredient is aliased by
+// its canonical key. It is safe to run more than once.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/cobyabrahams/hungr/ingredients"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+)
+
+// group is the catalog rows sharing a canonical key
+type group struct {
+	key     string
+	target  models.IngredientName
+	sources []models.IngredientName
+}
+
+// planMerges groups names by canonical key. Each group keeps a name that is
+// already canonical if it has one, and otherwise its oldest, and the rest are
+// merged into it. Groups come in key order.
+func planMerges(names []models.IngredientName) []group {
+	byKey := map[string][]models.IngredientName{}
+	for _, n := range names {
+		key := ingredients.Key(n.Name)
+		byKey[key] = append(byKey[key], n)
+	}
+
+	var groups []group
+	for key, members := range byKey {
+		slices.SortFunc(members, func(a, b models.IngredientName) int {
+			aCanonical, bCanonical := a.Name == ingredients.Name(a.Name), b.Name == ingredients.Name(b.Name)
+			switch {
+			case aCanonical && !bCanonical:
+				return -1
+			case bCanonical && !aCanonical:
+				return 1
+			}
+			if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+				return c
+			}
+			return strings.Compare(a.Name, b.Name)
+		})
+		groups = append(groups, group{key: key, target: members[0], sources: members[1:]})
+	}
+	slices.SortFunc(groups, func(a, b group) int { return strings.Compare(a.key, b.key) })
+	return groups
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "print the merges without making them")
+	flag.Parse()
+
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		log.Fatal("DATABASE_URL must be set")
+	}
+
+	if err := storage.Init(dbURL); err != nil {
+		log.Fatal("Failed to connect to database: ", err)
+	}
+
+	names, err := storage.ListIngredientNames()
+	if err != nil {
+		log.Fatal("Failed to list ingredients: ", err)
+	}
+
+	merged, renamed := 0, 0
+	for _, g := range planMerges(names) {
+		canonical := ingredients.Name(g.target.Name)
+		for _, source := range g.sources {
+			fmt.Printf("Merging %q into %q\n", source.Name, g.target.Name)
+			if *dryRun {
+				continue
+			}
+			if _, err := storage.MergeIngredientNames(source.UUID, g.target.UUID); err != nil {
+				log.Fatalf("Failed to merge %q into %q: %v", source.Name, g.target.Name, err)
+			}
+			merged++
+		}
+
+		if canonical != g.target.Name {
+			fmt.Printf("Renaming %q to %q\n", g.target.Name, canonical)
+			if !*dryRun {
+				if err := storage.RenameIngredientName(g.target.UUID, canonical); err != nil {
+					log.Fatalf("Failed to rename %q: %v", g.target.Name, err)
+				}
+				renamed++
+			}
+		}
+
+		if !*dryRun {
+			if err := storage.SetIngredientAlias(g.target.Name, g.target.UUID); err != nil {
+				log.Fatalf("Failed to alias %q: %v", g.target.Name, err)
+			}
+		}
+	}
+
+	if *dryRun {
+		fmt.Println("Dry run; nothing was changed")
+		return
+	}
+	fmt.Printf("Merged %d ingredients and renamed %d, leaving %d\n", merged, renamed, len(names)-merged)
+}
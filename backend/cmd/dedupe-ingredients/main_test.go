@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/gofrs/uuid"
+)
+
+func TestPlanMerges(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+	name := func(n string, created time.Time) models.IngredientName {
+		return models.IngredientName{UUID: uuid.Must(uuid.NewV4()), Name: n, CreatedAt: created}
+	}
+
+	groups := planMerges([]models.IngredientName{
+		name("Eggs", day(1)),
+		name("large eggs", day(2)),
+		name("eggs", day(3)),
+		name("egg ", day(4)),
+		name("salt", day(1)),
+		name("Tomatoes", day(2)),
+		name("Large Tomatoes", day(1)),
+	})
+
+	want := []struct {
+		key     string
+		target  string
+		sources []string
+	}{
+		{"egg", "eggs", []string{"Eggs", "large eggs", "egg "}},
+		{"salt", "salt", nil},
+		{"tomato", "Large Tomatoes", []string{"Tomatoes"}},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("Expected %d groups, got %+v", len(want), groups)
+	}
+	for i, w := range want {
+		g := groups[i]
+		var sources []string
+		for _, s := range g.sources {
+			sources = append(sources, s.Name)
+		}
+		if g.key != w.key || g.target.Name != w.target || len(sources) != len(w.sources) {
+			t.Errorf("Group %d: expected %s into %q from %q, got %s into %q from %q", i, w.key, w.target, w.sources, g.key, g.target.Name, sources)
+			continue
+		}
+		for j := range sources {
+			if sources[j] != w.sources[j] {
+				t.Errorf("Group %d: expected sources %q, got %q", i, w.sources, sources)
+				break
+			}
+		}
+	}
+}
@@ -7,11 +7,15 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/cobyabrahams/hungr/auth"
 	"github.com/cobyabrahams/hungr/storage"
 	"github.com/cobyabrahams/hungr/units"
 )
 
-const testUserEmail = "coby@hungr.com"
+const (
+	testUserEmail    = "coby@hungr.com"
+	testUserPassword = "hungr-dev-password"
+)
 
 type recipeStep struct {
 	instruction string
@@ -138,15 +142,24 @@ func main() {
 	fmt.Println("Seeding database...")
 
 	// Create test user if not exists
-	_, err := storage.GetUserByEmail(testUserEmail)
+	user, err := storage.GetUserByEmail(testUserEmail)
 	if err != nil {
-		_, err = storage.CreateUser(testUserEmail, "Test User")
+		user, err = storage.CreateUser(testUserEmail, "Test User")
 		if err != nil {
 			log.Fatal("Failed to create test user: ", err)
 		}
 		fmt.Println("Created test user:", testUserEmail)
 	}
 
+	passwordHash, err := auth.HashPassword(testUserPassword)
+	if err != nil {
+		log.Fatal("Failed to hash test user password: ", err)
+	}
+	if err := storage.SetUserPassword(user.UUID, passwordHash); err != nil {
+		log.Fatal("Failed to set test user password: ", err)
+	}
+	fmt.Println("Test user password:", testUserPassword)
+
 	for _, r := range recipes {
 		recipe, err := storage.InsertRecipeByEmail(r.name, testUserEmail, nil)
 		if err != nil {
@@ -208,6 +221,10 @@ func main() {
 				fmt.Printf("    Added %d step(s)\n", len(steps))
 			}
 		}
+
+		if _, err := storage.RecordRevision(recipe.UUID, user.UUID); err != nil {
+			log.Printf("    Failed to record revision: %v", err)
+		}
 	}
 
 	fmt.Printf("\nDone! Test user email: %s\n", testUserEmail)
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cobyabrahams/hungr/auth"
+	"github.com/cobyabrahams/hungr/logger"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+	"github.com/gofrs/uuid"
+)
+
+// CreateAPIKey issues a new API key for the signed-in user. The key itself is
+// only returned in this response; afterwards only its prefix is shown.
+func CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireSessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	if len(req.Scopes) == 0 {
+		req.Scopes = []string{auth.ScopeRead}
+	}
+	for _, scope := range req.Scopes {
+		if !auth.ValidScope(scope) {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid scope %q", scope))
+			return
+		}
+	}
+
+	key, prefix, err := auth.NewAPIKey()
+	if err != nil {
+		logger.Error(ctx, "failed to generate api key", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to create api key")
+		return
+	}
+
+	apiKey, err := storage.CreateAPIKey(user.UUID, req.Name, prefix, auth.HashToken(key), req.Scopes)
+	if err != nil {
+		logger.Error(ctx, "failed to create api key", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to create api key")
+		return
+	}
+
+	logger.Info(ctx, "api key created", "user_uuid", user.UUID, "api_key_uuid", apiKey.UUID, "scopes", req.Scopes)
+	response := models.CreateAPIKeyResponse{
+		Success: true,
+		APIKey:  *apiKey,
+		Key:     key,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireSessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := storage.GetAPIKeysByUser(user.UUID)
+	if err != nil {
+		logger.Error(ctx, "failed to get api keys", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get api keys")
+		return
+	}
+
+	response := models.APIKeysResponse{
+		Success: true,
+		APIKeys: keys,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireSessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	keyUUIDStr := r.URL.Query().Get("uuid")
+	if keyUUIDStr == "" {
+		respondWithError(w, http.StatusBadRequest, "uuid is required")
+		return
+	}
+
+	keyUUID, err := uuid.FromString(keyUUIDStr)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	revoked, err := storage.RevokeAPIKey(keyUUID, user.UUID)
+	if err != nil {
+		logger.Error(ctx, "failed to revoke api key", err, "api_key_uuid", keyUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to revoke api key")
+		return
+	}
+	if !revoked {
+		respondWithError(w, http.StatusNotFound, "api key not found")
+		return
+	}
+
+	logger.Info(ctx, "api key revoked", "user_uuid", user.UUID, "api_key_uuid", keyUUID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cobyabrahams/hungr/auth"
+	"github.com/cobyabrahams/hungr/middleware"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+	"github.com/gofrs/uuid"
+)
+
+// createAPIKey creates a key for testEmail and returns the response
+func createAPIKey(t *testing.T, body string) models.CreateAPIKeyResponse {
+	req := asUser(t, httptest.NewRequest("POST", "/api/api-keys", bytes.NewBufferString(body)), testEmail)
+	w := httptest.NewRecorder()
+
+	CreateAPIKey(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var response models.CreateAPIKeyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return response
+}
+
+func TestCreateAPIKey_ListAndRevoke(t *testing.T) {
+	ensureTestUser(t)
+	user, _ := storage.GetUserByEmail(testEmail)
+
+	created := createAPIKey(t, `{"name": "backups", "scopes": ["read", "write"]}`)
+	if !auth.IsAPIKey(created.Key) {
+		t.Fatalf("Expected an hk_ key, got %q", created.Key)
+	}
+	if !strings.HasPrefix(created.Key, created.APIKey.Prefix) {
+		t.Errorf("Expected prefix %q to match key", created.APIKey.Prefix)
+	}
+
+	listReq := asUser(t, httptest.NewRequest("GET", "/api/api-keys", nil), testEmail)
+	listW := httptest.NewRecorder()
+	GetAPIKeys(listW, listReq)
+
+	var listed models.APIKeysResponse
+	if err := json.NewDecoder(listW.Result().Body).Decode(&listed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	found := false
+	for _, k := range listed.APIKeys {
+		found = found || k.UUID == created.APIKey.UUID
+	}
+	if !found {
+		t.Error("Expected new key in list")
+	}
+	if strings.Contains(listW.Body.String(), created.Key) {
+		t.Error("Listing must not expose the full key")
+	}
+
+	revokeReq := asUser(t, httptest.NewRequest("DELETE", "/api/api-keys?uuid="+created.APIKey.UUID.String(), nil), testEmail)
+	revokeW := httptest.NewRecorder()
+	RevokeAPIKey(revokeW, revokeReq)
+
+	if revokeW.Result().StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", revokeW.Result().StatusCode)
+	}
+	if _, _, err := storage.GetUserByAPIKey(auth.HashToken(created.Key)); err == nil {
+		t.Error("Expected revoked key to stop working")
+	}
+
+	// Revoking someone else's (or an unknown) key is a 404
+	storage.RevokeAPIKey(created.APIKey.UUID, user.UUID)
+	againReq := asUser(t, httptest.NewRequest("DELETE", "/api/api-keys?uuid="+uuid.Must(uuid.NewV4()).String(), nil), testEmail)
+	againW := httptest.NewRecorder()
+	RevokeAPIKey(againW, againReq)
+	if againW.Result().StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", againW.Result().StatusCode)
+	}
+}
+
+func TestCreateAPIKey_Validation(t *testing.T) {
+	ensureTestUser(t)
+
+	tests := []struct {
+		desc string
+		body string
+	}{
+		{"missing name", `{"scopes": ["read"]}`},
+		{"unknown scope", `{"name": "x", "scopes": ["admin"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := asUser(t, httptest.NewRequest("POST", "/api/api-keys", bytes.NewBufferString(tt.body)), testEmail)
+			w := httptest.NewRecorder()
+
+			CreateAPIKey(w, req)
+
+			if w.Result().StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestAPIKey_Scopes(t *testing.T) {
+	ensureTestUser(t)
+	created := createAPIKey(t, `{"name": "read only"}`)
+	user, _ := storage.GetUserByEmail(testEmail)
+	defer storage.RevokeAPIKey(created.APIKey.UUID, user.UUID)
+
+	tests := []struct {
+		desc    string
+		method  string
+		path    string
+		handler http.HandlerFunc
+		want    int
+	}{
+		{"read recipes", "GET", "/api/recipes", GetRecipes, http.StatusOK},
+		{"create recipe", "POST", "/api/recipes?name=nope", CreateRecipe, http.StatusForbidden},
+		{"extract", "POST", "/api/extract-recipe", ExtractRecipe, http.StatusForbidden},
+		{"manage api keys", "GET", "/api/api-keys", GetAPIKeys, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+created.Key)
+			w := httptest.NewRecorder()
+
+			middleware.Authenticate(tt.handler)(w, req)
+
+			if w.Result().StatusCode != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, w.Result().StatusCode, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPIKey_Invalid(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/recipes", nil)
+	req.Header.Set("Authorization", "Bearer hk_not-a-real-key")
+	w := httptest.NewRecorder()
+
+	middleware.Authenticate(GetRecipes)(w, req)
+
+	if w.Result().StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", w.Result().StatusCode)
+	}
+}
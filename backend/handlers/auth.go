@@ -0,0 +1,655 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cobyabrahams/hungr/auth"
+	"github.com/cobyabrahams/hungr/authz"
+	"github.com/cobyabrahams/hungr/logger"
+	"github.com/cobyabrahams/hungr/mail"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+	"github.com/gofrs/uuid"
+)
+
+// requireUser returns the authenticated user for the request, responding with
+// 401 if the request has no valid session
+func requireUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	user := auth.UserFromContext(r.Context())
+	if user == nil {
+		respondWithError(w, http.StatusUnauthorized, "authentication required")
+		return nil, false
+	}
+	return user, true
+}
+
+// requireSessionUser is requireUser for account management endpoints, which
+// API keys may not use
+func requireSessionUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return nil, false
+	}
+	if _, isAPIKey := auth.APIKeyFromContext(r.Context()); isAPIKey {
+		respondWithError(w, http.StatusForbidden, "this endpoint requires a session, not an api key")
+		return nil, false
+	}
+	return user, true
+}
+
+// requireAdmin is requireSessionUser for endpoints that change data shared by
+// everyone, responding with 403 unless the caller is an admin
+func requireAdmin(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	user, ok := requireSessionUser(w, r)
+	if !ok {
+		return nil, false
+	}
+	if !authz.IsAdmin(user) {
+		logger.Info(r.Context(), "admin access forbidden", "user_uuid", user.UUID)
+		respondWithError(w, http.StatusForbidden, "admin access required")
+		return nil, false
+	}
+	return user, true
+}
+
+// editableRecipe loads a recipe the caller is allowed to modify, responding
+// with 401, 404 or 403 if they are anonymous, it doesn't exist, or it isn't theirs
+func editableRecipe(w http.ResponseWriter, r *http.Request, recipeUUID uuid.UUID) (*models.Recipe, bool) {
+	ctx := r.Context()
+	user, ok := requireUser(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	recipe, ok := loadRecipe(w, r, recipeUUID)
+	if !ok {
+		return nil, false
+	}
+
+	if !authz.CanEdit(user, recipe) {
+		logger.Info(ctx, "recipe edit forbidden", "recipe_uuid", recipeUUID, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusForbidden, "you do not have permission to modify this recipe")
+		return nil, false
+	}
+	return recipe, true
+}
+
+// viewableRecipe loads a recipe the caller is allowed to read. Anonymous
+// callers get 401 for private recipes and signed-in callers without access get 403.
+func viewableRecipe(w http.ResponseWriter, r *http.Request, recipeUUID uuid.UUID) (*models.Recipe, bool) {
+	recipe, ok := loadRecipe(w, r, recipeUUID)
+	if !ok {
+		return nil, false
+	}
+	if !authorizeView(w, r, recipe) {
+		return nil, false
+	}
+	return recipe, true
+}
+
+// authorizeView checks the caller may read recipe, responding with 401 or 403
+// if not
+func authorizeView(w http.ResponseWriter, r *http.Request, recipe *models.Recipe) bool {
+	ctx := r.Context()
+	user := auth.UserFromContext(ctx)
+	allowed, err := authz.CanView(user, recipe)
+	if err != nil {
+		logger.Error(ctx, "failed to check recipe access", err, "recipe_uuid", recipe.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get recipe")
+		return false
+	}
+	if !allowed {
+		if user == nil {
+			respondWithError(w, http.StatusUnauthorized, "authentication required")
+		} else {
+			respondWithError(w, http.StatusForbidden, "you do not have permission to view this recipe")
+		}
+		return false
+	}
+	return true
+}
+
+func loadRecipe(w http.ResponseWriter, r *http.Request, recipeUUID uuid.UUID) (*models.Recipe, bool) {
+	recipe, err := storage.GetRecipeByUUID(recipeUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "recipe not found")
+			return nil, false
+		}
+		logger.Error(r.Context(), "failed to get recipe", err, "recipe_uuid", recipeUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get recipe")
+		return nil, false
+	}
+	return recipe, true
+}
+
+func Login(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req models.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.Email == "" || req.Password == "" {
+		respondWithError(w, http.StatusBadRequest, "email and password are required")
+		return
+	}
+
+	user, creds, err := storage.GetCredentialsByEmail(req.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Spend the same time as a real check so response timing doesn't
+		// reveal which emails have accounts
+		auth.CheckPassword(dummyPasswordHash(), req.Password)
+		respondWithError(w, http.StatusUnauthorized, "invalid email or password")
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "failed to get credentials", err, "email", req.Email)
+		respondWithError(w, http.StatusInternalServerError, "login failed")
+		return
+	}
+
+	if creds.LockedUntil != nil && creds.LockedUntil.After(time.Now()) {
+		// Answer exactly like a wrong password, hash included, so a lockout
+		// doesn't confirm that the account exists
+		auth.CheckPassword(dummyPasswordHash(), req.Password)
+		respondWithError(w, http.StatusUnauthorized, "invalid email or password")
+		return
+	}
+
+	if creds.PasswordHash == nil || !auth.CheckPassword(*creds.PasswordHash, req.Password) {
+		lockedUntil, err := storage.RecordFailedLogin(user.UUID, auth.MaxFailedLogins, time.Now().Add(auth.LockoutDuration))
+		if err != nil {
+			logger.Error(ctx, "failed to record failed login", err, "user_uuid", user.UUID)
+		}
+		if lockedUntil != nil && lockedUntil.After(time.Now()) {
+			logger.Info(ctx, "account locked after failed logins", "user_uuid", user.UUID)
+		}
+		respondWithError(w, http.StatusUnauthorized, "invalid email or password")
+		return
+	}
+
+	if err := storage.RecordSuccessfulLogin(user.UUID); err != nil {
+		logger.Error(ctx, "failed to record login", err, "user_uuid", user.UUID)
+	}
+
+	startSession(w, r, user)
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+func dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = auth.HashPassword("not-a-real-password")
+	})
+	return dummyHash
+}
+
+func Register(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req models.RegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.Email == "" || req.Password == "" {
+		respondWithError(w, http.StatusBadRequest, "email and password are required")
+		return
+	}
+	if req.Name == "" {
+		req.Name = req.Email
+	}
+
+	passwordHash, err := auth.HashPassword(req.Password)
+	if errors.Is(err, auth.ErrPasswordTooShort) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "failed to hash password", err)
+		respondWithError(w, http.StatusInternalServerError, "registration failed")
+		return
+	}
+
+	existingUser, _ := storage.GetUserByEmail(req.Email)
+	if existingUser != nil {
+		respondWithError(w, http.StatusConflict, "user with this email already exists")
+		return
+	}
+
+	user, err := storage.CreateUserWithPassword(req.Email, req.Name, passwordHash)
+	if err != nil {
+		logger.Error(ctx, "failed to create user", err, "email", req.Email)
+		respondWithError(w, http.StatusInternalServerError, "registration failed")
+		return
+	}
+
+	logger.Info(ctx, "user registered", "user_uuid", user.UUID)
+	if err := sendVerificationEmail(ctx, user); err != nil {
+		logger.Error(ctx, "failed to send verification email", err, "user_uuid", user.UUID)
+	}
+
+	startSession(w, r, user)
+}
+
+// RequestMagicLink emails a one-time login link. It responds the same way
+// whether or not the email has an account.
+func RequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	requestEmailToken(w, r, storage.TokenMagicLink, auth.MagicLinkTTL,
+		"Your Hungr login link", "/login/magic",
+		"Use this link to log in to Hungr. It expires in 15 minutes and can only be used once.")
+}
+
+func VerifyMagicLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := consumeEmailToken(w, r, storage.TokenMagicLink)
+	if !ok {
+		return
+	}
+
+	// Following the link proves the user controls the address
+	if err := storage.MarkEmailVerified(user.UUID); err != nil {
+		logger.Error(ctx, "failed to mark email verified", err, "user_uuid", user.UUID)
+	} else {
+		user.EmailVerified = true
+	}
+	if err := storage.RecordSuccessfulLogin(user.UUID); err != nil {
+		logger.Error(ctx, "failed to record login", err, "user_uuid", user.UUID)
+	}
+
+	startSession(w, r, user)
+}
+
+// RequestPasswordReset emails a password reset link. It responds the same
+// way whether or not the email has an account.
+func RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	requestEmailToken(w, r, storage.TokenPasswordReset, auth.PasswordResetTTL,
+		"Reset your Hungr password", "/reset-password",
+		"Use this link to choose a new Hungr password. It expires in 1 hour. If you didn't ask for this, you can ignore this email.")
+}
+
+// ResetPassword sets a new password from a reset token and signs out every
+// existing session
+func ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req models.PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.Token == "" || req.Password == "" {
+		respondWithError(w, http.StatusBadRequest, "token and password are required")
+		return
+	}
+
+	passwordHash, err := auth.HashPassword(req.Password)
+	if errors.Is(err, auth.ErrPasswordTooShort) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "failed to hash password", err)
+		respondWithError(w, http.StatusInternalServerError, "password reset failed")
+		return
+	}
+
+	user, err := storage.ConsumeAuthToken(storage.TokenPasswordReset, auth.HashToken(req.Token))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusUnauthorized, "invalid or expired token")
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "failed to consume reset token", err)
+		respondWithError(w, http.StatusInternalServerError, "password reset failed")
+		return
+	}
+
+	if err := storage.SetUserPassword(user.UUID, passwordHash); err != nil {
+		logger.Error(ctx, "failed to set password", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "password reset failed")
+		return
+	}
+	if err := storage.RevokeUserSessions(user.UUID); err != nil {
+		logger.Error(ctx, "failed to revoke sessions", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "password reset failed")
+		return
+	}
+	if err := storage.MarkEmailVerified(user.UUID); err != nil {
+		logger.Error(ctx, "failed to mark email verified", err, "user_uuid", user.UUID)
+	} else {
+		user.EmailVerified = true
+	}
+
+	logger.Info(ctx, "password reset", "user_uuid", user.UUID)
+	startSession(w, r, user)
+}
+
+// ChangePassword sets the signed-in user's password. The current password is
+// required if one is already set.
+func ChangePassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireSessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	_, creds, err := storage.GetCredentialsByEmail(user.Email)
+	if err != nil {
+		logger.Error(ctx, "failed to get credentials", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to change password")
+		return
+	}
+
+	if creds.PasswordHash != nil && !auth.CheckPassword(*creds.PasswordHash, req.CurrentPassword) {
+		respondWithError(w, http.StatusForbidden, "current password is incorrect")
+		return
+	}
+
+	passwordHash, err := auth.HashPassword(req.NewPassword)
+	if errors.Is(err, auth.ErrPasswordTooShort) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "failed to hash password", err)
+		respondWithError(w, http.StatusInternalServerError, "failed to change password")
+		return
+	}
+
+	if err := storage.SetUserPassword(user.UUID, passwordHash); err != nil {
+		logger.Error(ctx, "failed to set password", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to change password")
+		return
+	}
+
+	logger.Info(ctx, "password changed", "user_uuid", user.UUID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+func VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := consumeEmailToken(w, r, storage.TokenVerifyEmail)
+	if !ok {
+		return
+	}
+
+	if err := storage.MarkEmailVerified(user.UUID); err != nil {
+		logger.Error(ctx, "failed to mark email verified", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to verify email")
+		return
+	}
+	user.EmailVerified = true
+
+	logger.Info(ctx, "email verified", "user_uuid", user.UUID)
+	response := models.UserResponse{
+		Success: true,
+		User:    *user,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ResendVerification emails a new verification link to the signed-in user
+func ResendVerification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireSessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	if user.EmailVerified {
+		respondWithError(w, http.StatusBadRequest, "email is already verified")
+		return
+	}
+
+	if err := sendVerificationEmail(ctx, user); err != nil {
+		logger.Error(ctx, "failed to send verification email", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to send verification email")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+func sendVerificationEmail(ctx context.Context, user *models.User) error {
+	return sendTokenEmail(ctx, user, storage.TokenVerifyEmail, auth.VerifyEmailTTL,
+		"Verify your Hungr email", "/verify-email",
+		"Use this link to confirm your email address for Hungr.")
+}
+
+// requestEmailToken handles the "email me a link" endpoints. Unknown emails get
+// the same response as known ones so the endpoint can't be used to find accounts.
+func requestEmailToken(w http.ResponseWriter, r *http.Request, purpose string, ttl time.Duration, subject, path, intro string) {
+	ctx := r.Context()
+	var req models.EmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.Email == "" {
+		respondWithError(w, http.StatusBadRequest, "email is required")
+		return
+	}
+
+	user, err := storage.GetUserByEmail(req.Email)
+	if err == nil {
+		if err := sendTokenEmail(ctx, user, purpose, ttl, subject, path, intro); err != nil {
+			logger.Error(ctx, "failed to send email", err, "user_uuid", user.UUID, "purpose", purpose)
+			respondWithError(w, http.StatusInternalServerError, "failed to send email")
+			return
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		logger.Error(ctx, "failed to get user", err, "email", req.Email)
+		respondWithError(w, http.StatusInternalServerError, "failed to send email")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// consumeEmailToken redeems the token in the request body, responding with
+// 401 if it is unknown, expired or already used
+func consumeEmailToken(w http.ResponseWriter, r *http.Request, purpose string) (*models.User, bool) {
+	ctx := r.Context()
+	var req models.TokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return nil, false
+	}
+
+	if req.Token == "" {
+		respondWithError(w, http.StatusBadRequest, "token is required")
+		return nil, false
+	}
+
+	user, err := storage.ConsumeAuthToken(purpose, auth.HashToken(req.Token))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusUnauthorized, "invalid or expired token")
+		return nil, false
+	}
+	if err != nil {
+		logger.Error(ctx, "failed to consume token", err, "purpose", purpose)
+		respondWithError(w, http.StatusInternalServerError, "failed to verify token")
+		return nil, false
+	}
+	return user, true
+}
+
+// sendTokenEmail stores a new one-time token and emails the user a link to
+// path on the frontend carrying it
+func sendTokenEmail(ctx context.Context, user *models.User, purpose string, ttl time.Duration, subject, path, intro string) error {
+	token, err := auth.NewToken()
+	if err != nil {
+		return err
+	}
+	if err := storage.CreateAuthToken(user.UUID, purpose, auth.HashToken(token), time.Now().Add(ttl)); err != nil {
+		return err
+	}
+
+	link := appBaseURL() + path + "?token=" + url.QueryEscape(token)
+	return mail.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body:    intro + "\n\n" + link + "\n",
+	})
+}
+
+// appBaseURL is the frontend origin used in emailed links
+func appBaseURL() string {
+	if base := os.Getenv("APP_BASE_URL"); base != "" {
+		return strings.TrimRight(base, "/")
+	}
+	return "http://localhost:5173"
+}
+
+// startSession issues a new session for the user and writes the login response
+func startSession(w http.ResponseWriter, r *http.Request, user *models.User) {
+	ctx := r.Context()
+
+	session, err := storage.CreateSession(user.UUID, r.UserAgent(), auth.SessionExpiry())
+	if err != nil {
+		logger.Error(ctx, "failed to create session", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "login failed")
+		return
+	}
+
+	token := auth.SessionToken(session.UUID)
+	auth.SetSessionCookie(w, token, session.ExpiresAt)
+
+	logger.Info(ctx, "user logged in", "user_uuid", user.UUID, "session_uuid", session.UUID)
+	response := models.LoginResponse{
+		Success:   true,
+		User:      *user,
+		Token:     token,
+		ExpiresAt: session.ExpiresAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func Logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireSessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	sessionUUID, ok := auth.SessionFromContext(ctx)
+	if ok {
+		if _, err := storage.RevokeSession(sessionUUID, user.UUID); err != nil {
+			logger.Error(ctx, "failed to revoke session", err, "session_uuid", sessionUUID)
+			respondWithError(w, http.StatusInternalServerError, "logout failed")
+			return
+		}
+	}
+
+	auth.ClearSessionCookie(w)
+	logger.Info(ctx, "user logged out", "user_uuid", user.UUID, "session_uuid", sessionUUID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+func GetSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireSessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := storage.GetSessionsByUser(user.UUID)
+	if err != nil {
+		logger.Error(ctx, "failed to get sessions", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get sessions")
+		return
+	}
+
+	currentUUID, _ := auth.SessionFromContext(ctx)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].UUID == currentUUID
+	}
+
+	response := models.SessionsResponse{
+		Success:  true,
+		Sessions: sessions,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RevokeSession revokes one of the caller's sessions (?uuid=), or all of them
+// with ?all=true
+func RevokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireSessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	if r.URL.Query().Get("all") == "true" {
+		if err := storage.RevokeUserSessions(user.UUID); err != nil {
+			logger.Error(ctx, "failed to revoke sessions", err, "user_uuid", user.UUID)
+			respondWithError(w, http.StatusInternalServerError, "failed to revoke sessions")
+			return
+		}
+		auth.ClearSessionCookie(w)
+		logger.Info(ctx, "all sessions revoked", "user_uuid", user.UUID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]bool{"success": true})
+		return
+	}
+
+	sessionUUIDStr := r.URL.Query().Get("uuid")
+	if sessionUUIDStr == "" {
+		respondWithError(w, http.StatusBadRequest, "uuid is required")
+		return
+	}
+
+	sessionUUID, err := uuid.FromString(sessionUUIDStr)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	revoked, err := storage.RevokeSession(sessionUUID, user.UUID)
+	if err != nil {
+		logger.Error(ctx, "failed to revoke session", err, "session_uuid", sessionUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to revoke session")
+		return
+	}
+	if !revoked {
+		respondWithError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	logger.Info(ctx, "session revoked", "user_uuid", user.UUID, "session_uuid", sessionUUID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
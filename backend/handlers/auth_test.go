@@ -0,0 +1,520 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/cobyabrahams/hungr/auth"
+	"github.com/cobyabrahams/hungr/mail"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+	"github.com/gofrs/uuid"
+)
+
+const testPassword = "test-password-123"
+
+// recordingSender keeps sent mail so tests can follow emailed links
+type recordingSender struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+func (s *recordingSender) Send(ctx context.Context, msg mail.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+var tokenPattern = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+// lastToken returns the token from the most recent email sent to the address
+func (s *recordingSender) lastToken(t *testing.T, to string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if s.messages[i].To != to {
+			continue
+		}
+		if m := tokenPattern.FindStringSubmatch(s.messages[i].Body); m != nil {
+			return m[1]
+		}
+	}
+	t.Fatalf("No email with a token sent to %s", to)
+	return ""
+}
+
+var sentMail = &recordingSender{}
+
+func init() {
+	mail.Init(sentMail)
+}
+
+// setTestPassword gives an existing test user the shared test password
+func setTestPassword(t *testing.T, email string) {
+	user, err := storage.GetUserByEmail(email)
+	if err != nil {
+		t.Fatalf("Failed to load user %s: %v", email, err)
+	}
+	hash, err := auth.HashPassword(testPassword)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	if err := storage.SetUserPassword(user.UUID, hash); err != nil {
+		t.Fatalf("Failed to set password: %v", err)
+	}
+}
+
+func postAuth(handler http.HandlerFunc, path, body string) *http.Response {
+	req := httptest.NewRequest("POST", path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w.Result()
+}
+
+// login performs a password login and returns the decoded response
+func login(t *testing.T, email string) models.LoginResponse {
+	setTestPassword(t, email)
+	body := `{"email": "` + email + `", "password": "` + testPassword + `"}`
+	req := httptest.NewRequest("POST", "/api/auth/login", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	Login(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var response models.LoginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode login response: %v", err)
+	}
+	return response
+}
+
+// withSession attaches the session behind a login token to the request context
+func withSession(t *testing.T, req *http.Request, token string) *http.Request {
+	sessionUUID, err := auth.ParseSessionToken(token)
+	if err != nil {
+		t.Fatalf("Failed to parse session token: %v", err)
+	}
+	user, err := storage.GetUserBySession(sessionUUID)
+	if err != nil {
+		t.Fatalf("Failed to load session: %v", err)
+	}
+	ctx := auth.WithSession(auth.WithUser(req.Context(), user), sessionUUID)
+	return req.WithContext(ctx)
+}
+
+func TestLogin_IssuesSession(t *testing.T) {
+	ensureTestUser(t)
+
+	response := login(t, testEmail)
+
+	if !response.Success {
+		t.Error("Expected success to be true")
+	}
+	if response.Token == "" {
+		t.Fatal("Expected a session token")
+	}
+	if response.User.Email != testEmail {
+		t.Errorf("Expected user %q, got %q", testEmail, response.User.Email)
+	}
+
+	sessionUUID, err := auth.ParseSessionToken(response.Token)
+	if err != nil {
+		t.Fatalf("Login returned an invalid token: %v", err)
+	}
+	user, err := storage.GetUserBySession(sessionUUID)
+	if err != nil {
+		t.Fatalf("Session not found: %v", err)
+	}
+	if user.Email != testEmail {
+		t.Errorf("Expected session for %q, got %q", testEmail, user.Email)
+	}
+
+	storage.RevokeSession(sessionUUID, user.UUID)
+}
+
+func TestLogin_MissingCredentials(t *testing.T) {
+	tests := []struct {
+		desc string
+		body string
+	}{
+		{"empty", `{}`},
+		{"no password", `{"email": "` + testEmail + `"}`},
+		{"no email", `{"password": "` + testPassword + `"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			resp := postAuth(Login, "/api/auth/login", tt.body)
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestLogin_WrongPassword(t *testing.T) {
+	ensureTestUser(t)
+	setTestPassword(t, testEmail)
+
+	resp := postAuth(Login, "/api/auth/login", `{"email": "`+testEmail+`", "password": "not-the-password"}`)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", resp.StatusCode)
+	}
+}
+
+func TestLogin_UnknownEmail(t *testing.T) {
+	resp := postAuth(Login, "/api/auth/login", `{"email": "nobody-`+uuid.Must(uuid.NewV4()).String()+`@example.com", "password": "whatever-password"}`)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", resp.StatusCode)
+	}
+}
+
+func TestLogin_LocksAfterFailedAttempts(t *testing.T) {
+	ensureTestUser2(t)
+	setTestPassword(t, testEmail2)
+	user, _ := storage.GetUserByEmail(testEmail2)
+	defer storage.RecordSuccessfulLogin(user.UUID)
+
+	wrong := `{"email": "` + testEmail2 + `", "password": "not-the-password"}`
+	for i := 0; i < auth.MaxFailedLogins; i++ {
+		resp := postAuth(Login, "/api/auth/login", wrong)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("Attempt %d: expected status 401, got %d", i+1, resp.StatusCode)
+		}
+	}
+
+	// Even the right password is refused while locked, with the same answer
+	// an unknown email gets
+	resp := postAuth(Login, "/api/auth/login", `{"email": "`+testEmail2+`", "password": "`+testPassword+`"}`)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Retry-After") != "" {
+		t.Error("Expected no Retry-After header while locked")
+	}
+	var errResp map[string]string
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	if errResp["error"] != "invalid email or password" {
+		t.Errorf("Expected generic error, got %q", errResp["error"])
+	}
+}
+
+func TestRegister(t *testing.T) {
+	email := "register-" + uuid.Must(uuid.NewV4()).String() + "@example.com"
+
+	resp := postAuth(Register, "/api/auth/register", `{"email": "`+email+`", "name": "New User", "password": "`+testPassword+`"}`)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var response models.LoginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	defer storage.DeleteUser(response.User.UUID)
+
+	if response.Token == "" {
+		t.Error("Expected a session token")
+	}
+	if response.User.EmailVerified {
+		t.Error("Expected new user to be unverified")
+	}
+
+	// The verification email confirms the address
+	token := sentMail.lastToken(t, email)
+	verifyResp := postAuth(VerifyEmail, "/api/auth/verify-email", `{"token": "`+token+`"}`)
+	defer verifyResp.Body.Close()
+
+	if verifyResp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", verifyResp.StatusCode)
+	}
+	user, _ := storage.GetUserByEmail(email)
+	if !user.EmailVerified {
+		t.Error("Expected email to be verified")
+	}
+}
+
+func TestRegister_ExistingEmail(t *testing.T) {
+	ensureTestUser(t)
+
+	resp := postAuth(Register, "/api/auth/register", `{"email": "`+testEmail+`", "password": "`+testPassword+`"}`)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", resp.StatusCode)
+	}
+}
+
+func TestRegister_ShortPassword(t *testing.T) {
+	resp := postAuth(Register, "/api/auth/register", `{"email": "short@example.com", "password": "abc"}`)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestMagicLink(t *testing.T) {
+	ensureTestUser(t)
+
+	resp := postAuth(RequestMagicLink, "/api/auth/magic-link", `{"email": "`+testEmail+`"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	token := sentMail.lastToken(t, testEmail)
+	body := `{"token": "` + token + `"}`
+
+	resp = postAuth(VerifyMagicLink, "/api/auth/magic-link/verify", body)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var response models.LoginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	sessionUUID, err := auth.ParseSessionToken(response.Token)
+	if err != nil {
+		t.Fatalf("Expected a valid session token: %v", err)
+	}
+	defer storage.RevokeSession(sessionUUID, response.User.UUID)
+
+	// Links are single use
+	again := postAuth(VerifyMagicLink, "/api/auth/magic-link/verify", body)
+	defer again.Body.Close()
+	if again.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected reused link to get 401, got %d", again.StatusCode)
+	}
+}
+
+func TestMagicLink_UnknownEmail(t *testing.T) {
+	resp := postAuth(RequestMagicLink, "/api/auth/magic-link", `{"email": "nobody-`+uuid.Must(uuid.NewV4()).String()+`@example.com"}`)
+	defer resp.Body.Close()
+
+	// Same response as a known email so accounts can't be enumerated
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestPasswordReset_RevokesSessions(t *testing.T) {
+	ensureTestUser(t)
+	existing := login(t, testEmail)
+	existingSessionUUID, _ := auth.ParseSessionToken(existing.Token)
+
+	resp := postAuth(RequestPasswordReset, "/api/auth/password-reset", `{"email": "`+testEmail+`"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	token := sentMail.lastToken(t, testEmail)
+	newPassword := "brand-new-password"
+	resp = postAuth(ResetPassword, "/api/auth/password-reset/confirm", `{"token": "`+token+`", "password": "`+newPassword+`"}`)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	if _, err := storage.GetUserBySession(existingSessionUUID); err == nil {
+		t.Error("Expected existing sessions to be revoked after a reset")
+	}
+
+	loginResp := postAuth(Login, "/api/auth/login", `{"email": "`+testEmail+`", "password": "`+newPassword+`"}`)
+	defer loginResp.Body.Close()
+	if loginResp.StatusCode != http.StatusOK {
+		t.Errorf("Expected login with new password to succeed, got %d", loginResp.StatusCode)
+	}
+}
+
+func TestResetPassword_InvalidToken(t *testing.T) {
+	resp := postAuth(ResetPassword, "/api/auth/password-reset/confirm", `{"token": "bogus", "password": "`+testPassword+`"}`)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", resp.StatusCode)
+	}
+}
+
+func TestChangePassword_WrongCurrent(t *testing.T) {
+	ensureTestUser(t)
+	setTestPassword(t, testEmail)
+
+	body := `{"current_password": "not-the-password", "new_password": "another-password"}`
+	req := asUser(t, httptest.NewRequest("PUT", "/api/auth/password", bytes.NewBufferString(body)), testEmail)
+	w := httptest.NewRecorder()
+
+	ChangePassword(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", resp.StatusCode)
+	}
+}
+
+func TestLogout_RevokesSession(t *testing.T) {
+	ensureTestUser(t)
+	response := login(t, testEmail)
+
+	req := withSession(t, httptest.NewRequest("POST", "/api/auth/logout", nil), response.Token)
+	w := httptest.NewRecorder()
+
+	Logout(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	sessionUUID, _ := auth.ParseSessionToken(response.Token)
+	if _, err := storage.GetUserBySession(sessionUUID); err == nil {
+		t.Error("Expected session to be revoked after logout")
+	}
+}
+
+func TestLogout_Unauthenticated(t *testing.T) {
+	req := httptest.NewRequest("POST", "/api/auth/logout", nil)
+	w := httptest.NewRecorder()
+
+	Logout(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", resp.StatusCode)
+	}
+}
+
+func TestGetUser_ReturnsSignedInUser(t *testing.T) {
+	ensureTestUser(t)
+
+	req := asUser(t, httptest.NewRequest("GET", "/api/users", nil), testEmail)
+	w := httptest.NewRecorder()
+
+	GetUser(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var response models.UserResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.User.Email != testEmail {
+		t.Errorf("Expected user %s, got %s", testEmail, response.User.Email)
+	}
+}
+
+func TestGetUser_Unauthenticated(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/users", nil)
+	w := httptest.NewRecorder()
+
+	GetUser(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", resp.StatusCode)
+	}
+}
+
+func TestGetSessions_MarksCurrent(t *testing.T) {
+	ensureTestUser(t)
+	response := login(t, testEmail)
+	sessionUUID, _ := auth.ParseSessionToken(response.Token)
+	defer storage.RevokeSession(sessionUUID, response.User.UUID)
+
+	req := withSession(t, httptest.NewRequest("GET", "/api/auth/sessions", nil), response.Token)
+	w := httptest.NewRecorder()
+
+	GetSessions(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var sessionsResp models.SessionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&sessionsResp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	found := false
+	for _, s := range sessionsResp.Sessions {
+		if s.UUID == sessionUUID {
+			found = true
+			if !s.Current {
+				t.Error("Expected the request's session to be marked current")
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected current session in session list")
+	}
+}
+
+func TestRevokeSession_OtherUsersSession(t *testing.T) {
+	ensureTestUser(t)
+	ensureTestUser2(t)
+
+	other := login(t, testEmail2)
+	otherSessionUUID, _ := auth.ParseSessionToken(other.Token)
+	defer storage.RevokeSession(otherSessionUUID, other.User.UUID)
+
+	req := asUser(t, httptest.NewRequest("DELETE", "/api/auth/sessions?uuid="+otherSessionUUID.String(), nil), testEmail)
+	w := httptest.NewRecorder()
+
+	RevokeSession(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", resp.StatusCode)
+	}
+
+	if _, err := storage.GetUserBySession(otherSessionUUID); err != nil {
+		t.Error("Expected other user's session to remain active")
+	}
+}
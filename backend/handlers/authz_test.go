@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cobyabrahams/hungr/auth"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+	"github.com/gofrs/uuid"
+)
+
+const testEmail3 = "test3@example.com"
+
+func ensureTestUser3(t *testing.T) {
+	_, err := storage.GetUserByEmail(testEmail3)
+	if err != nil {
+		_, err = storage.CreateUser(testEmail3, "Test User 3")
+		if err != nil {
+			t.Fatalf("Failed to create test user 3: %v", err)
+		}
+	}
+}
+
+// callers covers the owner (testEmail), a friend the owner has connected to
+// (testEmail2), an unconnected stranger (testEmail3) and an anonymous caller
+var callers = []struct {
+	desc  string
+	email string
+}{
+	{"owner", testEmail},
+	{"connected friend", testEmail2},
+	{"stranger", testEmail3},
+	{"anonymous", ""},
+}
+
+// withFriendConnection sets up the owner -> friend connection for the test
+func withFriendConnection(t *testing.T) {
+	ensureTestUser(t)
+	ensureTestUser2(t)
+	ensureTestUser3(t)
+
+	owner, _ := storage.GetUserByEmail(testEmail)
+	friend, _ := storage.GetUserByEmail(testEmail2)
+	storage.DeleteConnection(owner.UUID, friend.UUID)
+	if err := storage.CreateConnection(owner.UUID, friend.UUID); err != nil {
+		t.Fatalf("Failed to create connection: %v", err)
+	}
+	t.Cleanup(func() { storage.DeleteConnection(owner.UUID, friend.UUID) })
+}
+
+func asCaller(t *testing.T, req *http.Request, email string) *http.Request {
+	if email == "" {
+		return req
+	}
+	return asUser(t, req, email)
+}
+
+func TestRecipeMutations_Authorization(t *testing.T) {
+	withFriendConnection(t)
+
+	mutations := []struct {
+		desc    string
+		handler http.HandlerFunc
+		request func(recipeUUID uuid.UUID) *http.Request
+	}{
+		{"DeleteRecipe", DeleteRecipe, func(id uuid.UUID) *http.Request {
+			return httptest.NewRequest("DELETE", "/api/recipes?uuid="+id.String(), nil)
+		}},
+		{"PatchRecipe", PatchRecipe, func(id uuid.UUID) *http.Request {
+			return httptest.NewRequest("PATCH", "/api/recipes/"+id.String(), strings.NewReader(`{"tagString": "authz"}`))
+		}},
+		{"UpdateRecipeSteps", UpdateRecipeSteps, func(id uuid.UUID) *http.Request {
+			return httptest.NewRequest("PUT", "/api/recipes/"+id.String()+"/steps", strings.NewReader(`{"steps": []}`))
+		}},
+		{"SetRecipePublic", SetRecipePublic, func(id uuid.UUID) *http.Request {
+			return httptest.NewRequest("POST", "/api/recipes/"+id.String()+"/public", strings.NewReader(`{"is_public": true}`))
+		}},
+		{"SetRecipeRating", SetRecipeRating, func(id uuid.UUID) *http.Request {
+			return httptest.NewRequest("PUT", "/api/recipes/"+id.String()+"/rating", strings.NewReader(`{"rating": 4}`))
+		}},
+		{"MarkRecipeCooked", MarkRecipeCooked, func(id uuid.UUID) *http.Request {
+			return httptest.NewRequest("POST", "/api/recipes/"+id.String()+"/cooked", nil)
+		}},
+		{"AddRecipeFiles", AddRecipeFiles, func(id uuid.UUID) *http.Request {
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			part, _ := writer.CreateFormFile("file", "test.jpg")
+			part.Write([]byte("fake image data"))
+			writer.Close()
+			req := httptest.NewRequest("POST", "/api/recipes/"+id.String()+"/files", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			return req
+		}},
+	}
+
+	want := map[string]int{
+		"owner":            http.StatusOK,
+		"connected friend": http.StatusForbidden,
+		"stranger":         http.StatusForbidden,
+		"anonymous":        http.StatusUnauthorized,
+	}
+
+	for _, m := range mutations {
+		for _, c := range callers {
+			t.Run(m.desc+"/"+c.desc, func(t *testing.T) {
+				recipe, err := storage.InsertRecipeByEmail("authz-"+m.desc, testEmail, nil)
+				if err != nil {
+					t.Fatalf("Failed to create test recipe: %v", err)
+				}
+				defer storage.PurgeRecipe(recipe.UUID)
+
+				req := asCaller(t, m.request(recipe.UUID), c.email)
+				w := httptest.NewRecorder()
+
+				m.handler(w, req)
+
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				if resp.StatusCode != want[c.desc] {
+					body, _ := io.ReadAll(resp.Body)
+					t.Errorf("Expected status %d, got %d: %s", want[c.desc], resp.StatusCode, string(body))
+				}
+
+				// Rejected callers must not have changed anything
+				if want[c.desc] != http.StatusOK {
+					after, err := storage.GetRecipeByUUID(recipe.UUID)
+					if err != nil {
+						t.Fatalf("Recipe missing after rejected %s: %v", m.desc, err)
+					}
+					if after.IsPublic || after.TagString != "" || after.Rating != nil || after.LastCookedAt != nil {
+						t.Errorf("Recipe modified by rejected %s: %+v", m.desc, after)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestGetRecipeSteps_Authorization(t *testing.T) {
+	withFriendConnection(t)
+
+	recipe, err := storage.InsertRecipeByEmail("authz-view", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+
+	tests := []struct {
+		isPublic bool
+		want     map[string]int
+	}{
+		{false, map[string]int{
+			"owner":            http.StatusOK,
+			"connected friend": http.StatusOK,
+			"stranger":         http.StatusForbidden,
+			"anonymous":        http.StatusUnauthorized,
+		}},
+		{true, map[string]int{
+			"owner":            http.StatusOK,
+			"connected friend": http.StatusOK,
+			"stranger":         http.StatusOK,
+			"anonymous":        http.StatusOK,
+		}},
+	}
+
+	for _, tt := range tests {
+		if err := storage.SetRecipePublic(recipe.UUID, tt.isPublic); err != nil {
+			t.Fatalf("SetRecipePublic failed: %v", err)
+		}
+		visibility := "private"
+		if tt.isPublic {
+			visibility = "public"
+		}
+
+		for _, c := range callers {
+			t.Run(visibility+"/"+c.desc, func(t *testing.T) {
+				req := asCaller(t, httptest.NewRequest("GET", "/api/recipes/"+recipe.UUID.String()+"/steps", nil), c.email)
+				w := httptest.NewRecorder()
+
+				GetRecipeSteps(w, req)
+
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				if resp.StatusCode != tt.want[c.desc] {
+					t.Errorf("Expected status %d, got %d", tt.want[c.desc], resp.StatusCode)
+				}
+			})
+		}
+	}
+}
+
+func TestGetFile_Authorization(t *testing.T) {
+	withFriendConnection(t)
+
+	recipe, err := storage.InsertRecipeByEmail("authz-file", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+
+	file, err := storage.InsertFile(recipe.UUID, []byte("private image"), "image/png", 0, true)
+	if err != nil {
+		t.Fatalf("Failed to insert file: %v", err)
+	}
+
+	for _, c := range callers {
+		t.Run("private/"+c.desc, func(t *testing.T) {
+			want := map[string]int{
+				"owner":            http.StatusOK,
+				"connected friend": http.StatusOK,
+				"stranger":         http.StatusForbidden,
+				"anonymous":        http.StatusUnauthorized,
+			}[c.desc]
+
+			req := asCaller(t, httptest.NewRequest("GET", file.URL, nil), c.email)
+			w := httptest.NewRecorder()
+
+			GetFile(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if resp.StatusCode != want {
+				t.Fatalf("Expected status %d, got %d", want, resp.StatusCode)
+			}
+			if want == http.StatusOK && !strings.HasPrefix(resp.Header.Get("Cache-Control"), "private") {
+				t.Errorf("Expected private Cache-Control, got %q", resp.Header.Get("Cache-Control"))
+			}
+		})
+	}
+
+	t.Run("private/signed url", func(t *testing.T) {
+		signed := auth.SignURL(file.URL, time.Now().Add(time.Hour))
+		req := httptest.NewRequest("GET", signed, nil)
+		w := httptest.NewRecorder()
+
+		GetFile(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if !strings.HasPrefix(resp.Header.Get("Cache-Control"), "private") {
+			t.Errorf("Expected private Cache-Control, got %q", resp.Header.Get("Cache-Control"))
+		}
+	})
+
+	t.Run("private/expired signed url", func(t *testing.T) {
+		signed := auth.SignURL(file.URL, time.Now().Add(-time.Minute))
+		req := httptest.NewRequest("GET", signed, nil)
+		w := httptest.NewRecorder()
+
+		GetFile(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("public/anonymous", func(t *testing.T) {
+		if err := storage.SetRecipePublic(recipe.UUID, true); err != nil {
+			t.Fatalf("SetRecipePublic failed: %v", err)
+		}
+
+		req := httptest.NewRequest("GET", file.URL, nil)
+		w := httptest.NewRecorder()
+
+		GetFile(w, req)
+
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if resp.Header.Get("Cache-Control") != "public, max-age=31536000" {
+			t.Errorf("Expected public Cache-Control, got %q", resp.Header.Get("Cache-Control"))
+		}
+	})
+}
+
+func TestGetRecipes_SignsPrivateFileURLs(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("signed-urls", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+
+	file, err := storage.InsertFile(recipe.UUID, []byte("image"), "image/png", 0, true)
+	if err != nil {
+		t.Fatalf("Failed to insert file: %v", err)
+	}
+
+	req := asUser(t, httptest.NewRequest("GET", "/api/recipes", nil), testEmail)
+	w := httptest.NewRecorder()
+
+	GetRecipes(w, req)
+
+	var response models.RecipesResponse
+	if err := json.NewDecoder(w.Result().Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	for _, f := range response.FileData {
+		if f.UUID != file.UUID {
+			continue
+		}
+		u, err := url.Parse(f.URL)
+		if err != nil {
+			t.Fatalf("Invalid file URL %q: %v", f.URL, err)
+		}
+		if !auth.VerifySignedURL(u.Path, u.Query(), time.Now()) {
+			t.Errorf("Expected a signed URL for a private recipe's file, got %q", f.URL)
+		}
+		return
+	}
+	t.Error("File not found in recipes response")
+}
+
+func TestForkRecipe_Authorization(t *testing.T) {
+	withFriendConnection(t)
+
+	recipe, err := storage.InsertRecipeByEmail("authz-fork", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+
+	want := map[string]int{
+		"owner":            http.StatusOK,
+		"connected friend": http.StatusOK,
+		"stranger":         http.StatusForbidden,
+		"anonymous":        http.StatusUnauthorized,
+	}
+
+	for _, c := range callers {
+		t.Run(c.desc, func(t *testing.T) {
+			req := asCaller(t, httptest.NewRequest("POST", "/api/recipes/"+recipe.UUID.String()+"/fork", nil), c.email)
+			w := httptest.NewRecorder()
+
+			ForkRecipe(w, req)
+
+			if w.Code != want[c.desc] {
+				t.Fatalf("Expected status %d, got %d: %s", want[c.desc], w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var response models.ForkResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			defer storage.PurgeRecipe(response.Recipe.UUID)
+
+			if response.Recipe.OwnerEmail != c.email {
+				t.Errorf("Expected fork owned by %s, got %s", c.email, response.Recipe.OwnerEmail)
+			}
+			if response.Recipe.ForkedFrom == nil || *response.Recipe.ForkedFrom != recipe.UUID {
+				t.Errorf("Expected forked_from %v, got %v", recipe.UUID, response.Recipe.ForkedFrom)
+			}
+		})
+	}
+
+	t.Run("public recipe/stranger", func(t *testing.T) {
+		if err := storage.SetRecipePublic(recipe.UUID, true); err != nil {
+			t.Fatalf("SetRecipePublic failed: %v", err)
+		}
+		req := asUser(t, httptest.NewRequest("POST", "/api/recipes/"+recipe.UUID.String()+"/fork", nil), testEmail3)
+		w := httptest.NewRecorder()
+
+		ForkRecipe(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response models.ForkResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		storage.PurgeRecipe(response.Recipe.UUID)
+	})
+}
@@ -14,16 +14,8 @@ import (
 func CreateConnection(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	// Authenticate via email
-	email := r.URL.Query().Get("email")
-	if email == "" {
-		respondWithError(w, http.StatusBadRequest, "email is required")
-		return
-	}
-
-	authUser, err := storage.GetUserByEmail(email)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "invalid user")
+	authUser, ok := requireUser(w, r)
+	if !ok {
 		return
 	}
 
@@ -47,7 +39,7 @@ func CreateConnection(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if target user exists
-	_, err = storage.GetUserByUUID(req.TargetUserUUID)
+	_, err := storage.GetUserByUUID(req.TargetUserUUID)
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "target user not found")
 		return
@@ -84,17 +76,11 @@ func CreateConnection(w http.ResponseWriter, r *http.Request) {
 func GetConnections(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	userUUIDStr := r.URL.Query().Get("user_uuid")
-	if userUUIDStr == "" {
-		respondWithError(w, http.StatusBadRequest, "user_uuid is required")
-		return
-	}
-
-	userUUID, err := uuid.FromString(userUUIDStr)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid user_uuid")
+	authUser, ok := requireUser(w, r)
+	if !ok {
 		return
 	}
+	userUUID := authUser.UUID
 
 	direction := r.URL.Query().Get("direction")
 	if direction == "" {
@@ -102,6 +88,7 @@ func GetConnections(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var users []models.User
+	var err error
 	if direction == "incoming" {
 		users, err = storage.GetConnectionsByTargetUser(userUUID)
 	} else {
@@ -130,16 +117,8 @@ func GetConnections(w http.ResponseWriter, r *http.Request) {
 func DeleteConnection(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	// Authenticate via email
-	email := r.URL.Query().Get("email")
-	if email == "" {
-		respondWithError(w, http.StatusBadRequest, "email is required")
-		return
-	}
-
-	authUser, err := storage.GetUserByEmail(email)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "invalid user")
+	authUser, ok := requireUser(w, r)
+	if !ok {
 		return
 	}
 
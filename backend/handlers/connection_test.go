@@ -34,7 +34,7 @@ func TestCreateConnection_Success(t *testing.T) {
 	storage.DeleteConnection(user1.UUID, user2.UUID)
 
 	body := `{"target_user_uuid": "` + user2.UUID.String() + `"}`
-	req := httptest.NewRequest("POST", "/api/connections?email="+testEmail, bytes.NewBufferString(body))
+	req := asUser(t, httptest.NewRequest("POST", "/api/connections", bytes.NewBufferString(body)), testEmail)
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -51,25 +51,9 @@ func TestCreateConnection_Success(t *testing.T) {
 	storage.DeleteConnection(user1.UUID, user2.UUID)
 }
 
-func TestCreateConnection_MissingEmail(t *testing.T) {
-	body := `{"target_user_uuid": "00000000-0000-0000-0000-000000000001"}`
-	req := httptest.NewRequest("POST", "/api/connections", bytes.NewBufferString(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-
-	CreateConnection(w, req)
-
-	resp := w.Result()
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", resp.StatusCode)
-	}
-}
-
-func TestCreateConnection_InvalidEmail(t *testing.T) {
+func TestCreateConnection_Unauthenticated(t *testing.T) {
 	body := `{"target_user_uuid": "00000000-0000-0000-0000-000000000001"}`
-	req := httptest.NewRequest("POST", "/api/connections?email=nonexistent@example.com", bytes.NewBufferString(body))
+	req := httptest.NewRequest("POST", "/api/connections?email="+testEmail, bytes.NewBufferString(body))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -87,7 +71,7 @@ func TestCreateConnection_MissingTargetUser(t *testing.T) {
 	ensureTestUser(t)
 
 	body := `{}`
-	req := httptest.NewRequest("POST", "/api/connections?email="+testEmail, bytes.NewBufferString(body))
+	req := asUser(t, httptest.NewRequest("POST", "/api/connections", bytes.NewBufferString(body)), testEmail)
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -107,7 +91,7 @@ func TestCreateConnection_SelfConnection(t *testing.T) {
 	user1, _ := storage.GetUserByEmail(testEmail)
 
 	body := `{"target_user_uuid": "` + user1.UUID.String() + `"}`
-	req := httptest.NewRequest("POST", "/api/connections?email="+testEmail, bytes.NewBufferString(body))
+	req := asUser(t, httptest.NewRequest("POST", "/api/connections", bytes.NewBufferString(body)), testEmail)
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -125,7 +109,7 @@ func TestCreateConnection_TargetNotFound(t *testing.T) {
 	ensureTestUser(t)
 
 	body := `{"target_user_uuid": "00000000-0000-0000-0000-000000000001"}`
-	req := httptest.NewRequest("POST", "/api/connections?email="+testEmail, bytes.NewBufferString(body))
+	req := asUser(t, httptest.NewRequest("POST", "/api/connections", bytes.NewBufferString(body)), testEmail)
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -152,7 +136,7 @@ func TestCreateConnection_Duplicate(t *testing.T) {
 	defer storage.DeleteConnection(user1.UUID, user2.UUID)
 
 	body := `{"target_user_uuid": "` + user2.UUID.String() + `"}`
-	req := httptest.NewRequest("POST", "/api/connections?email="+testEmail, bytes.NewBufferString(body))
+	req := asUser(t, httptest.NewRequest("POST", "/api/connections", bytes.NewBufferString(body)), testEmail)
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -178,7 +162,7 @@ func TestGetConnections_Success(t *testing.T) {
 	storage.CreateConnection(user1.UUID, user2.UUID)
 	defer storage.DeleteConnection(user1.UUID, user2.UUID)
 
-	req := httptest.NewRequest("GET", "/api/connections?user_uuid="+user1.UUID.String(), nil)
+	req := asUser(t, httptest.NewRequest("GET", "/api/connections", nil), testEmail)
 	w := httptest.NewRecorder()
 
 	GetConnections(w, req)
@@ -224,7 +208,7 @@ func TestGetConnections_Incoming(t *testing.T) {
 	defer storage.DeleteConnection(user1.UUID, user2.UUID)
 
 	// Get incoming connections for user2
-	req := httptest.NewRequest("GET", "/api/connections?user_uuid="+user2.UUID.String()+"&direction=incoming", nil)
+	req := asUser(t, httptest.NewRequest("GET", "/api/connections?direction=incoming", nil), testEmail2)
 	w := httptest.NewRecorder()
 
 	GetConnections(w, req)
@@ -253,7 +237,7 @@ func TestGetConnections_Incoming(t *testing.T) {
 	}
 }
 
-func TestGetConnections_MissingUserUUID(t *testing.T) {
+func TestGetConnections_Unauthenticated(t *testing.T) {
 	req := httptest.NewRequest("GET", "/api/connections", nil)
 	w := httptest.NewRecorder()
 
@@ -262,22 +246,8 @@ func TestGetConnections_MissingUserUUID(t *testing.T) {
 	resp := w.Result()
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", resp.StatusCode)
-	}
-}
-
-func TestGetConnections_InvalidUserUUID(t *testing.T) {
-	req := httptest.NewRequest("GET", "/api/connections?user_uuid=invalid", nil)
-	w := httptest.NewRecorder()
-
-	GetConnections(w, req)
-
-	resp := w.Result()
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", resp.StatusCode)
 	}
 }
 
@@ -292,7 +262,7 @@ func TestDeleteConnection_Success(t *testing.T) {
 	storage.DeleteConnection(user1.UUID, user2.UUID)
 	storage.CreateConnection(user1.UUID, user2.UUID)
 
-	req := httptest.NewRequest("DELETE", "/api/connections?email="+testEmail+"&target_user_uuid="+user2.UUID.String(), nil)
+	req := asUser(t, httptest.NewRequest("DELETE", "/api/connections?target_user_uuid="+user2.UUID.String(), nil), testEmail)
 	w := httptest.NewRecorder()
 
 	DeleteConnection(w, req)
@@ -311,22 +281,8 @@ func TestDeleteConnection_Success(t *testing.T) {
 	}
 }
 
-func TestDeleteConnection_MissingEmail(t *testing.T) {
-	req := httptest.NewRequest("DELETE", "/api/connections?target_user_uuid=00000000-0000-0000-0000-000000000001", nil)
-	w := httptest.NewRecorder()
-
-	DeleteConnection(w, req)
-
-	resp := w.Result()
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", resp.StatusCode)
-	}
-}
-
-func TestDeleteConnection_InvalidEmail(t *testing.T) {
-	req := httptest.NewRequest("DELETE", "/api/connections?email=nonexistent@example.com&target_user_uuid=00000000-0000-0000-0000-000000000001", nil)
+func TestDeleteConnection_Unauthenticated(t *testing.T) {
+	req := httptest.NewRequest("DELETE", "/api/connections?email="+testEmail+"&target_user_uuid=00000000-0000-0000-0000-000000000001", nil)
 	w := httptest.NewRecorder()
 
 	DeleteConnection(w, req)
@@ -342,7 +298,7 @@ func TestDeleteConnection_InvalidEmail(t *testing.T) {
 func TestDeleteConnection_MissingTargetUUID(t *testing.T) {
 	ensureTestUser(t)
 
-	req := httptest.NewRequest("DELETE", "/api/connections?email="+testEmail, nil)
+	req := asUser(t, httptest.NewRequest("DELETE", "/api/connections", nil), testEmail)
 	w := httptest.NewRecorder()
 
 	DeleteConnection(w, req)
@@ -358,7 +314,7 @@ func TestDeleteConnection_MissingTargetUUID(t *testing.T) {
 func TestDeleteConnection_InvalidTargetUUID(t *testing.T) {
 	ensureTestUser(t)
 
-	req := httptest.NewRequest("DELETE", "/api/connections?email="+testEmail+"&target_user_uuid=invalid", nil)
+	req := asUser(t, httptest.NewRequest("DELETE", "/api/connections?target_user_uuid=invalid", nil), testEmail)
 	w := httptest.NewRecorder()
 
 	DeleteConnection(w, req)
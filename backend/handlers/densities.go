@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/cobyabrahams/hungr/logger"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+)
+
+// GetDensities handles GET /api/densities, listing the ingredient densities
+// used to convert between volume and mass, including the caller's own
+func GetDensities(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	densities, err := storage.GetIngredientDensities(&user.UUID)
+	if err != nil {
+		logger.Error(ctx, "failed to get ingredient densities", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get densities")
+		return
+	}
+	if densities == nil {
+		densities = []models.IngredientDensity{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.DensitiesResponse{Densities: densities})
+}
+
+// SetDensity handles PUT /api/densities, setting the caller's own density for
+// an ingredient in place of the default
+func SetDensity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.SetDensityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	req.IngredientName = strings.TrimSpace(req.IngredientName)
+	if req.IngredientName == "" {
+		respondWithError(w, http.StatusBadRequest, "ingredient_name is required")
+		return
+	}
+	if !(req.GramsPerML > 0) || math.IsInf(req.GramsPerML, 1) {
+		respondWithError(w, http.StatusBadRequest, "grams_per_ml must be a positive number")
+		return
+	}
+
+	if err := storage.SetUserIngredientDensity(user.UUID, req.IngredientName, req.GramsPerML); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusBadRequest, "ingredient "+req.IngredientName+" not found")
+			return
+		}
+		logger.Error(ctx, "failed to set ingredient density", err, "user_uuid", user.UUID, "ingredient", req.IngredientName)
+		respondWithError(w, http.StatusInternalServerError, "failed to set density")
+		return
+	}
+
+	logger.Info(ctx, "ingredient density set", "user_uuid", user.UUID, "ingredient", req.IngredientName)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// DeleteDensity handles DELETE /api/densities?ingredient=name, going back to
+// the default density for the ingredient
+func DeleteDensity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	name := strings.TrimSpace(r.URL.Query().Get("ingredient"))
+	if name == "" {
+		respondWithError(w, http.StatusBadRequest, "ingredient is required")
+		return
+	}
+
+	deleted, err := storage.DeleteUserIngredientDensity(user.UUID, name)
+	if err != nil {
+		logger.Error(ctx, "failed to delete ingredient density", err, "user_uuid", user.UUID, "ingredient", name)
+		respondWithError(w, http.StatusInternalServerError, "failed to delete density")
+		return
+	}
+	if !deleted {
+		respondWithError(w, http.StatusNotFound, "density not found")
+		return
+	}
+
+	logger.Info(ctx, "ingredient density deleted", "user_uuid", user.UUID, "ingredient", name)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
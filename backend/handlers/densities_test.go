@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cobyabrahams/hungr/models"
+)
+
+func TestSetDensity(t *testing.T) {
+	ensureTestUser(t)
+
+	tests := []struct {
+		desc       string
+		body       string
+		wantStatus int
+	}{
+		{"missing name", `{"ingredient_name": " ", "grams_per_ml": 0.6}`, http.StatusBadRequest},
+		{"zero density", `{"ingredient_name": "flour", "grams_per_ml": 0}`, http.StatusBadRequest},
+		{"negative density", `{"ingredient_name": "flour", "grams_per_ml": -1}`, http.StatusBadRequest},
+		{"unknown ingredient", `{"ingredient_name": "quuxflour-unknown", "grams_per_ml": 0.6}`, http.StatusBadRequest},
+		{"valid", `{"ingredient_name": "flour", "grams_per_ml": 0.6}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			SetDensity(w, asUser(t, httptest.NewRequest("PUT", "/api/densities", strings.NewReader(tt.body)), testEmail))
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	getW := httptest.NewRecorder()
+	GetDensities(getW, asUser(t, httptest.NewRequest("GET", "/api/densities", nil), testEmail))
+	var response models.DensitiesResponse
+	if err := json.NewDecoder(getW.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	found := false
+	for _, d := range response.Densities {
+		if d.IngredientName == "flour" {
+			found = d.GramsPerML == 0.6 && d.Custom
+		}
+	}
+	if !found {
+		t.Errorf("Expected the caller's flour density in %+v", response.Densities)
+	}
+
+	for _, wantStatus := range []int{http.StatusOK, http.StatusNotFound} {
+		w := httptest.NewRecorder()
+		DeleteDensity(w, asUser(t, httptest.NewRequest("DELETE", "/api/densities?ingredient=flour", nil), testEmail))
+		if w.Code != wantStatus {
+			t.Errorf("Expected status %d, got %d: %s", wantStatus, w.Code, w.Body.String())
+		}
+	}
+}
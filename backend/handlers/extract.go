@@ -23,14 +23,14 @@ type ExtractRequest struct {
 
 // Shared extraction rules used by all prompts
 const extractionRules = `Rules:
-1. ALWAYS start with a step that has an empty instruction "" containing ALL ingredients from the recipe. This must be the first element in the steps array.
-2. Then add additional steps for the actual cooking instructions (these steps should have empty ingredients arrays since all ingredients are in the first step).
+1. ALWAYS start with a step that has an empty instruction "" containing ALL ingredients from the recipe. This must be the first element in the steps array. If the recipe splits its ingredients into sections (e.g., "For the dough", "For the filling"), instead start with one such step per section, in order, each with the section heading as its "group_title". Otherwise use an empty "group_title" "".
+2. Then add additional steps for the actual cooking instructions (these steps should have empty ingredients arrays and an empty group_title since all ingredients are in the first steps).
 3. Format ingredients as "quantity unit ingredient" (e.g., "2 cups flour", "1 tsp salt", "3 eggs")
 4. Use standard cooking units: tsp, tbsp, cup, oz, lb, g, kg, ml, l
 5. For countable items without units, just use the number and name (e.g., "2 eggs", "1 onion")
 6. Do NOT include temperatures (e.g., "350°F", "180°C") in the ingredients list - temperatures belong in the instruction steps only
 7. IMPORTANT: Preserve ALL numbers in instructions including oven temperatures (e.g., "Preheat oven to 350°F"), cooking times (e.g., "bake for 25 minutes"), and quantities. Never omit or round these values.
-8. IMPORTANT: Watch for mixed fractions! "3 1/2 cups" means 3.5 cups (three and a half), NOT "3" followed by "1/2 cup". Similarly "2 1/4 tsp" means 2.25 tsp. Convert mixed fractions to decimals.
+8. Keep quantities as the recipe writes them, including fractions, mixed numbers and ranges (e.g., "1 1/2 cups flour", "½ tsp salt", "2-3 cloves garlic")
 9. Also return a "tags" array with 3-8 concise, lowercase tags (1-3 words each). Include at least one role tag (e.g., "dinner", "appetizer", "breakfast", "dessert") and 1-2 tags for major ingredients (e.g., "chicken", "salmon", "mushroom").`
 
 const extractImageSystemPrompt = `You are a recipe extraction assistant. Given an image of a recipe (such as a photo from a cookbook, a handwritten recipe card, or a screenshot), extract the recipe steps and ingredients into the JSON schema provided.
@@ -61,6 +61,10 @@ var recipeStepsSchema = map[string]interface{}{
 						"type":        "string",
 						"description": "The step instruction. Empty string if this is just an ingredients list.",
 					},
+					"group_title": map[string]interface{}{
+						"type":        "string",
+						"description": "Heading of the ingredient section this step lists (e.g., 'For the dough'). Empty string if the recipe has no sections.",
+					},
 					"ingredients": map[string]interface{}{
 						"type": "array",
 						"items": map[string]interface{}{
@@ -70,7 +74,7 @@ var recipeStepsSchema = map[string]interface{}{
 						"description": "Ingredients used in this step",
 					},
 				},
-				"required":             []string{"instruction", "ingredients"},
+				"required":             []string{"instruction", "group_title", "ingredients"},
 				"additionalProperties": false,
 			},
 		},
@@ -193,6 +197,9 @@ func callOpenAI(apiKey, model string, messages []openAIMessage, timeout time.Dur
 
 func ExtractRecipe(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	if _, ok := requireUser(w, r); !ok {
+		return
+	}
 
 	if r.Method != "POST" {
 		respondWithError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -253,6 +260,9 @@ func ExtractRecipe(w http.ResponseWriter, r *http.Request) {
 
 func ExtractRecipeFromImage(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	if _, ok := requireUser(w, r); !ok {
+		return
+	}
 
 	if r.Method != "POST" {
 		respondWithError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -408,6 +418,9 @@ type ExtractTextRequest struct {
 
 func ExtractRecipeFromText(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	if _, ok := requireUser(w, r); !ok {
+		return
+	}
 
 	if r.Method != "POST" {
 		respondWithError(w, http.StatusMethodNotAllowed, "method not allowed")
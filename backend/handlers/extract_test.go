@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
@@ -40,6 +41,30 @@ func loadEnvFileForTest(filename string) {
 	}
 }
 
+func TestExtract_Unauthenticated(t *testing.T) {
+	tests := []struct {
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"/api/extract-recipe", ExtractRecipe},
+		{"/api/extract-recipe-image", ExtractRecipeFromImage},
+		{"/api/extract-recipe-text", ExtractRecipeFromText},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.path, strings.NewReader(`{"url": "https://example.com", "text": "recipe"}`))
+			w := httptest.NewRecorder()
+
+			tt.handler(w, req)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("Expected status 401, got %d", w.Code)
+			}
+		})
+	}
+}
+
 func TestExtractRecipeFromImage_Expensive(t *testing.T) {
 	skipUnlessExpensiveTests(t)
 	loadEnvFileForTest(".env")
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/cobyabrahams/hungr/logger"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+)
+
+// MergeIngredients handles POST /api/admin/ingredients/merge, folding one
+// catalog ingredient into another so recipes, shopping lists and pantries
+// that used either add up as one. The source's name then refers to the
+// target. Admins only.
+func MergeIngredients(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.MergeIngredientsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Source, req.Target = strings.TrimSpace(req.Source), strings.TrimSpace(req.Target)
+	if req.Source == "" || req.Target == "" {
+		respondWithError(w, http.StatusBadRequest, "source and target are required")
+		return
+	}
+
+	var found [2]*models.IngredientName
+	for i, name := range []string{req.Source, req.Target} {
+		ingredient, err := storage.GetIngredientNameByName(name)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, http.StatusNotFound, "ingredient "+name+" not found")
+				return
+			}
+			logger.Error(ctx, "failed to get ingredient", err, "name", name)
+			respondWithError(w, http.StatusInternalServerError, "failed to merge ingredients")
+			return
+		}
+		found[i] = ingredient
+	}
+	source, target := found[0], found[1]
+
+	merged, err := storage.MergeIngredientNames(source.UUID, target.UUID)
+	if err != nil {
+		if errors.Is(err, storage.ErrSameIngredient) {
+			respondWithError(w, http.StatusBadRequest, req.Source+" and "+req.Target+" are already the same ingredient")
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "ingredient not found")
+			return
+		}
+		logger.Error(ctx, "failed to merge ingredients", err, "source_uuid", source.UUID, "target_uuid", target.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to merge ingredients")
+		return
+	}
+
+	aliases, err := storage.GetIngredientAliases(merged.UUID)
+	if err != nil {
+		logger.Error(ctx, "failed to get ingredient aliases", err, "ingredient_uuid", merged.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get ingredient")
+		return
+	}
+	if aliases == nil {
+		aliases = []string{}
+	}
+
+	logger.Info(ctx, "ingredients merged", "source", source.Name, "target", merged.Name, "user_uuid", user.UUID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.IngredientResponse{IngredientName: *merged, Aliases: aliases})
+}
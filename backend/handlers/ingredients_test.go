@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+)
+
+func TestMergeIngredients(t *testing.T) {
+	ensureTestUser(t)
+	ensureTestUser2(t)
+	t.Setenv("ADMIN_EMAILS", testEmail)
+	admin, err := storage.GetUserByEmail(testEmail)
+	if err != nil {
+		t.Fatalf("Failed to load user: %v", err)
+	}
+	if err := storage.MarkEmailVerified(admin.UUID); err != nil {
+		t.Fatalf("MarkEmailVerified failed: %v", err)
+	}
+
+	source, err := storage.CreateIngredientName("Quuxnuts")
+	if err != nil {
+		t.Fatalf("CreateIngredientName failed: %v", err)
+	}
+	defer storage.DeleteIngredientName(source.UUID)
+	target, err := storage.UpsertIngredientName("quuxnut")
+	if err != nil {
+		t.Fatalf("UpsertIngredientName failed: %v", err)
+	}
+	defer storage.DeleteIngredientName(target.UUID)
+
+	tests := []struct {
+		desc       string
+		email      string
+		body       string
+		wantStatus int
+	}{
+		{"not an admin", testEmail2, `{"source": "Quuxnuts", "target": "quuxnut"}`, http.StatusForbidden},
+		{"missing target", testEmail, `{"source": "Quuxnuts"}`, http.StatusBadRequest},
+		{"unknown source", testEmail, `{"source": "quuxberries-unknown", "target": "quuxnut"}`, http.StatusNotFound},
+		{"same ingredient", testEmail, `{"source": "quuxnuts", "target": "quuxnut"}`, http.StatusBadRequest},
+		{"invalid body", testEmail, `{`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			MergeIngredients(w, asUser(t, httptest.NewRequest("POST", "/api/admin/ingredients/merge", strings.NewReader(tt.body)), tt.email))
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	body := `{"source": "Quuxnuts", "target": "quuxnut"}`
+	MergeIngredients(w, asUser(t, httptest.NewRequest("POST", "/api/admin/ingredients/merge", strings.NewReader(body)), testEmail))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var merged models.IngredientResponse
+	if err := json.NewDecoder(w.Body).Decode(&merged); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if merged.UUID != target.UUID || strings.Join(merged.Aliases, ",") != "quuxnut" {
+		t.Errorf("Expected quuxnut aliased by quuxnut, got %+v", merged)
+	}
+}
@@ -0,0 +1,578 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cobyabrahams/hungr/auth"
+	"github.com/cobyabrahams/hungr/authz"
+	"github.com/cobyabrahams/hungr/calendar"
+	"github.com/cobyabrahams/hungr/logger"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	defaultMealPlanName = "Meal plan"
+	dateFormat          = "2006-01-02"
+	mealDuration        = time.Hour
+)
+
+// mealTimes is when each meal starts in calendar feeds, after midnight
+var mealTimes = map[string]time.Duration{
+	models.MealBreakfast: 8 * time.Hour,
+	models.MealLunch:     12*time.Hour + 30*time.Minute,
+	models.MealDinner:    18*time.Hour + 30*time.Minute,
+}
+
+// GetMealPlans handles GET /api/meal-plans, listing the caller's plans and
+// those shared with them
+func GetMealPlans(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	respondWithMealPlans(w, r, user)
+}
+
+// RegenerateCalendarFeedKey handles POST /api/meal-plans/feed-key, revoking
+// every calendar feed URL the caller has been given. It responds with their
+// plans, which carry the new feed URLs.
+func RegenerateCalendarFeedKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireSessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := storage.RegenerateCalendarFeedKey(user.UUID); err != nil {
+		logger.Error(ctx, "failed to regenerate calendar feed key", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to regenerate calendar feed key")
+		return
+	}
+
+	logger.Info(ctx, "calendar feed key regenerated", "user_uuid", user.UUID)
+	respondWithMealPlans(w, r, user)
+}
+
+// respondWithMealPlans writes the user's plans and those shared with them
+func respondWithMealPlans(w http.ResponseWriter, r *http.Request, user *models.User) {
+	ctx := r.Context()
+	plans, err := storage.GetMealPlansForUser(user.UUID)
+	if err != nil {
+		logger.Error(ctx, "failed to get meal plans", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get meal plans")
+		return
+	}
+	if plans == nil {
+		plans = []models.MealPlan{}
+	}
+	withFeeds := make([]*models.MealPlan, len(plans))
+	for i := range plans {
+		withFeeds[i] = &plans[i]
+	}
+	if !setFeedURLs(w, r, user, withFeeds...) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.MealPlansResponse{MealPlans: plans})
+}
+
+// CreateMealPlan handles POST /api/meal-plans
+func CreateMealPlan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.MealPlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	name := defaultMealPlanName
+	if req.Name != nil && strings.TrimSpace(*req.Name) != "" {
+		name = strings.TrimSpace(*req.Name)
+	}
+	shared := req.Shared != nil && *req.Shared
+
+	plan, err := storage.CreateMealPlan(user.UUID, name, shared)
+	if err != nil {
+		logger.Error(ctx, "failed to create meal plan", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to create meal plan")
+		return
+	}
+
+	logger.Info(ctx, "meal plan created", "meal_plan_uuid", plan.UUID, "user_uuid", user.UUID)
+	respondWithMealPlan(w, r, user, plan)
+}
+
+// GetMealPlan handles GET /api/meal-plans/{uuid}?from=YYYY-MM-DD&to=YYYY-MM-DD,
+// where either date may be left out
+func GetMealPlan(w http.ResponseWriter, r *http.Request) {
+	user, plan, _, ok := mealPlanFromPath(w, r, false)
+	if !ok {
+		return
+	}
+	respondWithMealPlan(w, r, user, plan)
+}
+
+// UpdateMealPlan handles PATCH /api/meal-plans/{uuid}, renaming the plan or
+// sharing it with the caller's connections
+func UpdateMealPlan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, plan, _, ok := mealPlanFromPath(w, r, true)
+	if !ok {
+		return
+	}
+
+	var req models.MealPlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			respondWithError(w, http.StatusBadRequest, "name must not be empty")
+			return
+		}
+		req.Name = &name
+	}
+
+	updated, err := storage.UpdateMealPlan(plan.UUID, req.Name, req.Shared)
+	if err != nil {
+		logger.Error(ctx, "failed to update meal plan", err, "meal_plan_uuid", plan.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to update meal plan")
+		return
+	}
+
+	logger.Info(ctx, "meal plan updated", "meal_plan_uuid", plan.UUID)
+	respondWithMealPlan(w, r, user, updated)
+}
+
+// DeleteMealPlan handles DELETE /api/meal-plans/{uuid}
+func DeleteMealPlan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	_, plan, _, ok := mealPlanFromPath(w, r, true)
+	if !ok {
+		return
+	}
+
+	if err := storage.DeleteMealPlan(plan.UUID); err != nil {
+		logger.Error(ctx, "failed to delete meal plan", err, "meal_plan_uuid", plan.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to delete meal plan")
+		return
+	}
+
+	logger.Info(ctx, "meal plan deleted", "meal_plan_uuid", plan.UUID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// AddMealPlanEntry handles POST /api/meal-plans/{uuid}/entries, planning a
+// recipe for a meal
+func AddMealPlanEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	_, plan, rest, ok := mealPlanFromPath(w, r, true)
+	if !ok {
+		return
+	}
+	if len(rest) != 1 {
+		respondWithError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	entry, ok := decodeMealPlanEntry(w, r)
+	if !ok {
+		return
+	}
+
+	added, err := storage.AddMealPlanEntry(plan.UUID, entry)
+	if err != nil {
+		logger.Error(ctx, "failed to add meal plan entry", err, "meal_plan_uuid", plan.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to add meal")
+		return
+	}
+
+	logger.Info(ctx, "meal plan entry added", "meal_plan_uuid", plan.UUID, "entry_uuid", added.UUID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(added)
+}
+
+// UpdateMealPlanEntry handles PUT /api/meal-plans/{uuid}/entries/{entry}
+func UpdateMealPlanEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	plan, entryUUID, ok := mealPlanEntryFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	entry, ok := decodeMealPlanEntry(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := storage.UpdateMealPlanEntry(plan.UUID, entryUUID, entry)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "entry not found")
+			return
+		}
+		logger.Error(ctx, "failed to update meal plan entry", err, "meal_plan_uuid", plan.UUID, "entry_uuid", entryUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to update meal")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteMealPlanEntry handles DELETE /api/meal-plans/{uuid}/entries/{entry}
+func DeleteMealPlanEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	plan, entryUUID, ok := mealPlanEntryFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	found, err := storage.DeleteMealPlanEntry(plan.UUID, entryUUID)
+	if err != nil {
+		logger.Error(ctx, "failed to delete meal plan entry", err, "meal_plan_uuid", plan.UUID, "entry_uuid", entryUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to delete meal")
+		return
+	}
+	if !found {
+		respondWithError(w, http.StatusNotFound, "entry not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// CreateMealPlanShoppingList handles POST /api/meal-plans/{uuid}/shopping-list,
+// making the caller a shopping list for a week of the plan. Each recipe is
+// scaled to the servings planned when it has servings, and added up across
+// the week.
+func CreateMealPlanShoppingList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, plan, _, ok := mealPlanFromPath(w, r, false)
+	if !ok {
+		return
+	}
+
+	var req models.MealPlanShoppingListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	from := startOfWeek(time.Now())
+	if req.From != "" {
+		parsed, err := time.Parse(dateFormat, req.From)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "from must be a date like 2026-10-19")
+			return
+		}
+		from = parsed
+	}
+	fromDate, toDate := from.Format(dateFormat), from.AddDate(0, 0, 6).Format(dateFormat)
+
+	entries, err := storage.GetMealPlanEntries(plan.UUID, &fromDate, &toDate)
+	if err != nil {
+		logger.Error(ctx, "failed to get meal plan entries", err, "meal_plan_uuid", plan.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to create shopping list")
+		return
+	}
+
+	var recipes []models.ShoppingListRecipe
+	for _, entry := range entries {
+		recipe, err := storage.GetRecipeByUUID(entry.RecipeUUID)
+		if err != nil {
+			logger.Error(ctx, "failed to get recipe", err, "recipe_uuid", entry.RecipeUUID)
+			respondWithError(w, http.StatusInternalServerError, "failed to create shopping list")
+			return
+		}
+		allowed, err := authz.CanView(user, recipe)
+		if err != nil {
+			logger.Error(ctx, "failed to check recipe access", err, "recipe_uuid", recipe.UUID)
+			respondWithError(w, http.StatusInternalServerError, "failed to create shopping list")
+			return
+		}
+		if !allowed {
+			continue
+		}
+
+		scale := 1.0
+		if entry.Servings != nil && recipe.Servings != nil && *recipe.Servings > 0 {
+			scale = float64(*entry.Servings) / float64(*recipe.Servings)
+		}
+		i := slices.IndexFunc(recipes, func(rr models.ShoppingListRecipe) bool { return rr.RecipeUUID == recipe.UUID })
+		if i < 0 {
+			recipes = append(recipes, models.ShoppingListRecipe{RecipeUUID: recipe.UUID, Name: recipe.Name})
+			i = len(recipes) - 1
+		}
+		recipes[i].Scale += scale
+	}
+	if len(recipes) == 0 {
+		respondWithError(w, http.StatusBadRequest, "no meals are planned that week")
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = plan.Name + ", week of " + fromDate
+	}
+	createShoppingList(w, r, user, name, recipes, nil)
+}
+
+// GetMealPlanCalendar handles GET /api/meal-plans/{uuid}/calendar.ics, the
+// plan as an iCalendar feed. Calendar apps can't sign in, so besides a session
+// it accepts the signed feed URL given to each user who can see the plan,
+// which stops working if they lose access or regenerate their feed key.
+func GetMealPlanCalendar(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if userUUID, ok := auth.FeedURLUser(r.URL.Query()); ok {
+		feedKey, err := storage.GetCalendarFeedKey(userUUID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			logger.Error(ctx, "failed to get calendar feed key", err, "user_uuid", userUUID)
+			respondWithError(w, http.StatusInternalServerError, "failed to get meal plan")
+			return
+		}
+		if err == nil && auth.VerifyFeedURL(r.URL.Path, r.URL.Query(), userUUID, feedKey) {
+			user, err := storage.GetUserByUUID(userUUID)
+			if err != nil {
+				respondWithError(w, http.StatusNotFound, "meal plan not found")
+				return
+			}
+			r = r.WithContext(auth.WithUser(ctx, user))
+		}
+	}
+
+	user, plan, _, ok := mealPlanFromPath(w, r, false)
+	if !ok {
+		return
+	}
+
+	entries, ok := viewableMealPlanEntries(w, r, user, plan, nil, nil)
+	if !ok {
+		return
+	}
+
+	cal := calendar.Calendar{Name: plan.Name}
+	for _, entry := range entries {
+		day, err := time.Parse(dateFormat, entry.Date)
+		if err != nil {
+			continue
+		}
+		summary := strings.ToUpper(entry.Meal[:1]) + entry.Meal[1:] + ": " + entry.RecipeName
+		event := calendar.Event{
+			UID:      entry.UUID.String() + "@hungr",
+			Start:    day.Add(mealTimes[entry.Meal]),
+			Duration: mealDuration,
+			Summary:  summary,
+			URL:      appBaseURL() + "/recipe/" + entry.RecipeUUID.String(),
+			Modified: entry.UpdatedAt,
+		}
+		if entry.Servings != nil {
+			event.Description = "Serves " + strconv.Itoa(*entry.Servings)
+		}
+		cal.Events = append(cal.Events, event)
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="meal-plan.ics"`)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Write([]byte(cal.Encode()))
+}
+
+// mealPlanFromPath parses /api/meal-plans/{uuid}[/...] and loads a plan the
+// caller may see, or change when edit is set, returning the path segments
+// after the uuid. Plans the caller can't see are reported as not found.
+func mealPlanFromPath(w http.ResponseWriter, r *http.Request, edit bool) (*models.User, *models.MealPlan, []string, bool) {
+	ctx := r.Context()
+	user, ok := requireUser(w, r)
+	if !ok {
+		return nil, nil, nil, false
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/meal-plans/"), "/")
+	if parts[0] == "" {
+		respondWithError(w, http.StatusBadRequest, "meal plan uuid is required")
+		return nil, nil, nil, false
+	}
+	planUUID, err := uuid.FromString(parts[0])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid meal plan uuid")
+		return nil, nil, nil, false
+	}
+
+	plan, err := storage.GetMealPlan(planUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "meal plan not found")
+			return nil, nil, nil, false
+		}
+		logger.Error(ctx, "failed to get meal plan", err, "meal_plan_uuid", planUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get meal plan")
+		return nil, nil, nil, false
+	}
+
+	allowed, err := authz.CanViewMealPlan(user, plan)
+	if err != nil {
+		logger.Error(ctx, "failed to check meal plan access", err, "meal_plan_uuid", planUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get meal plan")
+		return nil, nil, nil, false
+	}
+	if !allowed {
+		respondWithError(w, http.StatusNotFound, "meal plan not found")
+		return nil, nil, nil, false
+	}
+	if edit && !authz.CanEditMealPlan(user, plan) {
+		logger.Info(ctx, "meal plan edit forbidden", "meal_plan_uuid", planUUID, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusForbidden, "you do not have permission to modify this meal plan")
+		return nil, nil, nil, false
+	}
+	return user, plan, parts[1:], true
+}
+
+// mealPlanEntryFromPath parses /api/meal-plans/{uuid}/entries/{entry} for a
+// plan the caller may change
+func mealPlanEntryFromPath(w http.ResponseWriter, r *http.Request) (*models.MealPlan, uuid.UUID, bool) {
+	_, plan, rest, ok := mealPlanFromPath(w, r, true)
+	if !ok {
+		return nil, uuid.Nil, false
+	}
+	if len(rest) != 2 || rest[0] != "entries" {
+		respondWithError(w, http.StatusNotFound, "not found")
+		return nil, uuid.Nil, false
+	}
+	entryUUID, err := uuid.FromString(rest[1])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid entry uuid")
+		return nil, uuid.Nil, false
+	}
+	return plan, entryUUID, true
+}
+
+// decodeMealPlanEntry reads and validates an entry from the request body. The
+// plan's owner, who is the caller, must be able to view the recipe.
+func decodeMealPlanEntry(w http.ResponseWriter, r *http.Request) (models.MealPlanEntry, bool) {
+	var entry models.MealPlanEntry
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return entry, false
+	}
+	if _, err := time.Parse(dateFormat, entry.Date); err != nil {
+		respondWithError(w, http.StatusBadRequest, "date must be a date like 2026-10-19")
+		return entry, false
+	}
+	if !slices.Contains(models.Meals, entry.Meal) {
+		respondWithError(w, http.StatusBadRequest, "meal must be breakfast, lunch or dinner")
+		return entry, false
+	}
+	if entry.Servings != nil && *entry.Servings < 1 {
+		respondWithError(w, http.StatusBadRequest, "servings must be a positive integer")
+		return entry, false
+	}
+	if _, ok := viewableRecipe(w, r, entry.RecipeUUID); !ok {
+		return entry, false
+	}
+	return entry, true
+}
+
+// respondWithMealPlan writes a plan with its entries between ?from= and ?to=
+func respondWithMealPlan(w http.ResponseWriter, r *http.Request, user *models.User, plan *models.MealPlan) {
+	var bounds [2]*string
+	for i, param := range []string{"from", "to"} {
+		value := r.URL.Query().Get(param)
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse(dateFormat, value); err != nil {
+			respondWithError(w, http.StatusBadRequest, param+" must be a date like 2026-10-19")
+			return
+		}
+		bounds[i] = &value
+	}
+
+	entries, ok := viewableMealPlanEntries(w, r, user, plan, bounds[0], bounds[1])
+	if !ok {
+		return
+	}
+
+	if !setFeedURLs(w, r, user, plan) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.MealPlanResponse{MealPlan: *plan, Entries: entries})
+}
+
+// viewableMealPlanEntries returns a plan's entries between from and to,
+// leaving out recipes the user can't view when the plan isn't theirs
+func viewableMealPlanEntries(w http.ResponseWriter, r *http.Request, user *models.User, plan *models.MealPlan, from, to *string) ([]models.MealPlanEntry, bool) {
+	ctx := r.Context()
+	entries, err := storage.GetMealPlanEntries(plan.UUID, from, to)
+	if err != nil {
+		logger.Error(ctx, "failed to get meal plan entries", err, "meal_plan_uuid", plan.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get meal plan")
+		return nil, false
+	}
+	if entries == nil {
+		entries = []models.MealPlanEntry{}
+	}
+	if authz.CanEditMealPlan(user, plan) {
+		return entries, true
+	}
+
+	viewable := map[uuid.UUID]bool{}
+	for _, entry := range entries {
+		if _, checked := viewable[entry.RecipeUUID]; checked {
+			continue
+		}
+		recipe, err := storage.GetRecipeByUUID(entry.RecipeUUID)
+		if err != nil {
+			logger.Error(ctx, "failed to get recipe", err, "recipe_uuid", entry.RecipeUUID)
+			respondWithError(w, http.StatusInternalServerError, "failed to get meal plan")
+			return nil, false
+		}
+		allowed, err := authz.CanView(user, recipe)
+		if err != nil {
+			logger.Error(ctx, "failed to check recipe access", err, "recipe_uuid", recipe.UUID)
+			respondWithError(w, http.StatusInternalServerError, "failed to get meal plan")
+			return nil, false
+		}
+		viewable[entry.RecipeUUID] = allowed
+	}
+	return slices.DeleteFunc(entries, func(e models.MealPlanEntry) bool { return !viewable[e.RecipeUUID] }), true
+}
+
+// setFeedURLs fills in the user's own signed calendar feed path for each plan
+func setFeedURLs(w http.ResponseWriter, r *http.Request, user *models.User, plans ...*models.MealPlan) bool {
+	feedKey, err := storage.GetCalendarFeedKey(user.UUID)
+	if err != nil {
+		logger.Error(r.Context(), "failed to get calendar feed key", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get meal plan")
+		return false
+	}
+	for _, plan := range plans {
+		plan.FeedURL = auth.FeedURL("/api/meal-plans/"+plan.UUID.String()+"/calendar.ics", user.UUID, feedKey)
+	}
+	return true
+}
+
+// startOfWeek returns the Monday of t's week, at midnight UTC
+func startOfWeek(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+	return day.AddDate(0, 0, -offset)
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+)
+
+func TestStartOfWeek(t *testing.T) {
+	tests := []struct {
+		in   time.Time
+		want string
+	}{
+		{time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC), "2026-10-19"},
+		{time.Date(2026, 10, 21, 23, 59, 0, 0, time.UTC), "2026-10-19"},
+		{time.Date(2026, 10, 25, 12, 0, 0, 0, time.UTC), "2026-10-19"},
+		{time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC), "2026-10-26"},
+	}
+
+	for _, tt := range tests {
+		if got := startOfWeek(tt.in).Format(dateFormat); got != tt.want {
+			t.Errorf("startOfWeek(%s) = %s, want %s", tt.in.Format(time.RFC3339), got, tt.want)
+		}
+	}
+}
+
+func createMealPlan(t *testing.T, body string) models.MealPlanResponse {
+	t.Helper()
+	w := httptest.NewRecorder()
+	CreateMealPlan(w, asUser(t, httptest.NewRequest("POST", "/api/meal-plans", strings.NewReader(body)), testEmail))
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST meal plan failed: status %d: %s", w.Code, w.Body.String())
+	}
+	var plan models.MealPlanResponse
+	if err := json.NewDecoder(w.Body).Decode(&plan); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	t.Cleanup(func() { storage.DeleteMealPlan(plan.UUID) })
+	return plan
+}
+
+func TestAddMealPlanEntry_Invalid(t *testing.T) {
+	ensureTestUser(t)
+	plan := createMealPlan(t, `{"name": "meal-plan-invalid"}`)
+	recipeUUID := createShoppingListRecipe(t, "meal-plan-invalid", "1 onion")
+	path := "/api/meal-plans/" + plan.UUID.String() + "/entries"
+
+	tests := []struct {
+		desc       string
+		body       string
+		wantStatus int
+	}{
+		{"bad date", `{"recipe_uuid": "` + recipeUUID.String() + `", "date": "19/10/2026", "meal": "dinner"}`, http.StatusBadRequest},
+		{"unknown meal", `{"recipe_uuid": "` + recipeUUID.String() + `", "date": "2026-10-19", "meal": "brunch"}`, http.StatusBadRequest},
+		{"zero servings", `{"recipe_uuid": "` + recipeUUID.String() + `", "date": "2026-10-19", "meal": "dinner", "servings": 0}`, http.StatusBadRequest},
+		{"missing recipe", `{"date": "2026-10-19", "meal": "dinner"}`, http.StatusNotFound},
+		{"invalid body", `{`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			AddMealPlanEntry(w, asUser(t, httptest.NewRequest("POST", path, strings.NewReader(tt.body)), testEmail))
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMealPlan_ShoppingListAndCalendar(t *testing.T) {
+	ensureTestUser(t)
+	ensureTestUser2(t)
+	plan := createMealPlan(t, `{"name": "Week 43"}`)
+	pasta := createShoppingListRecipe(t, "meal-plan-pasta", "2 eggs", "1 onion")
+	planPath := "/api/meal-plans/" + plan.UUID.String()
+
+	for _, entry := range []string{
+		`{"recipe_uuid": "` + pasta.String() + `", "date": "2026-10-19", "meal": "dinner", "servings": 4}`,
+		`{"recipe_uuid": "` + pasta.String() + `", "date": "2026-10-22", "meal": "lunch"}`,
+		`{"recipe_uuid": "` + pasta.String() + `", "date": "2026-10-26", "meal": "dinner"}`,
+	} {
+		w := httptest.NewRecorder()
+		AddMealPlanEntry(w, asUser(t, httptest.NewRequest("POST", planPath+"/entries", strings.NewReader(entry)), testEmail))
+		if w.Code != http.StatusOK {
+			t.Fatalf("POST entry failed: status %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	GetMealPlan(w, asUser(t, httptest.NewRequest("GET", planPath+"?from=2026-10-19&to=2026-10-25", nil), testEmail))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET meal plan failed: status %d: %s", w.Code, w.Body.String())
+	}
+	var got models.MealPlanResponse
+	json.NewDecoder(w.Body).Decode(&got)
+	if len(got.Entries) != 2 || got.Entries[0].Date != "2026-10-19" || got.Entries[1].Meal != models.MealLunch {
+		t.Errorf("Expected the two meals that week, got %+v", got.Entries)
+	}
+
+	// The recipe has no servings, so each meal needs the recipe as written
+	w = httptest.NewRecorder()
+	CreateMealPlanShoppingList(w, asUser(t, httptest.NewRequest("POST", planPath+"/shopping-list", strings.NewReader(`{"from": "2026-10-19"}`)), testEmail))
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST shopping list failed: status %d: %s", w.Code, w.Body.String())
+	}
+	var list models.ShoppingListResponse
+	json.NewDecoder(w.Body).Decode(&list)
+	defer storage.DeleteShoppingList(list.UserUUID, list.UUID)
+	if list.Name != "Week 43, week of 2026-10-19" {
+		t.Errorf("Expected the list to be named after the week, got %q", list.Name)
+	}
+	if _, eggs := findShoppingListItem(list, "eggs"); eggs == nil || eggs.Quantity != "4" {
+		t.Errorf("Expected 4 eggs, got %+v", eggs)
+	}
+
+	w = httptest.NewRecorder()
+	CreateMealPlanShoppingList(w, asUser(t, httptest.NewRequest("POST", planPath+"/shopping-list", strings.NewReader(`{"from": "2026-11-02"}`)), testEmail))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an empty week, got %d", w.Code)
+	}
+
+	// The signed feed URL works without a session
+	feedURL, err := url.Parse(got.FeedURL)
+	if err != nil || feedURL.Path != planPath+"/calendar.ics" {
+		t.Fatalf("Expected a feed URL for the plan, got %q", got.FeedURL)
+	}
+	w = httptest.NewRecorder()
+	GetMealPlanCalendar(w, httptest.NewRequest("GET", got.FeedURL, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET calendar failed: status %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+		t.Errorf("Expected text/calendar, got %q", ct)
+	}
+	ics := w.Body.String()
+	for _, want := range []string{"X-WR-CALNAME:Week 43", "SUMMARY:Dinner: meal-plan-pasta", "DTSTART:20261019T183000", "DESCRIPTION:Serves 4"} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("Expected calendar to contain %q, got:\n%s", want, ics)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	GetMealPlanCalendar(w, httptest.NewRequest("GET", planPath+"/calendar.ics?"+feedURL.RawQuery+"0", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a tampered feed URL to be unauthorized, got %d", w.Code)
+	}
+
+	// Regenerating the feed key revokes the old feed URL
+	w = httptest.NewRecorder()
+	RegenerateCalendarFeedKey(w, asUser(t, httptest.NewRequest("POST", "/api/meal-plans/feed-key", nil), testEmail))
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST feed key failed: status %d: %s", w.Code, w.Body.String())
+	}
+	var regenerated models.MealPlansResponse
+	json.NewDecoder(w.Body).Decode(&regenerated)
+	for _, p := range regenerated.MealPlans {
+		if p.UUID == got.UUID && (p.FeedURL == "" || p.FeedURL == got.FeedURL) {
+			t.Errorf("Expected a new feed URL, got %q", p.FeedURL)
+		}
+	}
+	w = httptest.NewRecorder()
+	GetMealPlanCalendar(w, httptest.NewRequest("GET", got.FeedURL, nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the old feed URL to be unauthorized, got %d", w.Code)
+	}
+
+	// Other users only see the plan once it's shared with them
+	w = httptest.NewRecorder()
+	GetMealPlan(w, asUser(t, httptest.NewRequest("GET", planPath, nil), testEmail2))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected an unshared plan to be not found, got %d", w.Code)
+	}
+
+	owner, _ := storage.GetUserByEmail(testEmail)
+	friend, _ := storage.GetUserByEmail(testEmail2)
+	if err := storage.CreateConnection(owner.UUID, friend.UUID); err != nil {
+		t.Fatalf("Failed to create connection: %v", err)
+	}
+	defer storage.DeleteConnection(owner.UUID, friend.UUID)
+
+	w = httptest.NewRecorder()
+	UpdateMealPlan(w, asUser(t, httptest.NewRequest("PATCH", planPath, strings.NewReader(`{"shared": true}`)), testEmail))
+	if w.Code != http.StatusOK {
+		t.Fatalf("PATCH meal plan failed: status %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	GetMealPlan(w, asUser(t, httptest.NewRequest("GET", planPath, nil), testEmail2))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected a shared plan to be visible, got %d: %s", w.Code, w.Body.String())
+	}
+	var shared models.MealPlanResponse
+	json.NewDecoder(w.Body).Decode(&shared)
+	if len(shared.Entries) != 3 || shared.OwnerEmail != testEmail {
+		t.Errorf("Expected all 3 meals from %s, got %+v", testEmail, shared)
+	}
+
+	w = httptest.NewRecorder()
+	DeleteMealPlan(w, asUser(t, httptest.NewRequest("DELETE", planPath, nil), testEmail2))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected a shared plan to be read-only, got %d", w.Code)
+	}
+}
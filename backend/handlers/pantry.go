@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/cobyabrahams/hungr/logger"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+	"github.com/cobyabrahams/hungr/units"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	defaultMatchesLimit = 20
+	maxMatchesLimit     = 100
+)
+
+// GetPantry handles GET /api/pantry, listing what the caller has on hand
+func GetPantry(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	respondWithPantry(w, r, user.UUID)
+}
+
+// SetPantryItem handles PUT /api/pantry, adding an ingredient to the caller's
+// pantry or changing how much of it they have
+func SetPantryItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.SetPantryItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	req.IngredientName = strings.TrimSpace(req.IngredientName)
+	if req.IngredientName == "" {
+		respondWithError(w, http.StatusBadRequest, "ingredient_name is required")
+		return
+	}
+	if !(req.Quantity >= 0) || math.IsInf(req.Quantity, 1) {
+		respondWithError(w, http.StatusBadRequest, "quantity must not be negative")
+		return
+	}
+
+	var ingredientType *models.IngredientUnit
+	var baseQuantity *float64
+	if req.Quantity > 0 {
+		unit := "count"
+		if req.Unit != "" {
+			parsed, _, err := units.ParseUnit(req.Unit)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "invalid unit: "+err.Error())
+				return
+			}
+			unit = parsed
+		}
+		base, category, err := units.ToBaseUnit(req.Quantity, unit)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid unit: "+err.Error())
+			return
+		}
+		t := models.IngredientUnit(baseUnits[category])
+		ingredientType, baseQuantity = &t, &base
+	}
+
+	if err := storage.SetPantryItem(user.UUID, req.IngredientName, ingredientType, baseQuantity); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusBadRequest, "ingredient "+req.IngredientName+" not found")
+			return
+		}
+		logger.Error(ctx, "failed to set pantry item", err, "user_uuid", user.UUID, "ingredient", req.IngredientName)
+		respondWithError(w, http.StatusInternalServerError, "failed to update pantry")
+		return
+	}
+
+	logger.Info(ctx, "pantry item set", "user_uuid", user.UUID, "ingredient", req.IngredientName)
+	respondWithPantry(w, r, user.UUID)
+}
+
+// DeletePantryItem handles DELETE /api/pantry?ingredient=name
+func DeletePantryItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	name := strings.TrimSpace(r.URL.Query().Get("ingredient"))
+	if name == "" {
+		respondWithError(w, http.StatusBadRequest, "ingredient is required")
+		return
+	}
+
+	deleted, err := storage.DeletePantryItem(user.UUID, name)
+	if err != nil {
+		logger.Error(ctx, "failed to delete pantry item", err, "user_uuid", user.UUID, "ingredient", name)
+		respondWithError(w, http.StatusInternalServerError, "failed to update pantry")
+		return
+	}
+	if !deleted {
+		respondWithError(w, http.StatusNotFound, "ingredient not in pantry")
+		return
+	}
+
+	logger.Info(ctx, "pantry item deleted", "user_uuid", user.UUID, "ingredient", name)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// GetRecipeMatches handles GET /api/pantry/matches?limit=N, ranking the
+// recipes the caller can see by how many of their ingredients are in the
+// caller's pantry
+func GetRecipeMatches(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	limit, ok := parseLimit(w, r, defaultMatchesLimit, maxMatchesLimit)
+	if !ok {
+		return
+	}
+
+	matches, err := storage.GetRecipeMatches(user.UUID, limit)
+	if err != nil {
+		logger.Error(ctx, "failed to match recipes to pantry", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to match recipes")
+		return
+	}
+
+	recipes := make([]models.Recipe, len(matches))
+	recipeUUIDs := make([]uuid.UUID, len(matches))
+	for i, m := range matches {
+		recipes[i] = m.Recipe
+		recipeUUIDs[i] = m.Recipe.UUID
+	}
+
+	files, err := storage.GetFilesByRecipeUUIDs(recipeUUIDs)
+	if err != nil {
+		logger.Error(ctx, "failed to get files for recipes", err, "recipe_count", len(recipeUUIDs))
+		respondWithError(w, http.StatusInternalServerError, "failed to load recipe files")
+		return
+	}
+	signFileURLs(files, recipes)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.RecipeMatchesResponse{Matches: matches, FileData: files})
+}
+
+// DeductPantry handles POST /api/pantry/deduct, taking what a recipe uses,
+// multiplied by scale, out of the caller's pantry after they cook it.
+// Ingredients kept without a quantity stay in the pantry.
+func DeductPantry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.DeductPantryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Scale == 0 {
+		req.Scale = 1
+	}
+	if !(req.Scale > 0) || math.IsInf(req.Scale, 1) {
+		respondWithError(w, http.StatusBadRequest, "scale must be a positive number")
+		return
+	}
+
+	recipe, ok := viewableRecipe(w, r, req.RecipeUUID)
+	if !ok {
+		return
+	}
+
+	ingredients, err := recipeShoppingIngredients(user, recipe.UUID, req.Scale, []uuid.UUID{recipe.UUID})
+	if err != nil {
+		logger.Error(ctx, "failed to get recipe ingredients", err, "recipe_uuid", recipe.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to update pantry")
+		return
+	}
+	var deductions []storage.PantryDeduction
+	for _, item := range totalShoppingIngredients(ingredients) {
+		if item.Category == "" {
+			continue
+		}
+		deductions = append(deductions, storage.PantryDeduction{
+			IngredientName: item.IngredientName,
+			IngredientType: models.IngredientUnit(baseUnits[units.UnitCategory(item.Category)]),
+			BaseQuantity:   item.BaseQuantity,
+		})
+	}
+
+	if err := storage.DeductPantryItems(user.UUID, deductions); err != nil {
+		logger.Error(ctx, "failed to deduct from pantry", err, "user_uuid", user.UUID, "recipe_uuid", recipe.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to update pantry")
+		return
+	}
+
+	logger.Info(ctx, "recipe deducted from pantry", "user_uuid", user.UUID, "recipe_uuid", recipe.UUID, "scale", req.Scale)
+	respondWithPantry(w, r, user.UUID)
+}
+
+// respondWithPantry writes the user's pantry with quantities in their best
+// display unit
+func respondWithPantry(w http.ResponseWriter, r *http.Request, userUUID uuid.UUID) {
+	ctx := r.Context()
+	items, err := storage.GetPantryItems(userUUID)
+	if err != nil {
+		logger.Error(ctx, "failed to get pantry", err, "user_uuid", userUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get pantry")
+		return
+	}
+	if items == nil {
+		items = []models.PantryItem{}
+	}
+	for i := range items {
+		if items[i].Category != "" {
+			items[i].Quantity = units.FormatBest(items[i].BaseQuantity, units.UnitCategory(items[i].Category))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.PantryResponse{Items: items})
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+)
+
+func setPantryItem(t *testing.T, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	SetPantryItem(w, asUser(t, httptest.NewRequest("PUT", "/api/pantry", strings.NewReader(body)), testEmail))
+	return w
+}
+
+func pantryQuantities(t *testing.T, w *httptest.ResponseRecorder) map[string]string {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var pantry models.PantryResponse
+	if err := json.NewDecoder(w.Body).Decode(&pantry); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	quantities := map[string]string{}
+	for _, item := range pantry.Items {
+		quantities[item.IngredientName] = item.Quantity
+	}
+	return quantities
+}
+
+func TestSetPantryItem_Invalid(t *testing.T) {
+	ensureTestUser(t)
+
+	tests := []struct {
+		desc string
+		body string
+	}{
+		{"missing name", `{"quantity": 1}`},
+		{"negative quantity", `{"ingredient_name": "eggs", "quantity": -2}`},
+		{"unknown unit", `{"ingredient_name": "eggs", "quantity": 2, "unit": "bushels"}`},
+		{"unknown ingredient", `{"ingredient_name": "quuxberries-unknown", "quantity": 2}`},
+		{"invalid body", `{`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if w := setPantryItem(t, tt.body); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestPantry_MatchAndDeduct(t *testing.T) {
+	ensureTestUser(t)
+	user, err := storage.GetUserByEmail(testEmail)
+	if err != nil {
+		t.Fatalf("Failed to load user: %v", err)
+	}
+	for _, name := range []string{"flour", "eggs", "salt"} {
+		t.Cleanup(func() { storage.DeletePantryItem(user.UUID, name) })
+	}
+	recipeUUID := createShoppingListRecipe(t, "pantry-match-test", "2 cups flour", "2 eggs", "1 tsp salt", "1 shallot", "1 tbsp chives (optional)")
+
+	for _, body := range []string{
+		`{"ingredient_name": "flour", "quantity": 1, "unit": "kg"}`,
+		`{"ingredient_name": "eggs", "quantity": 6}`,
+		`{"ingredient_name": "salt"}`,
+	} {
+		if w := setPantryItem(t, body); w.Code != http.StatusOK {
+			t.Fatalf("PUT %s failed: status %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	GetRecipeMatches(w, asUser(t, httptest.NewRequest("GET", "/api/pantry/matches?limit=100", nil), testEmail))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response models.RecipeMatchesResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	var match *models.RecipeMatch
+	for i := range response.Matches {
+		if response.Matches[i].Recipe.UUID == recipeUUID {
+			match = &response.Matches[i]
+		}
+	}
+	if match == nil {
+		t.Fatalf("Expected the recipe among the matches, got %+v", response.Matches)
+	}
+	if match.Have != 3 || match.Total != 4 || strings.Join(match.Missing, ",") != "shallot" {
+		t.Errorf("Expected 3/4 missing shallot, got %d/%d missing %q", match.Have, match.Total, match.Missing)
+	}
+
+	deduct := func() map[string]string {
+		w := httptest.NewRecorder()
+		body := `{"recipe_uuid": "` + recipeUUID.String() + `"}`
+		DeductPantry(w, asUser(t, httptest.NewRequest("POST", "/api/pantry/deduct", strings.NewReader(body)), testEmail))
+		return pantryQuantities(t, w)
+	}
+
+	// Flour is kept by mass and used by volume, and salt has no quantity, so
+	// only the eggs go down
+	got := deduct()
+	want := map[string]string{"flour": "1 kg", "eggs": "4", "salt": ""}
+	for name, quantity := range want {
+		if q, ok := got[name]; !ok || q != quantity {
+			t.Errorf("After cooking once: expected %s %q, got %q (in pantry: %v)", name, quantity, q, ok)
+		}
+	}
+
+	deduct()
+	got = deduct()
+	if _, ok := got["eggs"]; ok {
+		t.Errorf("Expected eggs to be used up, got %q", got["eggs"])
+	}
+	if _, ok := got["salt"]; !ok {
+		t.Errorf("Expected salt to stay in the pantry")
+	}
+}
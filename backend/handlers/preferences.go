@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/cobyabrahams/hungr/logger"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+	"github.com/cobyabrahams/hungr/units"
+)
+
+// GetPreferences handles GET /api/preferences
+func GetPreferences(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.Preferences{MeasurementSystem: user.MeasurementSystem})
+}
+
+// UpdatePreferences handles PUT /api/preferences. A null or empty
+// measurement_system goes back to showing whichever unit reads best.
+func UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.Preferences
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	var system *string
+	if req.MeasurementSystem != nil {
+		if _, err := units.ParseMeasurementSystem(*req.MeasurementSystem); err != nil {
+			respondWithError(w, http.StatusBadRequest, "measurement_system must be metric, us, imperial or as_written")
+			return
+		}
+		if *req.MeasurementSystem != "" {
+			system = req.MeasurementSystem
+		}
+	}
+
+	updated, err := storage.UpdateUserMeasurementSystem(user.UUID, system)
+	if err != nil {
+		logger.Error(ctx, "failed to update preferences", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to update preferences")
+		return
+	}
+
+	logger.Info(ctx, "preferences updated", "user_uuid", user.UUID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.Preferences{MeasurementSystem: updated.MeasurementSystem})
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+)
+
+func TestUpdatePreferences(t *testing.T) {
+	ensureTestUser(t)
+	user, err := storage.GetUserByEmail(testEmail)
+	if err != nil {
+		t.Fatalf("Failed to load user: %v", err)
+	}
+	t.Cleanup(func() { storage.UpdateUserMeasurementSystem(user.UUID, nil) })
+
+	tests := []struct {
+		desc       string
+		body       string
+		wantStatus int
+		want       string
+	}{
+		{"metric", `{"measurement_system": "metric"}`, http.StatusOK, "metric"},
+		{"unknown system", `{"measurement_system": "cubits"}`, http.StatusBadRequest, "metric"},
+		{"as written", `{"measurement_system": "as_written"}`, http.StatusOK, "as_written"},
+		{"cleared", `{"measurement_system": null}`, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			UpdatePreferences(w, asUser(t, httptest.NewRequest("PUT", "/api/preferences", strings.NewReader(tt.body)), testEmail))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+
+			w = httptest.NewRecorder()
+			GetPreferences(w, asUser(t, httptest.NewRequest("GET", "/api/preferences", nil), testEmail))
+			var prefs models.Preferences
+			if err := json.NewDecoder(w.Body).Decode(&prefs); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			got := ""
+			if prefs.MeasurementSystem != nil {
+				got = *prefs.MeasurementSystem
+			}
+			if got != tt.want {
+				t.Errorf("Expected measurement_system %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetRecipeSteps_PreferredSystem(t *testing.T) {
+	ensureTestUser(t)
+	user, err := storage.GetUserByEmail(testEmail)
+	if err != nil {
+		t.Fatalf("Failed to load user: %v", err)
+	}
+	metric := "metric"
+	if _, err := storage.UpdateUserMeasurementSystem(user.UUID, &metric); err != nil {
+		t.Fatalf("Failed to set measurement system: %v", err)
+	}
+	t.Cleanup(func() { storage.UpdateUserMeasurementSystem(user.UUID, nil) })
+
+	recipe, err := storage.InsertRecipeByEmail("preferred-system-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+	path := "/api/recipes/" + recipe.UUID.String() + "/steps"
+
+	putBody := `{"steps": [{"instruction": "Melt", "ingredients": ["1 lb butter"]}]}`
+	putW := httptest.NewRecorder()
+	UpdateRecipeSteps(putW, asUser(t, httptest.NewRequest("PUT", path, strings.NewReader(putBody)), testEmail))
+	if putW.Code != http.StatusOK {
+		t.Fatalf("PUT failed: status %d: %s", putW.Code, putW.Body.String())
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "453.6 g butter"},
+		{"?system=us", "1 lb butter"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		GetRecipeSteps(w, asUser(t, httptest.NewRequest("GET", path+tt.query, nil), testEmail))
+		var response models.RecipeStepsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if got := response.Steps[0].Ingredients[0]; got != tt.want {
+			t.Errorf("GET %q: expected %q, got %q", tt.query, tt.want, got)
+		}
+	}
+}
@@ -7,30 +7,82 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/cobyabrahams/hungr/auth"
 	"github.com/cobyabrahams/hungr/logger"
 	"github.com/cobyabrahams/hungr/models"
 	"github.com/cobyabrahams/hungr/storage"
-	"github.com/cobyabrahams/hungr/units"
 	"github.com/gofrs/uuid"
 )
 
+const (
+	defaultRecipesLimit = 100
+	maxRecipesLimit     = 200
+
+	// maxServings is the largest value the servings SMALLINT column holds
+	maxServings = 32767
+)
+
+// GetRecipes handles GET /api/recipes?sort=&cursor=&limit=&tags=&exclude=&match=.
+// tags and exclude are comma separated; match is "all" (default) or "any".
 func GetRecipes(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	email := r.URL.Query().Get("email")
-	if email == "" {
-		respondWithError(w, http.StatusBadRequest, "email is required")
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	email := user.Email
+
+	sortBy, err := storage.ParseRecipeSort(r.URL.Query().Get("sort"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "sort must be one of created, name, last_cooked, rating")
+		return
+	}
+
+	limit, ok := parseLimit(w, r, defaultRecipesLimit, maxRecipesLimit)
+	if !ok {
 		return
 	}
 
-	recipes, err := storage.GetRecipesByUserEmail(email)
+	filter := storage.TagFilter{
+		Tags:    splitTagList(r.URL.Query().Get("tags")),
+		Exclude: splitTagList(r.URL.Query().Get("exclude")),
+	}
+	switch r.URL.Query().Get("match") {
+	case "", "all":
+	case "any":
+		filter.MatchAny = true
+	default:
+		respondWithError(w, http.StatusBadRequest, "match must be all or any")
+		return
+	}
+
+	page := storage.RecipePage{Sort: sortBy, Tags: filter, Limit: limit}
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		page.After, err = storage.DecodeRecipeCursor(cursor)
+		if err != nil || page.After.Sort != sortBy {
+			respondWithError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+	}
+
+	recipes, next, err := storage.GetRecipesByUserEmail(email, page)
 	if err != nil {
 		logger.Error(ctx, "failed to get recipes", err, "email", email)
 		respondWithError(w, http.StatusInternalServerError, "failed to load recipes")
 		return
 	}
 
+	tagCounts, err := storage.GetTagCountsByUserEmail(email, filter)
+	if err != nil {
+		logger.Error(ctx, "failed to count recipe tags", err, "email", email)
+		respondWithError(w, http.StatusInternalServerError, "failed to load recipes")
+		return
+	}
+
 	recipeUUIDs := make([]uuid.UUID, len(recipes))
 	for i, r := range recipes {
 		recipeUUIDs[i] = r.UUID
@@ -43,9 +95,16 @@ func GetRecipes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	signFileURLs(files, recipes)
+
 	response := models.RecipesResponse{
 		RecipeData: recipes,
 		FileData:   files,
+		TagCounts:  tagCounts,
+	}
+	if next != nil {
+		nextCursor := next.Encode()
+		response.NextCursor = &nextCursor
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -62,11 +121,11 @@ func CreateRecipe(w http.ResponseWriter, r *http.Request) {
 
 	tagString := r.URL.Query().Get("tagString")
 
-	email := r.URL.Query().Get("email")
-	if email == "" {
-		respondWithError(w, http.StatusBadRequest, "email is required")
+	user, ok := requireUser(w, r)
+	if !ok {
 		return
 	}
+	email := user.Email
 
 	sourceParam := r.URL.Query().Get("source")
 	var source *string
@@ -165,6 +224,12 @@ func CreateRecipe(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if _, err := storage.TxRecordRevision(ctx, tx, recipe.UUID, user.UUID); err != nil {
+		logger.Error(ctx, "failed to record recipe revision", err, "recipe_uuid", recipe.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to create recipe")
+		return
+	}
+
 	// Commit transaction
 	if err := tx.Commit(ctx); err != nil {
 		logger.Error(ctx, "failed to commit transaction", err, "recipe_uuid", recipe.UUID)
@@ -196,6 +261,10 @@ func DeleteRecipe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, ok := editableRecipe(w, r, recipeUUID); !ok {
+		return
+	}
+
 	err = storage.DeleteRecipe(recipeUUID)
 	if err != nil {
 		logger.Error(ctx, "failed to delete recipe", err, "recipe_uuid", recipeUUID)
@@ -203,7 +272,7 @@ func DeleteRecipe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Info(ctx, "recipe deleted", "recipe_uuid", recipeUUID)
+	logger.Info(ctx, "recipe moved to trash", "recipe_uuid", recipeUUID)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]bool{"success": true})
 }
@@ -225,15 +294,8 @@ func AddRecipeFiles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if recipe exists
-	_, err = storage.GetRecipeByUUID(recipeUUID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			respondWithError(w, http.StatusNotFound, "recipe not found")
-			return
-		}
-		logger.Error(ctx, "failed to get recipe", err, "recipe_uuid", recipeUUID)
-		respondWithError(w, http.StatusInternalServerError, "failed to get recipe")
+	recipe, ok := editableRecipe(w, r, recipeUUID)
+	if !ok {
 		return
 	}
 
@@ -318,6 +380,8 @@ func AddRecipeFiles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	signFileURLs(insertedFiles, []models.Recipe{*recipe})
+
 	response := models.FileUploadResponse{
 		Success: true,
 		Files:   insertedFiles,
@@ -343,6 +407,37 @@ func GetFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	recipeUUID, err := storage.GetFileRecipeUUID(fileUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "file not found")
+			return
+		}
+		logger.Error(ctx, "failed to get file", err, "file_uuid", fileUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get file")
+		return
+	}
+
+	recipe, ok := loadRecipe(w, r, recipeUUID)
+	if !ok {
+		return
+	}
+
+	// Files inherit their recipe's visibility. Public files can be cached by
+	// anyone; private ones need a signed URL or a session that can view the recipe.
+	var cacheControl string
+	if recipe.IsPublic {
+		cacheControl = "public, max-age=31536000"
+	} else if auth.VerifySignedURL(r.URL.Path, r.URL.Query(), time.Now()) {
+		expires, _ := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+		cacheControl = fmt.Sprintf("private, max-age=%d", expires-time.Now().Unix())
+	} else {
+		if !authorizeView(w, r, recipe) {
+			return
+		}
+		cacheControl = "private, no-cache"
+	}
+
 	data, contentType, err := storage.GetFileData(fileUUID)
 	if err != nil {
 		logger.Error(ctx, "failed to get file data", err, "file_uuid", fileUUID)
@@ -351,10 +446,52 @@ func GetFile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", contentType)
-	w.Header().Set("Cache-Control", "public, max-age=31536000")
+	w.Header().Set("Cache-Control", cacheControl)
 	w.Write(data)
 }
 
+// signFileURLs replaces the URLs of files belonging to private recipes with
+// signed, expiring URLs so they load in <img> tags without credentials
+func signFileURLs(files []models.File, recipes []models.Recipe) {
+	public := make(map[uuid.UUID]bool, len(recipes))
+	for _, recipe := range recipes {
+		public[recipe.UUID] = recipe.IsPublic
+	}
+
+	expiresAt := auth.SignedURLExpiry(time.Now())
+	for i := range files {
+		if !public[files[i].RecipeUUID] {
+			files[i].URL = auth.SignURL(files[i].URL, expiresAt)
+		}
+	}
+}
+
+// splitTagList splits a comma separated tag list query parameter
+func splitTagList(param string) []string {
+	var tags []string
+	for _, tag := range strings.Split(param, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// parseLimit reads the optional ?limit= parameter, capping it at maxLimit. It
+// responds with 400 and returns false when the value is not a positive integer.
+func parseLimit(w http.ResponseWriter, r *http.Request, defaultLimit, maxLimit int) (int, bool) {
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		return defaultLimit, true
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 {
+		respondWithError(w, http.StatusBadRequest, "limit must be a positive integer")
+		return 0, false
+	}
+	return min(limit, maxLimit), true
+}
+
 func respondWithError(w http.ResponseWriter, code int, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
@@ -400,15 +537,29 @@ func GetRecipeSteps(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if recipe exists
-	_, err = storage.GetRecipeByUUID(recipeUUID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			respondWithError(w, http.StatusNotFound, "recipe not found")
-			return
-		}
-		logger.Error(ctx, "failed to get recipe", err, "recipe_uuid", recipeUUID)
-		respondWithError(w, http.StatusInternalServerError, "failed to get recipe")
+	recipe, ok := viewableRecipe(w, r, recipeUUID)
+	if !ok {
+		return
+	}
+
+	format, ok := parseStepsFormat(w, r)
+	if !ok {
+		return
+	}
+	expand, ok := parseExpand(w, r, format)
+	if !ok {
+		return
+	}
+	scale, ok := parseScale(w, r, recipe)
+	if !ok {
+		return
+	}
+	convert, ok := parseConvert(w, r)
+	if !ok {
+		return
+	}
+	system, ok := parseMeasurementSystem(w, r)
+	if !ok {
 		return
 	}
 
@@ -420,6 +571,14 @@ func GetRecipeSteps(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	conversion, err := loadUnitConversion(auth.UserFromContext(ctx), convert)
+	if err != nil {
+		logger.Error(ctx, "failed to get ingredient densities", err, "recipe_uuid", recipeUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get recipe steps")
+		return
+	}
+	conversion.apply(stepsWithIngredients)
+
 	tags, err := storage.GetTagsByRecipeUUID(recipeUUID)
 	if err != nil {
 		logger.Error(ctx, "failed to get recipe tags", err, "recipe_uuid", recipeUUID)
@@ -432,28 +591,38 @@ func GetRecipeSteps(w http.ResponseWriter, r *http.Request) {
 		tagNames = append(tagNames, tag.Name)
 	}
 
-	// Build response
-	response := models.RecipeStepsResponse{
-		Steps: make([]models.RecipeStepResponse, len(stepsWithIngredients)),
-		Tags:  tagNames,
+	var servings *float64
+	if recipe.Servings != nil {
+		scaled := float64(*recipe.Servings) * scale
+		servings = &scaled
 	}
 
-	for i, step := range stepsWithIngredients {
-		ingredients := make([]string, len(step.Ingredients))
-		for j, ing := range step.Ingredients {
-			category := units.GetCategoryForIngredientUnit(ing.IngredientType)
-			formatted := units.FormatBest(ing.Quantity, category)
-			ingredients[j] = fmt.Sprintf("%s %s", formatted, ing.IngredientName)
-		}
-
-		response.Steps[i] = models.RecipeStepResponse{
-			Instruction: step.Instructions,
-			Ingredients: ingredients,
+	steps := buildStepsV2(stepsWithIngredients, scale, system)
+	if expand {
+		if err := expandSubRecipes(auth.UserFromContext(ctx), steps, []uuid.UUID{recipeUUID}, conversion, system); err != nil {
+			logger.Error(ctx, "failed to expand sub-recipes", err, "recipe_uuid", recipeUUID)
+			respondWithError(w, http.StatusInternalServerError, "failed to get recipe steps")
+			return
 		}
 	}
-
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	if format == stepsFormatV2 {
+		json.NewEncoder(w).Encode(models.RecipeStepsV2Response{
+			Steps:     steps,
+			Tags:      tagNames,
+			Servings:  servings,
+			YieldText: recipe.YieldText,
+			Scale:     scale,
+		})
+		return
+	}
+	json.NewEncoder(w).Encode(models.RecipeStepsResponse{
+		Steps:     stepResponsesFromV2(steps),
+		Tags:      tagNames,
+		Servings:  servings,
+		YieldText: recipe.YieldText,
+		Scale:     scale,
+	})
 }
 
 func UpdateRecipeSteps(w http.ResponseWriter, r *http.Request) {
@@ -473,54 +642,82 @@ func UpdateRecipeSteps(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if recipe exists
-	_, err = storage.GetRecipeByUUID(recipeUUID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			respondWithError(w, http.StatusNotFound, "recipe not found")
-			return
-		}
-		logger.Error(ctx, "failed to get recipe", err, "recipe_uuid", recipeUUID)
-		respondWithError(w, http.StatusInternalServerError, "failed to get recipe")
+	if _, ok := editableRecipe(w, r, recipeUUID); !ok {
 		return
 	}
 
-	// Parse request body
-	var request models.RecipeStepsResponse
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid request body")
+	format, ok := parseStepsFormat(w, r)
+	if !ok {
 		return
 	}
 
-	// Convert to storage input format
-	steps := make([]storage.StepInput, len(request.Steps))
-	for i, step := range request.Steps {
-		ingredients := make([]storage.IngredientInput, len(step.Ingredients))
-		for j, ingStr := range step.Ingredients {
-			parsed, err := units.ParseIngredientString(ingStr)
-			if err != nil {
-				respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid ingredient %q: %v", ingStr, err))
-				return
-			}
-			ingredients[j] = storage.IngredientInput{
-				Name:     parsed.IngredientName,
-				Unit:     parsed.Unit,
-				Quantity: parsed.Quantity,
-			}
+	// Parse request body and convert to storage input format
+	var steps []storage.StepInput
+	if format == stepsFormatV2 {
+		var request models.RecipeStepsV2Response
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid request body")
+			return
 		}
-		steps[i] = storage.StepInput{
-			Instruction: step.Instruction,
-			Ingredients: ingredients,
+		steps, err = stepInputsFromV2(request.Steps)
+	} else {
+		var request models.RecipeStepsResponse
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		steps, err = stepInputsFromStrings(request.Steps)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !resolveSubRecipes(w, r, steps) {
+		return
+	}
+	if format != stepsFormatV2 {
+		existing, err := storage.GetRecipeStepsWithIngredients(recipeUUID)
+		if err == nil {
+			err = keepSubRecipes(ctx, existing, steps)
+		}
+		if err != nil {
+			logger.Error(ctx, "failed to carry over sub-recipes", err, "recipe_uuid", recipeUUID)
+			respondWithError(w, http.StatusInternalServerError, "failed to update recipe steps")
+			return
 		}
 	}
 
+	tx, err := storage.BeginTx(ctx)
+	if err != nil {
+		logger.Error(ctx, "failed to begin transaction", err, "recipe_uuid", recipeUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to update recipe steps")
+		return
+	}
+	defer tx.Rollback(ctx)
+
 	// Replace all steps
-	if err := storage.ReplaceRecipeSteps(recipeUUID, steps); err != nil {
+	if err := storage.TxReplaceRecipeSteps(ctx, tx, recipeUUID, steps); err != nil {
+		if errors.Is(err, storage.ErrSubRecipeCycle) {
+			respondWithError(w, http.StatusConflict, "a recipe cannot include itself as a sub-recipe")
+			return
+		}
 		logger.Error(ctx, "failed to update recipe steps", err, "recipe_uuid", recipeUUID)
 		respondWithError(w, http.StatusInternalServerError, "failed to update recipe steps")
 		return
 	}
 
+	if _, err := storage.TxRecordRevision(ctx, tx, recipeUUID, auth.UserFromContext(ctx).UUID); err != nil {
+		logger.Error(ctx, "failed to record recipe revision", err, "recipe_uuid", recipeUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to update recipe steps")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error(ctx, "failed to commit transaction", err, "recipe_uuid", recipeUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to update recipe steps")
+		return
+	}
+
 	logger.Info(ctx, "recipe steps updated", "recipe_uuid", recipeUUID, "step_count", len(steps))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]bool{"success": true})
@@ -543,6 +740,15 @@ func GetPublicRecipe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	format, ok := parseStepsFormat(w, r)
+	if !ok {
+		return
+	}
+	system, ok := parseMeasurementSystem(w, r)
+	if !ok {
+		return
+	}
+
 	// Get the recipe
 	recipe, err := storage.GetRecipeByUUID(recipeUUID)
 	if err != nil {
@@ -590,30 +796,23 @@ func GetPublicRecipe(w http.ResponseWriter, r *http.Request) {
 		tagNames = append(tagNames, tag.Name)
 	}
 
-	// Build steps response
-	steps := make([]models.RecipeStepResponse, len(stepsWithIngredients))
-	for i, step := range stepsWithIngredients {
-		ingredients := make([]string, len(step.Ingredients))
-		for j, ing := range step.Ingredients {
-			category := units.GetCategoryForIngredientUnit(ing.IngredientType)
-			formatted := units.FormatBest(ing.Quantity, category)
-			ingredients[j] = fmt.Sprintf("%s %s", formatted, ing.IngredientName)
-		}
-		steps[i] = models.RecipeStepResponse{
-			Instruction: step.Instructions,
-			Ingredients: ingredients,
-		}
+	steps := buildStepsV2(stepsWithIngredients, 1, system)
+	w.Header().Set("Content-Type", "application/json")
+	if format == stepsFormatV2 {
+		json.NewEncoder(w).Encode(models.PublicRecipeV2Response{
+			Recipe: *recipe,
+			Files:  files,
+			Steps:  steps,
+			Tags:   tagNames,
+		})
+		return
 	}
-
-	response := models.PublicRecipeResponse{
+	json.NewEncoder(w).Encode(models.PublicRecipeResponse{
 		Recipe: *recipe,
 		Files:  files,
-		Steps:  steps,
+		Steps:  stepResponsesFromV2(steps),
 		Tags:   tagNames,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	})
 }
 
 func SetRecipePublic(w http.ResponseWriter, r *http.Request) {
@@ -633,15 +832,7 @@ func SetRecipePublic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if recipe exists
-	_, err = storage.GetRecipeByUUID(recipeUUID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			respondWithError(w, http.StatusNotFound, "recipe not found")
-			return
-		}
-		logger.Error(ctx, "failed to get recipe", err, "recipe_uuid", recipeUUID)
-		respondWithError(w, http.StatusInternalServerError, "failed to get recipe")
+	if _, ok := editableRecipe(w, r, recipeUUID); !ok {
 		return
 	}
 
@@ -681,15 +872,7 @@ func PatchRecipe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if recipe exists
-	_, err = storage.GetRecipeByUUID(recipeUUID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			respondWithError(w, http.StatusNotFound, "recipe not found")
-			return
-		}
-		logger.Error(ctx, "failed to get recipe", err, "recipe_uuid", recipeUUID)
-		respondWithError(w, http.StatusInternalServerError, "failed to get recipe")
+	if _, ok := editableRecipe(w, r, recipeUUID); !ok {
 		return
 	}
 
@@ -699,6 +882,14 @@ func PatchRecipe(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
+	if request.Servings != nil && *request.Servings < 0 {
+		respondWithError(w, http.StatusBadRequest, "servings must not be negative")
+		return
+	}
+	if request.Servings != nil && *request.Servings > maxServings {
+		respondWithError(w, http.StatusBadRequest, "servings must be at most "+strconv.Itoa(maxServings))
+		return
+	}
 
 	// Start transaction
 	tx, err := storage.BeginTx(ctx)
@@ -717,38 +908,71 @@ func PatchRecipe(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Delete existing recipe tags
-	if err := storage.TxDeleteRecipeTags(ctx, tx, recipeUUID); err != nil {
-		logger.Error(ctx, "failed to delete existing tags", err, "recipe_uuid", recipeUUID)
-		respondWithError(w, http.StatusInternalServerError, "failed to update tags")
-		return
+	if request.Servings != nil {
+		servings := request.Servings
+		if *servings == 0 {
+			servings = nil
+		}
+		if err := storage.TxUpdateRecipeServings(ctx, tx, recipeUUID, servings); err != nil {
+			logger.Error(ctx, "failed to update recipe servings", err, "recipe_uuid", recipeUUID)
+			respondWithError(w, http.StatusInternalServerError, "failed to update recipe")
+			return
+		}
 	}
 
-	// Insert new tags
-	if request.TagString != "" {
-		tags := strings.Split(request.TagString, ", ")
-		for _, tagName := range tags {
-			tagName = strings.TrimSpace(tagName)
-			if tagName == "" {
-				continue
-			}
+	if request.YieldText != nil {
+		yieldText := request.YieldText
+		if strings.TrimSpace(*yieldText) == "" {
+			yieldText = nil
+		}
+		if err := storage.TxUpdateRecipeYieldText(ctx, tx, recipeUUID, yieldText); err != nil {
+			logger.Error(ctx, "failed to update recipe yield", err, "recipe_uuid", recipeUUID)
+			respondWithError(w, http.StatusInternalServerError, "failed to update recipe")
+			return
+		}
+	}
 
-			tagUUID := storage.CreateTagUUID(tagName)
-			_, err := storage.TxUpsertTag(ctx, tx, tagUUID, tagName)
-			if err != nil {
-				logger.Error(ctx, "failed to upsert tag", err, "recipe_uuid", recipeUUID, "tag", tagName)
-				respondWithError(w, http.StatusInternalServerError, "failed to create tag")
-				return
-			}
+	// Replace the tags only when the request sets them
+	if request.TagString != nil {
+		// Delete existing recipe tags
+		if err := storage.TxDeleteRecipeTags(ctx, tx, recipeUUID); err != nil {
+			logger.Error(ctx, "failed to delete existing tags", err, "recipe_uuid", recipeUUID)
+			respondWithError(w, http.StatusInternalServerError, "failed to update tags")
+			return
+		}
 
-			if err := storage.TxInsertRecipeTag(ctx, tx, recipeUUID, tagUUID); err != nil {
-				logger.Error(ctx, "failed to link tag to recipe", err, "recipe_uuid", recipeUUID, "tag_uuid", tagUUID)
-				respondWithError(w, http.StatusInternalServerError, "failed to link tag")
-				return
+		// Insert new tags
+		if *request.TagString != "" {
+			tags := strings.Split(*request.TagString, ", ")
+			for _, tagName := range tags {
+				tagName = strings.TrimSpace(tagName)
+				if tagName == "" {
+					continue
+				}
+
+				tagUUID := storage.CreateTagUUID(tagName)
+				_, err := storage.TxUpsertTag(ctx, tx, tagUUID, tagName)
+				if err != nil {
+					logger.Error(ctx, "failed to upsert tag", err, "recipe_uuid", recipeUUID, "tag", tagName)
+					respondWithError(w, http.StatusInternalServerError, "failed to create tag")
+					return
+				}
+
+				if err := storage.TxInsertRecipeTag(ctx, tx, recipeUUID, tagUUID); err != nil {
+					logger.Error(ctx, "failed to link tag to recipe", err, "recipe_uuid", recipeUUID, "tag_uuid", tagUUID)
+					respondWithError(w, http.StatusInternalServerError, "failed to link tag")
+					return
+				}
 			}
 		}
 	}
 
+	if _, err := storage.TxRecordRevision(ctx, tx, recipeUUID, auth.UserFromContext(ctx).UUID); err != nil {
+		logger.Error(ctx, "failed to record recipe revision", err, "recipe_uuid", recipeUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to update recipe")
+		return
+	}
+
 	// Commit transaction
 	if err := tx.Commit(ctx); err != nil {
 		logger.Error(ctx, "failed to commit transaction", err, "recipe_uuid", recipeUUID)
@@ -756,7 +980,149 @@ func PatchRecipe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Info(ctx, "recipe tags updated", "recipe_uuid", recipeUUID, "tag_string", request.TagString)
+	logger.Info(ctx, "recipe updated", "recipe_uuid", recipeUUID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// SetRecipeRating handles PUT /api/recipes/{uuid}/rating
+func SetRecipeRating(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Parse recipe UUID from path: /api/recipes/{uuid}/rating
+	path := r.URL.Path
+	parts := strings.Split(strings.TrimPrefix(path, "/api/recipes/"), "/")
+	if len(parts) < 1 || parts[0] == "" {
+		respondWithError(w, http.StatusBadRequest, "recipe uuid is required")
+		return
+	}
+
+	recipeUUID, err := uuid.FromString(parts[0])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid recipe uuid")
+		return
+	}
+
+	if _, ok := editableRecipe(w, r, recipeUUID); !ok {
+		return
+	}
+
+	var request models.SetRatingRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if request.Rating != nil && (*request.Rating < 1 || *request.Rating > 5) {
+		respondWithError(w, http.StatusBadRequest, "rating must be between 1 and 5")
+		return
+	}
+
+	if err := storage.SetRecipeRating(recipeUUID, request.Rating); err != nil {
+		logger.Error(ctx, "failed to set recipe rating", err, "recipe_uuid", recipeUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to update recipe")
+		return
+	}
+
+	logger.Info(ctx, "recipe rating updated", "recipe_uuid", recipeUUID, "rating", request.Rating)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]bool{"success": true})
 }
+
+// MarkRecipeCooked handles POST /api/recipes/{uuid}/cooked
+func MarkRecipeCooked(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Parse recipe UUID from path: /api/recipes/{uuid}/cooked
+	path := r.URL.Path
+	parts := strings.Split(strings.TrimPrefix(path, "/api/recipes/"), "/")
+	if len(parts) < 1 || parts[0] == "" {
+		respondWithError(w, http.StatusBadRequest, "recipe uuid is required")
+		return
+	}
+
+	recipeUUID, err := uuid.FromString(parts[0])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid recipe uuid")
+		return
+	}
+
+	if _, ok := editableRecipe(w, r, recipeUUID); !ok {
+		return
+	}
+
+	cookedAt, err := storage.MarkRecipeCooked(recipeUUID)
+	if err != nil {
+		logger.Error(ctx, "failed to mark recipe cooked", err, "recipe_uuid", recipeUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to update recipe")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.CookedResponse{Success: true, LastCookedAt: cookedAt})
+}
+
+// ForkRecipe handles POST /api/recipes/{uuid}/fork, copying a recipe the caller
+// can view into their own collection
+func ForkRecipe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Parse recipe UUID from path: /api/recipes/{uuid}/fork
+	path := r.URL.Path
+	parts := strings.Split(strings.TrimPrefix(path, "/api/recipes/"), "/")
+	if len(parts) < 1 || parts[0] == "" {
+		respondWithError(w, http.StatusBadRequest, "recipe uuid is required")
+		return
+	}
+
+	recipeUUID, err := uuid.FromString(parts[0])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid recipe uuid")
+		return
+	}
+
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := viewableRecipe(w, r, recipeUUID); !ok {
+		return
+	}
+
+	tx, err := storage.BeginTx(ctx)
+	if err != nil {
+		logger.Error(ctx, "failed to begin transaction", err, "recipe_uuid", recipeUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to fork recipe")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	forkUUID, err := storage.TxForkRecipe(ctx, tx, recipeUUID, user.UUID)
+	if err != nil {
+		logger.Error(ctx, "failed to fork recipe", err, "recipe_uuid", recipeUUID, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to fork recipe")
+		return
+	}
+
+	if _, err := storage.TxRecordRevision(ctx, tx, forkUUID, user.UUID); err != nil {
+		logger.Error(ctx, "failed to record recipe revision", err, "recipe_uuid", forkUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to fork recipe")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error(ctx, "failed to commit transaction", err, "recipe_uuid", recipeUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to fork recipe")
+		return
+	}
+
+	fork, err := storage.GetRecipeByUUID(forkUUID)
+	if err != nil {
+		logger.Error(ctx, "failed to load forked recipe", err, "recipe_uuid", forkUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to load forked recipe")
+		return
+	}
+
+	logger.Info(ctx, "recipe forked", "recipe_uuid", recipeUUID, "fork_uuid", forkUUID, "user_uuid", user.UUID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.ForkResponse{Success: true, Recipe: *fork})
+}
@@ -9,9 +9,12 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/cobyabrahams/hungr/auth"
 	"github.com/cobyabrahams/hungr/logger"
 	"github.com/cobyabrahams/hungr/models"
 	"github.com/cobyabrahams/hungr/storage"
@@ -23,6 +26,7 @@ var _ = uuid.Nil
 
 func init() {
 	logger.Init()
+	auth.Init("test-secret")
 	dbURL := os.Getenv("DATABASE_URL")
 	if dbURL == "" {
 		panic("DATABASE_URL must be set to run tests")
@@ -44,10 +48,19 @@ func ensureTestUser(t *testing.T) {
 	}
 }
 
+// asUser attaches a user to the request context the way middleware.Authenticate does
+func asUser(t *testing.T, req *http.Request, email string) *http.Request {
+	user, err := storage.GetUserByEmail(email)
+	if err != nil {
+		t.Fatalf("Failed to load user %s: %v", email, err)
+	}
+	return req.WithContext(auth.WithUser(req.Context(), user))
+}
+
 func TestGetRecipes_ReturnsJSON(t *testing.T) {
 	ensureTestUser(t)
 
-	req := httptest.NewRequest("GET", "/api/recipes?email="+testEmail, nil)
+	req := asUser(t, httptest.NewRequest("GET", "/api/recipes", nil), testEmail)
 	w := httptest.NewRecorder()
 
 	GetRecipes(w, req)
@@ -78,8 +91,8 @@ func TestGetRecipes_ReturnsJSON(t *testing.T) {
 	}
 }
 
-func TestGetRecipes_MissingEmail(t *testing.T) {
-	req := httptest.NewRequest("GET", "/api/recipes", nil)
+func TestGetRecipes_Unauthenticated(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/recipes?email="+testEmail, nil)
 	w := httptest.NewRecorder()
 
 	GetRecipes(w, req)
@@ -87,17 +100,218 @@ func TestGetRecipes_MissingEmail(t *testing.T) {
 	resp := w.Result()
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", resp.StatusCode)
+	}
+}
+
+func TestGetRecipes_InvalidParams(t *testing.T) {
+	ensureTestUser(t)
+
+	otherSort := storage.RecipeCursor{Sort: storage.SortName, Key: "soup", UUID: uuid.Must(uuid.NewV4())}
+
+	tests := []struct {
+		desc  string
+		query string
+	}{
+		{"unknown sort", "sort=color"},
+		{"bad limit", "limit=abc"},
+		{"zero limit", "limit=0"},
+		{"malformed cursor", "cursor=not-a-cursor"},
+		{"cursor for another sort", "sort=rating&cursor=" + otherSort.Encode()},
+		{"unknown match", "tags=dinner&match=some"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := asUser(t, httptest.NewRequest("GET", "/api/recipes?"+tt.query, nil), testEmail)
+			w := httptest.NewRecorder()
+
+			GetRecipes(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetRecipes_Pagination(t *testing.T) {
+	ensureTestUser(t)
+
+	var recipeUUIDs []uuid.UUID
+	for _, name := range []string{"paging-a", "paging-b"} {
+		recipe, err := storage.InsertRecipeByEmail(name, testEmail, nil)
+		if err != nil {
+			t.Fatalf("Failed to create test recipe: %v", err)
+		}
+		defer storage.PurgeRecipe(recipe.UUID)
+		if _, err := storage.InsertFile(recipe.UUID, []byte("image"), "image/jpeg", 0, true); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		recipeUUIDs = append(recipeUUIDs, recipe.UUID)
+	}
+
+	getPage := func(query string) models.RecipesResponse {
+		req := asUser(t, httptest.NewRequest("GET", "/api/recipes?"+query, nil), testEmail)
+		w := httptest.NewRecorder()
+
+		GetRecipes(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response models.RecipesResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	// Newest first, so the second recipe is on the first page
+	first := getPage("limit=1")
+	if len(first.RecipeData) != 1 || first.RecipeData[0].UUID != recipeUUIDs[1] {
+		t.Fatalf("Expected only %v on the first page, got %+v", recipeUUIDs[1], first.RecipeData)
+	}
+	if first.NextCursor == nil {
+		t.Fatal("Expected next_cursor on the first page")
+	}
+	for _, f := range first.FileData {
+		if f.RecipeUUID != recipeUUIDs[1] {
+			t.Errorf("Expected only files for the returned page, got one for %v", f.RecipeUUID)
+		}
+	}
+
+	second := getPage("limit=1&cursor=" + url.QueryEscape(*first.NextCursor))
+	if len(second.RecipeData) != 1 || second.RecipeData[0].UUID != recipeUUIDs[0] {
+		t.Errorf("Expected only %v on the second page, got %+v", recipeUUIDs[0], second.RecipeData)
+	}
+}
+
+func TestGetRecipes_TagFilter(t *testing.T) {
+	ensureTestUser(t)
+
+	tagged, err := storage.InsertRecipeByEmail("tag-filter-tagged", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(tagged.UUID)
+	untagged, err := storage.InsertRecipeByEmail("tag-filter-untagged", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(untagged.UUID)
+
+	tagUUID := storage.CreateTagUUID("handler-tag-filter")
+	if _, err := storage.UpsertTag(tagUUID, "handler-tag-filter"); err != nil {
+		t.Fatalf("UpsertTag failed: %v", err)
+	}
+	if err := storage.InsertRecipeTag(tagged.UUID, tagUUID); err != nil {
+		t.Fatalf("InsertRecipeTag failed: %v", err)
+	}
+
+	req := asUser(t, httptest.NewRequest("GET", "/api/recipes?tags=handler-tag-filter", nil), testEmail)
+	w := httptest.NewRecorder()
+
+	GetRecipes(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response models.RecipesResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.RecipeData) != 1 || response.RecipeData[0].UUID != tagged.UUID {
+		t.Errorf("Expected only the tagged recipe, got %+v", response.RecipeData)
+	}
+	if len(response.TagCounts) != 1 || response.TagCounts[0] != (models.TagCount{Name: "handler-tag-filter", Count: 1}) {
+		t.Errorf("Expected a single tag count for handler-tag-filter, got %+v", response.TagCounts)
+	}
+}
+
+func TestSetRecipeRating(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("rating-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+
+	four := 4
+	tests := []struct {
+		desc       string
+		body       string
+		wantStatus int
+		wantRating *int
+	}{
+		{"set", `{"rating": 4}`, http.StatusOK, &four},
+		{"too high", `{"rating": 6}`, http.StatusBadRequest, &four},
+		{"too low", `{"rating": 0}`, http.StatusBadRequest, &four},
+		{"clear", `{"rating": null}`, http.StatusOK, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := asUser(t, httptest.NewRequest("PUT", "/api/recipes/"+recipe.UUID.String()+"/rating", strings.NewReader(tt.body)), testEmail)
+			w := httptest.NewRecorder()
+
+			SetRecipeRating(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			after, err := storage.GetRecipeByUUID(recipe.UUID)
+			if err != nil {
+				t.Fatalf("GetRecipeByUUID failed: %v", err)
+			}
+			if (after.Rating == nil) != (tt.wantRating == nil) || (after.Rating != nil && *after.Rating != *tt.wantRating) {
+				t.Errorf("Expected rating %v, got %v", tt.wantRating, after.Rating)
+			}
+		})
+	}
+}
+
+func TestMarkRecipeCooked(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("cooked-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+
+	req := asUser(t, httptest.NewRequest("POST", "/api/recipes/"+recipe.UUID.String()+"/cooked", nil), testEmail)
+	w := httptest.NewRecorder()
+
+	MarkRecipeCooked(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response models.CookedResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	after, err := storage.GetRecipeByUUID(recipe.UUID)
+	if err != nil {
+		t.Fatalf("GetRecipeByUUID failed: %v", err)
+	}
+	if after.LastCookedAt == nil || !after.LastCookedAt.Equal(response.LastCookedAt) {
+		t.Errorf("Expected last_cooked_at %v, got %v", response.LastCookedAt, after.LastCookedAt)
 	}
 }
 
 func TestCreateRecipe_MissingName(t *testing.T) {
+	ensureTestUser(t)
+
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 	writer.Close()
 
-	req := httptest.NewRequest("POST", "/api/recipes?email=test@example.com&tagString=test", body)
+	req := asUser(t, httptest.NewRequest("POST", "/api/recipes?tagString=test", body), testEmail)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	w := httptest.NewRecorder()
 
@@ -111,12 +325,12 @@ func TestCreateRecipe_MissingName(t *testing.T) {
 	}
 }
 
-func TestCreateRecipe_MissingEmail(t *testing.T) {
+func TestCreateRecipe_Unauthenticated(t *testing.T) {
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 	writer.Close()
 
-	req := httptest.NewRequest("POST", "/api/recipes?name=Test&tagString=test", body)
+	req := httptest.NewRequest("POST", "/api/recipes?name=Test&tagString=test&email="+testEmail, body)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	w := httptest.NewRecorder()
 
@@ -125,8 +339,8 @@ func TestCreateRecipe_MissingEmail(t *testing.T) {
 	resp := w.Result()
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", resp.StatusCode)
 	}
 }
 
@@ -144,7 +358,7 @@ func TestCreateRecipe_WithFiles(t *testing.T) {
 
 	writer.Close()
 
-	req := httptest.NewRequest("POST", "/api/recipes?email="+testEmail+"&name=TestRecipe&tagString=dinner,%20quick", body)
+	req := asUser(t, httptest.NewRequest("POST", "/api/recipes?name=TestRecipe&tagString=dinner,%20quick", body), testEmail)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	w := httptest.NewRecorder()
 
@@ -194,7 +408,7 @@ func TestCreateRecipe_WithFiles(t *testing.T) {
 	}
 
 	// Cleanup
-	storage.DeleteRecipe(response.Recipe.UUID)
+	storage.PurgeRecipe(response.Recipe.UUID)
 }
 
 func TestCreateRecipe_WithSource(t *testing.T) {
@@ -211,7 +425,7 @@ func TestCreateRecipe_WithSource(t *testing.T) {
 	writer.Close()
 
 	source := "cookbook"
-	req := httptest.NewRequest("POST", "/api/recipes?email="+testEmail+"&name=SourceRecipe&source="+url.QueryEscape(source), body)
+	req := asUser(t, httptest.NewRequest("POST", "/api/recipes?name=SourceRecipe&source="+url.QueryEscape(source), body), testEmail)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	w := httptest.NewRecorder()
 
@@ -234,7 +448,7 @@ func TestCreateRecipe_WithSource(t *testing.T) {
 		t.Fatalf("Expected source %q, got %v", source, response.Recipe.Source)
 	}
 
-	storage.DeleteRecipe(response.Recipe.UUID)
+	storage.PurgeRecipe(response.Recipe.UUID)
 }
 
 func TestCreateRecipe_MultipleFiles(t *testing.T) {
@@ -249,7 +463,7 @@ func TestCreateRecipe_MultipleFiles(t *testing.T) {
 	}
 	writer.Close()
 
-	req := httptest.NewRequest("POST", "/api/recipes?email="+testEmail+"&name=MultiPageRecipe&tagString=cookbook", body)
+	req := asUser(t, httptest.NewRequest("POST", "/api/recipes?name=MultiPageRecipe&tagString=cookbook", body), testEmail)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	w := httptest.NewRecorder()
 
@@ -269,7 +483,7 @@ func TestCreateRecipe_MultipleFiles(t *testing.T) {
 	}
 
 	// Cleanup
-	storage.DeleteRecipe(response.Recipe.UUID)
+	storage.PurgeRecipe(response.Recipe.UUID)
 }
 
 func TestRespondWithError(t *testing.T) {
@@ -346,9 +560,10 @@ func TestUpdateRecipeSteps_InvalidUUID(t *testing.T) {
 }
 
 func TestUpdateRecipeSteps_RecipeNotFound(t *testing.T) {
+	ensureTestUser(t)
 
 	body := `{"steps": []}`
-	req := httptest.NewRequest("PUT", "/api/recipes/00000000-0000-0000-0000-000000000001/steps", bytes.NewBufferString(body))
+	req := asUser(t, httptest.NewRequest("PUT", "/api/recipes/00000000-0000-0000-0000-000000000001/steps", bytes.NewBufferString(body)), testEmail)
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -370,10 +585,10 @@ func TestUpdateRecipeSteps_InvalidIngredient(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create test recipe: %v", err)
 	}
-	defer storage.DeleteRecipe(recipe.UUID)
+	defer storage.PurgeRecipe(recipe.UUID)
 
 	body := `{"steps": [{"instruction": "Test", "ingredients": ["2"]}]}`
-	req := httptest.NewRequest("PUT", "/api/recipes/"+recipe.UUID.String()+"/steps", bytes.NewBufferString(body))
+	req := asUser(t, httptest.NewRequest("PUT", "/api/recipes/"+recipe.UUID.String()+"/steps", bytes.NewBufferString(body)), testEmail)
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -396,7 +611,7 @@ func TestUpdateRecipeSteps_ValidRequest(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create test recipe: %v", err)
 	}
-	defer storage.DeleteRecipe(recipe.UUID)
+	defer storage.PurgeRecipe(recipe.UUID)
 
 	body := `{
 		"steps": [
@@ -410,7 +625,7 @@ func TestUpdateRecipeSteps_ValidRequest(t *testing.T) {
 			}
 		]
 	}`
-	req := httptest.NewRequest("PUT", "/api/recipes/"+recipe.UUID.String()+"/steps", bytes.NewBufferString(body))
+	req := asUser(t, httptest.NewRequest("PUT", "/api/recipes/"+recipe.UUID.String()+"/steps", bytes.NewBufferString(body)), testEmail)
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -442,7 +657,7 @@ func TestCreateRecipe_TagStringRoundTrip(t *testing.T) {
 	writer := multipart.NewWriter(body)
 	writer.Close()
 
-	req := httptest.NewRequest("POST", "/api/recipes?email="+testEmail+"&name=TagRoundTripTest&tagString=breakfast,%20easy,%20cereal", body)
+	req := asUser(t, httptest.NewRequest("POST", "/api/recipes?name=TagRoundTripTest&tagString=breakfast,%20easy,%20cereal", body), testEmail)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	w := httptest.NewRecorder()
 
@@ -460,10 +675,10 @@ func TestCreateRecipe_TagStringRoundTrip(t *testing.T) {
 	if err := json.NewDecoder(resp.Body).Decode(&createResp); err != nil {
 		t.Fatalf("Failed to decode create response: %v", err)
 	}
-	defer storage.DeleteRecipe(createResp.Recipe.UUID)
+	defer storage.PurgeRecipe(createResp.Recipe.UUID)
 
 	// Fetch recipes via GetRecipes and verify tag_string is computed correctly
-	getReq := httptest.NewRequest("GET", "/api/recipes?email="+testEmail, nil)
+	getReq := asUser(t, httptest.NewRequest("GET", "/api/recipes", nil), testEmail)
 	getW := httptest.NewRecorder()
 
 	GetRecipes(getW, getReq)
@@ -508,7 +723,7 @@ func TestUpdateRecipeSteps_RoundTrip(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create test recipe: %v", err)
 	}
-	defer storage.DeleteRecipe(recipe.UUID)
+	defer storage.PurgeRecipe(recipe.UUID)
 
 	// PUT some steps
 	putBody := `{
@@ -527,7 +742,7 @@ func TestUpdateRecipeSteps_RoundTrip(t *testing.T) {
 			}
 		]
 	}`
-	putReq := httptest.NewRequest("PUT", "/api/recipes/"+recipe.UUID.String()+"/steps", bytes.NewBufferString(putBody))
+	putReq := asUser(t, httptest.NewRequest("PUT", "/api/recipes/"+recipe.UUID.String()+"/steps", bytes.NewBufferString(putBody)), testEmail)
 	putReq.Header.Set("Content-Type", "application/json")
 	putW := httptest.NewRecorder()
 
@@ -542,7 +757,7 @@ func TestUpdateRecipeSteps_RoundTrip(t *testing.T) {
 	}
 
 	// GET the steps back
-	getReq := httptest.NewRequest("GET", "/api/recipes/"+recipe.UUID.String()+"/steps", nil)
+	getReq := asUser(t, httptest.NewRequest("GET", "/api/recipes/"+recipe.UUID.String()+"/steps", nil), testEmail)
 	getW := httptest.NewRecorder()
 
 	GetRecipeSteps(getW, getReq)
@@ -590,6 +805,98 @@ func TestUpdateRecipeSteps_RoundTrip(t *testing.T) {
 	}
 }
 
+func TestGetRecipeSteps_Scaling(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("steps-scaling-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+	id := recipe.UUID.String()
+
+	edits := []struct {
+		handler http.HandlerFunc
+		req     *http.Request
+	}{
+		{PatchRecipe, httptest.NewRequest("PATCH", "/api/recipes/"+id, strings.NewReader(`{"servings": 4, "yield_text": "1 loaf"}`))},
+		{UpdateRecipeSteps, httptest.NewRequest("PUT", "/api/recipes/"+id+"/steps",
+			strings.NewReader(`{"steps": [{"instruction": "Mix", "ingredients": ["3 tbsp butter", "3 eggs", "250 g flour"]}]}`))},
+	}
+	for _, e := range edits {
+		w := httptest.NewRecorder()
+		e.handler(w, asUser(t, e.req, testEmail))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Edit failed with %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	tests := []struct {
+		desc            string
+		query           string
+		wantStatus      int
+		wantServings    float64
+		wantIngredients []string
+	}{
+		{"unscaled", "", http.StatusOK, 4, []string{"3 tbsp butter", "3 eggs", "250 g flour"}},
+		{"servings", "?servings=8", http.StatusOK, 8, []string{"6 tbsp butter", "6 eggs", "500 g flour"}},
+		{"scale", "?scale=0.5", http.StatusOK, 2, []string{"4 ½ tsp butter", "2 eggs", "125 g flour"}},
+		{"both", "?servings=8&scale=2", http.StatusBadRequest, 0, nil},
+		{"zero servings", "?servings=0", http.StatusBadRequest, 0, nil},
+		{"negative scale", "?scale=-1", http.StatusBadRequest, 0, nil},
+		{"invalid scale", "?scale=abc", http.StatusBadRequest, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := asUser(t, httptest.NewRequest("GET", "/api/recipes/"+id+"/steps"+tt.query, nil), testEmail)
+			w := httptest.NewRecorder()
+
+			GetRecipeSteps(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response models.RecipeStepsResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Servings == nil || *response.Servings != tt.wantServings {
+				t.Errorf("Expected servings %v, got %v", tt.wantServings, response.Servings)
+			}
+			if response.YieldText == nil || *response.YieldText != "1 loaf" {
+				t.Errorf("Expected yield text '1 loaf', got %v", response.YieldText)
+			}
+			if len(response.Steps) != 1 || !slices.Equal(response.Steps[0].Ingredients, tt.wantIngredients) {
+				t.Errorf("Expected ingredients %v, got %+v", tt.wantIngredients, response.Steps)
+			}
+		})
+	}
+}
+
+func TestGetRecipeSteps_ServingsWithoutRecipeServings(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("steps-no-servings-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+
+	req := asUser(t, httptest.NewRequest("GET", "/api/recipes/"+recipe.UUID.String()+"/steps?servings=2", nil), testEmail)
+	w := httptest.NewRecorder()
+
+	GetRecipeSteps(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
 func TestAddRecipeFiles_MissingUUID(t *testing.T) {
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
@@ -610,13 +917,15 @@ func TestAddRecipeFiles_MissingUUID(t *testing.T) {
 }
 
 func TestAddRecipeFiles_RecipeNotFound(t *testing.T) {
+	ensureTestUser(t)
+
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 	part, _ := writer.CreateFormFile("file", "test.jpg")
 	part.Write([]byte("fake image data"))
 	writer.Close()
 
-	req := httptest.NewRequest("POST", "/api/recipes/00000000-0000-0000-0000-000000000001/files", body)
+	req := asUser(t, httptest.NewRequest("POST", "/api/recipes/00000000-0000-0000-0000-000000000001/files", body), testEmail)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	w := httptest.NewRecorder()
 
@@ -637,13 +946,13 @@ func TestAddRecipeFiles_MissingFile(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create test recipe: %v", err)
 	}
-	defer storage.DeleteRecipe(recipe.UUID)
+	defer storage.PurgeRecipe(recipe.UUID)
 
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 	writer.Close()
 
-	req := httptest.NewRequest("POST", "/api/recipes/"+recipe.UUID.String()+"/files", body)
+	req := asUser(t, httptest.NewRequest("POST", "/api/recipes/"+recipe.UUID.String()+"/files", body), testEmail)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	w := httptest.NewRecorder()
 
@@ -664,7 +973,7 @@ func TestAddRecipeFiles_Success(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create test recipe: %v", err)
 	}
-	defer storage.DeleteRecipe(recipe.UUID)
+	defer storage.PurgeRecipe(recipe.UUID)
 
 	_, err = storage.InsertFile(recipe.UUID, []byte("existing image data"), "image/jpeg", 0, true)
 	if err != nil {
@@ -680,7 +989,7 @@ func TestAddRecipeFiles_Success(t *testing.T) {
 	}
 	writer.Close()
 
-	req := httptest.NewRequest("POST", "/api/recipes/"+recipe.UUID.String()+"/files", body)
+	req := asUser(t, httptest.NewRequest("POST", "/api/recipes/"+recipe.UUID.String()+"/files", body), testEmail)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	w := httptest.NewRecorder()
 
@@ -752,8 +1061,10 @@ func TestPatchRecipe_InvalidUUID(t *testing.T) {
 }
 
 func TestPatchRecipe_RecipeNotFound(t *testing.T) {
+	ensureTestUser(t)
+
 	body := `{"tagString": "test"}`
-	req := httptest.NewRequest("PATCH", "/api/recipes/00000000-0000-0000-0000-000000000001", strings.NewReader(body))
+	req := asUser(t, httptest.NewRequest("PATCH", "/api/recipes/00000000-0000-0000-0000-000000000001", strings.NewReader(body)), testEmail)
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -774,10 +1085,10 @@ func TestPatchRecipe_InvalidBody(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create test recipe: %v", err)
 	}
-	defer storage.DeleteRecipe(recipe.UUID)
+	defer storage.PurgeRecipe(recipe.UUID)
 
 	body := `{invalid json`
-	req := httptest.NewRequest("PATCH", "/api/recipes/"+recipe.UUID.String(), strings.NewReader(body))
+	req := asUser(t, httptest.NewRequest("PATCH", "/api/recipes/"+recipe.UUID.String(), strings.NewReader(body)), testEmail)
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -798,10 +1109,10 @@ func TestPatchRecipe_SourceUpdate(t *testing.T) {
 	if err != nil {
 		t.Fatalf("InsertRecipeByEmail failed: %v", err)
 	}
-	defer storage.DeleteRecipe(recipe.UUID)
+	defer storage.PurgeRecipe(recipe.UUID)
 
 	patchBody := `{"source":"newsletter"}`
-	patchReq := httptest.NewRequest("PATCH", "/api/recipes/"+recipe.UUID.String(), strings.NewReader(patchBody))
+	patchReq := asUser(t, httptest.NewRequest("PATCH", "/api/recipes/"+recipe.UUID.String(), strings.NewReader(patchBody)), testEmail)
 	patchW := httptest.NewRecorder()
 
 	PatchRecipe(patchW, patchReq)
@@ -820,17 +1131,66 @@ func TestPatchRecipe_SourceUpdate(t *testing.T) {
 	}
 }
 
+func TestPatchRecipe_Servings(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("patch-servings-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("InsertRecipeByEmail failed: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+
+	six, muffins := 6, "12 muffins"
+	tests := []struct {
+		desc          string
+		body          string
+		wantStatus    int
+		wantServings  *int
+		wantYieldText *string
+	}{
+		{"set", `{"servings": 6, "yield_text": "12 muffins"}`, http.StatusOK, &six, &muffins},
+		{"omitted fields are unchanged", `{"source": "blog"}`, http.StatusOK, &six, &muffins},
+		{"negative servings", `{"servings": -1}`, http.StatusBadRequest, &six, &muffins},
+		{"too many servings", `{"servings": 32768}`, http.StatusBadRequest, &six, &muffins},
+		{"clear", `{"servings": 0, "yield_text": ""}`, http.StatusOK, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := asUser(t, httptest.NewRequest("PATCH", "/api/recipes/"+recipe.UUID.String(), strings.NewReader(tt.body)), testEmail)
+			w := httptest.NewRecorder()
+
+			PatchRecipe(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+
+			updated, err := storage.GetRecipeByUUID(recipe.UUID)
+			if err != nil {
+				t.Fatalf("GetRecipeByUUID failed: %v", err)
+			}
+			if !reflect.DeepEqual(updated.Servings, tt.wantServings) {
+				t.Errorf("Expected servings %v, got %v", tt.wantServings, updated.Servings)
+			}
+			if !reflect.DeepEqual(updated.YieldText, tt.wantYieldText) {
+				t.Errorf("Expected yield text %v, got %v", tt.wantYieldText, updated.YieldText)
+			}
+		})
+	}
+}
+
 // Helper to create a recipe with tags for patch tests
 func createRecipeWithTags(t *testing.T, name, tagString string) models.UploadResponse {
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 	writer.Close()
 
-	url := "/api/recipes?email=" + testEmail + "&name=" + name
+	url := "/api/recipes?name=" + name
 	if tagString != "" {
 		url += "&tagString=" + strings.ReplaceAll(tagString, " ", "%20")
 	}
-	createReq := httptest.NewRequest("POST", url, body)
+	createReq := asUser(t, httptest.NewRequest("POST", url, body), testEmail)
 	createReq.Header.Set("Content-Type", writer.FormDataContentType())
 	createW := httptest.NewRecorder()
 
@@ -846,16 +1206,46 @@ func createRecipeWithTags(t *testing.T, name, tagString string) models.UploadRes
 	return response
 }
 
+func TestPatchRecipe_ServingsKeepsTags(t *testing.T) {
+	ensureTestUser(t)
+
+	createResp := createRecipeWithTags(t, "PatchServingsKeepsTagsTest", "alpha, beta")
+	defer storage.PurgeRecipe(createResp.Recipe.UUID)
+
+	patchBody := `{"servings": 4}`
+	patchReq := asUser(t, httptest.NewRequest("PATCH", "/api/recipes/"+createResp.Recipe.UUID.String(), strings.NewReader(patchBody)), testEmail)
+	patchReq.Header.Set("Content-Type", "application/json")
+	patchW := httptest.NewRecorder()
+
+	PatchRecipe(patchW, patchReq)
+
+	if patchW.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", patchW.Code, patchW.Body.String())
+	}
+
+	updatedRecipe, err := storage.GetRecipeByUUID(createResp.Recipe.UUID)
+	if err != nil {
+		t.Fatalf("Failed to get updated recipe: %v", err)
+	}
+
+	if updatedRecipe.TagString != "alpha, beta" {
+		t.Errorf("Expected tag_string 'alpha, beta', got %q", updatedRecipe.TagString)
+	}
+	if updatedRecipe.Servings == nil || *updatedRecipe.Servings != 4 {
+		t.Errorf("Expected servings 4, got %v", updatedRecipe.Servings)
+	}
+}
+
 func TestPatchRecipe_SameTags(t *testing.T) {
 	ensureTestUser(t)
 
 	// Create recipe with tags
 	createResp := createRecipeWithTags(t, "PatchSameTagsTest", "alpha, beta, gamma")
-	defer storage.DeleteRecipe(createResp.Recipe.UUID)
+	defer storage.PurgeRecipe(createResp.Recipe.UUID)
 
 	// Patch with identical tags
 	patchBody := `{"tagString": "alpha, beta, gamma"}`
-	patchReq := httptest.NewRequest("PATCH", "/api/recipes/"+createResp.Recipe.UUID.String(), strings.NewReader(patchBody))
+	patchReq := asUser(t, httptest.NewRequest("PATCH", "/api/recipes/"+createResp.Recipe.UUID.String(), strings.NewReader(patchBody)), testEmail)
 	patchReq.Header.Set("Content-Type", "application/json")
 	patchW := httptest.NewRecorder()
 
@@ -885,11 +1275,11 @@ func TestPatchRecipe_SubsetDifferentOrder(t *testing.T) {
 
 	// Create recipe with tags
 	createResp := createRecipeWithTags(t, "PatchSubsetTest", "alpha, beta, gamma")
-	defer storage.DeleteRecipe(createResp.Recipe.UUID)
+	defer storage.PurgeRecipe(createResp.Recipe.UUID)
 
 	// Patch with subset in different order
 	patchBody := `{"tagString": "gamma, alpha"}`
-	patchReq := httptest.NewRequest("PATCH", "/api/recipes/"+createResp.Recipe.UUID.String(), strings.NewReader(patchBody))
+	patchReq := asUser(t, httptest.NewRequest("PATCH", "/api/recipes/"+createResp.Recipe.UUID.String(), strings.NewReader(patchBody)), testEmail)
 	patchReq.Header.Set("Content-Type", "application/json")
 	patchW := httptest.NewRecorder()
 
@@ -919,11 +1309,11 @@ func TestPatchRecipe_Superset(t *testing.T) {
 
 	// Create recipe with tags
 	createResp := createRecipeWithTags(t, "PatchSupersetTest", "alpha, beta")
-	defer storage.DeleteRecipe(createResp.Recipe.UUID)
+	defer storage.PurgeRecipe(createResp.Recipe.UUID)
 
 	// Patch with superset
 	patchBody := `{"tagString": "alpha, beta, gamma, delta"}`
-	patchReq := httptest.NewRequest("PATCH", "/api/recipes/"+createResp.Recipe.UUID.String(), strings.NewReader(patchBody))
+	patchReq := asUser(t, httptest.NewRequest("PATCH", "/api/recipes/"+createResp.Recipe.UUID.String(), strings.NewReader(patchBody)), testEmail)
 	patchReq.Header.Set("Content-Type", "application/json")
 	patchW := httptest.NewRecorder()
 
@@ -953,11 +1343,11 @@ func TestPatchRecipe_MixedNewAndOld(t *testing.T) {
 
 	// Create recipe with tags
 	createResp := createRecipeWithTags(t, "PatchMixedTest", "alpha, beta, gamma")
-	defer storage.DeleteRecipe(createResp.Recipe.UUID)
+	defer storage.PurgeRecipe(createResp.Recipe.UUID)
 
 	// Patch with mix of old and new tags
 	patchBody := `{"tagString": "beta, delta, epsilon"}`
-	patchReq := httptest.NewRequest("PATCH", "/api/recipes/"+createResp.Recipe.UUID.String(), strings.NewReader(patchBody))
+	patchReq := asUser(t, httptest.NewRequest("PATCH", "/api/recipes/"+createResp.Recipe.UUID.String(), strings.NewReader(patchBody)), testEmail)
 	patchReq.Header.Set("Content-Type", "application/json")
 	patchW := httptest.NewRecorder()
 
@@ -987,11 +1377,11 @@ func TestPatchRecipe_NewTagNotInTable(t *testing.T) {
 
 	// Create recipe with existing tag
 	createResp := createRecipeWithTags(t, "PatchNewTagTest", "existing-tag")
-	defer storage.DeleteRecipe(createResp.Recipe.UUID)
+	defer storage.PurgeRecipe(createResp.Recipe.UUID)
 
 	// Patch with a completely new tag that doesn't exist in tags table
 	patchBody := `{"tagString": "brand-new-unique-tag-12345"}`
-	patchReq := httptest.NewRequest("PATCH", "/api/recipes/"+createResp.Recipe.UUID.String(), strings.NewReader(patchBody))
+	patchReq := asUser(t, httptest.NewRequest("PATCH", "/api/recipes/"+createResp.Recipe.UUID.String(), strings.NewReader(patchBody)), testEmail)
 	patchReq.Header.Set("Content-Type", "application/json")
 	patchW := httptest.NewRecorder()
 
@@ -1021,11 +1411,11 @@ func TestPatchRecipe_ClearTags(t *testing.T) {
 
 	// Create recipe with tags
 	createResp := createRecipeWithTags(t, "PatchClearTagsTest", "breakfast, lunch")
-	defer storage.DeleteRecipe(createResp.Recipe.UUID)
+	defer storage.PurgeRecipe(createResp.Recipe.UUID)
 
 	// Patch with empty tag string to clear tags
 	patchBody := `{"tagString": ""}`
-	patchReq := httptest.NewRequest("PATCH", "/api/recipes/"+createResp.Recipe.UUID.String(), strings.NewReader(patchBody))
+	patchReq := asUser(t, httptest.NewRequest("PATCH", "/api/recipes/"+createResp.Recipe.UUID.String(), strings.NewReader(patchBody)), testEmail)
 	patchReq.Header.Set("Content-Type", "application/json")
 	patchW := httptest.NewRecorder()
 
@@ -1060,7 +1450,7 @@ func TestGetPublicRecipe_NotPublic(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create test recipe: %v", err)
 	}
-	defer storage.DeleteRecipe(recipe.UUID)
+	defer storage.PurgeRecipe(recipe.UUID)
 
 	req := httptest.NewRequest("GET", "/api/recipes/"+recipe.UUID.String()+"/public", nil)
 	w := httptest.NewRecorder()
@@ -1084,7 +1474,7 @@ func TestGetPublicRecipe_IsPublic(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create test recipe: %v", err)
 	}
-	defer storage.DeleteRecipe(recipe.UUID)
+	defer storage.PurgeRecipe(recipe.UUID)
 
 	// Make it public
 	err = storage.SetRecipePublic(recipe.UUID, true)
@@ -1153,10 +1543,10 @@ func TestSetRecipePublic_MakePublic(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create test recipe: %v", err)
 	}
-	defer storage.DeleteRecipe(recipe.UUID)
+	defer storage.PurgeRecipe(recipe.UUID)
 
 	body := `{"is_public": true}`
-	req := httptest.NewRequest("POST", "/api/recipes/"+recipe.UUID.String()+"/public", strings.NewReader(body))
+	req := asUser(t, httptest.NewRequest("POST", "/api/recipes/"+recipe.UUID.String()+"/public", strings.NewReader(body)), testEmail)
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -1188,7 +1578,7 @@ func TestSetRecipePublic_MakePrivate(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create test recipe: %v", err)
 	}
-	defer storage.DeleteRecipe(recipe.UUID)
+	defer storage.PurgeRecipe(recipe.UUID)
 
 	// First make it public
 	err = storage.SetRecipePublic(recipe.UUID, true)
@@ -1198,7 +1588,7 @@ func TestSetRecipePublic_MakePrivate(t *testing.T) {
 
 	// Then make it private via the endpoint
 	body := `{"is_public": false}`
-	req := httptest.NewRequest("POST", "/api/recipes/"+recipe.UUID.String()+"/public", strings.NewReader(body))
+	req := asUser(t, httptest.NewRequest("POST", "/api/recipes/"+recipe.UUID.String()+"/public", strings.NewReader(body)), testEmail)
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -1224,8 +1614,10 @@ func TestSetRecipePublic_MakePrivate(t *testing.T) {
 }
 
 func TestSetRecipePublic_NotFound(t *testing.T) {
+	ensureTestUser(t)
+
 	body := `{"is_public": true}`
-	req := httptest.NewRequest("POST", "/api/recipes/00000000-0000-0000-0000-000000000001/public", strings.NewReader(body))
+	req := asUser(t, httptest.NewRequest("POST", "/api/recipes/00000000-0000-0000-0000-000000000001/public", strings.NewReader(body)), testEmail)
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
@@ -1262,10 +1654,10 @@ func TestSetRecipePublic_InvalidBody(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create test recipe: %v", err)
 	}
-	defer storage.DeleteRecipe(recipe.UUID)
+	defer storage.PurgeRecipe(recipe.UUID)
 
 	body := `{invalid json`
-	req := httptest.NewRequest("POST", "/api/recipes/"+recipe.UUID.String()+"/public", strings.NewReader(body))
+	req := asUser(t, httptest.NewRequest("POST", "/api/recipes/"+recipe.UUID.String()+"/public", strings.NewReader(body)), testEmail)
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
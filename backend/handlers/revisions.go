@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cobyabrahams/hungr/auth"
+	"github.com/cobyabrahams/hungr/logger"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/revisions"
+	"github.com/cobyabrahams/hungr/storage"
+	"github.com/gofrs/uuid"
+)
+
+// GetRecipeRevisions handles GET /api/recipes/{uuid}/revisions
+func GetRecipeRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	recipeUUID, ok := revisionRecipeUUID(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := viewableRecipe(w, r, recipeUUID); !ok {
+		return
+	}
+
+	revs, err := storage.GetRecipeRevisions(recipeUUID)
+	if err != nil {
+		logger.Error(ctx, "failed to get recipe revisions", err, "recipe_uuid", recipeUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to load revisions")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.RevisionsResponse{Revisions: revs})
+}
+
+// DiffRecipeRevisions handles GET /api/recipes/{uuid}/revisions/diff?from=N&to=M
+func DiffRecipeRevisions(w http.ResponseWriter, r *http.Request) {
+	recipeUUID, ok := revisionRecipeUUID(w, r)
+	if !ok {
+		return
+	}
+
+	fromNumber, errFrom := strconv.Atoi(r.URL.Query().Get("from"))
+	toNumber, errTo := strconv.Atoi(r.URL.Query().Get("to"))
+	if errFrom != nil || errTo != nil {
+		respondWithError(w, http.StatusBadRequest, "from and to revision numbers are required")
+		return
+	}
+
+	if _, ok := viewableRecipe(w, r, recipeUUID); !ok {
+		return
+	}
+
+	from, ok := loadRevision(w, r, recipeUUID, fromNumber)
+	if !ok {
+		return
+	}
+	to, ok := loadRevision(w, r, recipeUUID, toNumber)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions.Diff(*from, *to))
+}
+
+// RestoreRecipeRevision handles POST /api/recipes/{uuid}/revisions/{n}/restore.
+// Restoring records a new revision rather than discarding the later ones.
+func RestoreRecipeRevision(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	recipeUUID, ok := revisionRecipeUUID(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse revision number from path: /api/recipes/{uuid}/revisions/{n}/restore
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/recipes/"), "/")
+	if len(parts) != 4 {
+		respondWithError(w, http.StatusBadRequest, "revision number is required")
+		return
+	}
+	number, err := strconv.Atoi(parts[2])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid revision number")
+		return
+	}
+
+	if _, ok := editableRecipe(w, r, recipeUUID); !ok {
+		return
+	}
+
+	rev, ok := loadRevision(w, r, recipeUUID, number)
+	if !ok {
+		return
+	}
+
+	tx, err := storage.BeginTx(ctx)
+	if err != nil {
+		logger.Error(ctx, "failed to begin transaction", err, "recipe_uuid", recipeUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to restore revision")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if err := storage.TxApplySnapshot(ctx, tx, recipeUUID, rev.Snapshot); err != nil {
+		if errors.Is(err, storage.ErrSubRecipeCycle) {
+			respondWithError(w, http.StatusConflict, "restoring this revision would make the recipe include itself as a sub-recipe")
+			return
+		}
+		logger.Error(ctx, "failed to apply revision snapshot", err, "recipe_uuid", recipeUUID, "revision", number)
+		respondWithError(w, http.StatusInternalServerError, "failed to restore revision")
+		return
+	}
+
+	newNumber, err := storage.TxRecordRevision(ctx, tx, recipeUUID, auth.UserFromContext(ctx).UUID)
+	if err != nil {
+		logger.Error(ctx, "failed to record recipe revision", err, "recipe_uuid", recipeUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to restore revision")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error(ctx, "failed to commit transaction", err, "recipe_uuid", recipeUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to restore revision")
+		return
+	}
+
+	logger.Info(ctx, "recipe revision restored", "recipe_uuid", recipeUUID, "revision", number, "new_revision", newNumber)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.RestoreRevisionResponse{Success: true, Revision: newNumber})
+}
+
+// revisionRecipeUUID parses the recipe UUID from /api/recipes/{uuid}/revisions...
+func revisionRecipeUUID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/recipes/"), "/")
+	if len(parts) < 1 || parts[0] == "" {
+		respondWithError(w, http.StatusBadRequest, "recipe uuid is required")
+		return uuid.Nil, false
+	}
+
+	recipeUUID, err := uuid.FromString(parts[0])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid recipe uuid")
+		return uuid.Nil, false
+	}
+	return recipeUUID, true
+}
+
+// loadRevision fetches a revision, responding with 404 if it doesn't exist
+func loadRevision(w http.ResponseWriter, r *http.Request, recipeUUID uuid.UUID, number int) (*models.RecipeRevision, bool) {
+	rev, err := storage.GetRecipeRevision(recipeUUID, number)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "revision not found")
+			return nil, false
+		}
+		logger.Error(r.Context(), "failed to get recipe revision", err, "recipe_uuid", recipeUUID, "revision", number)
+		respondWithError(w, http.StatusInternalServerError, "failed to load revision")
+		return nil, false
+	}
+	return rev, true
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+	"github.com/gofrs/uuid"
+)
+
+// createRevisedRecipe creates a recipe through the handlers so that each edit
+// records a revision: 1 is the new recipe, 2 adds a tag and 3 adds a step
+func createRevisedRecipe(t *testing.T) uuid.UUID {
+	t.Helper()
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("revisions-handler", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	t.Cleanup(func() { storage.PurgeRecipe(recipe.UUID) })
+	id := recipe.UUID.String()
+
+	// InsertRecipeByEmail bypasses CreateRecipe, so record the first revision
+	// with an empty patch
+	edits := []struct {
+		handler http.HandlerFunc
+		req     *http.Request
+	}{
+		{PatchRecipe, httptest.NewRequest("PATCH", "/api/recipes/"+id, strings.NewReader(`{"tagString": ""}`))},
+		{PatchRecipe, httptest.NewRequest("PATCH", "/api/recipes/"+id, strings.NewReader(`{"tagString": "revisions"}`))},
+		{UpdateRecipeSteps, httptest.NewRequest("PUT", "/api/recipes/"+id+"/steps",
+			strings.NewReader(`{"steps": [{"instruction": "Boil", "ingredients": ["1 cup water"]}]}`))},
+	}
+	for _, e := range edits {
+		w := httptest.NewRecorder()
+		e.handler(w, asUser(t, e.req, testEmail))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Edit failed with %d: %s", w.Code, w.Body.String())
+		}
+	}
+	return recipe.UUID
+}
+
+func TestGetRecipeRevisions(t *testing.T) {
+	recipeUUID := createRevisedRecipe(t)
+
+	req := asUser(t, httptest.NewRequest("GET", "/api/recipes/"+recipeUUID.String()+"/revisions", nil), testEmail)
+	w := httptest.NewRecorder()
+
+	GetRecipeRevisions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response models.RevisionsResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Revisions) != 3 || response.Revisions[0].Number != 3 {
+		t.Errorf("Expected 3 revisions newest first, got %+v", response.Revisions)
+	}
+}
+
+func TestDiffRecipeRevisions(t *testing.T) {
+	recipeUUID := createRevisedRecipe(t)
+	base := "/api/recipes/" + recipeUUID.String() + "/revisions/diff"
+
+	tests := []struct {
+		desc       string
+		query      string
+		wantStatus int
+	}{
+		{"missing numbers", "", http.StatusBadRequest},
+		{"unknown revision", "?from=1&to=42", http.StatusNotFound},
+		{"ok", "?from=1&to=3", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := asUser(t, httptest.NewRequest("GET", base+tt.query, nil), testEmail)
+			w := httptest.NewRecorder()
+
+			DiffRecipeRevisions(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var diff models.RevisionDiff
+			if err := json.NewDecoder(w.Body).Decode(&diff); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(diff.TagsAdded) != 1 || diff.TagsAdded[0] != "revisions" {
+				t.Errorf("Expected tag revisions added, got %v", diff.TagsAdded)
+			}
+			if len(diff.Steps) != 1 || diff.Steps[0].Change != models.StepAdded {
+				t.Errorf("Expected one added step, got %+v", diff.Steps)
+			}
+		})
+	}
+}
+
+func TestRestoreRecipeRevision(t *testing.T) {
+	recipeUUID := createRevisedRecipe(t)
+
+	req := asUser(t, httptest.NewRequest("POST", "/api/recipes/"+recipeUUID.String()+"/revisions/1/restore", nil), testEmail)
+	w := httptest.NewRecorder()
+
+	RestoreRecipeRevision(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response models.RestoreRevisionResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Revision != 4 {
+		t.Errorf("Expected restore to record revision 4, got %d", response.Revision)
+	}
+
+	recipe, err := storage.GetRecipeByUUID(recipeUUID)
+	if err != nil {
+		t.Fatalf("GetRecipeByUUID failed: %v", err)
+	}
+	if recipe.TagString != "" {
+		t.Errorf("Expected tags to be restored to none, got %q", recipe.TagString)
+	}
+	steps, err := storage.GetRecipeStepsByRecipeUUID(recipeUUID)
+	if err != nil {
+		t.Fatalf("GetRecipeStepsByRecipeUUID failed: %v", err)
+	}
+	if len(steps) != 0 {
+		t.Errorf("Expected steps to be restored to none, got %d", len(steps))
+	}
+}
+
+func TestRecipeRevisions_Authorization(t *testing.T) {
+	withFriendConnection(t)
+	recipeUUID := createRevisedRecipe(t)
+	base := "/api/recipes/" + recipeUUID.String() + "/revisions"
+
+	tests := []struct {
+		desc    string
+		handler http.HandlerFunc
+		method  string
+		path    string
+		want    map[string]int
+	}{
+		{"list", GetRecipeRevisions, "GET", base, map[string]int{
+			"owner":            http.StatusOK,
+			"connected friend": http.StatusOK,
+			"stranger":         http.StatusForbidden,
+			"anonymous":        http.StatusUnauthorized,
+		}},
+		{"restore", RestoreRecipeRevision, "POST", base + "/3/restore", map[string]int{
+			"owner":            http.StatusOK,
+			"connected friend": http.StatusForbidden,
+			"stranger":         http.StatusForbidden,
+			"anonymous":        http.StatusUnauthorized,
+		}},
+	}
+
+	for _, tt := range tests {
+		for _, c := range callers {
+			t.Run(tt.desc+"/"+c.desc, func(t *testing.T) {
+				req := asCaller(t, httptest.NewRequest(tt.method, tt.path, nil), c.email)
+				w := httptest.NewRecorder()
+
+				tt.handler(w, req)
+
+				if w.Code != tt.want[c.desc] {
+					t.Errorf("Expected status %d, got %d: %s", tt.want[c.desc], w.Code, w.Body.String())
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/cobyabrahams/hungr/logger"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchRecipes handles GET /api/recipes/search?q=...&limit=N
+func SearchRecipes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		respondWithError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+
+	limit, ok := parseLimit(w, r, defaultSearchLimit, maxSearchLimit)
+	if !ok {
+		return
+	}
+
+	results, err := storage.SearchRecipes(user.UUID, q, limit)
+	if err != nil {
+		logger.Error(ctx, "failed to search recipes", err, "user_uuid", user.UUID, "q", q)
+		respondWithError(w, http.StatusInternalServerError, "failed to search recipes")
+		return
+	}
+
+	recipes := make([]models.Recipe, len(results))
+	recipeUUIDs := make([]uuid.UUID, len(results))
+	for i, res := range results {
+		recipes[i] = res.Recipe
+		recipeUUIDs[i] = res.Recipe.UUID
+	}
+
+	files, err := storage.GetFilesByRecipeUUIDs(recipeUUIDs)
+	if err != nil {
+		logger.Error(ctx, "failed to get files for recipes", err, "recipe_count", len(recipeUUIDs))
+		respondWithError(w, http.StatusInternalServerError, "failed to load recipe files")
+		return
+	}
+	signFileURLs(files, recipes)
+
+	response := models.SearchResponse{
+		Results:  results,
+		FileData: files,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
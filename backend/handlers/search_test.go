@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+)
+
+func TestSearchRecipes_Validation(t *testing.T) {
+	ensureTestUser(t)
+
+	tests := []struct {
+		desc       string
+		url        string
+		authed     bool
+		wantStatus int
+	}{
+		{"unauthenticated", "/api/recipes/search?q=soup", false, http.StatusUnauthorized},
+		{"missing q", "/api/recipes/search", true, http.StatusBadRequest},
+		{"blank q", "/api/recipes/search?q=%20%20", true, http.StatusBadRequest},
+		{"bad limit", "/api/recipes/search?q=soup&limit=abc", true, http.StatusBadRequest},
+		{"zero limit", "/api/recipes/search?q=soup&limit=0", true, http.StatusBadRequest},
+		{"ok", "/api/recipes/search?q=soup&limit=5", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			if tt.authed {
+				req = asUser(t, req, testEmail)
+			}
+			w := httptest.NewRecorder()
+
+			SearchRecipes(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSearchRecipes_FindsRecipe(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("Handler search gazpacho", testEmail, nil)
+	if err != nil {
+		t.Fatalf("InsertRecipeByEmail failed: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+
+	req := asUser(t, httptest.NewRequest("GET", "/api/recipes/search?q=gazpacho", nil), testEmail)
+	w := httptest.NewRecorder()
+
+	SearchRecipes(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response models.SearchResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Results) == 0 || response.Results[0].Recipe.UUID != recipe.UUID {
+		t.Errorf("Expected recipe %v as the top result, got %+v", recipe.UUID, response.Results)
+	}
+}
@@ -0,0 +1,516 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/cobyabrahams/hungr/authz"
+	"github.com/cobyabrahams/hungr/logger"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+	"github.com/cobyabrahams/hungr/units"
+	"github.com/gofrs/uuid"
+)
+
+const defaultShoppingListName = "Shopping list"
+
+// shoppingAisles is the order aisles are listed in, roughly the order of a
+// walk around a supermarket. Aisles not listed here come after them, and
+// "other" comes last.
+var shoppingAisles = []string{
+	"produce",
+	"bakery",
+	"meat & seafood",
+	"dairy & eggs",
+	"baking",
+	"spices",
+	"pantry",
+	"frozen",
+	"beverages",
+}
+
+const otherAisle = "other"
+
+// baseUnits are the units quantities are stored in, by category
+var baseUnits = map[units.UnitCategory]string{
+	units.CategoryVolume: string(models.UnitML),
+	units.CategoryMass:   string(models.UnitMG),
+	units.CategoryCount:  string(models.UnitCount),
+}
+
+// shoppingIngredient is a recipe ingredient multiplied by the number of
+// batches of the recipe a shopping list is for
+type shoppingIngredient struct {
+	nameUUID     uuid.UUID
+	name         string
+	category     units.UnitCategory
+	baseQuantity float64
+}
+
+// GetShoppingLists handles GET /api/shopping-lists
+func GetShoppingLists(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	lists, err := storage.GetShoppingListsByUser(user.UUID)
+	if err != nil {
+		logger.Error(ctx, "failed to get shopping lists", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get shopping lists")
+		return
+	}
+	if lists == nil {
+		lists = []models.ShoppingList{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.ShoppingListsResponse{ShoppingLists: lists})
+}
+
+// CreateShoppingList handles POST /api/shopping-lists, totalling the
+// ingredients of the recipes, each multiplied by its scale, and adding any
+// free-text items
+func CreateShoppingList(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateShoppingListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = defaultShoppingListName
+	}
+
+	recipes := make([]models.ShoppingListRecipe, len(req.Recipes))
+	for i, rr := range req.Recipes {
+		scale := rr.Scale
+		if scale == 0 {
+			scale = 1
+		}
+		if !(scale > 0) || math.IsInf(scale, 1) {
+			respondWithError(w, http.StatusBadRequest, "scale must be a positive number")
+			return
+		}
+		if slices.ContainsFunc(recipes[:i], func(other models.ShoppingListRecipe) bool { return other.RecipeUUID == rr.RecipeUUID }) {
+			respondWithError(w, http.StatusBadRequest, "each recipe may only be listed once")
+			return
+		}
+
+		recipe, ok := viewableRecipe(w, r, rr.RecipeUUID)
+		if !ok {
+			return
+		}
+		recipes[i] = models.ShoppingListRecipe{RecipeUUID: recipe.UUID, Name: recipe.Name, Scale: scale}
+	}
+
+	createShoppingList(w, r, user, name, recipes, req.Items)
+}
+
+// createShoppingList saves a list of the totalled ingredients of recipes,
+// which the user must be able to view, and the free-text items, and writes it
+// as the response
+func createShoppingList(w http.ResponseWriter, r *http.Request, user *models.User, name string, recipes []models.ShoppingListRecipe, texts []string) {
+	ctx := r.Context()
+	var ingredients []shoppingIngredient
+	for _, recipe := range recipes {
+		found, err := recipeShoppingIngredients(user, recipe.RecipeUUID, recipe.Scale, []uuid.UUID{recipe.RecipeUUID})
+		if err != nil {
+			logger.Error(ctx, "failed to get recipe ingredients", err, "recipe_uuid", recipe.RecipeUUID)
+			respondWithError(w, http.StatusInternalServerError, "failed to create shopping list")
+			return
+		}
+		ingredients = append(ingredients, found...)
+	}
+
+	items := totalShoppingIngredients(ingredients)
+	for _, text := range texts {
+		if text = strings.TrimSpace(text); text != "" {
+			items = append(items, customShoppingItem(text))
+		}
+	}
+
+	list, err := storage.CreateShoppingList(user.UUID, name, recipes, items)
+	if err != nil {
+		logger.Error(ctx, "failed to create shopping list", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to create shopping list")
+		return
+	}
+
+	logger.Info(ctx, "shopping list created", "shopping_list_uuid", list.UUID, "recipes", len(recipes), "items", len(items))
+	respondWithShoppingList(w, r, list)
+}
+
+// GetShoppingList handles GET /api/shopping-lists/{uuid}
+func GetShoppingList(w http.ResponseWriter, r *http.Request) {
+	list, _, ok := shoppingListFromPath(w, r)
+	if !ok {
+		return
+	}
+	respondWithShoppingList(w, r, list)
+}
+
+// DeleteShoppingList handles DELETE /api/shopping-lists/{uuid}
+func DeleteShoppingList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	list, _, ok := shoppingListFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := storage.DeleteShoppingList(list.UserUUID, list.UUID); err != nil {
+		logger.Error(ctx, "failed to delete shopping list", err, "shopping_list_uuid", list.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to delete shopping list")
+		return
+	}
+
+	logger.Info(ctx, "shopping list deleted", "shopping_list_uuid", list.UUID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// AddShoppingListItem handles POST /api/shopping-lists/{uuid}/items, adding a
+// free-text item such as "paper towels"
+func AddShoppingListItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	list, rest, ok := shoppingListFromPath(w, r)
+	if !ok {
+		return
+	}
+	if len(rest) != 1 {
+		respondWithError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	var req models.AddShoppingListItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		respondWithError(w, http.StatusBadRequest, "text is required")
+		return
+	}
+
+	itemUUID, err := storage.AddShoppingListItem(list.UUID, customShoppingItem(text))
+	if err != nil {
+		logger.Error(ctx, "failed to add shopping list item", err, "shopping_list_uuid", list.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to add item")
+		return
+	}
+
+	logger.Info(ctx, "shopping list item added", "shopping_list_uuid", list.UUID, "item_uuid", itemUUID)
+	respondWithShoppingList(w, r, list)
+}
+
+// UpdateShoppingListItem handles PATCH /api/shopping-lists/{uuid}/items/{item},
+// checking the item off or unchecking it
+func UpdateShoppingListItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	list, itemUUID, ok := shoppingListItemFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateShoppingListItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Checked == nil {
+		respondWithError(w, http.StatusBadRequest, "checked is required")
+		return
+	}
+
+	found, err := storage.SetShoppingListItemChecked(list.UUID, itemUUID, *req.Checked)
+	if err != nil {
+		logger.Error(ctx, "failed to update shopping list item", err, "shopping_list_uuid", list.UUID, "item_uuid", itemUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to update item")
+		return
+	}
+	if !found {
+		respondWithError(w, http.StatusNotFound, "item not found")
+		return
+	}
+
+	respondWithShoppingList(w, r, list)
+}
+
+// DeleteShoppingListItem handles DELETE /api/shopping-lists/{uuid}/items/{item}
+func DeleteShoppingListItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	list, itemUUID, ok := shoppingListItemFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	found, err := storage.DeleteShoppingListItem(list.UUID, itemUUID)
+	if err != nil {
+		logger.Error(ctx, "failed to delete shopping list item", err, "shopping_list_uuid", list.UUID, "item_uuid", itemUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to delete item")
+		return
+	}
+	if !found {
+		respondWithError(w, http.StatusNotFound, "item not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// shoppingListFromPath parses /api/shopping-lists/{uuid}[/items[/{item}]] and
+// loads the caller's list, returning the path segments after the uuid. Other
+// users' lists are reported as not found.
+func shoppingListFromPath(w http.ResponseWriter, r *http.Request) (*models.ShoppingList, []string, bool) {
+	ctx := r.Context()
+	user, ok := requireUser(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/shopping-lists/"), "/")
+	if parts[0] == "" {
+		respondWithError(w, http.StatusBadRequest, "shopping list uuid is required")
+		return nil, nil, false
+	}
+	listUUID, err := uuid.FromString(parts[0])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid shopping list uuid")
+		return nil, nil, false
+	}
+
+	list, err := storage.GetShoppingList(user.UUID, listUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "shopping list not found")
+			return nil, nil, false
+		}
+		logger.Error(ctx, "failed to get shopping list", err, "shopping_list_uuid", listUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get shopping list")
+		return nil, nil, false
+	}
+	return list, parts[1:], true
+}
+
+// shoppingListItemFromPath parses /api/shopping-lists/{uuid}/items/{item}
+func shoppingListItemFromPath(w http.ResponseWriter, r *http.Request) (*models.ShoppingList, uuid.UUID, bool) {
+	list, rest, ok := shoppingListFromPath(w, r)
+	if !ok {
+		return nil, uuid.Nil, false
+	}
+	if len(rest) != 2 || rest[0] != "items" {
+		respondWithError(w, http.StatusNotFound, "not found")
+		return nil, uuid.Nil, false
+	}
+	itemUUID, err := uuid.FromString(rest[1])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid item uuid")
+		return nil, uuid.Nil, false
+	}
+	return list, itemUUID, true
+}
+
+// respondWithShoppingList writes a list with its items formatted and grouped
+// by aisle
+func respondWithShoppingList(w http.ResponseWriter, r *http.Request, list *models.ShoppingList) {
+	ctx := r.Context()
+	recipes, err := storage.GetShoppingListRecipes(list.UUID)
+	if err != nil {
+		logger.Error(ctx, "failed to get shopping list recipes", err, "shopping_list_uuid", list.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get shopping list")
+		return
+	}
+	items, err := storage.GetShoppingListItems(list.UUID)
+	if err != nil {
+		logger.Error(ctx, "failed to get shopping list items", err, "shopping_list_uuid", list.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to get shopping list")
+		return
+	}
+
+	if recipes == nil {
+		recipes = []models.ShoppingListRecipe{}
+	}
+	for i := range items {
+		if items[i].Category != "" {
+			items[i].Quantity = units.FormatBest(items[i].BaseQuantity, units.UnitCategory(items[i].Category))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.ShoppingListResponse{
+		ShoppingList: *list,
+		Recipes:      recipes,
+		Aisles:       groupByAisle(items),
+	})
+}
+
+// recipeShoppingIngredients returns a recipe's ingredients multiplied by
+// scale. Sub-recipes the user can view are replaced by their own ingredients,
+// recursively; seen holds the recipes already being expanded, so a cycle
+// can't recurse forever.
+func recipeShoppingIngredients(user *models.User, recipeUUID uuid.UUID, scale float64, seen []uuid.UUID) ([]shoppingIngredient, error) {
+	stored, err := storage.GetAllIngredientsForRecipe(recipeUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []shoppingIngredient
+	for _, ing := range stored {
+		found := shoppingIngredient{
+			nameUUID:     ing.IngredientNameUUID,
+			name:         ing.IngredientName,
+			category:     units.GetCategoryForIngredientUnit(ing.IngredientType),
+			baseQuantity: ing.Quantity * scale,
+		}
+		if ing.SubRecipeUUID != nil && !slices.Contains(seen, *ing.SubRecipeUUID) {
+			expanded, ok, err := subRecipeShoppingIngredients(user, *ing.SubRecipeUUID, found, seen)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				result = append(result, expanded...)
+				continue
+			}
+		}
+		result = append(result, found)
+	}
+	return result, nil
+}
+
+// subRecipeShoppingIngredients returns the ingredients of the sub-recipe ing
+// calls for, or false if it is in the trash or the user can't view it
+func subRecipeShoppingIngredients(user *models.User, recipeUUID uuid.UUID, ing shoppingIngredient, seen []uuid.UUID) ([]shoppingIngredient, bool, error) {
+	recipe, err := storage.GetRecipeByUUID(recipeUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get sub-recipe %s: %w", recipeUUID, err)
+	}
+	allowed, err := authz.CanView(user, recipe)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to check sub-recipe access: %w", err)
+	}
+	if !allowed {
+		return nil, false, nil
+	}
+
+	batches := subRecipeScale(models.Ingredient{BaseQuantity: ing.baseQuantity, Category: string(ing.category)}, recipe)
+	expanded, err := recipeShoppingIngredients(user, recipe.UUID, batches, append(slices.Clip(seen), recipe.UUID))
+	if err != nil {
+		return nil, false, err
+	}
+	return expanded, true, nil
+}
+
+// totalShoppingIngredients merges ingredients with the same name, summing the
+// quantities in each category. Counts are rounded up, since half an egg is
+// bought as a whole one. An ingredient without a quantity ("salt to taste")
+// is only listed when no recipe gives a quantity for it.
+func totalShoppingIngredients(ingredients []shoppingIngredient) []storage.ShoppingListItemInput {
+	type key struct {
+		nameUUID uuid.UUID
+		category units.UnitCategory
+	}
+
+	var order []key
+	names := map[uuid.UUID]string{}
+	quantities := map[key][]units.Quantity{}
+	hasQuantity := map[uuid.UUID]bool{}
+	for _, ing := range ingredients {
+		k := key{nameUUID: ing.nameUUID}
+		if ing.baseQuantity > 0 {
+			k.category = ing.category
+			hasQuantity[ing.nameUUID] = true
+		}
+		if _, ok := quantities[k]; !ok {
+			order = append(order, k)
+			quantities[k] = []units.Quantity{}
+		}
+		if k.category != "" {
+			quantities[k] = append(quantities[k], units.Quantity{Value: ing.baseQuantity, Unit: baseUnits[k.category], Category: k.category})
+		}
+		names[ing.nameUUID] = ing.name
+	}
+
+	var items []storage.ShoppingListItemInput
+	for _, k := range order {
+		item := storage.ShoppingListItemInput{Name: names[k.nameUUID], IngredientName: names[k.nameUUID]}
+		if k.category == "" {
+			if hasQuantity[k.nameUUID] {
+				continue
+			}
+			items = append(items, item)
+			continue
+		}
+
+		total, category, err := units.SumQuantities(quantities[k])
+		if err != nil {
+			// Only reachable with a unit missing from baseUnits
+			continue
+		}
+		if category == units.CategoryCount {
+			// Allow for floating point error, so 3 × ⅓ is 1
+			total = math.Ceil(total - 1e-9)
+		}
+		item.Category, item.BaseQuantity = string(category), total
+		items = append(items, item)
+	}
+	return items
+}
+
+// customShoppingItem is a free-text item, put in the aisle of the ingredient
+// it names when it parses as one, e.g. "2 lemons"
+func customShoppingItem(text string) storage.ShoppingListItemInput {
+	item := storage.ShoppingListItemInput{Name: text, IngredientName: text, Custom: true}
+	if parsed, err := units.ParseIngredientString(text); err == nil && parsed.IngredientName != "" {
+		item.IngredientName = parsed.IngredientName
+	}
+	return item
+}
+
+// groupByAisle groups items, already sorted by name, by aisle in the order of
+// shoppingAisles
+func groupByAisle(items []models.ShoppingListItem) []models.ShoppingListAisle {
+	aisles := []models.ShoppingListAisle{}
+	for _, item := range items {
+		i := slices.IndexFunc(aisles, func(a models.ShoppingListAisle) bool { return a.Aisle == item.Aisle })
+		if i < 0 {
+			aisles = append(aisles, models.ShoppingListAisle{Aisle: item.Aisle})
+			i = len(aisles) - 1
+		}
+		aisles[i].Items = append(aisles[i].Items, item)
+	}
+
+	rank := func(aisle string) int {
+		if aisle == otherAisle {
+			return len(shoppingAisles) + 1
+		}
+		if i := slices.Index(shoppingAisles, aisle); i >= 0 {
+			return i
+		}
+		return len(shoppingAisles)
+	}
+	slices.SortFunc(aisles, func(a, b models.ShoppingListAisle) int {
+		if ra, rb := rank(a.Aisle), rank(b.Aisle); ra != rb {
+			return ra - rb
+		}
+		return strings.Compare(a.Aisle, b.Aisle)
+	})
+	return aisles
+}
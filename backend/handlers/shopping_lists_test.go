@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+	"github.com/cobyabrahams/hungr/units"
+	"github.com/gofrs/uuid"
+)
+
+func TestTotalShoppingIngredients(t *testing.T) {
+	butter, eggs, salt, garlic := uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4())
+	items := totalShoppingIngredients([]shoppingIngredient{
+		{nameUUID: butter, name: "butter", category: units.CategoryMass, baseQuantity: 113400},
+		{nameUUID: eggs, name: "eggs", category: units.CategoryCount, baseQuantity: 1.5},
+		{nameUUID: salt, name: "salt", category: units.CategoryCount},
+		{nameUUID: butter, name: "butter", category: units.CategoryVolume, baseQuantity: 15},
+		{nameUUID: butter, name: "butter", category: units.CategoryMass, baseQuantity: 56700},
+		{nameUUID: eggs, name: "eggs", category: units.CategoryCount, baseQuantity: 2},
+		{nameUUID: garlic, name: "garlic", category: units.CategoryCount},
+		{nameUUID: garlic, name: "garlic", category: units.CategoryCount, baseQuantity: 2},
+	})
+
+	want := []storage.ShoppingListItemInput{
+		{Name: "butter", IngredientName: "butter", Category: "mass", BaseQuantity: 170100},
+		{Name: "eggs", IngredientName: "eggs", Category: "count", BaseQuantity: 4},
+		{Name: "salt", IngredientName: "salt"},
+		{Name: "butter", IngredientName: "butter", Category: "volume", BaseQuantity: 15},
+		{Name: "garlic", IngredientName: "garlic", Category: "count", BaseQuantity: 2},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("Expected %d items, got %+v", len(want), items)
+	}
+	for i := range want {
+		if items[i] != want[i] {
+			t.Errorf("Item %d: expected %+v, got %+v", i, want[i], items[i])
+		}
+	}
+}
+
+func TestGroupByAisle(t *testing.T) {
+	aisles := groupByAisle([]models.ShoppingListItem{
+		{Name: "butter", Aisle: "dairy & eggs"},
+		{Name: "candles", Aisle: "other"},
+		{Name: "lemons", Aisle: "produce"},
+		{Name: "milk", Aisle: "dairy & eggs"},
+		{Name: "soap", Aisle: "household"},
+	})
+
+	want := []struct {
+		aisle string
+		items []string
+	}{
+		{"produce", []string{"lemons"}},
+		{"dairy & eggs", []string{"butter", "milk"}},
+		{"household", []string{"soap"}},
+		{"other", []string{"candles"}},
+	}
+	if len(aisles) != len(want) {
+		t.Fatalf("Expected %d aisles, got %+v", len(want), aisles)
+	}
+	for i, w := range want {
+		var names []string
+		for _, item := range aisles[i].Items {
+			names = append(names, item.Name)
+		}
+		if aisles[i].Aisle != w.aisle || strings.Join(names, ",") != strings.Join(w.items, ",") {
+			t.Errorf("Aisle %d: expected %s %q, got %s %q", i, w.aisle, w.items, aisles[i].Aisle, names)
+		}
+	}
+}
+
+func createShoppingListRecipe(t *testing.T, name string, ingredients ...string) uuid.UUID {
+	t.Helper()
+	recipe, err := storage.InsertRecipeByEmail(name, testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	t.Cleanup(func() { storage.PurgeRecipe(recipe.UUID) })
+
+	body, _ := json.Marshal(models.RecipeStepsResponse{Steps: []models.RecipeStepResponse{{Instruction: "Mix", Ingredients: ingredients}}})
+	w := httptest.NewRecorder()
+	path := "/api/recipes/" + recipe.UUID.String() + "/steps"
+	UpdateRecipeSteps(w, asUser(t, httptest.NewRequest("PUT", path, strings.NewReader(string(body))), testEmail))
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT steps failed: status %d: %s", w.Code, w.Body.String())
+	}
+	return recipe.UUID
+}
+
+func findShoppingListItem(list models.ShoppingListResponse, name string) (string, *models.ShoppingListItem) {
+	for _, aisle := range list.Aisles {
+		for i := range aisle.Items {
+			if aisle.Items[i].Name == name {
+				return aisle.Aisle, &aisle.Items[i]
+			}
+		}
+	}
+	return "", nil
+}
+
+func TestCreateShoppingList(t *testing.T) {
+	ensureTestUser(t)
+	ensureTestUser2(t)
+	cookies := createShoppingListRecipe(t, "shopping-list-cookies", "1 cup butter", "2 eggs", "salt to taste")
+	cake := createShoppingListRecipe(t, "shopping-list-cake", "1/2 cup butter", "3 eggs")
+
+	body := `{"name": "Weekend", "recipes": [{"recipe_uuid": "` + cookies.String() + `", "scale": 2}, {"recipe_uuid": "` + cake.String() + `"}], "items": ["paper towels"]}`
+	w := httptest.NewRecorder()
+	CreateShoppingList(w, asUser(t, httptest.NewRequest("POST", "/api/shopping-lists", strings.NewReader(body)), testEmail))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var list models.ShoppingListResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	defer storage.DeleteShoppingList(list.UserUUID, list.UUID)
+
+	butter, _, _ := units.ToBaseUnit(2.5, "cup")
+	if list.Name != "Weekend" || len(list.Recipes) != 2 {
+		t.Errorf("Expected Weekend with 2 recipes, got %q with %+v", list.Name, list.Recipes)
+	}
+	tests := []struct {
+		name     string
+		aisle    string
+		quantity string
+	}{
+		{"butter", "dairy & eggs", units.FormatBest(butter, units.CategoryVolume)},
+		{"eggs", "dairy & eggs", "7"},
+		{"salt", "spices", ""},
+		{"paper towels", "other", ""},
+	}
+	for _, tt := range tests {
+		aisle, item := findShoppingListItem(list, tt.name)
+		if item == nil {
+			t.Errorf("Expected %s on the list, got %+v", tt.name, list.Aisles)
+			continue
+		}
+		if aisle != tt.aisle || item.Quantity != tt.quantity {
+			t.Errorf("%s: expected %q in %s, got %q in %s", tt.name, tt.quantity, tt.aisle, item.Quantity, aisle)
+		}
+	}
+
+	listPath := "/api/shopping-lists/" + list.UUID.String()
+	_, towels := findShoppingListItem(list, "paper towels")
+	w = httptest.NewRecorder()
+	UpdateShoppingListItem(w, asUser(t, httptest.NewRequest("PATCH", listPath+"/items/"+towels.UUID.String(), strings.NewReader(`{"checked": true}`)), testEmail))
+	if w.Code != http.StatusOK {
+		t.Fatalf("PATCH item failed: status %d: %s", w.Code, w.Body.String())
+	}
+	var updated models.ShoppingListResponse
+	json.NewDecoder(w.Body).Decode(&updated)
+	if _, item := findShoppingListItem(updated, "paper towels"); item == nil || !item.Checked {
+		t.Errorf("Expected paper towels to be checked off, got %+v", item)
+	}
+
+	w = httptest.NewRecorder()
+	GetShoppingList(w, asUser(t, httptest.NewRequest("GET", listPath, nil), testEmail2))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected another user's list to be not found, got %d", w.Code)
+	}
+}
+
+func TestCreateShoppingList_Invalid(t *testing.T) {
+	ensureTestUser(t)
+	recipeUUID := createShoppingListRecipe(t, "shopping-list-invalid", "1 onion")
+
+	tests := []struct {
+		desc       string
+		body       string
+		wantStatus int
+	}{
+		{"negative scale", `{"recipes": [{"recipe_uuid": "` + recipeUUID.String() + `", "scale": -1}]}`, http.StatusBadRequest},
+		{"duplicate recipe", `{"recipes": [{"recipe_uuid": "` + recipeUUID.String() + `"}, {"recipe_uuid": "` + recipeUUID.String() + `"}]}`, http.StatusBadRequest},
+		{"missing recipe", `{"recipes": [{"recipe_uuid": "` + uuid.Must(uuid.NewV4()).String() + `"}]}`, http.StatusNotFound},
+		{"invalid body", `{`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			CreateShoppingList(w, asUser(t, httptest.NewRequest("POST", "/api/shopping-lists", strings.NewReader(tt.body)), testEmail))
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,530 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cobyabrahams/hungr/auth"
+	"github.com/cobyabrahams/hungr/authz"
+	"github.com/cobyabrahams/hungr/instructions"
+	"github.com/cobyabrahams/hungr/logger"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+	"github.com/cobyabrahams/hungr/units"
+	"github.com/gofrs/uuid"
+)
+
+// The steps endpoints represent ingredients as "quantity unit name" strings
+// (v1, the default) or as models.Ingredient objects (v2), picked with ?format=
+const (
+	stepsFormatV1 = "v1"
+	stepsFormatV2 = "v2"
+)
+
+// parseStepsFormat reads ?format=, defaulting to v1
+func parseStepsFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", stepsFormatV1:
+		return stepsFormatV1, true
+	case stepsFormatV2:
+		return stepsFormatV2, true
+	default:
+		respondWithError(w, http.StatusBadRequest, "format must be v1 or v2")
+		return "", false
+	}
+}
+
+// parseExpand reads ?expand=, which nests sub-recipes in v2 steps
+func parseExpand(w http.ResponseWriter, r *http.Request, format string) (bool, bool) {
+	value := r.URL.Query().Get("expand")
+	if value == "" {
+		return false, true
+	}
+	expand, err := strconv.ParseBool(value)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "expand must be true or false")
+		return false, false
+	}
+	if expand && format != stepsFormatV2 {
+		respondWithError(w, http.StatusBadRequest, "expand requires format=v2")
+		return false, false
+	}
+	return expand, true
+}
+
+// parseConvert reads ?convert=mass or ?convert=volume, which renders every
+// ingredient with a known density in that category
+func parseConvert(w http.ResponseWriter, r *http.Request) (units.UnitCategory, bool) {
+	switch convert := units.UnitCategory(r.URL.Query().Get("convert")); convert {
+	case "", units.CategoryMass, units.CategoryVolume:
+		return convert, true
+	default:
+		respondWithError(w, http.StatusBadRequest, "convert must be mass or volume")
+		return "", false
+	}
+}
+
+// unitConversion renders volume and mass ingredients in one category using
+// densities keyed by lower-case ingredient name. Counts and ingredients without
+// a density are left as they are.
+type unitConversion struct {
+	category  units.UnitCategory
+	densities map[string]float64
+}
+
+// loadUnitConversion loads the densities user sees, or returns nil when
+// category is empty
+func loadUnitConversion(user *models.User, category units.UnitCategory) (*unitConversion, error) {
+	if category == "" {
+		return nil, nil
+	}
+	var userUUID *uuid.UUID
+	if user != nil {
+		userUUID = &user.UUID
+	}
+	densities, err := storage.GetIngredientDensities(userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &unitConversion{category: category, densities: make(map[string]float64, len(densities))}
+	for _, d := range densities {
+		c.densities[strings.ToLower(d.IngredientName)] = d.GramsPerML
+	}
+	return c, nil
+}
+
+// apply converts the stored base quantities in steps; a nil conversion does
+// nothing
+func (c *unitConversion) apply(steps []models.RecipeStepWithIngredients) {
+	if c == nil {
+		return
+	}
+	ingredientType := models.UnitML
+	if c.category == units.CategoryMass {
+		ingredientType = models.UnitMG
+	}
+
+	for i := range steps {
+		for j := range steps[i].Ingredients {
+			ing := &steps[i].Ingredients[j]
+			from := units.GetCategoryForIngredientUnit(ing.IngredientType)
+			density, ok := c.densities[strings.ToLower(ing.IngredientName)]
+			if !ok || from == c.category || from == units.CategoryCount {
+				continue
+			}
+
+			quantity, err := units.ConvertBase(ing.Quantity, from, c.category, density)
+			if err != nil {
+				continue
+			}
+			if ing.QuantityMax != nil {
+				quantityMax, err := units.ConvertBase(*ing.QuantityMax, from, c.category, density)
+				if err != nil {
+					continue
+				}
+				ing.QuantityMax = &quantityMax
+			}
+			ing.Quantity = quantity
+			ing.IngredientType = ingredientType
+		}
+	}
+}
+
+// parseScale reads the factor to scale a recipe's ingredients by from
+// ?servings=N, which needs the recipe to have servings, or ?scale=X.
+// Defaults to 1.
+func parseScale(w http.ResponseWriter, r *http.Request, recipe *models.Recipe) (float64, bool) {
+	servingsParam := r.URL.Query().Get("servings")
+	scaleParam := r.URL.Query().Get("scale")
+
+	switch {
+	case servingsParam != "" && scaleParam != "":
+		respondWithError(w, http.StatusBadRequest, "servings and scale cannot both be set")
+		return 0, false
+	case servingsParam != "":
+		servings, err := strconv.Atoi(servingsParam)
+		if err != nil || servings < 1 {
+			respondWithError(w, http.StatusBadRequest, "servings must be a positive integer")
+			return 0, false
+		}
+		if recipe.Servings == nil {
+			respondWithError(w, http.StatusBadRequest, "recipe has no servings to scale from")
+			return 0, false
+		}
+		return float64(servings) / float64(*recipe.Servings), true
+	case scaleParam != "":
+		scale, err := strconv.ParseFloat(scaleParam, 64)
+		if err != nil || !(scale > 0) || math.IsInf(scale, 1) {
+			respondWithError(w, http.StatusBadRequest, "scale must be a positive number")
+			return 0, false
+		}
+		return scale, true
+	}
+	return 1, true
+}
+
+// parseMeasurementSystem reads ?system=, falling back to the caller's saved
+// preference
+func parseMeasurementSystem(w http.ResponseWriter, r *http.Request) (units.MeasurementSystem, bool) {
+	value := r.URL.Query().Get("system")
+	if value == "" {
+		if user := auth.UserFromContext(r.Context()); user != nil && user.MeasurementSystem != nil {
+			value = *user.MeasurementSystem
+		}
+	}
+	system, err := units.ParseMeasurementSystem(value)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "system must be metric, us, imperial or as_written")
+		return "", false
+	}
+	return system, true
+}
+
+// displayQuantity picks the unit to show a stored ingredient in after
+// multiplying it by scale. as_written keeps the unit from the original text
+// when it is still in the same category, e.g. not after a density conversion.
+func displayQuantity(ing models.StepIngredientWithName, category units.UnitCategory, scale float64, system units.MeasurementSystem) units.Quantity {
+	if system == units.SystemAsWritten && ing.OriginalText != nil && category != units.CategoryCount {
+		parsed, err := units.ParseIngredientString(*ing.OriginalText)
+		if err == nil && parsed.Category == category {
+			if value, err := units.FromBaseUnit(ing.Quantity*scale, category, parsed.Unit); err == nil {
+				return units.Quantity{Value: value, Unit: parsed.Unit, Category: category}
+			}
+		}
+	}
+	return units.ScaleBase(ing.Quantity, category, scale, system.Units())
+}
+
+// buildStepsV2 converts stored steps to structured ingredients in their best
+// display unit for system after multiplying the quantities by scale, and
+// annotates their instructions
+func buildStepsV2(steps []models.RecipeStepWithIngredients, scale float64, system units.MeasurementSystem) []models.RecipeStepV2 {
+	result := make([]models.RecipeStepV2, len(steps))
+	for i, step := range steps {
+		ingredients := make([]models.Ingredient, len(step.Ingredients))
+		for j, ing := range step.Ingredients {
+			category := units.GetCategoryForIngredientUnit(ing.IngredientType)
+			q := displayQuantity(ing, category, scale, system)
+			ingredient := models.Ingredient{
+				Quantity:     q.Value,
+				Unit:         q.Unit,
+				Category:     string(q.Category),
+				BaseQuantity: ing.Quantity * scale,
+				Name:         ing.IngredientName,
+				Optional:     ing.Optional,
+				RecipeUUID:   ing.SubRecipeUUID,
+			}
+			if ing.QuantityMax != nil {
+				ingredient.QuantityMax = units.ScaleRangeMax(*ing.QuantityMax, q, scale)
+			}
+			if ing.QuantityText != nil {
+				ingredient.QuantityText = *ing.QuantityText
+			}
+			if ing.Preparation != nil {
+				ingredient.Preparation = *ing.Preparation
+			}
+			if ing.OriginalText != nil {
+				ingredient.OriginalText = *ing.OriginalText
+			}
+			ingredient.Display = ingredientDisplay(q, ingredient)
+			ingredients[j] = ingredient
+		}
+		result[i] = models.RecipeStepV2{
+			Instruction: step.Instructions,
+			Ingredients: ingredients,
+		}
+		if step.GroupTitle != nil {
+			result[i].GroupTitle = *step.GroupTitle
+		}
+	}
+	instructions.Annotate(result)
+	return result
+}
+
+// expandSubRecipes fills in Recipe for the sub-recipe ingredients in steps that
+// user can view, recursively, converting their units like the parent's. seen
+// holds the recipes already being expanded, so a cycle saved by concurrent
+// edits can't recurse forever.
+func expandSubRecipes(user *models.User, steps []models.RecipeStepV2, seen []uuid.UUID, conversion *unitConversion, system units.MeasurementSystem) error {
+	for i := range steps {
+		for j := range steps[i].Ingredients {
+			ing := &steps[i].Ingredients[j]
+			if ing.RecipeUUID == nil || slices.Contains(seen, *ing.RecipeUUID) {
+				continue
+			}
+
+			recipe, err := storage.GetRecipeByUUID(*ing.RecipeUUID)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue // in the trash
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get sub-recipe %s: %w", ing.RecipeUUID, err)
+			}
+			allowed, err := authz.CanView(user, recipe)
+			if err != nil {
+				return fmt.Errorf("failed to check sub-recipe access: %w", err)
+			}
+			if !allowed {
+				continue
+			}
+
+			subSteps, err := storage.GetRecipeStepsWithIngredients(recipe.UUID)
+			if err != nil {
+				return fmt.Errorf("failed to get sub-recipe steps: %w", err)
+			}
+			conversion.apply(subSteps)
+			scale := subRecipeScale(*ing, recipe)
+			sub := &models.SubRecipe{UUID: recipe.UUID, Name: recipe.Name, Scale: scale, Steps: buildStepsV2(subSteps, scale, system)}
+			if err := expandSubRecipes(user, sub.Steps, append(slices.Clip(seen), recipe.UUID), conversion, system); err != nil {
+				return err
+			}
+			ing.Recipe = sub
+		}
+	}
+	return nil
+}
+
+// subRecipeScale is how many batches of recipe ing calls for. A count is a
+// number of batches; a mass or volume is divided by the recipe's yield when
+// that is in the same category, e.g. 250 ml of stock from a recipe that makes
+// "1 l". Anything else is one batch.
+func subRecipeScale(ing models.Ingredient, recipe *models.Recipe) float64 {
+	if ing.BaseQuantity <= 0 {
+		return 1
+	}
+	if ing.Category == string(units.CategoryCount) {
+		return ing.BaseQuantity
+	}
+	if recipe.YieldText != nil {
+		yield, category, err := units.ParseYield(*recipe.YieldText)
+		if err == nil && string(category) == ing.Category {
+			return ing.BaseQuantity / yield
+		}
+	}
+	return 1
+}
+
+// ingredientDisplay formats an ingredient as a v1 string, in a form
+// units.ParseIngredientString parses back to the same ingredient, e.g.
+// "1 can (14 oz) tomatoes, drained" or "salt to taste (optional)"
+func ingredientDisplay(q units.Quantity, ing models.Ingredient) string {
+	freeText := units.IsFreeTextQuantity(ing.QuantityText)
+
+	var display string
+	if ing.Quantity == 0 && freeText {
+		display = ing.Name
+	} else {
+		if ing.QuantityMax > 0 {
+			display = units.FormatRange(q, ing.QuantityMax)
+		} else {
+			display = units.Format(q)
+		}
+		if ing.QuantityText != "" && !freeText {
+			display += " (" + ing.QuantityText + ")"
+		}
+		display += " " + ing.Name
+	}
+
+	if ing.Preparation != "" {
+		display += ", " + ing.Preparation
+	}
+	if freeText {
+		display += " " + ing.QuantityText
+	}
+	if ing.Optional {
+		display += " (optional)"
+	}
+	return display
+}
+
+// stepResponsesFromV2 flattens structured ingredients to their v1 strings
+func stepResponsesFromV2(steps []models.RecipeStepV2) []models.RecipeStepResponse {
+	responses := make([]models.RecipeStepResponse, len(steps))
+	for i, step := range steps {
+		ingredients := make([]string, len(step.Ingredients))
+		for j, ing := range step.Ingredients {
+			ingredients[j] = ing.Display
+		}
+		responses[i] = models.RecipeStepResponse{
+			Instruction: step.Instruction,
+			GroupTitle:  step.GroupTitle,
+			Ingredients: ingredients,
+			Annotations: step.Annotations,
+		}
+	}
+	return responses
+}
+
+// stepInputsFromStrings parses v1 ingredient strings
+func stepInputsFromStrings(steps []models.RecipeStepResponse) ([]storage.StepInput, error) {
+	inputs := make([]storage.StepInput, len(steps))
+	for i, step := range steps {
+		ingredients := make([]storage.IngredientInput, len(step.Ingredients))
+		for j, ingStr := range step.Ingredients {
+			parsed, err := units.ParseIngredientString(ingStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ingredient %q: %v", ingStr, err)
+			}
+			ingredients[j] = storage.IngredientInput{
+				Name:         parsed.IngredientName,
+				Unit:         parsed.Unit,
+				Quantity:     parsed.Quantity,
+				QuantityMax:  parsed.QuantityMax,
+				QuantityText: parsed.QuantityText,
+				Preparation:  parsed.Preparation,
+				Optional:     parsed.Optional,
+				OriginalText: parsed.OriginalText,
+			}
+		}
+		inputs[i] = storage.StepInput{
+			Instruction: step.Instruction,
+			GroupTitle:  strings.TrimSpace(step.GroupTitle),
+			Ingredients: ingredients,
+		}
+	}
+	return inputs, nil
+}
+
+// stepInputsFromV2 validates structured ingredients. The unit may be any name
+// units.ParseUnit understands and defaults to count.
+func stepInputsFromV2(steps []models.RecipeStepV2) ([]storage.StepInput, error) {
+	inputs := make([]storage.StepInput, len(steps))
+	for i, step := range steps {
+		ingredients := make([]storage.IngredientInput, len(step.Ingredients))
+		for j, ing := range step.Ingredients {
+			// resolveSubRecipes names sub-recipes left unnamed
+			name := strings.TrimSpace(ing.Name)
+			if name == "" && ing.RecipeUUID == nil {
+				return nil, fmt.Errorf("step %d ingredient %d: name is required", i+1, j+1)
+			}
+			quantityText := strings.TrimSpace(ing.QuantityText)
+			// "salt to taste" has no numeric quantity
+			if (ing.Quantity == 0 && quantityText == "") || !(ing.Quantity >= 0) || math.IsInf(ing.Quantity, 1) {
+				return nil, fmt.Errorf("invalid quantity for ingredient %q: must be a positive number", name)
+			}
+			if ing.QuantityMax != 0 && (ing.QuantityMax < ing.Quantity || math.IsInf(ing.QuantityMax, 1)) {
+				return nil, fmt.Errorf("invalid quantity_max for ingredient %q: must not be below quantity", name)
+			}
+
+			unit := "count"
+			if ing.Unit != "" {
+				parsed, _, err := units.ParseUnit(ing.Unit)
+				if err != nil {
+					return nil, fmt.Errorf("invalid unit for ingredient %q: %v", name, err)
+				}
+				unit = parsed
+			}
+			// ParseUnit knows some aliases that have no conversion
+			if _, _, err := units.ToBaseUnit(ing.Quantity, unit); err != nil {
+				return nil, fmt.Errorf("invalid unit for ingredient %q: %v", name, err)
+			}
+
+			ingredients[j] = storage.IngredientInput{
+				Name:         name,
+				Unit:         unit,
+				Quantity:     ing.Quantity,
+				QuantityMax:  ing.QuantityMax,
+				QuantityText: quantityText,
+				Preparation:  strings.TrimSpace(ing.Preparation),
+				Optional:     ing.Optional,
+				OriginalText: strings.TrimSpace(ing.OriginalText),
+				RecipeUUID:   ing.RecipeUUID,
+			}
+		}
+		inputs[i] = storage.StepInput{
+			Instruction: step.Instruction,
+			GroupTitle:  strings.TrimSpace(step.GroupTitle),
+			Ingredients: ingredients,
+		}
+	}
+	return inputs, nil
+}
+
+// resolveSubRecipes checks the caller can view every sub-recipe the steps refer
+// to, defaulting unnamed sub-recipe ingredients to the recipe's name. Recipes
+// the caller can't view are reported as not found.
+func resolveSubRecipes(w http.ResponseWriter, r *http.Request, steps []storage.StepInput) bool {
+	ctx := r.Context()
+	user := auth.UserFromContext(ctx)
+	for i := range steps {
+		for j := range steps[i].Ingredients {
+			ing := &steps[i].Ingredients[j]
+			if ing.RecipeUUID == nil {
+				continue
+			}
+
+			recipe, err := viewableSubRecipe(user, *ing.RecipeUUID)
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, http.StatusBadRequest, fmt.Sprintf("sub-recipe %s not found", ing.RecipeUUID))
+				return false
+			}
+			if err != nil {
+				logger.Error(ctx, "failed to get sub-recipe", err, "recipe_uuid", *ing.RecipeUUID)
+				respondWithError(w, http.StatusInternalServerError, "failed to update recipe steps")
+				return false
+			}
+
+			if ing.Name == "" {
+				ing.Name = recipe.Name
+			}
+		}
+	}
+	return true
+}
+
+// viewableSubRecipe gets a sub-recipe, returning sql.ErrNoRows when it doesn't
+// exist or user can't view it
+func viewableSubRecipe(user *models.User, recipeUUID uuid.UUID) (*models.Recipe, error) {
+	recipe, err := storage.GetRecipeByUUID(recipeUUID)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := authz.CanView(user, recipe)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, sql.ErrNoRows
+	}
+	return recipe, nil
+}
+
+// keepSubRecipes carries sub-recipe references over from existing steps to
+// ingredients of the same name, since v1 strings can't express them. Links to
+// recipes the caller can no longer view are dropped rather than failing the
+// save.
+func keepSubRecipes(ctx context.Context, existing []models.RecipeStepWithIngredients, steps []storage.StepInput) error {
+	user := auth.UserFromContext(ctx)
+	subRecipes := make(map[string]uuid.UUID)
+	for _, step := range existing {
+		for _, ing := range step.Ingredients {
+			if ing.SubRecipeUUID == nil {
+				continue
+			}
+			if _, err := viewableSubRecipe(user, *ing.SubRecipeUUID); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					continue
+				}
+				return err
+			}
+			subRecipes[ing.IngredientName] = *ing.SubRecipeUUID
+		}
+	}
+
+	for i := range steps {
+		for j := range steps[i].Ingredients {
+			if recipeUUID, ok := subRecipes[steps[i].Ingredients[j].Name]; ok {
+				steps[i].Ingredients[j].RecipeUUID = &recipeUUID
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,589 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+)
+
+func TestRecipeSteps_V2RoundTrip(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("steps-v2-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+	path := "/api/recipes/" + recipe.UUID.String() + "/steps?format=v2"
+
+	// "7 spice blend" can't survive the v1 string round trip
+	putBody := `{"steps": [{"instruction": "Season", "ingredients": [
+		{"quantity": 2, "unit": "teaspoons", "name": "7 spice blend"},
+		{"quantity": 3, "name": "eggs"}
+	]}]}`
+	putW := httptest.NewRecorder()
+	UpdateRecipeSteps(putW, asUser(t, httptest.NewRequest("PUT", path, strings.NewReader(putBody)), testEmail))
+	if putW.Code != http.StatusOK {
+		t.Fatalf("PUT failed: status %d: %s", putW.Code, putW.Body.String())
+	}
+
+	getW := httptest.NewRecorder()
+	GetRecipeSteps(getW, asUser(t, httptest.NewRequest("GET", path, nil), testEmail))
+	if getW.Code != http.StatusOK {
+		t.Fatalf("GET failed: status %d: %s", getW.Code, getW.Body.String())
+	}
+
+	var response models.RecipeStepsV2Response
+	if err := json.NewDecoder(getW.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Steps) != 1 || len(response.Steps[0].Ingredients) != 2 {
+		t.Fatalf("Expected 1 step with 2 ingredients, got %+v", response.Steps)
+	}
+
+	spice, eggs := response.Steps[0].Ingredients[0], response.Steps[0].Ingredients[1]
+	if spice.Name != "7 spice blend" || spice.Quantity != 2 || spice.Unit != "tsp" || spice.Category != "volume" {
+		t.Errorf("Unexpected spice ingredient %+v", spice)
+	}
+	if spice.BaseQuantity < 9.85 || spice.BaseQuantity > 9.86 {
+		t.Errorf("Expected base quantity of about 9.86 ml, got %v", spice.BaseQuantity)
+	}
+	if spice.Display != "2 tsp 7 spice blend" {
+		t.Errorf("Expected display '2 tsp 7 spice blend', got %q", spice.Display)
+	}
+	if eggs.Name != "eggs" || eggs.Quantity != 3 || eggs.Unit != "count" || eggs.Display != "3 eggs" {
+		t.Errorf("Unexpected eggs ingredient %+v", eggs)
+	}
+
+	// v1 still returns the display strings
+	v1W := httptest.NewRecorder()
+	GetRecipeSteps(v1W, asUser(t, httptest.NewRequest("GET", "/api/recipes/"+recipe.UUID.String()+"/steps", nil), testEmail))
+	var v1 models.RecipeStepsResponse
+	if err := json.NewDecoder(v1W.Body).Decode(&v1); err != nil {
+		t.Fatalf("Failed to decode v1 response: %v", err)
+	}
+	if len(v1.Steps) != 1 || len(v1.Steps[0].Ingredients) != 2 || v1.Steps[0].Ingredients[0] != "2 tsp 7 spice blend" {
+		t.Errorf("Unexpected v1 steps %+v", v1.Steps)
+	}
+}
+
+func TestRecipeSteps_IngredientDetails(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("steps-details-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+	path := "/api/recipes/" + recipe.UUID.String() + "/steps"
+
+	putBody := `{"steps": [{"instruction": "Cook", "ingredients": [
+		"2 onions, finely diced", "salt to taste", "1 (14 oz) can tomatoes",
+		"2-3 cloves garlic", "1 tsp chili flakes (optional)"
+	]}]}`
+	putW := httptest.NewRecorder()
+	UpdateRecipeSteps(putW, asUser(t, httptest.NewRequest("PUT", path, strings.NewReader(putBody)), testEmail))
+	if putW.Code != http.StatusOK {
+		t.Fatalf("PUT failed: status %d: %s", putW.Code, putW.Body.String())
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"2 onions, finely diced", "salt to taste", "1 can (14 oz) tomatoes",
+			"2-3 cloves garlic", "1 tsp chili flakes (optional)"}},
+		{"?scale=2", []string{"4 onions, finely diced", "salt to taste", "2 can (14 oz) tomatoes",
+			"4-6 cloves garlic", "2 tsp chili flakes (optional)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			GetRecipeSteps(w, asUser(t, httptest.NewRequest("GET", path+tt.query, nil), testEmail))
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET failed: status %d: %s", w.Code, w.Body.String())
+			}
+
+			var response models.RecipeStepsResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(response.Steps) != 1 {
+				t.Fatalf("Expected 1 step, got %d", len(response.Steps))
+			}
+			got := response.Steps[0].Ingredients
+			slices.Sort(got)
+			slices.Sort(tt.want)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Expected ingredients %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	GetRecipeSteps(w, asUser(t, httptest.NewRequest("GET", path+"?format=v2", nil), testEmail))
+	var response models.RecipeStepsV2Response
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode v2 response: %v", err)
+	}
+	for _, ing := range response.Steps[0].Ingredients {
+		if ing.Name == "onions" && (ing.Preparation != "finely diced" || ing.OriginalText != "2 onions, finely diced") {
+			t.Errorf("Unexpected onions ingredient %+v", ing)
+		}
+		if ing.Name == "salt" && (ing.Quantity != 0 || ing.QuantityText != "to taste") {
+			t.Errorf("Unexpected salt ingredient %+v", ing)
+		}
+		if ing.Name == "cloves garlic" && (ing.Quantity != 2 || ing.QuantityMax != 3) {
+			t.Errorf("Unexpected garlic ingredient %+v", ing)
+		}
+		if ing.Name == "chili flakes" && !ing.Optional {
+			t.Errorf("Expected chili flakes to be optional, got %+v", ing)
+		}
+	}
+}
+
+func TestRecipeSteps_GroupTitles(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("steps-groups-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+	path := "/api/recipes/" + recipe.UUID.String() + "/steps"
+
+	putBody := `{"steps": [
+		{"instruction": "", "group_title": " For the dough ", "ingredients": ["2 cups flour", "1 tsp salt"]},
+		{"instruction": "", "group_title": "For the filling", "ingredients": ["3 apples"]},
+		{"instruction": "Roll out the dough", "ingredients": []}
+	]}`
+	putW := httptest.NewRecorder()
+	UpdateRecipeSteps(putW, asUser(t, httptest.NewRequest("PUT", path, strings.NewReader(putBody)), testEmail))
+	if putW.Code != http.StatusOK {
+		t.Fatalf("PUT failed: status %d: %s", putW.Code, putW.Body.String())
+	}
+
+	want := []string{"For the dough", "For the filling", ""}
+
+	getW := httptest.NewRecorder()
+	GetRecipeSteps(getW, asUser(t, httptest.NewRequest("GET", path, nil), testEmail))
+	var v1 models.RecipeStepsResponse
+	if err := json.NewDecoder(getW.Body).Decode(&v1); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(v1.Steps) != len(want) {
+		t.Fatalf("Expected %d steps, got %d", len(want), len(v1.Steps))
+	}
+	for i, step := range v1.Steps {
+		if step.GroupTitle != want[i] {
+			t.Errorf("Step %d: expected group title %q, got %q", i+1, want[i], step.GroupTitle)
+		}
+	}
+
+	v2W := httptest.NewRecorder()
+	GetRecipeSteps(v2W, asUser(t, httptest.NewRequest("GET", path+"?format=v2", nil), testEmail))
+	var v2 models.RecipeStepsV2Response
+	if err := json.NewDecoder(v2W.Body).Decode(&v2); err != nil {
+		t.Fatalf("Failed to decode v2 response: %v", err)
+	}
+	if len(v2.Steps) != len(want) {
+		t.Fatalf("Expected %d v2 steps, got %d", len(want), len(v2.Steps))
+	}
+	for i, step := range v2.Steps {
+		if step.GroupTitle != want[i] {
+			t.Errorf("v2 step %d: expected group title %q, got %q", i+1, want[i], step.GroupTitle)
+		}
+	}
+}
+
+func TestUpdateRecipeSteps_InvalidV2Ingredient(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("steps-v2-invalid-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+	path := "/api/recipes/" + recipe.UUID.String() + "/steps?format=v2"
+
+	tests := []struct {
+		desc       string
+		ingredient string
+	}{
+		{"missing name", `{"quantity": 1, "unit": "cup", "name": " "}`},
+		{"zero quantity", `{"quantity": 0, "unit": "cup", "name": "flour"}`},
+		{"negative quantity", `{"quantity": -2, "unit": "cup", "name": "flour"}`},
+		{"range below quantity", `{"quantity": 2, "quantity_max": 1, "unit": "cup", "name": "flour"}`},
+		{"unknown unit", `{"quantity": 1, "unit": "bushel", "name": "apples"}`},
+		{"unit without conversion", `{"quantity": 1, "unit": "pint", "name": "milk"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			body := `{"steps": [{"instruction": "Mix", "ingredients": [` + tt.ingredient + `]}]}`
+			w := httptest.NewRecorder()
+
+			UpdateRecipeSteps(w, asUser(t, httptest.NewRequest("PUT", path, strings.NewReader(body)), testEmail))
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRecipeSteps_InvalidFormat(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("steps-format-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+	path := "/api/recipes/" + recipe.UUID.String() + "/steps?format=v3"
+
+	getW := httptest.NewRecorder()
+	GetRecipeSteps(getW, asUser(t, httptest.NewRequest("GET", path, nil), testEmail))
+	if getW.Code != http.StatusBadRequest {
+		t.Errorf("GET: expected status 400, got %d", getW.Code)
+	}
+
+	putW := httptest.NewRecorder()
+	UpdateRecipeSteps(putW, asUser(t, httptest.NewRequest("PUT", path, strings.NewReader(`{"steps": []}`)), testEmail))
+	if putW.Code != http.StatusBadRequest {
+		t.Errorf("PUT: expected status 400, got %d", putW.Code)
+	}
+}
+
+func TestRecipeSteps_SubRecipes(t *testing.T) {
+	ensureTestUser(t)
+
+	stock, err := storage.InsertRecipeByEmail("sub-recipe-stock", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(stock.UUID)
+	soup, err := storage.InsertRecipeByEmail("sub-recipe-soup", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(soup.UUID)
+	stockPath := "/api/recipes/" + stock.UUID.String()
+	soupPath := "/api/recipes/" + soup.UUID.String()
+
+	edits := []struct {
+		handler http.HandlerFunc
+		req     *http.Request
+	}{
+		{PatchRecipe, httptest.NewRequest("PATCH", stockPath, strings.NewReader(`{"yield_text": "1 l stock"}`))},
+		{UpdateRecipeSteps, httptest.NewRequest("PUT", stockPath+"/steps",
+			strings.NewReader(`{"steps": [{"instruction": "Simmer", "ingredients": ["4 cups water", "4 carrots"]}]}`))},
+		{UpdateRecipeSteps, httptest.NewRequest("PUT", soupPath+"/steps?format=v2",
+			strings.NewReader(`{"steps": [{"instruction": "Heat", "ingredients": [
+				{"quantity": 250, "unit": "ml", "recipe_uuid": "`+stock.UUID.String()+`"}
+			]}]}`))},
+	}
+	for _, e := range edits {
+		w := httptest.NewRecorder()
+		e.handler(w, asUser(t, e.req, testEmail))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Edit failed with %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	GetRecipeSteps(w, asUser(t, httptest.NewRequest("GET", soupPath+"/steps?format=v2&expand=true", nil), testEmail))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET failed: status %d: %s", w.Code, w.Body.String())
+	}
+	var response models.RecipeStepsV2Response
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	ing := response.Steps[0].Ingredients[0]
+	if ing.Name != "sub-recipe-stock" || ing.RecipeUUID == nil || *ing.RecipeUUID != stock.UUID {
+		t.Fatalf("Expected a sub-recipe ingredient named after the stock, got %+v", ing)
+	}
+	if ing.Recipe == nil || ing.Recipe.Scale != 0.25 || len(ing.Recipe.Steps) != 1 {
+		t.Fatalf("Expected a quarter batch of stock, got %+v", ing.Recipe)
+	}
+	var got []string
+	for _, sub := range ing.Recipe.Steps[0].Ingredients {
+		got = append(got, sub.Display)
+	}
+	slices.Sort(got)
+	if want := []string{"1 carrots", "1 cup water"}; !slices.Equal(got, want) {
+		t.Errorf("Expected sub-recipe ingredients %q, got %q", want, got)
+	}
+
+	// Saving v1 strings keeps the reference
+	v1W := httptest.NewRecorder()
+	UpdateRecipeSteps(v1W, asUser(t, httptest.NewRequest("PUT", soupPath+"/steps",
+		strings.NewReader(`{"steps": [{"instruction": "Heat", "ingredients": ["1 l sub-recipe-stock"]}]}`)), testEmail))
+	if v1W.Code != http.StatusOK {
+		t.Fatalf("v1 PUT failed: status %d: %s", v1W.Code, v1W.Body.String())
+	}
+	steps, err := storage.GetRecipeStepsWithIngredients(soup.UUID)
+	if err != nil {
+		t.Fatalf("GetRecipeStepsWithIngredients failed: %v", err)
+	}
+	if ref := steps[0].Ingredients[0].SubRecipeUUID; ref == nil || *ref != stock.UUID {
+		t.Errorf("Expected the v1 save to keep the sub-recipe, got %v", ref)
+	}
+
+	cycles := []struct {
+		desc string
+		path string
+		ref  string
+	}{
+		{"self", soupPath, soup.UUID.String()},
+		{"through sub-recipe", stockPath, soup.UUID.String()},
+	}
+	for _, tt := range cycles {
+		t.Run(tt.desc, func(t *testing.T) {
+			body := `{"steps": [{"instruction": "Mix", "ingredients": [{"quantity": 1, "recipe_uuid": "` + tt.ref + `"}]}]}`
+			w := httptest.NewRecorder()
+			UpdateRecipeSteps(w, asUser(t, httptest.NewRequest("PUT", tt.path+"/steps?format=v2", strings.NewReader(body)), testEmail))
+			if w.Code != http.StatusConflict {
+				t.Errorf("Expected status 409, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// Once the stock is trashed, v1 saves drop the reference instead of failing
+	if err := storage.DeleteRecipe(stock.UUID); err != nil {
+		t.Fatalf("DeleteRecipe failed: %v", err)
+	}
+	trashedW := httptest.NewRecorder()
+	UpdateRecipeSteps(trashedW, asUser(t, httptest.NewRequest("PUT", soupPath+"/steps",
+		strings.NewReader(`{"steps": [{"instruction": "Heat", "ingredients": ["1 l sub-recipe-stock"]}]}`)), testEmail))
+	if trashedW.Code != http.StatusOK {
+		t.Fatalf("v1 PUT after trashing failed: status %d: %s", trashedW.Code, trashedW.Body.String())
+	}
+	steps, err = storage.GetRecipeStepsWithIngredients(soup.UUID)
+	if err != nil {
+		t.Fatalf("GetRecipeStepsWithIngredients failed: %v", err)
+	}
+	if ref := steps[0].Ingredients[0].SubRecipeUUID; ref != nil {
+		t.Errorf("Expected the trashed sub-recipe to be dropped, got %v", ref)
+	}
+}
+
+func TestUpdateRecipeSteps_SubRecipeNotViewable(t *testing.T) {
+	ensureTestUser(t)
+	ensureTestUser2(t)
+
+	private, err := storage.InsertRecipeByEmail("sub-recipe-private", testEmail2, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(private.UUID)
+	recipe, err := storage.InsertRecipeByEmail("sub-recipe-parent", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+
+	body := `{"steps": [{"instruction": "Mix", "ingredients": [{"quantity": 1, "recipe_uuid": "` + private.UUID.String() + `"}]}]}`
+	w := httptest.NewRecorder()
+	UpdateRecipeSteps(w, asUser(t, httptest.NewRequest("PUT", "/api/recipes/"+recipe.UUID.String()+"/steps?format=v2",
+		strings.NewReader(body)), testEmail))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSubRecipeScale(t *testing.T) {
+	litre, muffins := "1 l", "12 muffins"
+
+	tests := []struct {
+		desc  string
+		ing   models.Ingredient
+		yield *string
+		want  float64
+	}{
+		{"batches", models.Ingredient{Category: "count", BaseQuantity: 2}, nil, 2},
+		{"batches ignore a count yield", models.Ingredient{Category: "count", BaseQuantity: 2}, &muffins, 2},
+		{"volume of a volume yield", models.Ingredient{Category: "volume", BaseQuantity: 250}, &litre, 0.25},
+		{"mass of a volume yield", models.Ingredient{Category: "mass", BaseQuantity: 250000}, &litre, 1},
+		{"volume without a yield", models.Ingredient{Category: "volume", BaseQuantity: 250}, nil, 1},
+		{"no quantity", models.Ingredient{Category: "count", QuantityText: "as needed"}, nil, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := subRecipeScale(tt.ing, &models.Recipe{YieldText: tt.yield}); got != tt.want {
+				t.Errorf("subRecipeScale() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRecipeSteps_Convert(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("steps-convert-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+	path := "/api/recipes/" + recipe.UUID.String() + "/steps"
+
+	putBody := `{"steps": [{"instruction": "Mix", "ingredients": ["1 cup flour", "100 g butter", "2 eggs", "1 tsp saffron"]}]}`
+	putW := httptest.NewRecorder()
+	UpdateRecipeSteps(putW, asUser(t, httptest.NewRequest("PUT", path, strings.NewReader(putBody)), testEmail))
+	if putW.Code != http.StatusOK {
+		t.Fatalf("PUT failed: status %d: %s", putW.Code, putW.Body.String())
+	}
+
+	type want struct {
+		category string
+		base     float64
+	}
+	tests := []struct {
+		convert string
+		want    map[string]want
+	}{
+		{"mass", map[string]want{
+			"flour": {"mass", 125391}, "butter": {"mass", 100000}, "eggs": {"count", 2}, "saffron": {"volume", 4.93},
+		}},
+		{"volume", map[string]want{
+			"flour": {"volume", 236.59}, "butter": {"volume", 104.17}, "eggs": {"count", 2}, "saffron": {"volume", 4.93},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.convert, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			GetRecipeSteps(w, asUser(t, httptest.NewRequest("GET", path+"?format=v2&convert="+tt.convert, nil), testEmail))
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET failed: status %d: %s", w.Code, w.Body.String())
+			}
+			var response models.RecipeStepsV2Response
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			for _, ing := range response.Steps[0].Ingredients {
+				expected := tt.want[ing.Name]
+				if ing.Category != expected.category || ing.BaseQuantity < expected.base*0.999 || ing.BaseQuantity > expected.base*1.001 {
+					t.Errorf("%s: expected %v %s, got %v %s", ing.Name, expected.base, expected.category, ing.BaseQuantity, ing.Category)
+				}
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	GetRecipeSteps(w, asUser(t, httptest.NewRequest("GET", path+"?convert=weight", nil), testEmail))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown convert, got %d", w.Code)
+	}
+}
+
+func TestGetRecipeSteps_MeasurementSystem(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("steps-system-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+	path := "/api/recipes/" + recipe.UUID.String() + "/steps"
+
+	putBody := `{"steps": [{"instruction": "Mix", "ingredients": ["1 cup flour", "250 g butter", "16 tbsp sugar"]}]}`
+	putW := httptest.NewRecorder()
+	UpdateRecipeSteps(putW, asUser(t, httptest.NewRequest("PUT", path, strings.NewReader(putBody)), testEmail))
+	if putW.Code != http.StatusOK {
+		t.Fatalf("PUT failed: status %d: %s", putW.Code, putW.Body.String())
+	}
+
+	tests := []struct {
+		system string
+		want   []string
+	}{
+		{"", []string{"1 cup flour", "250 g butter", "1 cup sugar"}},
+		{"metric", []string{"236.6 ml flour", "250 g butter", "236.6 ml sugar"}},
+		{"us", []string{"1 cup flour", "8 ¾ oz butter", "1 cup sugar"}},
+		{"as_written", []string{"1 cup flour", "250 g butter", "16 tbsp sugar"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.system, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			GetRecipeSteps(w, asUser(t, httptest.NewRequest("GET", path+"?system="+tt.system, nil), testEmail))
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET failed: status %d: %s", w.Code, w.Body.String())
+			}
+			var response models.RecipeStepsResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if !slices.Equal(response.Steps[0].Ingredients, tt.want) {
+				t.Errorf("Expected %q, got %q", tt.want, response.Steps[0].Ingredients)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	GetRecipeSteps(w, asUser(t, httptest.NewRequest("GET", path+"?system=cubits", nil), testEmail))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown system, got %d", w.Code)
+	}
+}
+
+func TestGetRecipeSteps_Annotations(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("steps-annotations-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+	path := "/api/recipes/" + recipe.UUID.String() + "/steps"
+
+	putBody := `{"steps": [
+		{"instruction": "", "ingredients": ["2 cups flour", "1 cup sugar"]},
+		{"instruction": "Mix the flour and sugar, then bake at 350°F for 25-30 minutes.", "ingredients": []}
+	]}`
+	putW := httptest.NewRecorder()
+	UpdateRecipeSteps(putW, asUser(t, httptest.NewRequest("PUT", path, strings.NewReader(putBody)), testEmail))
+	if putW.Code != http.StatusOK {
+		t.Fatalf("PUT failed: status %d: %s", putW.Code, putW.Body.String())
+	}
+
+	w := httptest.NewRecorder()
+	GetRecipeSteps(w, asUser(t, httptest.NewRequest("GET", path+"?format=v2", nil), testEmail))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET failed: status %d: %s", w.Code, w.Body.String())
+	}
+	var response models.RecipeStepsV2Response
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	var types []string
+	for _, a := range response.Steps[1].Annotations {
+		types = append(types, a.Type)
+	}
+	want := []string{models.AnnotationIngredient, models.AnnotationIngredient, models.AnnotationTemperature, models.AnnotationDuration}
+	if !slices.Equal(types, want) {
+		t.Fatalf("Expected annotations %q, got %q", want, types)
+	}
+	if sugar := response.Steps[1].Annotations[1].Ingredient; sugar.Step != 0 || sugar.Index != 1 {
+		t.Errorf("Expected sugar to link to step 0 ingredient 1, got %+v", sugar)
+	}
+	if temp := response.Steps[1].Annotations[2].Temperature; temp.Celsius != 177 {
+		t.Errorf("Expected 177°C, got %+v", temp)
+	}
+	if d := response.Steps[1].Annotations[3].Duration; d.Seconds != 1500 || d.SecondsMax != 1800 {
+		t.Errorf("Expected 25-30 minutes, got %+v", d)
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cobyabrahams/hungr/logger"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+	"github.com/gofrs/uuid"
+)
+
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+// TrashRetention is how long deleted recipes stay in the trash before they are
+// purged, from TRASH_RETENTION (a Go duration such as "720h"). Defaults to 30
+// days.
+func TrashRetention() time.Duration {
+	if value := os.Getenv("TRASH_RETENTION"); value != "" {
+		if retention, err := time.ParseDuration(value); err == nil && retention > 0 {
+			return retention
+		}
+	}
+	return defaultTrashRetention
+}
+
+// GetTrash handles GET /api/trash, listing the caller's deleted recipes
+func GetTrash(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	recipes, err := storage.GetDeletedRecipesByUser(user.UUID)
+	if err != nil {
+		logger.Error(ctx, "failed to get deleted recipes", err, "user_uuid", user.UUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to load trash")
+		return
+	}
+
+	retention := TrashRetention()
+	for i := range recipes {
+		recipes[i].PurgeAt = recipes[i].DeletedAt.Add(retention)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.TrashResponse{Recipes: recipes})
+}
+
+// RestoreTrashedRecipe handles POST /api/trash/{uuid}/restore
+func RestoreTrashedRecipe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	recipeUUID, ok := trashedRecipe(w, r)
+	if !ok {
+		return
+	}
+
+	if err := storage.RestoreRecipe(recipeUUID); err != nil {
+		logger.Error(ctx, "failed to restore recipe", err, "recipe_uuid", recipeUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to restore recipe")
+		return
+	}
+
+	logger.Info(ctx, "recipe restored from trash", "recipe_uuid", recipeUUID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// PurgeTrashedRecipe handles DELETE /api/trash/{uuid}, permanently deleting a
+// recipe that is already in the trash
+func PurgeTrashedRecipe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	recipeUUID, ok := trashedRecipe(w, r)
+	if !ok {
+		return
+	}
+
+	if err := storage.PurgeRecipe(recipeUUID); err != nil {
+		logger.Error(ctx, "failed to purge recipe", err, "recipe_uuid", recipeUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to delete recipe")
+		return
+	}
+
+	logger.Info(ctx, "recipe purged from trash", "recipe_uuid", recipeUUID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// trashedRecipe parses /api/trash/{uuid}[/restore] and checks the recipe is in
+// the caller's trash. Other users' trash is reported as not found.
+func trashedRecipe(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	ctx := r.Context()
+	user, ok := requireUser(w, r)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/trash/"), "/")
+	if len(parts) < 1 || parts[0] == "" {
+		respondWithError(w, http.StatusBadRequest, "recipe uuid is required")
+		return uuid.Nil, false
+	}
+
+	recipeUUID, err := uuid.FromString(parts[0])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid recipe uuid")
+		return uuid.Nil, false
+	}
+
+	if _, err := storage.GetDeletedRecipe(user.UUID, recipeUUID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "recipe not found in trash")
+			return uuid.Nil, false
+		}
+		logger.Error(ctx, "failed to get deleted recipe", err, "recipe_uuid", recipeUUID)
+		respondWithError(w, http.StatusInternalServerError, "failed to load recipe")
+		return uuid.Nil, false
+	}
+	return recipeUUID, true
+}
+
+// PurgeExpiredTrash permanently deletes recipes that have been in the trash
+// longer than TrashRetention, checking every interval until the process exits
+func PurgeExpiredTrash(interval time.Duration) {
+	for {
+		purged, err := storage.PurgeDeletedRecipes(time.Now().Add(-TrashRetention()))
+		if err != nil {
+			logger.Log.Error("failed to purge expired trash", "error", err, "purged", purged)
+		} else if purged > 0 {
+			logger.Log.Info("purged expired trash", "purged", purged)
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/storage"
+	"github.com/gofrs/uuid"
+)
+
+// createTrashedRecipe creates a recipe owned by the test user and deletes it
+// through the handler
+func createTrashedRecipe(t *testing.T) uuid.UUID {
+	t.Helper()
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("trash-handler", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	t.Cleanup(func() { storage.PurgeRecipe(recipe.UUID) })
+
+	req := asUser(t, httptest.NewRequest("DELETE", "/api/recipes/"+recipe.UUID.String(), nil), testEmail)
+	w := httptest.NewRecorder()
+	DeleteRecipe(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("DeleteRecipe failed with %d: %s", w.Code, w.Body.String())
+	}
+	return recipe.UUID
+}
+
+func TestGetTrash(t *testing.T) {
+	recipeUUID := createTrashedRecipe(t)
+
+	req := asUser(t, httptest.NewRequest("GET", "/api/trash", nil), testEmail)
+	w := httptest.NewRecorder()
+
+	GetTrash(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response models.TrashResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	var found *models.TrashedRecipe
+	for i := range response.Recipes {
+		if response.Recipes[i].Recipe.UUID == recipeUUID {
+			found = &response.Recipes[i]
+		}
+	}
+	if found == nil {
+		t.Fatal("Expected deleted recipe in the trash")
+	}
+	if got := found.PurgeAt.Sub(found.DeletedAt); got != defaultTrashRetention {
+		t.Errorf("Expected purge_at %v after deleted_at, got %v", defaultTrashRetention, got)
+	}
+}
+
+func TestTrashRetention(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", defaultTrashRetention},
+		{"48h", 48 * time.Hour},
+		{"not-a-duration", defaultTrashRetention},
+		{"-1h", defaultTrashRetention},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("TRASH_RETENTION", tt.value)
+			if got := TrashRetention(); got != tt.want {
+				t.Errorf("TrashRetention() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestoreTrashedRecipe(t *testing.T) {
+	recipeUUID := createTrashedRecipe(t)
+	path := "/api/trash/" + recipeUUID.String() + "/restore"
+
+	tests := []struct {
+		desc       string
+		email      string
+		wantStatus int
+	}{
+		{"anonymous", "", http.StatusUnauthorized},
+		{"other user", testEmail2, http.StatusNotFound},
+		{"owner", testEmail, http.StatusOK},
+		{"already restored", testEmail, http.StatusNotFound},
+	}
+
+	ensureTestUser2(t)
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := asCaller(t, httptest.NewRequest("POST", path, nil), tt.email)
+			w := httptest.NewRecorder()
+
+			RestoreTrashedRecipe(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	if _, err := storage.GetRecipeByUUID(recipeUUID); err != nil {
+		t.Errorf("Expected restored recipe to be found, got %v", err)
+	}
+}
+
+func TestPurgeTrashedRecipe(t *testing.T) {
+	recipeUUID := createTrashedRecipe(t)
+	path := "/api/trash/" + recipeUUID.String()
+
+	tests := []struct {
+		desc       string
+		email      string
+		wantStatus int
+	}{
+		{"other user", testEmail2, http.StatusNotFound},
+		{"owner", testEmail, http.StatusOK},
+		{"already purged", testEmail, http.StatusNotFound},
+	}
+
+	ensureTestUser2(t)
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := asCaller(t, httptest.NewRequest("DELETE", path, nil), tt.email)
+			w := httptest.NewRecorder()
+
+			PurgeTrashedRecipe(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestPurgeTrashedRecipe_NotInTrash(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := storage.InsertRecipeByEmail("trash-live", testEmail, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test recipe: %v", err)
+	}
+	defer storage.PurgeRecipe(recipe.UUID)
+
+	req := asUser(t, httptest.NewRequest("DELETE", "/api/trash/"+recipe.UUID.String(), nil), testEmail)
+	w := httptest.NewRecorder()
+
+	PurgeTrashedRecipe(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := storage.GetRecipeByUUID(recipe.UUID); err != nil {
+		t.Errorf("Live recipe must not be purged, got %v", err)
+	}
+}
@@ -10,43 +10,8 @@ import (
 	"github.com/gofrs/uuid"
 )
 
-func CreateUser(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	var req models.CreateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-
-	if req.Email == "" || req.Name == "" {
-		respondWithError(w, http.StatusBadRequest, "email and name are required")
-		return
-	}
-
-	// Check if user already exists
-	existingUser, _ := storage.GetUserByEmail(req.Email)
-	if existingUser != nil {
-		respondWithError(w, http.StatusConflict, "user with this email already exists")
-		return
-	}
-
-	user, err := storage.CreateUser(req.Email, req.Name)
-	if err != nil {
-		logger.Error(ctx, "failed to create user", err, "email", req.Email)
-		respondWithError(w, http.StatusInternalServerError, "failed to create user")
-		return
-	}
-
-	logger.Info(ctx, "user created", "user_uuid", user.UUID, "email", req.Email)
-	response := models.UserResponse{
-		Success: true,
-		User:    *user,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
+// GetUser handles GET /api/users, looking a user up by ?uuid= or ?email=, or
+// returning the signed-in user when neither is given
 func GetUser(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	userUUIDStr := r.URL.Query().Get("uuid")
@@ -65,8 +30,11 @@ func GetUser(w http.ResponseWriter, r *http.Request) {
 	} else if email != "" {
 		user, err = storage.GetUserByEmail(email)
 	} else {
-		respondWithError(w, http.StatusBadRequest, "uuid or email is required")
-		return
+		authUser, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+		user = authUser
 	}
 
 	if err != nil {
@@ -84,17 +52,11 @@ func GetUser(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// UpdateUser handles PUT /api/users, renaming the signed-in user
 func UpdateUser(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	userUUIDStr := r.URL.Query().Get("uuid")
-	if userUUIDStr == "" {
-		respondWithError(w, http.StatusBadRequest, "uuid is required")
-		return
-	}
-
-	userUUID, err := uuid.FromString(userUUIDStr)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid uuid")
+	authUser, ok := requireSessionUser(w, r)
+	if !ok {
 		return
 	}
 
@@ -111,14 +73,14 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := storage.UpdateUser(userUUID, updateReq.Name)
+	user, err := storage.UpdateUser(authUser.UUID, updateReq.Name)
 	if err != nil {
-		logger.Error(ctx, "failed to update user", err, "user_uuid", userUUID)
+		logger.Error(ctx, "failed to update user", err, "user_uuid", authUser.UUID)
 		respondWithError(w, http.StatusInternalServerError, "failed to update user")
 		return
 	}
 
-	logger.Info(ctx, "user updated", "user_uuid", userUUID)
+	logger.Info(ctx, "user updated", "user_uuid", authUser.UUID)
 	response := models.UserResponse{
 		Success: true,
 		User:    *user,
@@ -128,60 +90,22 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// DeleteUser handles DELETE /api/users, deleting the signed-in user's account
+// and everything it owns
 func DeleteUser(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	userUUIDStr := r.URL.Query().Get("uuid")
-	if userUUIDStr == "" {
-		respondWithError(w, http.StatusBadRequest, "uuid is required")
-		return
-	}
-
-	userUUID, err := uuid.FromString(userUUIDStr)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid uuid")
+	authUser, ok := requireSessionUser(w, r)
+	if !ok {
 		return
 	}
 
-	err = storage.DeleteUser(userUUID)
-	if err != nil {
-		logger.Error(ctx, "failed to delete user", err, "user_uuid", userUUID)
+	if err := storage.DeleteUser(authUser.UUID); err != nil {
+		logger.Error(ctx, "failed to delete user", err, "user_uuid", authUser.UUID)
 		respondWithError(w, http.StatusInternalServerError, "failed to delete user")
 		return
 	}
 
-	logger.Info(ctx, "user deleted", "user_uuid", userUUID)
+	logger.Info(ctx, "user deleted", "user_uuid", authUser.UUID)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]bool{"success": true})
 }
-
-func Login(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	var req struct {
-		Email string `json:"email"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-
-	if req.Email == "" {
-		respondWithError(w, http.StatusBadRequest, "email is required")
-		return
-	}
-
-	user, err := storage.UpsertUserOnLogin(req.Email)
-	if err != nil {
-		logger.Error(ctx, "failed to login/upsert user", err, "email", req.Email)
-		respondWithError(w, http.StatusInternalServerError, "login failed")
-		return
-	}
-
-	logger.Info(ctx, "user logged in", "user_uuid", user.UUID, "email", req.Email)
-	response := models.UserResponse{
-		Success: true,
-		User:    *user,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
@@ -0,0 +1,116 @@
+// Package ingredients canonicalizes ingredient names, so that "Eggs", "egg ",
+// and "large eggs" are recognised as the same ingredient
+package ingredients
+
+import (
+	"strings"
+	"unicode"
+)
+
+// descriptors describe the size or quality of an ingredient rather than what
+// it is, so "large eggs" are eggs. Words that change what is bought, like
+// "whole" milk or "unsalted" butter, are left in names.
+var descriptors = [][]string{
+	{"extra", "large"},
+	{"extra-large"},
+	{"large"},
+	{"medium"},
+	{"small"},
+	{"jumbo"},
+	{"organic"},
+	{"free", "range"},
+	{"free-range"},
+	{"ripe"},
+	{"good", "quality"},
+	{"good-quality"},
+	{"high", "quality"},
+	{"high-quality"},
+	{"best", "quality"},
+	{"best-quality"},
+}
+
+// irregulars are plurals Singular would get wrong
+var irregulars = map[string]string{
+	"leaves":    "leaf",
+	"halves":    "half",
+	"loaves":    "loaf",
+	"cookies":   "cookie",
+	"brownies":  "brownie",
+	"veggies":   "veggie",
+	"smoothies": "smoothie",
+	"chillies":  "chilli",
+	"quiches":   "quiche",
+	"molasses":  "molasses",
+}
+
+// Name is how an ingredient is written in the catalog: lower case, single
+// spaced, without surrounding punctuation or descriptors of size or quality.
+// A name made only of descriptors is kept as it is.
+func Name(s string) string {
+	s = strings.TrimFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := strings.Fields(s)
+
+	var kept []string
+	for i := 0; i < len(words); {
+		if n := descriptorAt(words[i:]); n > 0 {
+			i += n
+			continue
+		}
+		kept = append(kept, words[i])
+		i++
+	}
+	if len(kept) == 0 {
+		return strings.Join(words, " ")
+	}
+	return strings.Join(kept, " ")
+}
+
+// Key is Name made singular. Names with the same key are the same
+// ingredient, so it is what aliases are looked up by.
+func Key(s string) string {
+	return Singular(Name(s))
+}
+
+// Singular is a rough singular of an English word or phrase, enough to match
+// "eggs" with "egg" and "cherry tomatoes" with "cherry tomato". Only the last
+// word of a phrase changes.
+func Singular(s string) string {
+	i := strings.LastIndexByte(s, ' ') + 1
+	head, word := s[:i], s[i:]
+	if singular, ok := irregulars[word]; ok {
+		return head + singular
+	}
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return head + word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "oes"), strings.HasSuffix(word, "shes"), strings.HasSuffix(word, "ches"):
+		return head + word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"):
+		return s
+	case strings.HasSuffix(word, "s") && len(word) > 3:
+		return head + word[:len(word)-1]
+	}
+	return s
+}
+
+// descriptorAt returns how many of the leading words make up a descriptor
+func descriptorAt(words []string) int {
+	for _, d := range descriptors {
+		if len(words) < len(d) {
+			continue
+		}
+		match := true
+		for i := range d {
+			if words[i] != d[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return len(d)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,60 @@
+package ingredients
+
+import "testing"
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"eggs", "eggs"},
+		{"Eggs", "eggs"},
+		{"egg ", "egg"},
+		{"  Large   Eggs ", "eggs"},
+		{"extra large eggs", "eggs"},
+		{"extra-large free-range eggs", "eggs"},
+		{"ripe bananas.", "bananas"},
+		{"good quality olive oil", "olive oil"},
+		{"medium-grain rice", "medium-grain rice"},
+		{"unsalted butter", "unsalted butter"},
+		{"whole milk", "whole milk"},
+		{"small", "small"},
+	}
+
+	for _, tt := range tests {
+		if got := Name(tt.in); got != tt.want {
+			t.Errorf("Name(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Eggs", "egg"},
+		{"egg", "egg"},
+		{"large eggs", "egg"},
+		{"egg ", "egg"},
+		{"Cherry Tomatoes", "cherry tomato"},
+		{"fresh berries", "fresh berry"},
+		{"chocolate chip cookies", "chocolate chip cookie"},
+		{"bay leaves", "bay leaf"},
+		{"radishes", "radish"},
+		{"red chillies", "red chilli"},
+		{"quiches", "quiche"},
+		{"asparagus", "asparagus"},
+		{"hummus", "hummus"},
+		{"molasses", "molasses"},
+		{"swiss", "swiss"},
+		{"peas", "pea"},
+		{"gas", "gas"},
+	}
+
+	for _, tt := range tests {
+		if got := Key(tt.in); got != tt.want {
+			t.Errorf("Key(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
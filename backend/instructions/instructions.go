@@ -0,0 +1,239 @@
+// Package instructions recognises temperatures, durations and ingredient
+// mentions in the text of recipe steps, so clients can convert temperatures,
+// start timers and highlight ingredients
+package instructions
+
+import (
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/units"
+)
+
+// number matches the quantities units.ParseQuantityRange understands, mixed
+// numbers first so "1 1/2" isn't read as 1
+const number = `\d+\s+\d+/\d+|\d+/\d+|\d+(?:[.,]\d+)?\s*[½⅓⅔¼¾⅛⅜⅝⅞]?|[½⅓⅔¼¾⅛⅜⅝⅞]`
+
+// spelledNumber adds the words used for quantities of time, like "an hour"
+const spelledNumber = number + `|half an?|an?|one|two|three|four|five|six|seven|eight|nine|ten|eleven|twelve`
+
+var (
+	temperaturePattern = regexp.MustCompile(`(?i)(` + number + `)(?:\s*(?:-|–|to)\s*(` + number + `))?` +
+		`\s*(?:([°º˚])|(degrees?|deg\.?))?\s*(fahrenheit|celsius|centigrade|f|c)\b`)
+
+	durationPattern = regexp.MustCompile(`(?i)(` + spelledNumber + `)(?:\s*(?:-|–|to|or)\s*(` + spelledNumber + `))?` +
+		`\s*(hours?|hrs?|minutes?|mins?|seconds?|secs?)\b`)
+
+	// durationJoin is what may separate the parts of "1 hour and 30 minutes"
+	durationJoin = regexp.MustCompile(`^(?:\s*,)?\s*(?:and\s+)?$`)
+)
+
+var durationSeconds = map[string]int{
+	"hour": 3600, "hours": 3600, "hr": 3600, "hrs": 3600,
+	"minute": 60, "minutes": 60, "min": 60, "mins": 60,
+	"second": 1, "seconds": 1, "sec": 1, "secs": 1,
+}
+
+// span is an annotation with byte offsets into the instruction, which are
+// converted to character offsets once every annotation has been found
+type span struct {
+	start, end int
+	annotation models.StepAnnotation
+}
+
+// Annotate fills in the Annotations of each step from its instruction.
+// Ingredient mentions can refer to an ingredient of any of the steps, since
+// recipes often list every ingredient in the first step.
+func Annotate(steps []models.RecipeStepV2) {
+	mentions := newMentionMatcher(steps)
+	for i := range steps {
+		steps[i].Annotations = annotate(steps[i].Instruction, mentions)
+	}
+}
+
+func annotate(text string, mentions *mentionMatcher) []models.StepAnnotation {
+	if text == "" {
+		return nil
+	}
+
+	var spans []span
+	add := func(found []span) {
+		for _, s := range found {
+			if !overlaps(spans, s) {
+				spans = append(spans, s)
+			}
+		}
+	}
+	add(temperatures(text, mentions))
+	add(durations(text))
+	add(mentions.find(text))
+
+	slices.SortFunc(spans, func(a, b span) int { return a.start - b.start })
+	annotations := make([]models.StepAnnotation, len(spans))
+	for i, s := range spans {
+		annotations[i] = s.annotation
+		annotations[i].Type = annotationType(s.annotation)
+		annotations[i].Start = utf8.RuneCountInString(text[:s.start])
+		annotations[i].End = annotations[i].Start + utf8.RuneCountInString(text[s.start:s.end])
+		annotations[i].Text = text[s.start:s.end]
+	}
+	return annotations
+}
+
+func annotationType(a models.StepAnnotation) string {
+	switch {
+	case a.Temperature != nil:
+		return models.AnnotationTemperature
+	case a.Duration != nil:
+		return models.AnnotationDuration
+	default:
+		return models.AnnotationIngredient
+	}
+}
+
+func overlaps(spans []span, s span) bool {
+	for _, other := range spans {
+		if s.start < other.end && other.start < s.end {
+			return true
+		}
+	}
+	return false
+}
+
+// minBareTemperature is the lowest value read as a temperature when only a
+// bare "C" or "F" follows it; below it "2c" is far more likely to be cups
+const minBareTemperature = 90
+
+// temperatures finds temperatures like "350°F", "180 degrees C", "200C" or
+// "375-400 Fahrenheit". A bare letter is only a temperature from
+// minBareTemperature up and when no ingredient follows it, so "2c flour" and
+// "Add 2 c of the stock" aren't read as temperatures.
+func temperatures(text string, mentions *mentionMatcher) []span {
+	var found []span
+	for _, m := range temperaturePattern.FindAllStringSubmatchIndex(text, -1) {
+		group := func(n int) string {
+			if m[2*n] < 0 {
+				return ""
+			}
+			return text[m[2*n]:m[2*n+1]]
+		}
+		if !startsWord(text, m[0]) {
+			continue
+		}
+		unit, _, err := units.ParseTemperatureUnit(group(5))
+		if err != nil {
+			continue
+		}
+		low, high, ok := parseRange(group(1), group(2))
+		if !ok {
+			continue
+		}
+		bare := len(group(5)) == 1 && group(3) == "" && group(4) == ""
+		if bare && (low < minBareTemperature || mentions.startsAt(text, m[1])) {
+			continue
+		}
+
+		t := &models.TemperatureAnnotation{Unit: unit}
+		t.Celsius, t.Fahrenheit = bothScales(low, unit)
+		if high > 0 {
+			t.CelsiusMax, t.FahrenheitMax = bothScales(high, unit)
+		}
+		found = append(found, span{start: m[0], end: m[1], annotation: models.StepAnnotation{Temperature: t}})
+	}
+	return found
+}
+
+// bothScales returns value, in unit, in Celsius and Fahrenheit, rounding the
+// converted one to the nearest degree
+func bothScales(value float64, unit string) (float64, float64) {
+	celsius, _ := units.ConvertTemperature(value, unit, units.Celsius)
+	fahrenheit, _ := units.ConvertTemperature(value, unit, units.Fahrenheit)
+	if unit == units.Celsius {
+		return value, math.Round(fahrenheit)
+	}
+	return math.Round(celsius), value
+}
+
+// durations finds lengths of time like "25-30 minutes", "an hour" or
+// "1 hour and 15 minutes", which is one duration
+func durations(text string) []span {
+	var found []span
+	for _, m := range durationPattern.FindAllStringSubmatchIndex(text, -1) {
+		if !startsWord(text, m[0]) {
+			continue
+		}
+		var high string
+		if m[4] >= 0 {
+			high = text[m[4]:m[5]]
+		}
+		low, max, ok := parseRange(text[m[2]:m[3]], high)
+		if !ok {
+			continue
+		}
+		seconds := durationSeconds[strings.ToLower(text[m[6]:m[7]])]
+		d := &models.DurationAnnotation{Seconds: int(math.Round(low * float64(seconds)))}
+		if max > 0 {
+			d.SecondsMax = int(math.Round(max * float64(seconds)))
+		}
+
+		// Join "1 hour" and "30 minutes" when only "and" or a comma is
+		// between them and neither is a range
+		if n := len(found); n > 0 {
+			prev := found[n-1].annotation.Duration
+			if prev.SecondsMax == 0 && d.SecondsMax == 0 && d.Seconds < prev.Seconds &&
+				durationJoin.MatchString(text[found[n-1].end:m[0]]) {
+				prev.Seconds += d.Seconds
+				found[n-1].end = m[1]
+				continue
+			}
+		}
+		found = append(found, span{start: m[0], end: m[1], annotation: models.StepAnnotation{Duration: d}})
+	}
+	return found
+}
+
+// parseRange parses the quantities of a match, and a range's upper end if
+// high isn't empty, returning 0 for the upper end of a single quantity
+func parseRange(low, high string) (float64, float64, bool) {
+	lowValue, ok := parseNumber(low)
+	if !ok {
+		return 0, 0, false
+	}
+	if high == "" {
+		return lowValue, 0, true
+	}
+	highValue, ok := parseNumber(high)
+	if !ok || highValue < lowValue {
+		return 0, 0, false
+	}
+	if highValue == lowValue {
+		highValue = 0
+	}
+	return lowValue, highValue, true
+}
+
+func parseNumber(s string) (float64, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "a", "an":
+		return 1, true
+	case "half a", "half an":
+		return 0.5, true
+	}
+	value, _, err := units.ParseQuantityRange(s)
+	return value, err == nil
+}
+
+// startsWord reports whether the match at i isn't part of a longer word or
+// number, like the "ten" in "often"
+func startsWord(text string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(text[:i])
+	return !isWordRune(r) && r != '.' && r != ','
+}
@@ -0,0 +1,166 @@
+package instructions
+
+import (
+	"testing"
+
+	"github.com/cobyabrahams/hungr/models"
+)
+
+func annotateOne(instruction string, ingredients ...string) []models.StepAnnotation {
+	steps := []models.RecipeStepV2{{}, {Instruction: instruction}}
+	for _, name := range ingredients {
+		steps[0].Ingredients = append(steps[0].Ingredients, models.Ingredient{Name: name})
+	}
+	Annotate(steps)
+	return steps[1].Annotations
+}
+
+func TestAnnotate_Temperatures(t *testing.T) {
+	tests := []struct {
+		instruction string
+		text        string
+		want        models.TemperatureAnnotation
+	}{
+		{"Preheat the oven to 350°F.", "350°F", models.TemperatureAnnotation{Unit: "F", Celsius: 177, Fahrenheit: 350}},
+		{"Heat to 180 °C", "180 °C", models.TemperatureAnnotation{Unit: "C", Celsius: 180, Fahrenheit: 356}},
+		{"Bake at 200C", "200C", models.TemperatureAnnotation{Unit: "C", Celsius: 200, Fahrenheit: 392}},
+		{"preheat to 350F", "350F", models.TemperatureAnnotation{Unit: "F", Celsius: 177, Fahrenheit: 350}},
+		{"Bake at 180 C until golden", "180 C", models.TemperatureAnnotation{Unit: "C", Celsius: 180, Fahrenheit: 356}},
+		{"Roast at 425 degrees F", "425 degrees F", models.TemperatureAnnotation{Unit: "F", Celsius: 218, Fahrenheit: 425}},
+		{"Bake at 220 degrees Celsius", "220 degrees Celsius", models.TemperatureAnnotation{Unit: "C", Celsius: 220, Fahrenheit: 428}},
+		{"Set to 160 fahrenheit", "160 fahrenheit", models.TemperatureAnnotation{Unit: "F", Celsius: 71, Fahrenheit: 160}},
+		{"Bake at 375-400°F", "375-400°F", models.TemperatureAnnotation{Unit: "F", Celsius: 191, Fahrenheit: 375, CelsiusMax: 204, FahrenheitMax: 400}},
+		{"Cool to 37.5ºC", "37.5ºC", models.TemperatureAnnotation{Unit: "C", Celsius: 37.5, Fahrenheit: 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.instruction, func(t *testing.T) {
+			annotations := annotateOne(tt.instruction)
+			if len(annotations) != 1 {
+				t.Fatalf("Expected 1 annotation, got %+v", annotations)
+			}
+			a := annotations[0]
+			if a.Type != models.AnnotationTemperature || a.Text != tt.text || a.Temperature == nil {
+				t.Fatalf("Expected temperature %q, got %+v", tt.text, a)
+			}
+			if *a.Temperature != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, *a.Temperature)
+			}
+		})
+	}
+}
+
+func TestAnnotate_NotTemperatures(t *testing.T) {
+	for _, instruction := range []string{"Add 2 c of the stock", "Sift 2c flour", "Add 100c flour", "Cut into 2 cm pieces", "Stir in 2 cups"} {
+		for _, a := range annotateOne(instruction, "flour") {
+			if a.Type == models.AnnotationTemperature {
+				t.Errorf("%q: unexpected temperature %q", instruction, a.Text)
+			}
+		}
+	}
+}
+
+func TestAnnotate_Durations(t *testing.T) {
+	tests := []struct {
+		instruction string
+		text        string
+		seconds     int
+		secondsMax  int
+	}{
+		{"Bake for 25-30 minutes.", "25-30 minutes", 1500, 1800},
+		{"Simmer for 1 hour.", "1 hour", 3600, 0},
+		{"Rest 10 mins", "10 mins", 600, 0},
+		{"Boil for 90 seconds", "90 seconds", 90, 0},
+		{"Roast for 1 ½ hours", "1 ½ hours", 5400, 0},
+		{"Prove for 1 to 2 hrs", "1 to 2 hrs", 3600, 7200},
+		{"Braise for 2 hours and 30 minutes", "2 hours and 30 minutes", 9000, 0},
+		{"Cook 1 hr 15 min", "1 hr 15 min", 4500, 0},
+		{"Leave for an hour", "an hour", 3600, 0},
+		{"Chill for half an hour", "half an hour", 1800, 0},
+		{"Marinate for two hours", "two hours", 7200, 0},
+		{"Stir for a minute or two", "a minute", 60, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.instruction, func(t *testing.T) {
+			annotations := annotateOne(tt.instruction)
+			if len(annotations) != 1 {
+				t.Fatalf("Expected 1 annotation, got %+v", annotations)
+			}
+			a := annotations[0]
+			if a.Type != models.AnnotationDuration || a.Text != tt.text || a.Duration == nil {
+				t.Fatalf("Expected duration %q, got %+v", tt.text, a)
+			}
+			if a.Duration.Seconds != tt.seconds || a.Duration.SecondsMax != tt.secondsMax {
+				t.Errorf("Expected %d-%d seconds, got %d-%d", tt.seconds, tt.secondsMax, a.Duration.Seconds, a.Duration.SecondsMax)
+			}
+		})
+	}
+}
+
+func TestAnnotate_IngredientMentions(t *testing.T) {
+	tests := []struct {
+		instruction string
+		ingredients []string
+		want        []string // mentioned ingredient names, in order
+	}{
+		{"Whisk the eggs with the sugar.", []string{"egg", "sugar"}, []string{"egg", "sugar"}},
+		{"Beat the egg.", []string{"eggs"}, []string{"eggs"}},
+		{"Dice the tomatoes.", []string{"tomato"}, []string{"tomato"}},
+		{"Sift the flour.", []string{"all-purpose flour"}, []string{"all-purpose flour"}},
+		{"Add the brown sugar, then the sugar.", []string{"sugar", "brown sugar"}, []string{"brown sugar", "sugar"}},
+		{"Fold in the sugar.", []string{"brown sugar", "white sugar"}, nil},
+		{"Add the Olive Oil.", []string{"olive oil"}, []string{"olive oil"}},
+		{"Season with salt.", []string{"salt"}, []string{"salt"}},
+		{"Add the saltines.", []string{"salt"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.instruction, func(t *testing.T) {
+			var got []string
+			for _, a := range annotateOne(tt.instruction, tt.ingredients...) {
+				if a.Type == models.AnnotationIngredient {
+					got = append(got, a.Ingredient.Name)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected mentions %q, got %q", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected mentions %q, got %q", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestAnnotate_Offsets(t *testing.T) {
+	steps := []models.RecipeStepV2{
+		{Ingredients: []models.Ingredient{{Name: "butter"}, {Name: "crème fraîche"}}},
+		{Instruction: "Heat the butter to 150°C, add the crème fraîche and cook for 5 minutes."},
+	}
+	Annotate(steps)
+
+	want := []models.StepAnnotation{
+		{Type: models.AnnotationIngredient, Start: 9, End: 15, Text: "butter"},
+		{Type: models.AnnotationTemperature, Start: 19, End: 24, Text: "150°C"},
+		{Type: models.AnnotationIngredient, Start: 34, End: 47, Text: "crème fraîche"},
+		{Type: models.AnnotationDuration, Start: 61, End: 70, Text: "5 minutes"},
+	}
+	got := steps[1].Annotations
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d annotations, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i].Type != want[i].Type || got[i].Start != want[i].Start || got[i].End != want[i].End || got[i].Text != want[i].Text {
+			t.Errorf("Annotation %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+	if mention := got[2].Ingredient; mention == nil || mention.Step != 0 || mention.Index != 1 {
+		t.Errorf("Expected crème fraîche to link to step 0 ingredient 1, got %+v", mention)
+	}
+	if steps[0].Annotations != nil {
+		t.Errorf("Expected no annotations for an empty instruction, got %+v", steps[0].Annotations)
+	}
+}
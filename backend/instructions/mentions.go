@@ -0,0 +1,130 @@
+package instructions
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/cobyabrahams/hungr/ingredients"
+	"github.com/cobyabrahams/hungr/models"
+)
+
+// minHeadNounLength is the shortest last word of an ingredient's name that is
+// matched on its own
+const minHeadNounLength = 3
+
+// mentionTerm is a way an ingredient can be written in an instruction
+type mentionTerm struct {
+	pattern *regexp.Regexp
+	mention models.IngredientMention
+}
+
+// mentionMatcher finds mentions of a recipe's ingredients. Each ingredient is
+// matched by its full name, singular or plural, and then by the last word of
+// its name ("flour" for "all-purpose flour") when no other ingredient shares
+// that word.
+type mentionMatcher struct {
+	names     []mentionTerm
+	headNouns []mentionTerm
+}
+
+func newMentionMatcher(steps []models.RecipeStepV2) *mentionMatcher {
+	var mentioned []models.IngredientMention
+	for i, step := range steps {
+		for j, ing := range step.Ingredients {
+			name := strings.ToLower(strings.TrimSpace(ing.Name))
+			if name != "" {
+				mentioned = append(mentioned, models.IngredientMention{Step: i, Index: j, Name: ing.Name})
+			}
+		}
+	}
+
+	// Longer names first, so "brown sugar" is matched before "sugar"
+	slices.SortStableFunc(mentioned, func(a, b models.IngredientMention) int {
+		return len(b.Name) - len(a.Name)
+	})
+
+	heads := map[string]int{}
+	for _, ing := range mentioned {
+		words := strings.Fields(strings.ToLower(ing.Name))
+		if len(words) > 1 {
+			heads[ingredients.Singular(words[len(words)-1])]++
+		}
+		// A single-word name counts too, so "sugar" keeps "brown sugar"
+		// from claiming mentions of plain sugar
+		if len(words) == 1 {
+			heads[ingredients.Singular(words[0])]++
+		}
+	}
+
+	m := &mentionMatcher{}
+	for _, ing := range mentioned {
+		name := strings.ToLower(strings.TrimSpace(ing.Name))
+		m.names = append(m.names, mentionTerm{pattern: termPattern(name), mention: ing})
+
+		words := strings.Fields(name)
+		head := words[len(words)-1]
+		if len(words) > 1 && len(head) >= minHeadNounLength && heads[ingredients.Singular(head)] == 1 {
+			m.headNouns = append(m.headNouns, mentionTerm{pattern: termPattern(head), mention: ing})
+		}
+	}
+	return m
+}
+
+// find returns the ingredient mentions in text, full names first
+func (m *mentionMatcher) find(text string) []span {
+	var found []span
+	for _, terms := range [][]mentionTerm{m.names, m.headNouns} {
+		for _, term := range terms {
+			for _, loc := range term.pattern.FindAllStringSubmatchIndex(text, -1) {
+				s := span{start: loc[2], end: loc[3]}
+				if overlaps(found, s) {
+					continue
+				}
+				mention := term.mention
+				s.annotation = models.StepAnnotation{Ingredient: &mention}
+				found = append(found, s)
+			}
+		}
+	}
+	return found
+}
+
+// startsAt reports whether an ingredient mention is the next word in text
+// after byte offset i
+func (m *mentionMatcher) startsAt(text string, i int) bool {
+	next := i + len(text[i:]) - len(strings.TrimLeftFunc(text[i:], unicode.IsSpace))
+	for _, s := range m.find(text) {
+		if s.start == next {
+			return true
+		}
+	}
+	return false
+}
+
+// termPattern matches term, singular or plural, as whole words
+func termPattern(term string) *regexp.Regexp {
+	forms := []string{term, ingredients.Singular(term), plural(ingredients.Singular(term))}
+	slices.SortFunc(forms, func(a, b string) int { return len(b) - len(a) })
+	forms = slices.Compact(forms)
+	for i := range forms {
+		forms[i] = regexp.QuoteMeta(forms[i])
+	}
+	return regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}])(` + strings.Join(forms, "|") + `)(?:$|[^\p{L}\p{N}])`)
+}
+
+// plural is the rough plural of a singular word or phrase
+func plural(s string) string {
+	switch {
+	case strings.HasSuffix(s, "y") && len(s) > 2 && !strings.ContainsRune("aeiou", rune(s[len(s)-2])):
+		return s[:len(s)-1] + "ies"
+	case strings.HasSuffix(s, "o"), strings.HasSuffix(s, "sh"), strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "ss"):
+		return s + "es"
+	}
+	return s + "s"
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
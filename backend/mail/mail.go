@@ -0,0 +1,124 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers outgoing email
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var sender Sender = StdoutSender{Out: os.Stdout}
+
+// Init sets the sender used by Send
+func Init(s Sender) {
+	sender = s
+}
+
+// Send delivers a message through the configured sender
+func Send(ctx context.Context, msg Message) error {
+	return sender.Send(ctx, msg)
+}
+
+// NewSenderFromEnv builds a sender from MAIL_SENDER ("stdout", "file" or "smtp")
+func NewSenderFromEnv() (Sender, error) {
+	switch os.Getenv("MAIL_SENDER") {
+	case "", "stdout":
+		return StdoutSender{Out: os.Stdout}, nil
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "mail-outbox"
+		}
+		return FileSender{Dir: dir}, nil
+	case "smtp":
+		addr := os.Getenv("SMTP_ADDR")
+		from := os.Getenv("MAIL_FROM")
+		if addr == "" || from == "" {
+			return nil, fmt.Errorf("SMTP_ADDR and MAIL_FROM must be set for the smtp sender")
+		}
+		return SMTPSender{
+			Addr:     addr,
+			From:     from,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_SENDER %q", os.Getenv("MAIL_SENDER"))
+	}
+}
+
+// StdoutSender prints messages instead of delivering them, for local development
+type StdoutSender struct {
+	Out io.Writer
+}
+
+func (s StdoutSender) Send(ctx context.Context, msg Message) error {
+	_, err := fmt.Fprintf(s.Out, "----- email -----\nTo: %s\nSubject: %s\n\n%s\n-----------------\n", msg.To, msg.Subject, msg.Body)
+	return err
+}
+
+// FileSender writes each message to its own file in Dir
+type FileSender struct {
+	Dir string
+}
+
+func (s FileSender) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), sanitizeFilename(msg.To))
+	return os.WriteFile(filepath.Join(s.Dir, name), []byte(format(msg, "")), 0o644)
+}
+
+// SMTPSender delivers messages through an SMTP server
+type SMTPSender struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+func (s SMTPSender) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, _ := strings.Cut(s.Addr, ":")
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	return smtp.SendMail(s.Addr, auth, s.From, []string{msg.To}, []byte(format(msg, s.From)))
+}
+
+func format(msg Message, from string) string {
+	var sb strings.Builder
+	if from != "" {
+		fmt.Fprintf(&sb, "From: %s\r\n", from)
+	}
+	fmt.Fprintf(&sb, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&sb, "Subject: %s\r\n", msg.Subject)
+	sb.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	sb.WriteString(msg.Body)
+	return sb.String()
+}
+
+func sanitizeFilename(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' {
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,76 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStdoutSender(t *testing.T) {
+	var buf bytes.Buffer
+	s := StdoutSender{Out: &buf}
+
+	err := s.Send(context.Background(), Message{To: "a@example.com", Subject: "Hello", Body: "link: x"})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"a@example.com", "Hello", "link: x"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got %q", want, out)
+		}
+	}
+}
+
+func TestFileSender(t *testing.T) {
+	dir := t.TempDir()
+	s := FileSender{Dir: dir}
+
+	err := s.Send(context.Background(), Message{To: "a@example.com", Subject: "Reset", Body: "token abc"})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 message file, got %d", len(entries))
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !strings.Contains(string(data), "Subject: Reset") || !strings.Contains(string(data), "token abc") {
+		t.Errorf("Unexpected message contents: %q", string(data))
+	}
+}
+
+func TestNewSenderFromEnv(t *testing.T) {
+	t.Setenv("MAIL_SENDER", "file")
+	t.Setenv("MAIL_DIR", "/tmp/outbox")
+	s, err := NewSenderFromEnv()
+	if err != nil {
+		t.Fatalf("NewSenderFromEnv failed: %v", err)
+	}
+	if fs, ok := s.(FileSender); !ok || fs.Dir != "/tmp/outbox" {
+		t.Errorf("Expected FileSender for /tmp/outbox, got %#v", s)
+	}
+
+	t.Setenv("MAIL_SENDER", "smtp")
+	t.Setenv("SMTP_ADDR", "")
+	if _, err := NewSenderFromEnv(); err == nil {
+		t.Error("Expected error for smtp sender without SMTP_ADDR")
+	}
+
+	t.Setenv("MAIL_SENDER", "carrier-pigeon")
+	if _, err := NewSenderFromEnv(); err == nil {
+		t.Error("Expected error for unknown sender")
+	}
+}
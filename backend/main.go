@@ -1,13 +1,18 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"log"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/cobyabrahams/hungr/auth"
 	"github.com/cobyabrahams/hungr/handlers"
 	"github.com/cobyabrahams/hungr/logger"
+	"github.com/cobyabrahams/hungr/mail"
 	"github.com/cobyabrahams/hungr/middleware"
 	"github.com/cobyabrahams/hungr/storage"
 )
@@ -24,17 +29,58 @@ func main() {
 		log.Fatal("Failed to connect to database: ", err)
 	}
 
+	sessionSecret := os.Getenv("SESSION_SECRET")
+	if sessionSecret == "" {
+		// Sessions won't survive a restart, which is fine for local development
+		logger.Log.Warn("SESSION_SECRET not set, using a random per-process secret")
+		key := make([]byte, 32)
+		rand.Read(key)
+		sessionSecret = hex.EncodeToString(key)
+	}
+	auth.Init(sessionSecret)
+
+	sender, err := mail.NewSenderFromEnv()
+	if err != nil {
+		log.Fatal("Failed to configure mail: ", err)
+	}
+	mail.Init(sender)
+
+	go handlers.PurgeExpiredTrash(time.Hour)
+
 	http.HandleFunc("/health", healthCheck)
-	http.HandleFunc("/api/recipes", middleware.RequestLogger(middleware.CORS(handleRecipes, "GET, POST, DELETE, OPTIONS")))
-	http.HandleFunc("/api/recipes/", middleware.RequestLogger(middleware.CORS(handleRecipeSubresources, "GET, PUT, PATCH, POST, OPTIONS")))
-	http.HandleFunc("/api/files/", middleware.RequestLogger(middleware.CORS(handleFiles, "GET")))
-	http.HandleFunc("/api/users", middleware.RequestLogger(middleware.CORS(handleUsers, "GET, POST, PUT, DELETE, OPTIONS")))
-	http.HandleFunc("/api/auth/login", middleware.RequestLogger(middleware.CORS(handleLogin, "POST, OPTIONS")))
-	http.HandleFunc("/api/extract-recipe", middleware.RequestLogger(middleware.CORS(handleExtractRecipe, "POST, OPTIONS")))
-	http.HandleFunc("/api/extract-recipe-image", middleware.RequestLogger(middleware.CORS(handleExtractRecipeImage, "POST, OPTIONS")))
-	http.HandleFunc("/api/extract-recipe-text", middleware.RequestLogger(middleware.CORS(handleExtractRecipeText, "POST, OPTIONS")))
-	http.HandleFunc("/api/tags", middleware.RequestLogger(middleware.CORS(handleTags, "GET, OPTIONS")))
-	http.HandleFunc("/api/connections", middleware.RequestLogger(middleware.CORS(handleConnections, "GET, POST, DELETE, OPTIONS")))
+	http.HandleFunc("/api/recipes", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleRecipes), "GET, POST, DELETE, OPTIONS")))
+	http.HandleFunc("/api/recipes/", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleRecipeSubresources), "GET, PUT, PATCH, POST, OPTIONS")))
+	http.HandleFunc("/api/files/", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleFiles), "GET")))
+	http.HandleFunc("/api/users", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleUsers), "GET, PUT, DELETE, OPTIONS")))
+	http.HandleFunc("/api/auth/login", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleLogin), "POST, OPTIONS")))
+	http.HandleFunc("/api/auth/register", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleRegister), "POST, OPTIONS")))
+	http.HandleFunc("/api/auth/magic-link", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleMagicLink), "POST, OPTIONS")))
+	http.HandleFunc("/api/auth/magic-link/verify", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleMagicLinkVerify), "POST, OPTIONS")))
+	http.HandleFunc("/api/auth/password-reset", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handlePasswordReset), "POST, OPTIONS")))
+	http.HandleFunc("/api/auth/password-reset/confirm", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handlePasswordResetConfirm), "POST, OPTIONS")))
+	http.HandleFunc("/api/auth/password", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handlePassword), "PUT, OPTIONS")))
+	http.HandleFunc("/api/auth/verify-email", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleVerifyEmail), "POST, OPTIONS")))
+	http.HandleFunc("/api/auth/verify-email/resend", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleResendVerification), "POST, OPTIONS")))
+	http.HandleFunc("/api/auth/logout", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleLogout), "POST, OPTIONS")))
+	http.HandleFunc("/api/auth/sessions", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleSessions), "GET, DELETE, OPTIONS")))
+	http.HandleFunc("/api/api-keys", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleAPIKeys), "GET, POST, DELETE, OPTIONS")))
+	http.HandleFunc("/api/extract-recipe", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleExtractRecipe), "POST, OPTIONS")))
+	http.HandleFunc("/api/extract-recipe-image", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleExtractRecipeImage), "POST, OPTIONS")))
+	http.HandleFunc("/api/extract-recipe-text", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleExtractRecipeText), "POST, OPTIONS")))
+	http.HandleFunc("/api/tags", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleTags), "GET, OPTIONS")))
+	http.HandleFunc("/api/trash", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleTrash), "GET, OPTIONS")))
+	http.HandleFunc("/api/trash/", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleTrash), "POST, DELETE, OPTIONS")))
+	http.HandleFunc("/api/connections", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleConnections), "GET, POST, DELETE, OPTIONS")))
+	http.HandleFunc("/api/densities", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleDensities), "GET, PUT, DELETE, OPTIONS")))
+	http.HandleFunc("/api/preferences", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handlePreferences), "GET, PUT, OPTIONS")))
+	http.HandleFunc("/api/shopping-lists", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleShoppingLists), "GET, POST, OPTIONS")))
+	http.HandleFunc("/api/shopping-lists/", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleShoppingLists), "GET, POST, PATCH, DELETE, OPTIONS")))
+	http.HandleFunc("/api/pantry", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handlePantry), "GET, PUT, DELETE, OPTIONS")))
+	http.HandleFunc("/api/pantry/matches", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handlePantryMatches), "GET, OPTIONS")))
+	http.HandleFunc("/api/pantry/deduct", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handlePantryDeduct), "POST, OPTIONS")))
+	http.HandleFunc("/api/meal-plans", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleMealPlans), "GET, POST, OPTIONS")))
+	http.HandleFunc("/api/meal-plans/", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleMealPlans), "GET, POST, PUT, PATCH, DELETE, OPTIONS")))
+	http.HandleFunc("/api/admin/ingredients/merge", middleware.RequestLogger(middleware.CORS(middleware.Authenticate(handleMergeIngredients), "POST, OPTIONS")))
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -63,7 +109,13 @@ func handleRecipes(w http.ResponseWriter, r *http.Request) {
 }
 
 func handleRecipeSubresources(w http.ResponseWriter, r *http.Request) {
-	if strings.HasSuffix(r.URL.Path, "/steps") {
+	if r.URL.Path == "/api/recipes/search" {
+		if r.Method == "GET" {
+			handlers.SearchRecipes(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else if strings.HasSuffix(r.URL.Path, "/steps") {
 		switch r.Method {
 		case "GET":
 			handlers.GetRecipeSteps(w, r)
@@ -87,6 +139,42 @@ func handleRecipeSubresources(w http.ResponseWriter, r *http.Request) {
 		default:
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
+	} else if strings.HasSuffix(r.URL.Path, "/rating") {
+		if r.Method == "PUT" {
+			handlers.SetRecipeRating(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else if strings.HasSuffix(r.URL.Path, "/cooked") {
+		if r.Method == "POST" {
+			handlers.MarkRecipeCooked(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else if strings.HasSuffix(r.URL.Path, "/revisions") {
+		if r.Method == "GET" {
+			handlers.GetRecipeRevisions(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else if strings.HasSuffix(r.URL.Path, "/revisions/diff") {
+		if r.Method == "GET" {
+			handlers.DiffRecipeRevisions(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else if strings.HasSuffix(r.URL.Path, "/restore") {
+		if r.Method == "POST" {
+			handlers.RestoreRecipeRevision(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else if strings.HasSuffix(r.URL.Path, "/fork") {
+		if r.Method == "POST" {
+			handlers.ForkRecipe(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
 	} else if r.Method == "PATCH" {
 		handlers.PatchRecipe(w, r)
 	} else {
@@ -94,6 +182,26 @@ func handleRecipeSubresources(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func handleTrash(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/trash" {
+		if r.Method == "GET" {
+			handlers.GetTrash(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else if strings.HasSuffix(r.URL.Path, "/restore") {
+		if r.Method == "POST" {
+			handlers.RestoreTrashedRecipe(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else if r.Method == "DELETE" {
+		handlers.PurgeTrashedRecipe(w, r)
+	} else {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 func handleFiles(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "GET" {
 		handlers.GetFile(w, r)
@@ -106,8 +214,6 @@ func handleUsers(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
 		handlers.GetUser(w, r)
-	case "POST":
-		handlers.CreateUser(w, r)
 	case "PUT":
 		handlers.UpdateUser(w, r)
 	case "DELETE":
@@ -125,6 +231,102 @@ func handleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func handleRegister(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		handlers.Register(w, r)
+	} else {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleMagicLink(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		handlers.RequestMagicLink(w, r)
+	} else {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleMagicLinkVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		handlers.VerifyMagicLink(w, r)
+	} else {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handlePasswordReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		handlers.RequestPasswordReset(w, r)
+	} else {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handlePasswordResetConfirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		handlers.ResetPassword(w, r)
+	} else {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handlePassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "PUT" {
+		handlers.ChangePassword(w, r)
+	} else {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		handlers.VerifyEmail(w, r)
+	} else {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleResendVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		handlers.ResendVerification(w, r)
+	} else {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		handlers.Logout(w, r)
+	} else {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleSessions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		handlers.GetSessions(w, r)
+	case "DELETE":
+		handlers.RevokeSession(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		handlers.GetAPIKeys(w, r)
+	case "POST":
+		handlers.CreateAPIKey(w, r)
+	case "DELETE":
+		handlers.RevokeAPIKey(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 func handleExtractRecipe(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "POST" {
 		handlers.ExtractRecipe(w, r)
@@ -169,3 +371,158 @@ func handleConnections(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
+
+func handleDensities(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		handlers.GetDensities(w, r)
+	case "PUT":
+		handlers.SetDensity(w, r)
+	case "DELETE":
+		handlers.DeleteDensity(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handlePreferences(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		handlers.GetPreferences(w, r)
+	case "PUT":
+		handlers.UpdatePreferences(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleShoppingLists(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/shopping-lists" {
+		switch r.Method {
+		case "GET":
+			handlers.GetShoppingLists(w, r)
+		case "POST":
+			handlers.CreateShoppingList(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else if strings.HasSuffix(r.URL.Path, "/items") {
+		if r.Method == "POST" {
+			handlers.AddShoppingListItem(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else if strings.Contains(r.URL.Path, "/items/") {
+		switch r.Method {
+		case "PATCH":
+			handlers.UpdateShoppingListItem(w, r)
+		case "DELETE":
+			handlers.DeleteShoppingListItem(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else {
+		switch r.Method {
+		case "GET":
+			handlers.GetShoppingList(w, r)
+		case "DELETE":
+			handlers.DeleteShoppingList(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func handlePantry(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		handlers.GetPantry(w, r)
+	case "PUT":
+		handlers.SetPantryItem(w, r)
+	case "DELETE":
+		handlers.DeletePantryItem(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handlePantryMatches(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		handlers.GetRecipeMatches(w, r)
+	} else {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handlePantryDeduct(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		handlers.DeductPantry(w, r)
+	} else {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleMealPlans(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/meal-plans" {
+		switch r.Method {
+		case "GET":
+			handlers.GetMealPlans(w, r)
+		case "POST":
+			handlers.CreateMealPlan(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else if r.URL.Path == "/api/meal-plans/feed-key" {
+		if r.Method == "POST" {
+			handlers.RegenerateCalendarFeedKey(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else if strings.HasSuffix(r.URL.Path, "/calendar.ics") {
+		if r.Method == "GET" {
+			handlers.GetMealPlanCalendar(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else if strings.HasSuffix(r.URL.Path, "/shopping-list") {
+		if r.Method == "POST" {
+			handlers.CreateMealPlanShoppingList(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else if strings.HasSuffix(r.URL.Path, "/entries") {
+		if r.Method == "POST" {
+			handlers.AddMealPlanEntry(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else if strings.Contains(r.URL.Path, "/entries/") {
+		switch r.Method {
+		case "PUT":
+			handlers.UpdateMealPlanEntry(w, r)
+		case "DELETE":
+			handlers.DeleteMealPlanEntry(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	} else {
+		switch r.Method {
+		case "GET":
+			handlers.GetMealPlan(w, r)
+		case "PATCH":
+			handlers.UpdateMealPlan(w, r)
+		case "DELETE":
+			handlers.DeleteMealPlan(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func handleMergeIngredients(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		handlers.MergeIngredients(w, r)
+	} else {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -1,11 +1,19 @@
 package middleware
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/cobyabrahams/hungr/auth"
 	"github.com/cobyabrahams/hungr/logger"
+	"github.com/cobyabrahams/hungr/storage"
 	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type responseWriter struct {
@@ -47,11 +55,27 @@ func RequestLogger(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// appOrigin is the frontend origin allowed to send the session cookie
+func appOrigin() string {
+	if base := os.Getenv("APP_BASE_URL"); base != "" {
+		return strings.TrimRight(base, "/")
+	}
+	return "http://localhost:5173"
+}
+
+// CORS allows any origin to call the API, but only the frontend origin may
+// send credentials, since browsers refuse cookies with a wildcard origin
 func CORS(next http.HandlerFunc, methods string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Add("Vary", "Origin")
+		if origin := r.Header.Get("Origin"); origin != "" && origin == appOrigin() {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
 		w.Header().Set("Access-Control-Allow-Methods", methods)
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -61,3 +85,87 @@ func CORS(next http.HandlerFunc, methods string) http.HandlerFunc {
 		next(w, r)
 	}
 }
+
+// Authenticate resolves the request's session token or API key to a user and
+// stores it in the request context. Requests without valid credentials
+// continue anonymously; handlers that need a user reject them.
+func Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := auth.TokenFromRequest(r)
+		if token == "" {
+			next(w, r)
+			return
+		}
+
+		if auth.IsAPIKey(token) {
+			authenticateAPIKey(w, r, token, next)
+			return
+		}
+
+		ctx := r.Context()
+		sessionUUID, err := auth.ParseSessionToken(token)
+		if err != nil {
+			logger.Debug(ctx, "rejected session token", "error", err.Error())
+			auth.ClearSessionCookie(w)
+			next(w, r)
+			return
+		}
+
+		user, err := storage.GetUserBySession(sessionUUID)
+		if err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				logger.Error(ctx, "failed to load session", err, "session_uuid", sessionUUID)
+			}
+			auth.ClearSessionCookie(w)
+			next(w, r)
+			return
+		}
+
+		ctx = auth.WithUser(ctx, user)
+		ctx = auth.WithSession(ctx, sessionUUID)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+func authenticateAPIKey(w http.ResponseWriter, r *http.Request, key string, next http.HandlerFunc) {
+	ctx := r.Context()
+	user, apiKey, err := storage.GetUserByAPIKey(auth.HashToken(key))
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logger.Error(ctx, "failed to load api key", err)
+		}
+		respondWithError(w, http.StatusUnauthorized, "invalid api key")
+		return
+	}
+
+	scope := requiredScope(r)
+	if !slices.Contains(apiKey.Scopes, scope) {
+		logger.Info(ctx, "api key missing scope", "api_key_uuid", apiKey.UUID, "scope", scope)
+		respondWithError(w, http.StatusForbidden, "api key lacks the "+scope+" scope")
+		return
+	}
+
+	ctx = auth.WithUser(ctx, user)
+	ctx = auth.WithAPIKey(ctx, apiKey.UUID, apiKey.Scopes)
+	next(w, r.WithContext(ctx))
+}
+
+// requiredScope returns the API key scope needed for a request: extract for
+// the recipe extraction endpoints, read for safe methods and write otherwise
+func requiredScope(r *http.Request) string {
+	if strings.HasPrefix(r.URL.Path, "/api/extract-recipe") {
+		return auth.ScopeExtract
+	}
+	switch r.Method {
+	case "GET", "HEAD", "OPTIONS":
+		return auth.ScopeRead
+	default:
+		return auth.ScopeWrite
+	}
+}
+
+func respondWithError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cobyabrahams/hungr/auth"
+)
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{"GET", "/api/recipes", auth.ScopeRead},
+		{"GET", "/api/files/abc", auth.ScopeRead},
+		{"POST", "/api/recipes", auth.ScopeWrite},
+		{"PUT", "/api/recipes/abc/steps", auth.ScopeWrite},
+		{"PATCH", "/api/recipes/abc", auth.ScopeWrite},
+		{"DELETE", "/api/recipes", auth.ScopeWrite},
+		{"POST", "/api/extract-recipe", auth.ScopeExtract},
+		{"POST", "/api/extract-recipe-image", auth.ScopeExtract},
+		{"POST", "/api/extract-recipe-text", auth.ScopeExtract},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if got := requiredScope(req); got != tt.want {
+				t.Errorf("requiredScope() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORSCredentialsOnlyForAppOrigin(t *testing.T) {
+	t.Setenv("APP_BASE_URL", "https://app.example.com/")
+	handler := CORS(func(w http.ResponseWriter, r *http.Request) {}, "GET, OPTIONS")
+
+	tests := []struct {
+		origin          string
+		wantOrigin      string
+		wantCredentials string
+	}{
+		{"https://app.example.com", "https://app.example.com", "true"},
+		{"https://evil.example.com", "*", ""},
+		{"", "*", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/recipes", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+				t.Errorf("Allow-Credentials = %q, want %q", got, tt.wantCredentials)
+			}
+		})
+	}
+}
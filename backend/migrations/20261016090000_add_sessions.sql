@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE sessions (
+    uuid UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_uuid UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_sessions_user_uuid ON sessions(user_uuid);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_sessions_user_uuid;
+DROP TABLE IF EXISTS sessions;
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN password_hash TEXT;
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN failed_login_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN locked_until TIMESTAMPTZ;
+
+CREATE TABLE auth_tokens (
+    uuid UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_uuid UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    purpose TEXT NOT NULL CHECK (purpose IN ('magic_link', 'password_reset', 'verify_email')),
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_auth_tokens_user_uuid ON auth_tokens(user_uuid);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_auth_tokens_user_uuid;
+DROP TABLE IF EXISTS auth_tokens;
+ALTER TABLE users DROP COLUMN locked_until;
+ALTER TABLE users DROP COLUMN failed_login_attempts;
+ALTER TABLE users DROP COLUMN email_verified_at;
+ALTER TABLE users DROP COLUMN password_hash;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE api_keys (
+    uuid UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_uuid UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{read}',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_api_keys_user_uuid ON api_keys(user_uuid);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_api_keys_user_uuid;
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,97 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE recipes ADD COLUMN search_vector tsvector NOT NULL DEFAULT ''::tsvector;
+
+-- Weighted document for a recipe: name (A), tags (B), ingredient names (C)
+-- and step instructions (D)
+CREATE FUNCTION recipe_search_vector(recipe UUID) RETURNS tsvector AS $$
+    SELECT
+        setweight(to_tsvector('english', COALESCE((
+            SELECT name FROM recipes WHERE uuid = recipe), '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE((
+            SELECT STRING_AGG(t.name, ' ')
+            FROM recipe_tags rt JOIN tags t ON t.uuid = rt.tag_uuid
+            WHERE rt.recipe_uuid = recipe), '')), 'B') ||
+        setweight(to_tsvector('english', COALESCE((
+            SELECT STRING_AGG(i.name, ' ')
+            FROM recipe_steps rs
+            JOIN step_ingredients si ON si.recipe_step_uuid = rs.uuid
+            JOIN ingredient_names i ON i.uuid = si.ingredient_name_uuid
+            WHERE rs.recipe_uuid = recipe), '')), 'C') ||
+        setweight(to_tsvector('english', COALESCE((
+            SELECT STRING_AGG(instructions, ' ' ORDER BY step_number)
+            FROM recipe_steps WHERE recipe_uuid = recipe), '')), 'D')
+$$ LANGUAGE SQL STABLE;
+
+CREATE FUNCTION refresh_recipe_search_vector(recipe UUID) RETURNS void AS $$
+    UPDATE recipes SET search_vector = recipe_search_vector(recipe) WHERE uuid = recipe
+$$ LANGUAGE SQL;
+
+CREATE FUNCTION recipes_search_trigger() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_recipe_search_vector(NEW.uuid);
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION recipe_children_search_trigger() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        PERFORM refresh_recipe_search_vector(NEW.recipe_uuid);
+    END IF;
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM refresh_recipe_search_vector(OLD.recipe_uuid);
+    END IF;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION step_ingredients_search_trigger() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        PERFORM refresh_recipe_search_vector(recipe_uuid)
+        FROM recipe_steps WHERE uuid = NEW.recipe_step_uuid;
+    END IF;
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM refresh_recipe_search_vector(recipe_uuid)
+        FROM recipe_steps WHERE uuid = OLD.recipe_step_uuid;
+    END IF;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER recipes_search_vector
+    AFTER INSERT OR UPDATE OF name ON recipes
+    FOR EACH ROW EXECUTE FUNCTION recipes_search_trigger();
+
+CREATE TRIGGER recipe_tags_search_vector
+    AFTER INSERT OR UPDATE OR DELETE ON recipe_tags
+    FOR EACH ROW EXECUTE FUNCTION recipe_children_search_trigger();
+
+CREATE TRIGGER recipe_steps_search_vector
+    AFTER INSERT OR UPDATE OR DELETE ON recipe_steps
+    FOR EACH ROW EXECUTE FUNCTION recipe_children_search_trigger();
+
+CREATE TRIGGER step_ingredients_search_vector
+    AFTER INSERT OR UPDATE OR DELETE ON step_ingredients
+    FOR EACH ROW EXECUTE FUNCTION step_ingredients_search_trigger();
+
+UPDATE recipes SET search_vector = recipe_search_vector(uuid);
+
+CREATE INDEX idx_recipes_search_vector ON recipes USING GIN (search_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_recipes_search_vector;
+DROP TRIGGER IF EXISTS step_ingredients_search_vector ON step_ingredients;
+DROP TRIGGER IF EXISTS recipe_steps_search_vector ON recipe_steps;
+DROP TRIGGER IF EXISTS recipe_tags_search_vector ON recipe_tags;
+DROP TRIGGER IF EXISTS recipes_search_vector ON recipes;
+DROP FUNCTION IF EXISTS step_ingredients_search_trigger();
+DROP FUNCTION IF EXISTS recipe_children_search_trigger();
+DROP FUNCTION IF EXISTS recipes_search_trigger();
+DROP FUNCTION IF EXISTS refresh_recipe_search_vector(UUID);
+DROP FUNCTION IF EXISTS recipe_search_vector(UUID);
+ALTER TABLE recipes DROP COLUMN search_vector;
+-- +goose StatementEnd
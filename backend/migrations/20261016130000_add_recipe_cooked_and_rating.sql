@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE recipes ADD COLUMN last_cooked_at TIMESTAMPTZ;
+ALTER TABLE recipes ADD COLUMN rating SMALLINT CHECK (rating BETWEEN 1 AND 5);
+
+-- Keyset pagination orders by (sort key, uuid)
+CREATE INDEX idx_recipes_user_created ON recipes(user_uuid, created_at DESC, uuid DESC);
+CREATE INDEX idx_recipes_user_name ON recipes(user_uuid, name, uuid);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_recipes_user_name;
+DROP INDEX IF EXISTS idx_recipes_user_created;
+ALTER TABLE recipes DROP COLUMN IF EXISTS rating;
+ALTER TABLE recipes DROP COLUMN IF EXISTS last_cooked_at;
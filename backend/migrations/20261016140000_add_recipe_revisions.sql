@@ -0,0 +1,79 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE recipe_revisions (
+    uuid UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    recipe_uuid UUID NOT NULL REFERENCES recipes(uuid) ON DELETE CASCADE,
+    revision_number INT NOT NULL,
+    author_uuid UUID REFERENCES users(uuid) ON DELETE SET NULL,
+    snapshot JSONB NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (recipe_uuid, revision_number)
+);
+
+-- Revisions are append-only; they only go away with their recipe
+CREATE FUNCTION recipe_revisions_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'recipe revisions cannot be modified';
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER recipe_revisions_immutable
+    BEFORE UPDATE ON recipe_revisions
+    FOR EACH ROW EXECUTE FUNCTION recipe_revisions_immutable();
+
+-- The current name, source, tags, steps and ingredients of a recipe
+CREATE FUNCTION recipe_snapshot(recipe UUID) RETURNS JSONB AS $$
+    SELECT jsonb_build_object(
+        'name', r.name,
+        'source', r.source,
+        'tags', COALESCE((
+            SELECT jsonb_agg(t.name ORDER BY rt.id)
+            FROM recipe_tags rt JOIN tags t ON t.uuid = rt.tag_uuid
+            WHERE rt.recipe_uuid = r.uuid), '[]'::jsonb),
+        'steps', COALESCE((
+            SELECT jsonb_agg(jsonb_build_object(
+                'instruction', rs.instructions,
+                'ingredients', COALESCE((
+                    SELECT jsonb_agg(jsonb_build_object(
+                        'name', i.name,
+                        'unit', si.ingredient_type,
+                        'quantity', si.quantity) ORDER BY i.name, si.quantity)
+                    FROM step_ingredients si
+                    JOIN ingredient_names i ON i.uuid = si.ingredient_name_uuid
+                    WHERE si.recipe_step_uuid = rs.uuid), '[]'::jsonb)
+            ) ORDER BY rs.step_number)
+            FROM recipe_steps rs WHERE rs.recipe_uuid = r.uuid), '[]'::jsonb))
+    FROM recipes r WHERE r.uuid = recipe
+$$ LANGUAGE SQL STABLE;
+
+-- Appends a snapshot of the recipe's current state and returns its revision
+-- number. Locks the recipe so concurrent edits get consecutive numbers.
+CREATE FUNCTION record_recipe_revision(recipe UUID, author UUID) RETURNS INT AS $$
+DECLARE
+    next_number INT;
+BEGIN
+    PERFORM 1 FROM recipes WHERE uuid = recipe FOR UPDATE;
+
+    SELECT COALESCE(MAX(revision_number), 0) + 1 INTO next_number
+    FROM recipe_revisions WHERE recipe_uuid = recipe;
+
+    INSERT INTO recipe_revisions (recipe_uuid, revision_number, author_uuid, snapshot)
+    VALUES (recipe, next_number, author, recipe_snapshot(recipe));
+
+    RETURN next_number;
+END
+$$ LANGUAGE plpgsql;
+
+-- Existing recipes start their history from their current state
+INSERT INTO recipe_revisions (recipe_uuid, revision_number, author_uuid, snapshot, created_at)
+SELECT r.uuid, 1, u.uuid, recipe_snapshot(r.uuid), r.created_at
+FROM recipes r LEFT JOIN users u ON u.uuid = r.user_uuid;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS record_recipe_revision(UUID, UUID);
+DROP FUNCTION IF EXISTS recipe_snapshot(UUID);
+DROP TABLE IF EXISTS recipe_revisions;
+DROP FUNCTION IF EXISTS recipe_revisions_immutable();
+-- +goose StatementEnd
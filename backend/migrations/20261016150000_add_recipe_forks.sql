@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE recipes ADD COLUMN forked_from UUID REFERENCES recipes(uuid) ON DELETE SET NULL;
+
+CREATE INDEX idx_recipes_forked_from ON recipes(forked_from);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_recipes_forked_from;
+ALTER TABLE recipes DROP COLUMN IF EXISTS forked_from;
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE recipes ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_recipes_deleted_at ON recipes(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_recipes_deleted_at;
+ALTER TABLE recipes DROP COLUMN IF EXISTS deleted_at;
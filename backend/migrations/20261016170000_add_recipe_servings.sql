@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE recipes ADD COLUMN servings SMALLINT CHECK (servings > 0);
+-- Free-form yield for recipes that aren't measured in servings, e.g. "2 loaves"
+ALTER TABLE recipes ADD COLUMN yield_text TEXT;
+
+-- +goose Down
+ALTER TABLE recipes DROP COLUMN IF EXISTS yield_text;
+ALTER TABLE recipes DROP COLUMN IF EXISTS servings;
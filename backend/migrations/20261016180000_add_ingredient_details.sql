@@ -0,0 +1,73 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE step_ingredients ADD COLUMN preparation TEXT;
+ALTER TABLE step_ingredients ADD COLUMN optional BOOLEAN NOT NULL DEFAULT false;
+-- The ingredient as it was written, before parsing
+ALTER TABLE step_ingredients ADD COLUMN original_text TEXT;
+-- Upper end of a range such as "2-3 cloves", in the same base unit as quantity
+ALTER TABLE step_ingredients ADD COLUMN quantity_max DECIMAL CHECK (quantity_max >= quantity);
+-- A quantity that isn't a number ("to taste"), or a size note ("14 oz")
+ALTER TABLE step_ingredients ADD COLUMN quantity_text TEXT;
+
+CREATE OR REPLACE FUNCTION recipe_snapshot(recipe UUID) RETURNS JSONB AS $$
+    SELECT jsonb_build_object(
+        'name', r.name,
+        'source', r.source,
+        'tags', COALESCE((
+            SELECT jsonb_agg(t.name ORDER BY rt.id)
+            FROM recipe_tags rt JOIN tags t ON t.uuid = rt.tag_uuid
+            WHERE rt.recipe_uuid = r.uuid), '[]'::jsonb),
+        'steps', COALESCE((
+            SELECT jsonb_agg(jsonb_build_object(
+                'instruction', rs.instructions,
+                'ingredients', COALESCE((
+                    SELECT jsonb_agg(jsonb_strip_nulls(jsonb_build_object(
+                        'name', i.name,
+                        'unit', si.ingredient_type,
+                        'quantity', si.quantity,
+                        'quantity_max', si.quantity_max,
+                        'quantity_text', si.quantity_text,
+                        'preparation', si.preparation,
+                        'optional', NULLIF(si.optional, false),
+                        'original_text', si.original_text)) ORDER BY i.name, si.quantity)
+                    FROM step_ingredients si
+                    JOIN ingredient_names i ON i.uuid = si.ingredient_name_uuid
+                    WHERE si.recipe_step_uuid = rs.uuid), '[]'::jsonb)
+            ) ORDER BY rs.step_number)
+            FROM recipe_steps rs WHERE rs.recipe_uuid = r.uuid), '[]'::jsonb))
+    FROM recipes r WHERE r.uuid = recipe
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION recipe_snapshot(recipe UUID) RETURNS JSONB AS $$
+    SELECT jsonb_build_object(
+        'name', r.name,
+        'source', r.source,
+        'tags', COALESCE((
+            SELECT jsonb_agg(t.name ORDER BY rt.id)
+            FROM recipe_tags rt JOIN tags t ON t.uuid = rt.tag_uuid
+            WHERE rt.recipe_uuid = r.uuid), '[]'::jsonb),
+        'steps', COALESCE((
+            SELECT jsonb_agg(jsonb_build_object(
+                'instruction', rs.instructions,
+                'ingredients', COALESCE((
+                    SELECT jsonb_agg(jsonb_build_object(
+                        'name', i.name,
+                        'unit', si.ingredient_type,
+                        'quantity', si.quantity) ORDER BY i.name, si.quantity)
+                    FROM step_ingredients si
+                    JOIN ingredient_names i ON i.uuid = si.ingredient_name_uuid
+                    WHERE si.recipe_step_uuid = rs.uuid), '[]'::jsonb)
+            ) ORDER BY rs.step_number)
+            FROM recipe_steps rs WHERE rs.recipe_uuid = r.uuid), '[]'::jsonb))
+    FROM recipes r WHERE r.uuid = recipe
+$$ LANGUAGE SQL STABLE;
+
+ALTER TABLE step_ingredients DROP COLUMN IF EXISTS quantity_text;
+ALTER TABLE step_ingredients DROP COLUMN IF EXISTS quantity_max;
+ALTER TABLE step_ingredients DROP COLUMN IF EXISTS original_text;
+ALTER TABLE step_ingredients DROP COLUMN IF EXISTS optional;
+ALTER TABLE step_ingredients DROP COLUMN IF EXISTS preparation;
+-- +goose StatementEnd
@@ -0,0 +1,69 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Groups a recipe's ingredients into sections such as "For the dough"
+ALTER TABLE recipe_steps ADD COLUMN group_title TEXT;
+
+CREATE OR REPLACE FUNCTION recipe_snapshot(recipe UUID) RETURNS JSONB AS $$
+    SELECT jsonb_build_object(
+        'name', r.name,
+        'source', r.source,
+        'tags', COALESCE((
+            SELECT jsonb_agg(t.name ORDER BY rt.id)
+            FROM recipe_tags rt JOIN tags t ON t.uuid = rt.tag_uuid
+            WHERE rt.recipe_uuid = r.uuid), '[]'::jsonb),
+        'steps', COALESCE((
+            SELECT jsonb_agg(jsonb_strip_nulls(jsonb_build_object(
+                'instruction', rs.instructions,
+                'group_title', rs.group_title,
+                'ingredients', COALESCE((
+                    SELECT jsonb_agg(jsonb_strip_nulls(jsonb_build_object(
+                        'name', i.name,
+                        'unit', si.ingredient_type,
+                        'quantity', si.quantity,
+                        'quantity_max', si.quantity_max,
+                        'quantity_text', si.quantity_text,
+                        'preparation', si.preparation,
+                        'optional', NULLIF(si.optional, false),
+                        'original_text', si.original_text)) ORDER BY i.name, si.quantity)
+                    FROM step_ingredients si
+                    JOIN ingredient_names i ON i.uuid = si.ingredient_name_uuid
+                    WHERE si.recipe_step_uuid = rs.uuid), '[]'::jsonb)
+            )) ORDER BY rs.step_number)
+            FROM recipe_steps rs WHERE rs.recipe_uuid = r.uuid), '[]'::jsonb))
+    FROM recipes r WHERE r.uuid = recipe
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION recipe_snapshot(recipe UUID) RETURNS JSONB AS $$
+    SELECT jsonb_build_object(
+        'name', r.name,
+        'source', r.source,
+        'tags', COALESCE((
+            SELECT jsonb_agg(t.name ORDER BY rt.id)
+            FROM recipe_tags rt JOIN tags t ON t.uuid = rt.tag_uuid
+            WHERE rt.recipe_uuid = r.uuid), '[]'::jsonb),
+        'steps', COALESCE((
+            SELECT jsonb_agg(jsonb_build_object(
+                'instruction', rs.instructions,
+                'ingredients', COALESCE((
+                    SELECT jsonb_agg(jsonb_strip_nulls(jsonb_build_object(
+                        'name', i.name,
+                        'unit', si.ingredient_type,
+                        'quantity', si.quantity,
+                        'quantity_max', si.quantity_max,
+                        'quantity_text', si.quantity_text,
+                        'preparation', si.preparation,
+                        'optional', NULLIF(si.optional, false),
+                        'original_text', si.original_text)) ORDER BY i.name, si.quantity)
+                    FROM step_ingredients si
+                    JOIN ingredient_names i ON i.uuid = si.ingredient_name_uuid
+                    WHERE si.recipe_step_uuid = rs.uuid), '[]'::jsonb)
+            ) ORDER BY rs.step_number)
+            FROM recipe_steps rs WHERE rs.recipe_uuid = r.uuid), '[]'::jsonb))
+    FROM recipes r WHERE r.uuid = recipe
+$$ LANGUAGE SQL STABLE;
+
+ALTER TABLE recipe_steps DROP COLUMN IF EXISTS group_title;
+-- +goose StatementEnd
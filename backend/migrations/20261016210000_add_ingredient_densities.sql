@@ -0,0 +1,76 @@
+-- +goose Up
+-- Densities in grams per ml convert an ingredient between volume and mass
+CREATE TABLE ingredient_densities (
+    ingredient_name_uuid UUID PRIMARY KEY REFERENCES ingredient_names(uuid) ON DELETE CASCADE,
+    grams_per_ml DECIMAL NOT NULL CHECK (grams_per_ml > 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- A user's own measurements take precedence over the defaults
+CREATE TABLE user_ingredient_densities (
+    user_uuid UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    ingredient_name_uuid UUID NOT NULL REFERENCES ingredient_names(uuid) ON DELETE CASCADE,
+    grams_per_ml DECIMAL NOT NULL CHECK (grams_per_ml > 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (user_uuid, ingredient_name_uuid)
+);
+
+CREATE TEMPORARY TABLE default_densities (name TEXT, grams_per_ml DECIMAL);
+INSERT INTO default_densities (name, grams_per_ml) VALUES
+    ('flour', 0.53),
+    ('all-purpose flour', 0.53),
+    ('bread flour', 0.55),
+    ('cake flour', 0.48),
+    ('whole wheat flour', 0.51),
+    ('almond flour', 0.41),
+    ('cornmeal', 0.65),
+    ('cornstarch', 0.54),
+    ('cocoa powder', 0.42),
+    ('sugar', 0.85),
+    ('granulated sugar', 0.85),
+    ('brown sugar', 0.93),
+    ('powdered sugar', 0.51),
+    ('honey', 1.42),
+    ('maple syrup', 1.32),
+    ('molasses', 1.42),
+    ('butter', 0.96),
+    ('unsalted butter', 0.96),
+    ('olive oil', 0.91),
+    ('vegetable oil', 0.92),
+    ('water', 1.0),
+    ('milk', 1.03),
+    ('whole milk', 1.03),
+    ('buttermilk', 1.03),
+    ('heavy cream', 1.01),
+    ('sour cream', 1.02),
+    ('yogurt', 1.03),
+    ('salt', 1.2),
+    ('table salt', 1.2),
+    ('kosher salt', 0.54),
+    ('baking soda', 0.92),
+    ('baking powder', 0.81),
+    ('rolled oats', 0.34),
+    ('rice', 0.85),
+    ('chocolate chips', 0.72),
+    ('peanut butter', 1.09);
+
+INSERT INTO ingredient_names (name)
+SELECT name FROM default_densities
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO ingredient_densities (ingredient_name_uuid, grams_per_ml)
+SELECT n.uuid, d.grams_per_ml
+FROM default_densities d
+JOIN ingredient_names n ON n.name = d.name;
+
+DROP TABLE default_densities;
+
+-- +goose Down
+-- The defaults' names go too, unless a recipe has used them since
+DELETE FROM ingredient_names n
+WHERE n.uuid IN (SELECT ingredient_name_uuid FROM ingredient_densities)
+    AND NOT EXISTS (SELECT 1 FROM step_ingredients si WHERE si.ingredient_name_uuid = n.uuid);
+DROP TABLE IF EXISTS user_ingredient_densities;
+DROP TABLE IF EXISTS ingredient_densities;
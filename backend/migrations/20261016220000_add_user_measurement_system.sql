@@ -0,0 +1,7 @@
+-- +goose Up
+-- NULL shows each quantity in whichever unit reads best
+ALTER TABLE users ADD COLUMN measurement_system TEXT
+    CHECK (measurement_system IN ('metric', 'us', 'imperial', 'as_written'));
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS measurement_system;
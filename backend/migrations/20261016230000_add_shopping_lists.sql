@@ -0,0 +1,142 @@
+-- +goose Up
+-- The supermarket aisle an ingredient is found in, for grouping shopping lists
+ALTER TABLE ingredient_names ADD COLUMN aisle TEXT;
+
+CREATE TEMPORARY TABLE default_aisles (name TEXT, aisle TEXT);
+INSERT INTO default_aisles (name, aisle) VALUES
+    ('onion', 'produce'),
+    ('onions', 'produce'),
+    ('garlic', 'produce'),
+    ('shallot', 'produce'),
+    ('carrot', 'produce'),
+    ('carrots', 'produce'),
+    ('celery', 'produce'),
+    ('potato', 'produce'),
+    ('potatoes', 'produce'),
+    ('tomato', 'produce'),
+    ('tomatoes', 'produce'),
+    ('lemon', 'produce'),
+    ('lime', 'produce'),
+    ('ginger', 'produce'),
+    ('parsley', 'produce'),
+    ('cilantro', 'produce'),
+    ('basil', 'produce'),
+    ('spinach', 'produce'),
+    ('mushrooms', 'produce'),
+    ('bell pepper', 'produce'),
+    ('avocado', 'produce'),
+    ('apple', 'produce'),
+    ('banana', 'produce'),
+    ('chicken', 'meat & seafood'),
+    ('chicken breast', 'meat & seafood'),
+    ('chicken thighs', 'meat & seafood'),
+    ('ground beef', 'meat & seafood'),
+    ('beef', 'meat & seafood'),
+    ('pork', 'meat & seafood'),
+    ('bacon', 'meat & seafood'),
+    ('sausage', 'meat & seafood'),
+    ('salmon', 'meat & seafood'),
+    ('shrimp', 'meat & seafood'),
+    ('egg', 'dairy & eggs'),
+    ('eggs', 'dairy & eggs'),
+    ('milk', 'dairy & eggs'),
+    ('whole milk', 'dairy & eggs'),
+    ('buttermilk', 'dairy & eggs'),
+    ('butter', 'dairy & eggs'),
+    ('unsalted butter', 'dairy & eggs'),
+    ('heavy cream', 'dairy & eggs'),
+    ('sour cream', 'dairy & eggs'),
+    ('yogurt', 'dairy & eggs'),
+    ('cheese', 'dairy & eggs'),
+    ('parmesan', 'dairy & eggs'),
+    ('cream cheese', 'dairy & eggs'),
+    ('bread', 'bakery'),
+    ('tortillas', 'bakery'),
+    ('flour', 'baking'),
+    ('all-purpose flour', 'baking'),
+    ('bread flour', 'baking'),
+    ('sugar', 'baking'),
+    ('brown sugar', 'baking'),
+    ('powdered sugar', 'baking'),
+    ('baking soda', 'baking'),
+    ('baking powder', 'baking'),
+    ('yeast', 'baking'),
+    ('vanilla extract', 'baking'),
+    ('cocoa powder', 'baking'),
+    ('chocolate chips', 'baking'),
+    ('salt', 'spices'),
+    ('kosher salt', 'spices'),
+    ('black pepper', 'spices'),
+    ('pepper', 'spices'),
+    ('cumin', 'spices'),
+    ('paprika', 'spices'),
+    ('cinnamon', 'spices'),
+    ('chili flakes', 'spices'),
+    ('oregano', 'spices'),
+    ('olive oil', 'pantry'),
+    ('vegetable oil', 'pantry'),
+    ('rice', 'pantry'),
+    ('pasta', 'pantry'),
+    ('stock', 'pantry'),
+    ('chicken stock', 'pantry'),
+    ('soy sauce', 'pantry'),
+    ('vinegar', 'pantry'),
+    ('honey', 'pantry'),
+    ('maple syrup', 'pantry'),
+    ('peanut butter', 'pantry'),
+    ('rolled oats', 'pantry'),
+    ('frozen peas', 'frozen'),
+    ('ice cream', 'frozen');
+
+INSERT INTO ingredient_names (name)
+SELECT name FROM default_aisles
+ON CONFLICT (name) DO NOTHING;
+
+UPDATE ingredient_names n
+SET aisle = d.aisle
+FROM default_aisles d
+WHERE n.name = d.name;
+
+DROP TABLE default_aisles;
+
+CREATE TABLE shopping_lists (
+    uuid UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_uuid UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_shopping_lists_user_uuid ON shopping_lists(user_uuid);
+
+-- The recipes a list was made from, with the scale each was bought for
+CREATE TABLE shopping_list_recipes (
+    shopping_list_uuid UUID NOT NULL REFERENCES shopping_lists(uuid) ON DELETE CASCADE,
+    recipe_uuid UUID NOT NULL REFERENCES recipes(uuid) ON DELETE CASCADE,
+    scale DOUBLE PRECISION NOT NULL DEFAULT 1 CHECK (scale > 0),
+    PRIMARY KEY (shopping_list_uuid, recipe_uuid)
+);
+
+-- Items are totals across the list's recipes in base units (ml, mg or a
+-- count), or free text the user added. base_quantity is NULL for items without
+-- a quantity, such as "salt to taste".
+CREATE TABLE shopping_list_items (
+    uuid UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    shopping_list_uuid UUID NOT NULL REFERENCES shopping_lists(uuid) ON DELETE CASCADE,
+    ingredient_name_uuid UUID REFERENCES ingredient_names(uuid) ON DELETE SET NULL,
+    name TEXT NOT NULL,
+    category TEXT CHECK (category IN ('volume', 'mass', 'count')),
+    base_quantity DOUBLE PRECISION,
+    custom BOOLEAN NOT NULL DEFAULT FALSE,
+    checked BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_shopping_list_items_list_uuid ON shopping_list_items(shopping_list_uuid);
+
+-- +goose Down
+DROP TABLE IF EXISTS shopping_list_items;
+DROP TABLE IF EXISTS shopping_list_recipes;
+DROP TABLE IF EXISTS shopping_lists;
+ALTER TABLE ingredient_names DROP COLUMN IF EXISTS aisle;
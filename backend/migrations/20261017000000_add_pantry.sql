@@ -0,0 +1,16 @@
+-- +goose Up
+-- What each user has on hand. ingredient_type and base_quantity (in ml, mg or
+-- a count) are NULL when the user hasn't said how much they have.
+CREATE TABLE pantry_items (
+    user_uuid UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    ingredient_name_uuid UUID NOT NULL REFERENCES ingredient_names(uuid) ON DELETE CASCADE,
+    ingredient_type TEXT CHECK (ingredient_type IN ('ml', 'mg', 'count')),
+    base_quantity DOUBLE PRECISION CHECK (base_quantity > 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (user_uuid, ingredient_name_uuid),
+    CHECK ((ingredient_type IS NULL) = (base_quantity IS NULL))
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS pantry_items;
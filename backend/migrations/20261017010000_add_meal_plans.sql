@@ -0,0 +1,30 @@
+-- +goose Up
+-- shared plans are visible to the users the owner has connected to, like
+-- their recipes
+CREATE TABLE meal_plans (
+    uuid UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_uuid UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    shared BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_meal_plans_user_uuid ON meal_plans(user_uuid);
+
+CREATE TABLE meal_plan_entries (
+    uuid UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    meal_plan_uuid UUID NOT NULL REFERENCES meal_plans(uuid) ON DELETE CASCADE,
+    recipe_uuid UUID NOT NULL REFERENCES recipes(uuid) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    meal TEXT NOT NULL CHECK (meal IN ('breakfast', 'lunch', 'dinner')),
+    servings INTEGER CHECK (servings > 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_meal_plan_entries_plan_date ON meal_plan_entries(meal_plan_uuid, date);
+
+-- +goose Down
+DROP TABLE IF EXISTS meal_plan_entries;
+DROP TABLE IF EXISTS meal_plans;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Canonical keys (see ingredients.Key) of the ways an ingredient is written,
+-- so "Eggs", "egg " and "large eggs" all find the same ingredient_names row.
+-- Merging an ingredient into another moves its aliases along with it.
+--
+-- Existing names are given aliases, and duplicates among them merged, by
+-- running cmd/dedupe-ingredients after this migration.
+CREATE TABLE ingredient_aliases (
+    alias TEXT PRIMARY KEY,
+    ingredient_name_uuid UUID NOT NULL REFERENCES ingredient_names(uuid) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_ingredient_aliases_ingredient_name_uuid ON ingredient_aliases(ingredient_name_uuid);
+
+-- +goose Down
+DROP TABLE IF EXISTS ingredient_aliases;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Calendar feed URLs are signed with the user's feed key, so giving them a
+-- new key revokes every feed URL they have handed out
+ALTER TABLE users ADD COLUMN calendar_feed_key UUID NOT NULL DEFAULT uuid_generate_v4();
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS calendar_feed_key;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- The name a recipe writes an ingredient under, e.g. "large eggs", which is
+-- shown instead of the catalog's canonical name. ingredient_name_uuid still
+-- identifies the ingredient for totals. NULL uses the catalog name.
+ALTER TABLE step_ingredients ADD COLUMN name TEXT;
+
+UPDATE step_ingredients si
+SET name = n.name
+FROM ingredient_names n
+WHERE n.uuid = si.ingredient_name_uuid;
+
+-- +goose Down
+ALTER TABLE step_ingredients DROP COLUMN IF EXISTS name;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- The name sort orders by lower(name), which the plain name index can't serve
+DROP INDEX IF EXISTS idx_recipes_user_name;
+CREATE INDEX idx_recipes_user_name ON recipes(user_uuid, lower(name), uuid);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_recipes_user_name;
+CREATE INDEX idx_recipes_user_name ON recipes(user_uuid, name, uuid);
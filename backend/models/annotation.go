@@ -0,0 +1,46 @@
+package models
+
+// Step annotation types
+const (
+	AnnotationTemperature = "temperature"
+	AnnotationDuration    = "duration"
+	AnnotationIngredient  = "ingredient"
+)
+
+// StepAnnotation marks something recognised in a step's instruction: the text
+// between the character offsets Start and End
+type StepAnnotation struct {
+	Type        string                 `json:"type"`
+	Start       int                    `json:"start"`
+	End         int                    `json:"end"`
+	Text        string                 `json:"text"`
+	Temperature *TemperatureAnnotation `json:"temperature,omitempty"`
+	Duration    *DurationAnnotation    `json:"duration,omitempty"`
+	Ingredient  *IngredientMention     `json:"ingredient,omitempty"`
+}
+
+// TemperatureAnnotation is a temperature, or a range such as "375-400°F", in
+// both Celsius and Fahrenheit. Converted values are rounded to the nearest
+// degree.
+type TemperatureAnnotation struct {
+	// Unit is the unit the instruction uses, "C" or "F"
+	Unit          string  `json:"unit"`
+	Celsius       float64 `json:"celsius"`
+	Fahrenheit    float64 `json:"fahrenheit"`
+	CelsiusMax    float64 `json:"celsius_max,omitempty"`
+	FahrenheitMax float64 `json:"fahrenheit_max,omitempty"`
+}
+
+// DurationAnnotation is a length of time, or a range such as "25-30 minutes"
+type DurationAnnotation struct {
+	Seconds    int `json:"seconds"`
+	SecondsMax int `json:"seconds_max,omitempty"`
+}
+
+// IngredientMention links a mention of an ingredient to the ingredient, by its
+// position in the steps of the same response
+type IngredientMention struct {
+	Step  int    `json:"step"`
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type APIKey struct {
+	UUID       uuid.UUID  `json:"uuid"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// CreateAPIKeyResponse carries the full key, which is only ever shown once
+type CreateAPIKeyResponse struct {
+	Success bool   `json:"success"`
+	APIKey  APIKey `json:"api_key"`
+	Key     string `json:"key"`
+}
+
+type APIKeysResponse struct {
+	Success bool     `json:"success"`
+	APIKeys []APIKey `json:"api_keys"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// IngredientDensity converts an ingredient between volume and mass
+type IngredientDensity struct {
+	IngredientName string  `json:"ingredient_name"`
+	GramsPerML     float64 `json:"grams_per_ml"`
+	// Custom is set for the user's own densities, which take precedence over
+	// the defaults
+	Custom bool `json:"custom"`
+}
+
+type DensitiesResponse struct {
+	Densities []IngredientDensity `json:"densities"`
+}
+
+type SetDensityRequest struct {
+	IngredientName string  `json:"ingredient_name"`
+	GramsPerML     float64 `json:"grams_per_ml"`
+}
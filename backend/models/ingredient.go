@@ -21,13 +21,30 @@ type IngredientName struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// IngredientResponse is a catalog ingredient with the canonical keys of the
+// names that refer to it
+type IngredientResponse struct {
+	IngredientName
+	Aliases []string `json:"aliases"`
+}
+
+// MergeIngredientsRequest folds the ingredient named Source into the one named
+// Target
+type MergeIngredientsRequest struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
 type RecipeStep struct {
 	UUID         uuid.UUID `json:"uuid"`
 	RecipeUUID   uuid.UUID `json:"recipe_uuid"`
 	StepNumber   int       `json:"step_number"`
 	Instructions string    `json:"instructions"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	// GroupTitle names the section of the recipe the step belongs to, e.g.
+	// "For the dough"
+	GroupTitle *string   `json:"group_title"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 type StepIngredient struct {
@@ -36,8 +53,20 @@ type StepIngredient struct {
 	IngredientNameUUID uuid.UUID      `json:"ingredient_name_uuid"`
 	IngredientType     IngredientUnit `json:"ingredient_type"`
 	Quantity           float64        `json:"quantity"`
-	CreatedAt          time.Time      `json:"created_at"`
-	UpdatedAt          time.Time      `json:"updated_at"`
+	// QuantityMax is the upper end of a range such as "2-3 cloves"
+	QuantityMax *float64 `json:"quantity_max"`
+	// QuantityText is a quantity that isn't a number ("to taste"), or a size
+	// note ("14 oz")
+	QuantityText *string `json:"quantity_text"`
+	Preparation  *string `json:"preparation"`
+	Optional     bool    `json:"optional"`
+	// OriginalText is the ingredient as it was written, before parsing
+	OriginalText *string `json:"original_text"`
+	// SubRecipeUUID is set when the ingredient is another recipe, such as
+	// "1 batch pizza dough"
+	SubRecipeUUID *uuid.UUID `json:"sub_recipe_uuid"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
 // StepIngredientWithName includes the ingredient name for convenience
@@ -56,10 +85,70 @@ type RecipeStepWithIngredients struct {
 
 type RecipeStepResponse struct {
 	Instruction string   `json:"instruction"`
+	GroupTitle  string   `json:"group_title,omitempty"`
 	Ingredients []string `json:"ingredients"`
+	// Annotations are read-only, and ignored on write
+	Annotations []StepAnnotation `json:"annotations,omitempty"`
 }
 
 type RecipeStepsResponse struct {
 	Steps []RecipeStepResponse `json:"steps"`
 	Tags  []string             `json:"tags"`
+	// Servings is the recipe's servings multiplied by Scale, when it has them
+	Servings  *float64 `json:"servings,omitempty"`
+	YieldText *string  `json:"yield_text,omitempty"`
+	// Scale is the factor ingredient quantities were multiplied by
+	Scale float64 `json:"scale,omitempty"`
+}
+
+// Ingredient is the structured (v2) form of a step ingredient, returned by the
+// steps endpoints with ?format=v2. On write Category, BaseQuantity and Display
+// are ignored.
+type Ingredient struct {
+	// Quantity is in Unit, e.g. 3 for "3 tbsp"
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+	Category string  `json:"category"`
+	// BaseQuantity is in ml, mg or count, before rounding for display
+	BaseQuantity float64 `json:"base_quantity"`
+	// QuantityMax is the upper end of a range in Unit, or 0
+	QuantityMax  float64 `json:"quantity_max,omitempty"`
+	QuantityText string  `json:"quantity_text,omitempty"`
+	Name         string  `json:"name"`
+	Preparation  string  `json:"preparation,omitempty"`
+	Optional     bool    `json:"optional,omitempty"`
+	OriginalText string  `json:"original_text,omitempty"`
+	// RecipeUUID makes the ingredient a sub-recipe, e.g. "1 batch pizza
+	// dough" or "250 ml chicken stock". Name defaults to the recipe's name.
+	RecipeUUID *uuid.UUID `json:"recipe_uuid,omitempty"`
+	// Recipe is the sub-recipe scaled to this ingredient's quantity, when the
+	// steps are requested with ?expand=true and the caller can view it
+	Recipe *SubRecipe `json:"recipe,omitempty"`
+	// Display is the v1 string form, e.g. "3 tbsp butter"
+	Display string `json:"display"`
+}
+
+// SubRecipe is an expanded sub-recipe ingredient
+type SubRecipe struct {
+	UUID uuid.UUID `json:"uuid"`
+	Name string    `json:"name"`
+	// Scale is how many batches of the recipe the ingredient calls for
+	Scale float64        `json:"scale"`
+	Steps []RecipeStepV2 `json:"steps"`
+}
+
+type RecipeStepV2 struct {
+	Instruction string       `json:"instruction"`
+	GroupTitle  string       `json:"group_title,omitempty"`
+	Ingredients []Ingredient `json:"ingredients"`
+	// Annotations are read-only, and ignored on write
+	Annotations []StepAnnotation `json:"annotations,omitempty"`
+}
+
+type RecipeStepsV2Response struct {
+	Steps     []RecipeStepV2 `json:"steps"`
+	Tags      []string       `json:"tags"`
+	Servings  *float64       `json:"servings,omitempty"`
+	YieldText *string        `json:"yield_text,omitempty"`
+	Scale     float64        `json:"scale,omitempty"`
 }
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// Meal slots, in the order they are eaten
+const (
+	MealBreakfast = "breakfast"
+	MealLunch     = "lunch"
+	MealDinner    = "dinner"
+)
+
+var Meals = []string{MealBreakfast, MealLunch, MealDinner}
+
+// MealPlan schedules recipes for meals. A shared plan can be seen, but not
+// changed, by the users its owner has connected to.
+type MealPlan struct {
+	UUID       uuid.UUID `json:"uuid"`
+	UserUUID   uuid.UUID `json:"user_uuid"`
+	OwnerEmail string    `json:"owner_email"`
+	Name       string    `json:"name"`
+	Shared     bool      `json:"shared"`
+	// FeedURL is the caller's own path to the plan's iCalendar feed, which
+	// works without a session
+	FeedURL   string    `json:"feed_url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MealPlanEntry is a recipe planned for a meal. On write RecipeName is
+// ignored.
+type MealPlanEntry struct {
+	UUID       uuid.UUID `json:"uuid"`
+	RecipeUUID uuid.UUID `json:"recipe_uuid"`
+	RecipeName string    `json:"recipe_name"`
+	// Date is the day of the meal, as YYYY-MM-DD
+	Date string `json:"date"`
+	Meal string `json:"meal"`
+	// Servings scales the recipe when it has servings; nil cooks it as
+	// written
+	Servings  *int      `json:"servings"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type MealPlanResponse struct {
+	MealPlan
+	Entries []MealPlanEntry `json:"entries"`
+}
+
+type MealPlansResponse struct {
+	MealPlans []MealPlan `json:"meal_plans"`
+}
+
+// MealPlanRequest creates a meal plan, or updates the fields that are set
+type MealPlanRequest struct {
+	Name   *string `json:"name"`
+	Shared *bool   `json:"shared"`
+}
+
+// MealPlanShoppingListRequest makes a shopping list for the week starting on
+// From (YYYY-MM-DD), which defaults to this week's Monday
+type MealPlanShoppingListRequest struct {
+	From string `json:"from"`
+	Name string `json:"name"`
+}
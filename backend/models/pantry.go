@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// PantryItem is an ingredient the user has on hand
+type PantryItem struct {
+	IngredientName string `json:"ingredient_name"`
+	// Category and BaseQuantity (in ml, mg or count) are empty when the user
+	// hasn't said how much they have
+	Category     string  `json:"category,omitempty"`
+	BaseQuantity float64 `json:"base_quantity,omitempty"`
+	// Quantity is BaseQuantity in its best display unit, e.g. "1 kg"
+	Quantity  string    `json:"quantity,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type PantryResponse struct {
+	Items []PantryItem `json:"items"`
+}
+
+type SetPantryItemRequest struct {
+	IngredientName string `json:"ingredient_name"`
+	// Quantity is in Unit, which defaults to count. 0 records the ingredient
+	// without a quantity.
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+}
+
+type DeductPantryRequest struct {
+	RecipeUUID uuid.UUID `json:"recipe_uuid"`
+	// Scale is how many batches were cooked, defaulting to 1
+	Scale float64 `json:"scale"`
+}
+
+// RecipeMatch is how much of a recipe's ingredients the user has on hand
+type RecipeMatch struct {
+	Recipe Recipe `json:"recipe"`
+	// Total counts the recipe's distinct ingredients, not counting optional
+	// ones and sub-recipes
+	Total int `json:"total"`
+	Have  int `json:"have"`
+	// Missing names the ingredients the user doesn't have, or doesn't have
+	// enough of
+	Missing []string `json:"missing"`
+}
+
+type RecipeMatchesResponse struct {
+	Matches  []RecipeMatch `json:"matches"`
+	FileData []File        `json:"fileData"`
+}
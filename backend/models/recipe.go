@@ -7,14 +7,23 @@ import (
 )
 
 type Recipe struct {
-	UUID       uuid.UUID `json:"uuid"`
-	Name       string    `json:"name"`
-	User       uuid.UUID `json:"user_uuid"`
-	OwnerEmail string    `json:"owner_email"`
-	TagString  string    `json:"tag_string"`
-	Source     *string   `json:"source"`
-	IsPublic   bool      `json:"is_public"`
-	CreatedAt  time.Time `json:"created_at"`
+	UUID         uuid.UUID  `json:"uuid"`
+	Name         string     `json:"name"`
+	User         uuid.UUID  `json:"user_uuid"`
+	OwnerEmail   string     `json:"owner_email"`
+	TagString    string     `json:"tag_string"`
+	Source       *string    `json:"source"`
+	IsPublic     bool       `json:"is_public"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastCookedAt *time.Time `json:"last_cooked_at"`
+	Rating       *int       `json:"rating"`
+	Servings     *int       `json:"servings"`
+	// YieldText describes the yield when it isn't a number of servings, e.g. "2 loaves"
+	YieldText  *string    `json:"yield_text"`
+	ForkedFrom *uuid.UUID `json:"forked_from"`
+	// ForkedFromEmail is the owner of the recipe this was forked from
+	ForkedFromEmail *string `json:"forked_from_email"`
+	ForkCount       int     `json:"fork_count"`
 }
 
 type File struct {
@@ -37,9 +46,19 @@ type RecipeTag struct {
 	TagUUID    uuid.UUID `json:"tag_uuid"`
 }
 
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
 type RecipesResponse struct {
 	RecipeData []Recipe `json:"recipeData"`
 	FileData   []File   `json:"fileData"`
+	// TagCounts counts tags across every recipe matching the filter, not
+	// just this page
+	TagCounts []TagCount `json:"tag_counts"`
+	// NextCursor fetches the following page; nil on the last page
+	NextCursor *string `json:"next_cursor"`
 }
 
 type UploadResponse struct {
@@ -58,17 +77,66 @@ type TagsResponse struct {
 }
 
 type PatchRecipeRequest struct {
-	TagString string  `json:"tagString"`
+	// TagString replaces all of the recipe's tags when present; "" removes them
+	TagString *string `json:"tagString"`
 	Source    *string `json:"source"`
+	// Servings and YieldText are left unchanged when omitted; 0 or "" clears them
+	Servings  *int    `json:"servings"`
+	YieldText *string `json:"yield_text"`
 }
 
 type SetPublicRequest struct {
 	IsPublic bool `json:"is_public"`
 }
 
+type SetRatingRequest struct {
+	// Rating is 1-5, or null to clear it
+	Rating *int `json:"rating"`
+}
+
+type TrashedRecipe struct {
+	Recipe    Recipe    `json:"recipe"`
+	DeletedAt time.Time `json:"deleted_at"`
+	// PurgeAt is when the recipe will be permanently deleted
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+type TrashResponse struct {
+	Recipes []TrashedRecipe `json:"recipes"`
+}
+
+type ForkResponse struct {
+	Success bool   `json:"success"`
+	Recipe  Recipe `json:"recipe"`
+}
+
+type CookedResponse struct {
+	Success      bool      `json:"success"`
+	LastCookedAt time.Time `json:"last_cooked_at"`
+}
+
 type PublicRecipeResponse struct {
 	Recipe Recipe               `json:"recipe"`
 	Files  []File               `json:"files"`
 	Steps  []RecipeStepResponse `json:"steps"`
 	Tags   []string             `json:"tags"`
 }
+
+type PublicRecipeV2Response struct {
+	Recipe Recipe         `json:"recipe"`
+	Files  []File         `json:"files"`
+	Steps  []RecipeStepV2 `json:"steps"`
+	Tags   []string       `json:"tags"`
+}
+
+type SearchResult struct {
+	Recipe Recipe  `json:"recipe"`
+	Rank   float32 `json:"rank"`
+	// Snippet is HTML-escaped text with matches wrapped in <mark> tags
+	Snippet string `json:"snippet"`
+}
+
+type SearchResponse struct {
+	Results  []SearchResult `json:"results"`
+	FileData []File         `json:"fileData"`
+}
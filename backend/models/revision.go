@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// RecipeSnapshot is the editable content of a recipe at one revision
+type RecipeSnapshot struct {
+	Name   string         `json:"name"`
+	Source *string        `json:"source"`
+	Tags   []string       `json:"tags"`
+	Steps  []SnapshotStep `json:"steps"`
+}
+
+type SnapshotStep struct {
+	Instruction string               `json:"instruction"`
+	GroupTitle  string               `json:"group_title,omitempty"`
+	Ingredients []SnapshotIngredient `json:"ingredients"`
+}
+
+// SnapshotIngredient stores quantities in base units, as step_ingredients does
+type SnapshotIngredient struct {
+	Name         string         `json:"name"`
+	Unit         IngredientUnit `json:"unit"`
+	Quantity     float64        `json:"quantity"`
+	QuantityMax  float64        `json:"quantity_max,omitempty"`
+	QuantityText string         `json:"quantity_text,omitempty"`
+	Preparation  string         `json:"preparation,omitempty"`
+	Optional     bool           `json:"optional,omitempty"`
+	OriginalText string         `json:"original_text,omitempty"`
+	// RecipeUUID is the sub-recipe the ingredient refers to, if any
+	RecipeUUID string `json:"recipe_uuid,omitempty"`
+}
+
+type RecipeRevision struct {
+	UUID       uuid.UUID      `json:"uuid"`
+	RecipeUUID uuid.UUID      `json:"recipe_uuid"`
+	Number     int            `json:"revision_number"`
+	AuthorUUID *uuid.UUID     `json:"author_uuid"`
+	CreatedAt  time.Time      `json:"created_at"`
+	Snapshot   RecipeSnapshot `json:"snapshot"`
+}
+
+type RevisionsResponse struct {
+	Revisions []RecipeRevision `json:"revisions"`
+}
+
+type FieldChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Step change kinds in a StepDiff
+const (
+	StepAdded    = "added"
+	StepRemoved  = "removed"
+	StepModified = "modified"
+)
+
+type StepDiff struct {
+	// Step is the 1-based step number in the newer revision, or in the
+	// older one for removed steps
+	Step               int                  `json:"step"`
+	Change             string               `json:"change"`
+	Instruction        *FieldChange         `json:"instruction,omitempty"`
+	GroupTitle         *FieldChange         `json:"group_title,omitempty"`
+	IngredientsAdded   []SnapshotIngredient `json:"ingredients_added,omitempty"`
+	IngredientsRemoved []SnapshotIngredient `json:"ingredients_removed,omitempty"`
+}
+
+// RevisionDiff describes what changed between two revisions of a recipe.
+// Unchanged fields are omitted.
+type RevisionDiff struct {
+	From        int          `json:"from"`
+	To          int          `json:"to"`
+	Name        *FieldChange `json:"name,omitempty"`
+	Source      *FieldChange `json:"source,omitempty"`
+	TagsAdded   []string     `json:"tags_added,omitempty"`
+	TagsRemoved []string     `json:"tags_removed,omitempty"`
+	Steps       []StepDiff   `json:"steps,omitempty"`
+}
+
+type RestoreRevisionResponse struct {
+	Success bool `json:"success"`
+	// Revision is the new revision recording the restored state
+	Revision int `json:"revision_number"`
+}
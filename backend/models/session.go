@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type Session struct {
+	UUID       uuid.UUID  `json:"uuid"`
+	UserUUID   uuid.UUID  `json:"user_uuid"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Current    bool       `json:"current"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type RegisterRequest struct {
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// EmailRequest starts an emailed flow (magic link, password reset, verification)
+type EmailRequest struct {
+	Email string `json:"email"`
+}
+
+// TokenRequest redeems a one-time token received by email
+type TokenRequest struct {
+	Token string `json:"token"`
+}
+
+type PasswordResetRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type LoginResponse struct {
+	Success   bool      `json:"success"`
+	User      User      `json:"user"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type SessionsResponse struct {
+	Success  bool      `json:"success"`
+	Sessions []Session `json:"sessions"`
+}
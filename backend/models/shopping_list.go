@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type ShoppingList struct {
+	UUID      uuid.UUID `json:"uuid"`
+	UserUUID  uuid.UUID `json:"user_uuid"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ShoppingListRecipe is a recipe a shopping list was made from. On write Name
+// is ignored and Scale defaults to 1.
+type ShoppingListRecipe struct {
+	RecipeUUID uuid.UUID `json:"recipe_uuid"`
+	Name       string    `json:"name,omitempty"`
+	Scale      float64   `json:"scale,omitempty"`
+}
+
+// ShoppingListItem is an ingredient totalled across a list's recipes, or free
+// text the user added
+type ShoppingListItem struct {
+	UUID uuid.UUID `json:"uuid"`
+	Name string    `json:"name"`
+	// Quantity is the total in its best display unit, e.g. "500 g"; empty
+	// for items without one, such as salt to taste
+	Quantity     string  `json:"quantity,omitempty"`
+	Category     string  `json:"category,omitempty"`
+	BaseQuantity float64 `json:"base_quantity,omitempty"`
+	Aisle        string  `json:"aisle"`
+	Checked      bool    `json:"checked"`
+	// Custom is set for free-text items the user added
+	Custom bool `json:"custom"`
+}
+
+// ShoppingListAisle is the items of a list found in one aisle of a
+// supermarket, e.g. "produce"
+type ShoppingListAisle struct {
+	Aisle string             `json:"aisle"`
+	Items []ShoppingListItem `json:"items"`
+}
+
+type ShoppingListResponse struct {
+	ShoppingList
+	Recipes []ShoppingListRecipe `json:"recipes"`
+	Aisles  []ShoppingListAisle  `json:"aisles"`
+}
+
+type ShoppingListsResponse struct {
+	ShoppingLists []ShoppingList `json:"shopping_lists"`
+}
+
+type CreateShoppingListRequest struct {
+	Name    string               `json:"name"`
+	Recipes []ShoppingListRecipe `json:"recipes"`
+	// Items are free-text items to add, e.g. "paper towels"
+	Items []string `json:"items"`
+}
+
+type AddShoppingListItemRequest struct {
+	Text string `json:"text"`
+}
+
+type UpdateShoppingListItemRequest struct {
+	Checked *bool `json:"checked"`
+}
@@ -7,18 +7,22 @@ import (
 )
 
 type User struct {
-	UUID      uuid.UUID `json:"uuid"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
+	UUID          uuid.UUID `json:"uuid"`
+	Email         string    `json:"email"`
+	Name          string    `json:"name"`
+	CreatedAt     time.Time `json:"created_at"`
+	EmailVerified bool      `json:"email_verified"`
+	// MeasurementSystem is the units recipes are shown in: "metric", "us",
+	// "imperial" or "as_written". Nil shows whichever unit reads best.
+	MeasurementSystem *string `json:"measurement_system"`
+}
+
+// Preferences are the signed-in user's display settings
+type Preferences struct {
+	MeasurementSystem *string `json:"measurement_system"`
 }
 
 type UserResponse struct {
 	Success bool `json:"success"`
 	User    User `json:"user"`
 }
-
-type CreateUserRequest struct {
-	Email string `json:"email"`
-	Name  string `json:"name"`
-}
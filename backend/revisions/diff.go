@@ -0,0 +1,127 @@
+package revisions
+
+import (
+	"slices"
+
+	"github.com/cobyabrahams/hungr/models"
+)
+
+// Diff compares two revisions of a recipe. Steps are aligned on identical
+// instructions, so inserting or deleting a step doesn't report every later step
+// as modified; unaligned steps between two aligned ones are paired up in order
+// as modifications, and any left over are additions or removals.
+func Diff(from, to models.RecipeRevision) models.RevisionDiff {
+	a, b := from.Snapshot, to.Snapshot
+	diff := models.RevisionDiff{From: from.Number, To: to.Number}
+
+	if a.Name != b.Name {
+		diff.Name = &models.FieldChange{From: a.Name, To: b.Name}
+	}
+	if fromSource, toSource := deref(a.Source), deref(b.Source); fromSource != toSource {
+		diff.Source = &models.FieldChange{From: fromSource, To: toSource}
+	}
+
+	diff.TagsAdded = missing(b.Tags, a.Tags)
+	diff.TagsRemoved = missing(a.Tags, b.Tags)
+	diff.Steps = diffSteps(a.Steps, b.Steps)
+	return diff
+}
+
+func diffSteps(from, to []models.SnapshotStep) []models.StepDiff {
+	var diffs []models.StepDiff
+	i, j := 0, 0
+	for _, match := range alignSteps(from, to) {
+		// Pair up the unaligned steps before this match
+		for ; i < match[0] && j < match[1]; i, j = i+1, j+1 {
+			diffs = append(diffs, modifiedStep(j, from[i], to[j]))
+		}
+		for ; i < match[0]; i++ {
+			diffs = append(diffs, models.StepDiff{Step: i + 1, Change: models.StepRemoved,
+				IngredientsRemoved: from[i].Ingredients})
+		}
+		for ; j < match[1]; j++ {
+			diffs = append(diffs, models.StepDiff{Step: j + 1, Change: models.StepAdded,
+				IngredientsAdded: to[j].Ingredients})
+		}
+
+		if i == len(from) {
+			break // the sentinel
+		}
+		if step := modifiedStep(j, from[i], to[j]); step.GroupTitle != nil || step.IngredientsAdded != nil || step.IngredientsRemoved != nil {
+			diffs = append(diffs, step)
+		}
+		i, j = i+1, j+1
+	}
+	return diffs
+}
+
+// modifiedStep describes how from became to; toIndex is to's 0-based position
+func modifiedStep(toIndex int, from, to models.SnapshotStep) models.StepDiff {
+	step := models.StepDiff{
+		Step:               toIndex + 1,
+		Change:             models.StepModified,
+		IngredientsAdded:   missing(to.Ingredients, from.Ingredients),
+		IngredientsRemoved: missing(from.Ingredients, to.Ingredients),
+	}
+	if from.Instruction != to.Instruction {
+		step.Instruction = &models.FieldChange{From: from.Instruction, To: to.Instruction}
+	}
+	if from.GroupTitle != to.GroupTitle {
+		step.GroupTitle = &models.FieldChange{From: from.GroupTitle, To: to.GroupTitle}
+	}
+	return step
+}
+
+// alignSteps returns the index pairs of the longest common subsequence of step
+// instructions, followed by a sentinel pair one past the end of both slices
+func alignSteps(from, to []models.SnapshotStep) [][2]int {
+	// lcs[i][j] is the LCS length of from[i:] and to[j:]
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i].Instruction == to[j].Instruction {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var matches [][2]int
+	for i, j := 0, 0; i < len(from) && j < len(to); {
+		switch {
+		case from[i].Instruction == to[j].Instruction:
+			matches = append(matches, [2]int{i, j})
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return append(matches, [2]int{len(from), len(to)})
+}
+
+// missing returns the items of want that aren't in have, counting duplicates
+func missing[T comparable](want, have []T) []T {
+	remaining := slices.Clone(have)
+	var result []T
+	for _, item := range want {
+		if k := slices.Index(remaining, item); k >= 0 {
+			remaining = slices.Delete(remaining, k, k+1)
+		} else {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,143 @@
+package revisions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cobyabrahams/hungr/models"
+)
+
+func revision(number int, snapshot models.RecipeSnapshot) models.RecipeRevision {
+	return models.RecipeRevision{Number: number, Snapshot: snapshot}
+}
+
+func step(instruction string, ingredients ...models.SnapshotIngredient) models.SnapshotStep {
+	return models.SnapshotStep{Instruction: instruction, Ingredients: ingredients}
+}
+
+var (
+	flour = models.SnapshotIngredient{Name: "flour", Unit: models.UnitMG, Quantity: 250000}
+	milk  = models.SnapshotIngredient{Name: "milk", Unit: models.UnitML, Quantity: 300}
+	eggs  = models.SnapshotIngredient{Name: "egg", Unit: models.UnitCount, Quantity: 2}
+	more  = models.SnapshotIngredient{Name: "milk", Unit: models.UnitML, Quantity: 350}
+)
+
+func TestDiff(t *testing.T) {
+	source := "https://example.com/pancakes"
+	base := models.RecipeSnapshot{
+		Name: "Pancakes",
+		Tags: []string{"breakfast", "quick"},
+		Steps: []models.SnapshotStep{
+			step("Whisk the batter", flour, milk, eggs),
+			step("Rest for 10 minutes"),
+			step("Fry in butter"),
+		},
+	}
+
+	tests := []struct {
+		desc string
+		to   models.RecipeSnapshot
+		want models.RevisionDiff
+	}{
+		{
+			desc: "unchanged",
+			to:   base,
+			want: models.RevisionDiff{From: 1, To: 2},
+		},
+		{
+			desc: "name, source and tags",
+			to: models.RecipeSnapshot{
+				Name: "Fluffy pancakes", Source: &source, Tags: []string{"quick", "sweet"}, Steps: base.Steps,
+			},
+			want: models.RevisionDiff{
+				From:        1,
+				To:          2,
+				Name:        &models.FieldChange{From: "Pancakes", To: "Fluffy pancakes"},
+				Source:      &models.FieldChange{From: "", To: source},
+				TagsAdded:   []string{"sweet"},
+				TagsRemoved: []string{"breakfast"},
+			},
+		},
+		{
+			desc: "ingredient quantity changed",
+			to: models.RecipeSnapshot{Name: "Pancakes", Tags: base.Tags, Steps: []models.SnapshotStep{
+				step("Whisk the batter", flour, more, eggs), base.Steps[1], base.Steps[2],
+			}},
+			want: models.RevisionDiff{From: 1, To: 2, Steps: []models.StepDiff{{
+				Step: 1, Change: models.StepModified,
+				IngredientsAdded:   []models.SnapshotIngredient{more},
+				IngredientsRemoved: []models.SnapshotIngredient{milk},
+			}}},
+		},
+		{
+			desc: "step inserted",
+			to: models.RecipeSnapshot{Name: "Pancakes", Tags: base.Tags, Steps: []models.SnapshotStep{
+				step("Sift the flour"), base.Steps[0], base.Steps[1], base.Steps[2],
+			}},
+			want: models.RevisionDiff{From: 1, To: 2, Steps: []models.StepDiff{
+				{Step: 1, Change: models.StepAdded},
+			}},
+		},
+		{
+			desc: "step removed",
+			to: models.RecipeSnapshot{Name: "Pancakes", Tags: base.Tags, Steps: []models.SnapshotStep{
+				base.Steps[0], base.Steps[2],
+			}},
+			want: models.RevisionDiff{From: 1, To: 2, Steps: []models.StepDiff{
+				{Step: 2, Change: models.StepRemoved},
+			}},
+		},
+		{
+			desc: "instruction reworded",
+			to: models.RecipeSnapshot{Name: "Pancakes", Tags: base.Tags, Steps: []models.SnapshotStep{
+				base.Steps[0], step("Rest for 30 minutes"), base.Steps[2],
+			}},
+			want: models.RevisionDiff{From: 1, To: 2, Steps: []models.StepDiff{{
+				Step: 2, Change: models.StepModified,
+				Instruction: &models.FieldChange{From: "Rest for 10 minutes", To: "Rest for 30 minutes"},
+			}}},
+		},
+		{
+			desc: "group title added",
+			to: models.RecipeSnapshot{Name: "Pancakes", Tags: base.Tags, Steps: []models.SnapshotStep{
+				{Instruction: "Whisk the batter", GroupTitle: "For the batter", Ingredients: base.Steps[0].Ingredients},
+				base.Steps[1], base.Steps[2],
+			}},
+			want: models.RevisionDiff{From: 1, To: 2, Steps: []models.StepDiff{{
+				Step: 1, Change: models.StepModified,
+				GroupTitle: &models.FieldChange{From: "", To: "For the batter"},
+			}}},
+		},
+		{
+			desc: "all steps replaced",
+			to: models.RecipeSnapshot{Name: "Pancakes", Tags: base.Tags, Steps: []models.SnapshotStep{
+				step("Buy pancakes"),
+			}},
+			want: models.RevisionDiff{From: 1, To: 2, Steps: []models.StepDiff{
+				{
+					Step: 1, Change: models.StepModified,
+					Instruction:        &models.FieldChange{From: "Whisk the batter", To: "Buy pancakes"},
+					IngredientsRemoved: []models.SnapshotIngredient{flour, milk, eggs},
+				},
+				{Step: 2, Change: models.StepRemoved},
+				{Step: 3, Change: models.StepRemoved},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := Diff(revision(1, base), revision(2, tt.to))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissing_CountsDuplicates(t *testing.T) {
+	got := missing([]string{"a", "a", "b"}, []string{"a"})
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("missing() = %q, want %q", got, want)
+	}
+}
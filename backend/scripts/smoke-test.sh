@@ -4,6 +4,7 @@
 
 BASE_URL="${1:-http://localhost:8080}"
 TEST_EMAIL="smoke-test@example.com"
+TEST_PASSWORD="${SMOKE_TEST_PASSWORD:-smoke-test-password}"
 FAILED=0
 
 echo "Running smoke tests against $BASE_URL"
@@ -33,30 +34,41 @@ HTTP_CODE=$(echo "$RESPONSE" | tail -1)
 BODY=$(echo "$RESPONSE" | head -n -1)
 check_response "GET /health" 200 "$HTTP_CODE" "$BODY"
 
-# Test 2: Create user (for testing)
+# Test 2: Register user (for testing)
 echo ""
-echo "2. Create test user"
-RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "$BASE_URL/api/users" \
+echo "2. Register test user"
+RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "$BASE_URL/api/auth/register" \
     -H "Content-Type: application/json" \
-    -d "{\"email\": \"$TEST_EMAIL\", \"name\": \"Smoke Test User\"}")
+    -d "{\"email\": \"$TEST_EMAIL\", \"name\": \"Smoke Test User\", \"password\": \"$TEST_PASSWORD\"}")
 HTTP_CODE=$(echo "$RESPONSE" | tail -1)
 BODY=$(echo "$RESPONSE" | head -n -1)
 # 200 = created, 409 = already exists (both OK)
 if [ "$HTTP_CODE" -eq 200 ] || [ "$HTTP_CODE" -eq 409 ]; then
-    echo "✓ POST /api/users (HTTP $HTTP_CODE)"
+    echo "✓ POST /api/auth/register (HTTP $HTTP_CODE)"
 else
-    echo "✗ POST /api/users - Expected HTTP 200 or 409, got $HTTP_CODE"
+    echo "✗ POST /api/auth/register - Expected HTTP 200 or 409, got $HTTP_CODE"
     echo "  Response: $BODY"
     FAILED=1
 fi
 
-# Test 3: Get recipes with email parameter (the bug we fixed)
+# Test 3: Log in to get a session token
 echo ""
-echo "3. Get recipes with email parameter"
-RESPONSE=$(curl -s -w "\n%{http_code}" "$BASE_URL/api/recipes?email=$TEST_EMAIL")
+echo "3. Log in"
+RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "$BASE_URL/api/auth/login" \
+    -H "Content-Type: application/json" \
+    -d "{\"email\": \"$TEST_EMAIL\", \"password\": \"$TEST_PASSWORD\"}")
 HTTP_CODE=$(echo "$RESPONSE" | tail -1)
 BODY=$(echo "$RESPONSE" | head -n -1)
-check_response "GET /api/recipes?email=..." 200 "$HTTP_CODE" "$BODY"
+check_response "POST /api/auth/login" 200 "$HTTP_CODE" "$BODY"
+TOKEN=$(echo "$BODY" | sed -n 's/.*"token":"\([^"]*\)".*/\1/p')
+
+# Test 4: Get recipes with the session token
+echo ""
+echo "4. Get recipes with session token"
+RESPONSE=$(curl -s -w "\n%{http_code}" -H "Authorization: Bearer $TOKEN" "$BASE_URL/api/recipes")
+HTTP_CODE=$(echo "$RESPONSE" | tail -1)
+BODY=$(echo "$RESPONSE" | head -n -1)
+check_response "GET /api/recipes" 200 "$HTTP_CODE" "$BODY"
 
 # Verify response has expected structure
 if echo "$BODY" | grep -q '"recipeData"'; then
@@ -66,22 +78,35 @@ else
     FAILED=1
 fi
 
-# Test 4: Get recipes with old user_uuid parameter should fail
+# Test 5: The email query parameter is no longer trusted as identity
 echo ""
-echo "4. Verify old user_uuid parameter is rejected"
-RESPONSE=$(curl -s -w "\n%{http_code}" "$BASE_URL/api/recipes?user_uuid=some-uuid")
+echo "5. Verify email parameter without a session is rejected"
+RESPONSE=$(curl -s -w "\n%{http_code}" "$BASE_URL/api/recipes?email=$TEST_EMAIL")
 HTTP_CODE=$(echo "$RESPONSE" | tail -1)
 BODY=$(echo "$RESPONSE" | head -n -1)
-check_response "GET /api/recipes?user_uuid=... (should fail)" 400 "$HTTP_CODE" "$BODY"
+check_response "GET /api/recipes?email=... (should fail)" 401 "$HTTP_CODE" "$BODY"
 
-# Test 5: Get user by email
+# Test 6: Get user by email
 echo ""
-echo "5. Get user by email"
+echo "6. Get user by email"
 RESPONSE=$(curl -s -w "\n%{http_code}" "$BASE_URL/api/users?email=$TEST_EMAIL")
 HTTP_CODE=$(echo "$RESPONSE" | tail -1)
 BODY=$(echo "$RESPONSE" | head -n -1)
 check_response "GET /api/users?email=..." 200 "$HTTP_CODE" "$BODY"
 
+# Test 7: Log out revokes the session
+echo ""
+echo "7. Log out"
+RESPONSE=$(curl -s -w "\n%{http_code}" -X POST -H "Authorization: Bearer $TOKEN" "$BASE_URL/api/auth/logout")
+HTTP_CODE=$(echo "$RESPONSE" | tail -1)
+BODY=$(echo "$RESPONSE" | head -n -1)
+check_response "POST /api/auth/logout" 200 "$HTTP_CODE" "$BODY"
+
+RESPONSE=$(curl -s -w "\n%{http_code}" -H "Authorization: Bearer $TOKEN" "$BASE_URL/api/recipes")
+HTTP_CODE=$(echo "$RESPONSE" | tail -1)
+BODY=$(echo "$RESPONSE" | head -n -1)
+check_response "GET /api/recipes after logout (should fail)" 401 "$HTTP_CODE" "$BODY"
+
 echo ""
 echo "========================================"
 if [ "$FAILED" -eq 0 ]; then
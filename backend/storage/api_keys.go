@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	queryCreateAPIKey = `
+		INSERT INTO api_keys (user_uuid, name, prefix, key_hash, scopes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING uuid, name, prefix, scopes, created_at, last_used_at`
+
+	queryGetAPIKeysByUser = `
+		SELECT uuid, name, prefix, scopes, created_at, last_used_at
+		FROM api_keys
+		WHERE user_uuid = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC`
+
+	queryGetUserByAPIKey = `
+		UPDATE api_keys k SET last_used_at = NOW()
+		FROM users u
+		WHERE k.key_hash = $1
+			AND u.uuid = k.user_uuid
+			AND k.revoked_at IS NULL
+		RETURNING u.uuid, u.email, u.name, u.created_at, u.email_verified_at IS NOT NULL, u.measurement_system,
+			k.uuid, k.name, k.prefix, k.scopes, k.created_at, k.last_used_at`
+
+	queryRevokeAPIKey = `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE uuid = $1 AND user_uuid = $2 AND revoked_at IS NULL`
+)
+
+func CreateAPIKey(userUUID uuid.UUID, name, prefix, keyHash string, scopes []string) (*models.APIKey, error) {
+	var k models.APIKey
+	err := db.QueryRow(context.Background(), queryCreateAPIKey, userUUID, name, prefix, keyHash, scopes).Scan(
+		&k.UUID, &k.Name, &k.Prefix, &k.Scopes, &k.CreatedAt, &k.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func GetAPIKeysByUser(userUUID uuid.UUID) ([]models.APIKey, error) {
+	rows, err := db.Query(context.Background(), queryGetAPIKeysByUser, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var k models.APIKey
+		if err := rows.Scan(&k.UUID, &k.Name, &k.Prefix, &k.Scopes, &k.CreatedAt, &k.LastUsedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// GetUserByAPIKey returns the owner of an active API key and records its use.
+// Unknown and revoked keys return pgx.ErrNoRows.
+func GetUserByAPIKey(keyHash string) (*models.User, *models.APIKey, error) {
+	var u models.User
+	var k models.APIKey
+	err := db.QueryRow(context.Background(), queryGetUserByAPIKey, keyHash).Scan(
+		&u.UUID, &u.Email, &u.Name, &u.CreatedAt, &u.EmailVerified, &u.MeasurementSystem,
+		&k.UUID, &k.Name, &k.Prefix, &k.Scopes, &k.CreatedAt, &k.LastUsedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &u, &k, nil
+}
+
+// RevokeAPIKey revokes one of a user's API keys. Returns false if the user
+// has no active key with that UUID.
+func RevokeAPIKey(keyUUID, userUUID uuid.UUID) (bool, error) {
+	tag, err := db.Exec(context.Background(), queryRevokeAPIKey, keyUUID, userUUID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
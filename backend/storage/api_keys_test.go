@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	ensureTestUser(t)
+	user, _ := GetUserByEmail(testEmail)
+
+	keyHash := "api-key-hash-" + time.Now().Format(time.RFC3339Nano)
+	key, err := CreateAPIKey(user.UUID, "backups", "hk_abcdefgh", keyHash, []string{"read"})
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+	if key.LastUsedAt != nil {
+		t.Error("Expected new key to be unused")
+	}
+
+	found, foundKey, err := GetUserByAPIKey(keyHash)
+	if err != nil {
+		t.Fatalf("GetUserByAPIKey failed: %v", err)
+	}
+	if found.UUID != user.UUID {
+		t.Errorf("Expected user %v, got %v", user.UUID, found.UUID)
+	}
+	if foundKey.LastUsedAt == nil {
+		t.Error("Expected last_used_at to be recorded")
+	}
+	if len(foundKey.Scopes) != 1 || foundKey.Scopes[0] != "read" {
+		t.Errorf("Expected [read] scopes, got %v", foundKey.Scopes)
+	}
+
+	keys, err := GetAPIKeysByUser(user.UUID)
+	if err != nil {
+		t.Fatalf("GetAPIKeysByUser failed: %v", err)
+	}
+	listed := false
+	for _, k := range keys {
+		listed = listed || k.UUID == key.UUID
+	}
+	if !listed {
+		t.Error("Expected key in user's key list")
+	}
+
+	revoked, err := RevokeAPIKey(key.UUID, user.UUID)
+	if err != nil {
+		t.Fatalf("RevokeAPIKey failed: %v", err)
+	}
+	if !revoked {
+		t.Error("Expected key to be revoked")
+	}
+
+	if _, _, err := GetUserByAPIKey(keyHash); err == nil {
+		t.Error("Expected revoked key to be rejected")
+	}
+}
@@ -13,14 +13,14 @@ const (
 		VALUES ($1, $2)`
 
 	queryGetConnectionsBySourceUser = `
-		SELECT u.uuid, u.email, u.name, u.created_at
+		SELECT u.uuid, u.email, u.name, u.created_at, u.email_verified_at IS NOT NULL, u.measurement_system
 		FROM user_connections uc
 		JOIN users u ON u.uuid = uc.target_user_uuid
 		WHERE uc.source_user_uuid = $1
 		ORDER BY u.name`
 
 	queryGetConnectionsByTargetUser = `
-		SELECT u.uuid, u.email, u.name, u.created_at
+		SELECT u.uuid, u.email, u.name, u.created_at, u.email_verified_at IS NOT NULL, u.measurement_system
 		FROM user_connections uc
 		JOIN users u ON u.uuid = uc.source_user_uuid
 		WHERE uc.target_user_uuid = $1
@@ -50,7 +50,7 @@ func GetConnectionsBySourceUser(sourceUserUUID uuid.UUID) ([]models.User, error)
 	var users []models.User
 	for rows.Next() {
 		var u models.User
-		err := rows.Scan(&u.UUID, &u.Email, &u.Name, &u.CreatedAt)
+		err := rows.Scan(&u.UUID, &u.Email, &u.Name, &u.CreatedAt, &u.EmailVerified, &u.MeasurementSystem)
 		if err != nil {
 			return nil, err
 		}
@@ -69,7 +69,7 @@ func GetConnectionsByTargetUser(targetUserUUID uuid.UUID) ([]models.User, error)
 	var users []models.User
 	for rows.Next() {
 		var u models.User
-		err := rows.Scan(&u.UUID, &u.Email, &u.Name, &u.CreatedAt)
+		err := rows.Scan(&u.UUID, &u.Email, &u.Name, &u.CreatedAt, &u.EmailVerified, &u.MeasurementSystem)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/gofrs/uuid"
+)
+
+// Purposes of the one-time tokens stored in auth_tokens
+const (
+	TokenMagicLink     = "magic_link"
+	TokenPasswordReset = "password_reset"
+	TokenVerifyEmail   = "verify_email"
+)
+
+// Credentials is the login state stored alongside a user
+type Credentials struct {
+	PasswordHash        *string
+	FailedLoginAttempts int
+	LockedUntil         *time.Time
+}
+
+const (
+	queryCreateUserWithPassword = `
+		INSERT INTO users (email, name, password_hash, last_seen)
+		VALUES ($1, $2, $3, NOW())
+		RETURNING uuid, email, name, created_at, email_verified_at IS NOT NULL, measurement_system`
+
+	queryGetCredentialsByEmail = `
+		SELECT uuid, email, name, created_at, email_verified_at IS NOT NULL, measurement_system,
+			password_hash, failed_login_attempts, locked_until
+		FROM users WHERE email = $1`
+
+	queryRecordFailedLogin = `
+		UPDATE users SET
+			failed_login_attempts = CASE WHEN failed_login_attempts + 1 >= $2 THEN 0 ELSE failed_login_attempts + 1 END,
+			locked_until = CASE WHEN failed_login_attempts + 1 >= $2 THEN $3 ELSE locked_until END
+		WHERE uuid = $1
+		RETURNING locked_until`
+
+	queryRecordSuccessfulLogin = `
+		UPDATE users SET failed_login_attempts = 0, locked_until = NULL, last_seen = NOW()
+		WHERE uuid = $1`
+
+	querySetUserPassword = `
+		UPDATE users SET password_hash = $2, failed_login_attempts = 0, locked_until = NULL
+		WHERE uuid = $1`
+
+	queryMarkEmailVerified = `
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW())
+		WHERE uuid = $1`
+
+	queryCreateAuthToken = `
+		INSERT INTO auth_tokens (user_uuid, purpose, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)`
+
+	queryConsumeAuthToken = `
+		UPDATE auth_tokens t SET used_at = NOW()
+		FROM users u
+		WHERE t.token_hash = $1
+			AND t.purpose = $2
+			AND u.uuid = t.user_uuid
+			AND t.used_at IS NULL
+			AND t.expires_at > NOW()
+		RETURNING u.uuid, u.email, u.name, u.created_at, u.email_verified_at IS NOT NULL, u.measurement_system`
+)
+
+func CreateUserWithPassword(email, name, passwordHash string) (*models.User, error) {
+	var u models.User
+	err := db.QueryRow(context.Background(), queryCreateUserWithPassword, email, name, passwordHash).Scan(
+		&u.UUID, &u.Email, &u.Name, &u.CreatedAt, &u.EmailVerified, &u.MeasurementSystem)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func GetCredentialsByEmail(email string) (*models.User, *Credentials, error) {
+	var u models.User
+	var c Credentials
+	err := db.QueryRow(context.Background(), queryGetCredentialsByEmail, email).Scan(
+		&u.UUID, &u.Email, &u.Name, &u.CreatedAt, &u.EmailVerified, &u.MeasurementSystem,
+		&c.PasswordHash, &c.FailedLoginAttempts, &c.LockedUntil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &u, &c, nil
+}
+
+// RecordFailedLogin counts a wrong password against the user. Once
+// maxAttempts is reached the account is locked until lockUntil and the
+// counter starts over. Returns the user's current lock, if any.
+func RecordFailedLogin(userUUID uuid.UUID, maxAttempts int, lockUntil time.Time) (*time.Time, error) {
+	var lockedUntil *time.Time
+	err := db.QueryRow(context.Background(), queryRecordFailedLogin, userUUID, maxAttempts, lockUntil).Scan(&lockedUntil)
+	return lockedUntil, err
+}
+
+func RecordSuccessfulLogin(userUUID uuid.UUID) error {
+	_, err := db.Exec(context.Background(), queryRecordSuccessfulLogin, userUUID)
+	return err
+}
+
+// SetUserPassword replaces the user's password hash and clears any lockout
+func SetUserPassword(userUUID uuid.UUID, passwordHash string) error {
+	_, err := db.Exec(context.Background(), querySetUserPassword, userUUID, passwordHash)
+	return err
+}
+
+func MarkEmailVerified(userUUID uuid.UUID) error {
+	_, err := db.Exec(context.Background(), queryMarkEmailVerified, userUUID)
+	return err
+}
+
+// CreateAuthToken stores the hash of a one-time token for the given purpose
+func CreateAuthToken(userUUID uuid.UUID, purpose, tokenHash string, expiresAt time.Time) error {
+	_, err := db.Exec(context.Background(), queryCreateAuthToken, userUUID, purpose, tokenHash, expiresAt)
+	return err
+}
+
+// ConsumeAuthToken marks an unused, unexpired token as used and returns its
+// user. Unknown, expired and already-used tokens return pgx.ErrNoRows.
+func ConsumeAuthToken(purpose, tokenHash string) (*models.User, error) {
+	var u models.User
+	err := db.QueryRow(context.Background(), queryConsumeAuthToken, tokenHash, purpose).Scan(
+		&u.UUID, &u.Email, &u.Name, &u.CreatedAt, &u.EmailVerified, &u.MeasurementSystem)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
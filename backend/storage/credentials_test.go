@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConsumeAuthToken_SingleUse(t *testing.T) {
+	ensureTestUser(t)
+	user, _ := GetUserByEmail(testEmail)
+
+	tokenHash := "test-hash-" + time.Now().Format(time.RFC3339Nano)
+	if err := CreateAuthToken(user.UUID, TokenMagicLink, tokenHash, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("CreateAuthToken failed: %v", err)
+	}
+
+	if _, err := ConsumeAuthToken(TokenPasswordReset, tokenHash); err == nil {
+		t.Error("Expected token to be rejected for a different purpose")
+	}
+
+	found, err := ConsumeAuthToken(TokenMagicLink, tokenHash)
+	if err != nil {
+		t.Fatalf("ConsumeAuthToken failed: %v", err)
+	}
+	if found.UUID != user.UUID {
+		t.Errorf("Expected user %v, got %v", user.UUID, found.UUID)
+	}
+
+	if _, err := ConsumeAuthToken(TokenMagicLink, tokenHash); err == nil {
+		t.Error("Expected token to be single use")
+	}
+}
+
+func TestConsumeAuthToken_Expired(t *testing.T) {
+	ensureTestUser(t)
+	user, _ := GetUserByEmail(testEmail)
+
+	tokenHash := "expired-hash-" + time.Now().Format(time.RFC3339Nano)
+	if err := CreateAuthToken(user.UUID, TokenMagicLink, tokenHash, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("CreateAuthToken failed: %v", err)
+	}
+
+	if _, err := ConsumeAuthToken(TokenMagicLink, tokenHash); err == nil {
+		t.Error("Expected expired token to be rejected")
+	}
+}
+
+func TestRecordFailedLogin_Locks(t *testing.T) {
+	ensureTestUser(t)
+	user, _ := GetUserByEmail(testEmail)
+	defer RecordSuccessfulLogin(user.UUID)
+
+	if err := RecordSuccessfulLogin(user.UUID); err != nil {
+		t.Fatalf("RecordSuccessfulLogin failed: %v", err)
+	}
+
+	lockUntil := time.Now().Add(time.Minute)
+	for i := 0; i < 2; i++ {
+		locked, err := RecordFailedLogin(user.UUID, 3, lockUntil)
+		if err != nil {
+			t.Fatalf("RecordFailedLogin failed: %v", err)
+		}
+		if locked != nil {
+			t.Fatalf("Expected no lock after %d failures", i+1)
+		}
+	}
+
+	locked, err := RecordFailedLogin(user.UUID, 3, lockUntil)
+	if err != nil {
+		t.Fatalf("RecordFailedLogin failed: %v", err)
+	}
+	if locked == nil || locked.Before(time.Now()) {
+		t.Errorf("Expected account to be locked, got %v", locked)
+	}
+
+	_, creds, err := GetCredentialsByEmail(testEmail)
+	if err != nil {
+		t.Fatalf("GetCredentialsByEmail failed: %v", err)
+	}
+	if creds.FailedLoginAttempts != 0 {
+		t.Errorf("Expected counter to reset on lock, got %d", creds.FailedLoginAttempts)
+	}
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/cobyabrahams/hungr/ingredients"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	// queryGetIngredientDensities lists the default densities overlaid with
+	// the user's own; $1 may be NULL for the defaults alone
+	queryGetIngredientDensities = `
+		SELECT n.name, COALESCE(u.grams_per_ml, d.grams_per_ml), u.grams_per_ml IS NOT NULL
+		FROM ingredient_names n
+		LEFT JOIN ingredient_densities d ON d.ingredient_name_uuid = n.uuid
+		LEFT JOIN user_ingredient_densities u ON u.ingredient_name_uuid = n.uuid AND u.user_uuid = $1
+		WHERE d.grams_per_ml IS NOT NULL OR u.grams_per_ml IS NOT NULL
+		ORDER BY n.name`
+
+	querySetUserIngredientDensity = `
+		INSERT INTO user_ingredient_densities (user_uuid, ingredient_name_uuid, grams_per_ml)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_uuid, ingredient_name_uuid)
+		DO UPDATE SET grams_per_ml = EXCLUDED.grams_per_ml, updated_at = NOW()`
+
+	queryDeleteUserIngredientDensity = `
+		DELETE FROM user_ingredient_densities u
+		USING ingredient_names n
+		WHERE u.ingredient_name_uuid = n.uuid AND u.user_uuid = $1
+			AND (n.name = $2 OR n.uuid = (SELECT ingredient_name_uuid FROM ingredient_aliases WHERE alias = $3))`
+)
+
+// GetIngredientDensities returns every ingredient with a known density, using
+// the user's own density where they have set one. A nil user gets the defaults.
+func GetIngredientDensities(userUUID *uuid.UUID) ([]models.IngredientDensity, error) {
+	rows, err := db.Query(context.Background(), queryGetIngredientDensities, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var densities []models.IngredientDensity
+	for rows.Next() {
+		var d models.IngredientDensity
+		if err := rows.Scan(&d.IngredientName, &d.GramsPerML, &d.Custom); err != nil {
+			return nil, err
+		}
+		densities = append(densities, d)
+	}
+	return densities, rows.Err()
+}
+
+// SetUserIngredientDensity records the user's own density for an ingredient
+// already in the catalog, returning sql.ErrNoRows if there is none by that
+// name
+func SetUserIngredientDensity(userUUID uuid.UUID, ingredientName string, gramsPerML float64) error {
+	ingredient, err := GetIngredientNameByName(ingredientName)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(context.Background(), querySetUserIngredientDensity, userUUID, ingredient.UUID, gramsPerML)
+	return err
+}
+
+// DeleteUserIngredientDensity removes the user's own density for an
+// ingredient, reporting whether they had one
+func DeleteUserIngredientDensity(userUUID uuid.UUID, ingredientName string) (bool, error) {
+	tag, err := db.Exec(context.Background(), queryDeleteUserIngredientDensity, userUUID, ingredientName, ingredients.Key(ingredientName))
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
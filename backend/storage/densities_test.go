@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/gofrs/uuid"
+)
+
+func findDensity(densities []models.IngredientDensity, name string) *models.IngredientDensity {
+	for i := range densities {
+		if densities[i].IngredientName == name {
+			return &densities[i]
+		}
+	}
+	return nil
+}
+
+func TestUserIngredientDensity(t *testing.T) {
+	ensureTestUser(t)
+	user, _ := GetUserByEmail(testEmail)
+	defer DeleteUserIngredientDensity(user.UUID, "flour")
+
+	if err := SetUserIngredientDensity(user.UUID, "flour", 0.6); err != nil {
+		t.Fatalf("SetUserIngredientDensity failed: %v", err)
+	}
+
+	tests := []struct {
+		desc        string
+		user        *uuid.UUID
+		wantDensity float64
+		wantCustom  bool
+	}{
+		{"user's own density", &user.UUID, 0.6, true},
+		{"default density", nil, 0.53, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			densities, err := GetIngredientDensities(tt.user)
+			if err != nil {
+				t.Fatalf("GetIngredientDensities failed: %v", err)
+			}
+			flour := findDensity(densities, "flour")
+			if flour == nil || flour.GramsPerML != tt.wantDensity || flour.Custom != tt.wantCustom {
+				t.Errorf("Expected flour at %v g/ml (custom %v), got %+v", tt.wantDensity, tt.wantCustom, flour)
+			}
+		})
+	}
+
+	deleted, err := DeleteUserIngredientDensity(user.UUID, "flour")
+	if err != nil || !deleted {
+		t.Fatalf("DeleteUserIngredientDensity = %v, %v", deleted, err)
+	}
+	deleted, err = DeleteUserIngredientDensity(user.UUID, "flour")
+	if err != nil || deleted {
+		t.Errorf("Expected nothing left to delete, got %v, %v", deleted, err)
+	}
+}
@@ -22,6 +22,8 @@ const (
 	queryUpdateFileURL = `UPDATE files SET url = $1 WHERE uuid = $2`
 
 	queryGetFileData = `SELECT data, content_type FROM files WHERE uuid = $1`
+
+	queryGetFileRecipeUUID = `SELECT recipe_uuid FROM files WHERE uuid = $1`
 )
 
 func GetFilesByRecipeUUIDs(recipeUUIDs []uuid.UUID) ([]models.File, error) {
@@ -94,3 +96,9 @@ func GetFileData(fileUUID uuid.UUID) ([]byte, string, error) {
 	}
 	return data, contentType, nil
 }
+
+func GetFileRecipeUUID(fileUUID uuid.UUID) (uuid.UUID, error) {
+	var recipeUUID uuid.UUID
+	err := db.QueryRow(context.Background(), queryGetFileRecipeUUID, fileUUID).Scan(&recipeUUID)
+	return recipeUUID, err
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cobyabrahams/hungr/ingredients"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrSameIngredient is returned when merging an ingredient into itself
+var ErrSameIngredient = errors.New("ingredients are the same")
+
+const (
+	// queryResolveIngredientName finds the ingredient aliased by the key $1,
+	// or else creates or reuses the ingredient named $2 and aliases it by $1
+	queryResolveIngredientName = `
+		WITH aliased AS (
+			SELECT ingredient_name_uuid AS uuid FROM ingredient_aliases WHERE alias = $1
+		),
+		created AS (
+			INSERT INTO ingredient_names (name)
+			SELECT $2 WHERE NOT EXISTS (SELECT 1 FROM aliased)
+			ON CONFLICT (name) DO UPDATE SET updated_at = NOW()
+			RETURNING uuid
+		),
+		new_alias AS (
+			INSERT INTO ingredient_aliases (alias, ingredient_name_uuid)
+			SELECT $1, uuid FROM created
+			ON CONFLICT (alias) DO NOTHING
+		)
+		SELECT uuid FROM aliased
+		UNION ALL
+		SELECT uuid FROM created`
+
+	querySetIngredientAlias = `
+		INSERT INTO ingredient_aliases (alias, ingredient_name_uuid)
+		VALUES ($1, $2)
+		ON CONFLICT (alias) DO UPDATE SET ingredient_name_uuid = EXCLUDED.ingredient_name_uuid`
+
+	// The queryMerge* statements move everything that refers to ingredient $1
+	// over to ingredient $2. Where both have a pantry item, the quantities are
+	// added up if they are kept in the same unit type; where both have a
+	// density, $2's is kept.
+	queryMergeStepIngredients = `UPDATE step_ingredients SET ingredient_name_uuid = $2 WHERE ingredient_name_uuid = $1`
+
+	queryMergeShoppingListItems = `UPDATE shopping_list_items SET ingredient_name_uuid = $2 WHERE ingredient_name_uuid = $1`
+
+	queryMergePantryItems = `
+		INSERT INTO pantry_items (user_uuid, ingredient_name_uuid, ingredient_type, base_quantity)
+		SELECT user_uuid, $2, ingredient_type, base_quantity
+		FROM pantry_items WHERE ingredient_name_uuid = $1
+		ON CONFLICT (user_uuid, ingredient_name_uuid) DO UPDATE SET
+			base_quantity = CASE WHEN pantry_items.ingredient_type = EXCLUDED.ingredient_type
+				THEN pantry_items.base_quantity + EXCLUDED.base_quantity
+				ELSE pantry_items.base_quantity END,
+			updated_at = NOW()`
+
+	queryMergeIngredientDensities = `
+		INSERT INTO ingredient_densities (ingredient_name_uuid, grams_per_ml)
+		SELECT $2, grams_per_ml FROM ingredient_densities WHERE ingredient_name_uuid = $1
+		ON CONFLICT (ingredient_name_uuid) DO NOTHING`
+
+	queryMergeUserIngredientDensities = `
+		INSERT INTO user_ingredient_densities (user_uuid, ingredient_name_uuid, grams_per_ml)
+		SELECT user_uuid, $2, grams_per_ml FROM user_ingredient_densities WHERE ingredient_name_uuid = $1
+		ON CONFLICT (user_uuid, ingredient_name_uuid) DO NOTHING`
+
+	queryMergeAisle = `
+		UPDATE ingredient_names t
+		SET aisle = COALESCE(t.aisle, s.aisle), updated_at = NOW()
+		FROM ingredient_names s
+		WHERE t.uuid = $2 AND s.uuid = $1`
+
+	queryMergeIngredientAliases = `UPDATE ingredient_aliases SET ingredient_name_uuid = $2 WHERE ingredient_name_uuid = $1`
+)
+
+// rowQuerier runs single-row queries, on the pool or in a transaction
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// resolveIngredientName returns the ingredient a name refers to, adding it to
+// the catalog under its canonical name if it is new
+func resolveIngredientName(ctx context.Context, q rowQuerier, name string) (uuid.UUID, error) {
+	var ingredientUUID uuid.UUID
+	err := q.QueryRow(ctx, queryResolveIngredientName, ingredients.Key(name), ingredients.Name(name)).Scan(&ingredientUUID)
+	return ingredientUUID, err
+}
+
+// SetIngredientAlias points the canonical key of name at an ingredient
+func SetIngredientAlias(name string, ingredientUUID uuid.UUID) error {
+	_, err := db.Exec(context.Background(), querySetIngredientAlias, ingredients.Key(name), ingredientUUID)
+	return err
+}
+
+func GetIngredientAliases(ingredientUUID uuid.UUID) ([]string, error) {
+	rows, err := db.Query(context.Background(),
+		`SELECT alias FROM ingredient_aliases WHERE ingredient_name_uuid = $1 ORDER BY alias`, ingredientUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var aliases []string
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, rows.Err()
+}
+
+// RenameIngredientName changes how an ingredient is written in the catalog
+func RenameIngredientName(ingredientUUID uuid.UUID, name string) error {
+	_, err := db.Exec(context.Background(),
+		`UPDATE ingredient_names SET name = $2, updated_at = NOW() WHERE uuid = $1`, ingredientUUID, name)
+	return err
+}
+
+// MergeIngredientNames folds the source ingredient into the target: recipes,
+// shopping lists, pantries, densities and aliases that used the source use
+// the target instead, the source's name becomes an alias of the target, and
+// the source is deleted. It returns sql.ErrNoRows if either doesn't exist.
+func MergeIngredientNames(sourceUUID, targetUUID uuid.UUID) (*models.IngredientName, error) {
+	if sourceUUID == targetUUID {
+		return nil, ErrSameIngredient
+	}
+
+	ctx := context.Background()
+	t, err := BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer t.Rollback(ctx)
+
+	var sourceName string
+	if err := t.tx.QueryRow(ctx,
+		`SELECT name FROM ingredient_names WHERE uuid = $1 FOR UPDATE`, sourceUUID).Scan(&sourceName); err != nil {
+		return nil, err
+	}
+	var target models.IngredientName
+	if err := t.tx.QueryRow(ctx,
+		`SELECT uuid, name, created_at, updated_at FROM ingredient_names WHERE uuid = $1 FOR UPDATE`, targetUUID).Scan(
+		&target.UUID, &target.Name, &target.CreatedAt, &target.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	for _, query := range []string{
+		queryMergeStepIngredients,
+		queryMergeShoppingListItems,
+		queryMergePantryItems,
+		queryMergeIngredientDensities,
+		queryMergeUserIngredientDensities,
+		queryMergeAisle,
+		queryMergeIngredientAliases,
+	} {
+		if _, err := t.tx.Exec(ctx, query, sourceUUID, targetUUID); err != nil {
+			return nil, fmt.Errorf("failed to merge ingredient: %w", err)
+		}
+	}
+	if _, err := t.tx.Exec(ctx, querySetIngredientAlias, ingredients.Key(sourceName), targetUUID); err != nil {
+		return nil, fmt.Errorf("failed to alias ingredient: %w", err)
+	}
+	if _, err := t.tx.Exec(ctx, `DELETE FROM ingredient_names WHERE uuid = $1`, sourceUUID); err != nil {
+		return nil, fmt.Errorf("failed to delete ingredient: %w", err)
+	}
+
+	if err := t.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &target, nil
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cobyabrahams/hungr/models"
+)
+
+func TestUpsertIngredientName_Canonicalizes(t *testing.T) {
+	first, err := UpsertIngredientName("  Large Quuxfruits ")
+	if err != nil {
+		t.Fatalf("UpsertIngredientName failed: %v", err)
+	}
+	defer DeleteIngredientName(first.UUID)
+	if first.Name != "quuxfruits" {
+		t.Errorf("Expected the name to be catalogued as quuxfruits, got %q", first.Name)
+	}
+
+	for _, variant := range []string{"quuxfruit", "QUUXFRUITS", "ripe quuxfruit "} {
+		same, err := UpsertIngredientName(variant)
+		if err != nil {
+			t.Fatalf("UpsertIngredientName(%q) failed: %v", variant, err)
+		}
+		if same.UUID != first.UUID {
+			t.Errorf("Expected %q to be the same ingredient as %q, got %q", variant, first.Name, same.Name)
+		}
+	}
+
+	found, err := GetIngredientNameByName("Quuxfruit")
+	if err != nil || found.UUID != first.UUID {
+		t.Errorf("Expected Quuxfruit to find %q, got %+v, %v", first.Name, found, err)
+	}
+}
+
+func TestMergeIngredientNames(t *testing.T) {
+	ensureTestUser(t)
+	user, _ := GetUserByEmail(testEmail)
+
+	// Names created before aliases existed aren't canonical
+	source, err := CreateIngredientName("Quuxplums")
+	if err != nil {
+		t.Fatalf("CreateIngredientName failed: %v", err)
+	}
+	defer DeleteIngredientName(source.UUID)
+	target, err := UpsertIngredientName("quuxplum")
+	if err != nil {
+		t.Fatalf("UpsertIngredientName failed: %v", err)
+	}
+	defer DeleteIngredientName(target.UUID)
+
+	recipe, err := InsertRecipeByEmail("ingredient-merge-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("InsertRecipeByEmail failed: %v", err)
+	}
+	defer PurgeRecipe(recipe.UUID)
+	step, err := CreateRecipeStep(recipe.UUID, 1, "Slice")
+	if err != nil {
+		t.Fatalf("CreateRecipeStep failed: %v", err)
+	}
+	if _, err := CreateStepIngredientWithUnit(step.UUID, source.UUID, "Quuxplums", "count", 3); err != nil {
+		t.Fatalf("CreateStepIngredientWithUnit failed: %v", err)
+	}
+
+	// SetPantryItem would find quuxplum by its alias, so the source's pantry
+	// item goes in directly
+	count, quantity := models.UnitCount, 2.0
+	if err := SetPantryItem(user.UUID, "quuxplum", &count, &quantity); err != nil {
+		t.Fatalf("SetPantryItem failed: %v", err)
+	}
+	defer DeletePantryItem(user.UUID, "quuxplum")
+	if _, err := db.Exec(context.Background(),
+		`INSERT INTO pantry_items (user_uuid, ingredient_name_uuid, ingredient_type, base_quantity) VALUES ($1, $2, 'count', 3)`,
+		user.UUID, source.UUID); err != nil {
+		t.Fatalf("Failed to add pantry item: %v", err)
+	}
+
+	merged, err := MergeIngredientNames(source.UUID, target.UUID)
+	if err != nil {
+		t.Fatalf("MergeIngredientNames failed: %v", err)
+	}
+	if merged.UUID != target.UUID {
+		t.Errorf("Expected to keep %s, got %s", target.UUID, merged.UUID)
+	}
+
+	ingredients, err := GetAllIngredientsForRecipe(recipe.UUID)
+	if err != nil {
+		t.Fatalf("GetAllIngredientsForRecipe failed: %v", err)
+	}
+	if len(ingredients) != 1 || ingredients[0].IngredientNameUUID != target.UUID || ingredients[0].IngredientName != "Quuxplums" {
+		t.Errorf("Expected the recipe to use quuxplum, still written as Quuxplums, got %+v", ingredients)
+	}
+
+	items, err := GetPantryItems(user.UUID)
+	if err != nil {
+		t.Fatalf("GetPantryItems failed: %v", err)
+	}
+	if item := findPantryItem(items, "quuxplum"); item == nil || item.BaseQuantity != 5 {
+		t.Errorf("Expected the pantry quantities to be added up, got %+v", item)
+	}
+
+	if _, err := GetIngredientNameByUUID(source.UUID); err == nil {
+		t.Error("Expected the merged ingredient to be deleted")
+	}
+	if found, err := GetIngredientNameByName("Quuxplums"); err != nil || found.UUID != target.UUID {
+		t.Errorf("Expected Quuxplums to find quuxplum, got %+v, %v", found, err)
+	}
+
+	if _, err := MergeIngredientNames(target.UUID, target.UUID); !errors.Is(err, ErrSameIngredient) {
+		t.Errorf("Expected ErrSameIngredient, got %v", err)
+	}
+}
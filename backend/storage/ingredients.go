@@ -2,8 +2,10 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/cobyabrahams/hungr/ingredients"
 	"github.com/cobyabrahams/hungr/models"
 	"github.com/cobyabrahams/hungr/units"
 	"github.com/gofrs/uuid"
@@ -23,11 +25,17 @@ func GetIngredientNameByUUID(ingredientUUID uuid.UUID) (*models.IngredientName,
 	return &i, nil
 }
 
+// GetIngredientNameByName finds the ingredient with exactly this name, or else
+// the one the name is an alias of
 func GetIngredientNameByName(name string) (*models.IngredientName, error) {
 	var i models.IngredientName
 	err := db.QueryRow(context.Background(),
-		`SELECT uuid, name, created_at, updated_at
-		 FROM ingredient_names WHERE name = $1`, name).Scan(
+		`SELECT n.uuid, n.name, n.created_at, n.updated_at
+		 FROM ingredient_names n
+		 LEFT JOIN ingredient_aliases a ON a.ingredient_name_uuid = n.uuid AND a.alias = $2
+		 WHERE a.alias IS NOT NULL OR n.name = $1
+		 ORDER BY n.name <> $1
+		 LIMIT 1`, name, ingredients.Key(name)).Scan(
 		&i.UUID, &i.Name, &i.CreatedAt, &i.UpdatedAt)
 	if err != nil {
 		return nil, err
@@ -48,18 +56,15 @@ func CreateIngredientName(name string) (*models.IngredientName, error) {
 	return &i, nil
 }
 
+// UpsertIngredientName returns the ingredient a name refers to, adding it to
+// the catalog if it is new
 func UpsertIngredientName(name string) (*models.IngredientName, error) {
-	var i models.IngredientName
-	err := db.QueryRow(context.Background(),
-		`INSERT INTO ingredient_names (name)
-		 VALUES ($1)
-		 ON CONFLICT (name) DO UPDATE SET updated_at = NOW()
-		 RETURNING uuid, name, created_at, updated_at`, name).Scan(
-		&i.UUID, &i.Name, &i.CreatedAt, &i.UpdatedAt)
+	ctx := context.Background()
+	ingredientUUID, err := resolveIngredientName(ctx, db, name)
 	if err != nil {
 		return nil, err
 	}
-	return &i, nil
+	return GetIngredientNameByUUID(ingredientUUID)
 }
 
 func ListIngredientNames() ([]models.IngredientName, error) {
@@ -93,9 +98,9 @@ func DeleteIngredientName(ingredientUUID uuid.UUID) error {
 func GetRecipeStepByUUID(stepUUID uuid.UUID) (*models.RecipeStep, error) {
 	var s models.RecipeStep
 	err := db.QueryRow(context.Background(),
-		`SELECT uuid, recipe_uuid, step_number, instructions, created_at, updated_at
+		`SELECT uuid, recipe_uuid, step_number, instructions, group_title, created_at, updated_at
 		 FROM recipe_steps WHERE uuid = $1`, stepUUID).Scan(
-		&s.UUID, &s.RecipeUUID, &s.StepNumber, &s.Instructions, &s.CreatedAt, &s.UpdatedAt)
+		&s.UUID, &s.RecipeUUID, &s.StepNumber, &s.Instructions, &s.GroupTitle, &s.CreatedAt, &s.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -104,7 +109,7 @@ func GetRecipeStepByUUID(stepUUID uuid.UUID) (*models.RecipeStep, error) {
 
 func GetRecipeStepsByRecipeUUID(recipeUUID uuid.UUID) ([]models.RecipeStep, error) {
 	rows, err := db.Query(context.Background(),
-		`SELECT uuid, recipe_uuid, step_number, instructions, created_at, updated_at
+		`SELECT uuid, recipe_uuid, step_number, instructions, group_title, created_at, updated_at
 		 FROM recipe_steps WHERE recipe_uuid = $1
 		 ORDER BY step_number`, recipeUUID)
 	if err != nil {
@@ -115,7 +120,7 @@ func GetRecipeStepsByRecipeUUID(recipeUUID uuid.UUID) ([]models.RecipeStep, erro
 	var steps []models.RecipeStep
 	for rows.Next() {
 		var s models.RecipeStep
-		if err := rows.Scan(&s.UUID, &s.RecipeUUID, &s.StepNumber, &s.Instructions, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		if err := rows.Scan(&s.UUID, &s.RecipeUUID, &s.StepNumber, &s.Instructions, &s.GroupTitle, &s.CreatedAt, &s.UpdatedAt); err != nil {
 			return nil, err
 		}
 		steps = append(steps, s)
@@ -128,9 +133,9 @@ func CreateRecipeStep(recipeUUID uuid.UUID, stepNumber int, instructions string)
 	err := db.QueryRow(context.Background(),
 		`INSERT INTO recipe_steps (recipe_uuid, step_number, instructions)
 		 VALUES ($1, $2, $3)
-		 RETURNING uuid, recipe_uuid, step_number, instructions, created_at, updated_at`,
+		 RETURNING uuid, recipe_uuid, step_number, instructions, group_title, created_at, updated_at`,
 		recipeUUID, stepNumber, instructions).Scan(
-		&s.UUID, &s.RecipeUUID, &s.StepNumber, &s.Instructions, &s.CreatedAt, &s.UpdatedAt)
+		&s.UUID, &s.RecipeUUID, &s.StepNumber, &s.Instructions, &s.GroupTitle, &s.CreatedAt, &s.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -142,9 +147,9 @@ func UpdateRecipeStep(stepUUID uuid.UUID, stepNumber int, instructions string) (
 	err := db.QueryRow(context.Background(),
 		`UPDATE recipe_steps SET step_number = $1, instructions = $2, updated_at = NOW()
 		 WHERE uuid = $3
-		 RETURNING uuid, recipe_uuid, step_number, instructions, created_at, updated_at`,
+		 RETURNING uuid, recipe_uuid, step_number, instructions, group_title, created_at, updated_at`,
 		stepNumber, instructions, stepUUID).Scan(
-		&s.UUID, &s.RecipeUUID, &s.StepNumber, &s.Instructions, &s.CreatedAt, &s.UpdatedAt)
+		&s.UUID, &s.RecipeUUID, &s.StepNumber, &s.Instructions, &s.GroupTitle, &s.CreatedAt, &s.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -168,9 +173,9 @@ func DeleteRecipeStepsByRecipeUUID(recipeUUID uuid.UUID) error {
 func GetStepIngredientByUUID(ingredientUUID uuid.UUID) (*models.StepIngredient, error) {
 	var si models.StepIngredient
 	err := db.QueryRow(context.Background(),
-		`SELECT uuid, recipe_step_uuid, ingredient_name_uuid, ingredient_type, quantity, created_at, updated_at
+		`SELECT uuid, recipe_step_uuid, ingredient_name_uuid, ingredient_type, quantity, quantity_max, quantity_text, preparation, optional, original_text, sub_recipe_uuid, created_at, updated_at
 		 FROM step_ingredients WHERE uuid = $1`, ingredientUUID).Scan(
-		&si.UUID, &si.RecipeStepUUID, &si.IngredientNameUUID, &si.IngredientType, &si.Quantity, &si.CreatedAt, &si.UpdatedAt)
+		&si.UUID, &si.RecipeStepUUID, &si.IngredientNameUUID, &si.IngredientType, &si.Quantity, &si.QuantityMax, &si.QuantityText, &si.Preparation, &si.Optional, &si.OriginalText, &si.SubRecipeUUID, &si.CreatedAt, &si.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -179,7 +184,7 @@ func GetStepIngredientByUUID(ingredientUUID uuid.UUID) (*models.StepIngredient,
 
 func GetStepIngredientsByStepUUID(stepUUID uuid.UUID) ([]models.StepIngredient, error) {
 	rows, err := db.Query(context.Background(),
-		`SELECT uuid, recipe_step_uuid, ingredient_name_uuid, ingredient_type, quantity, created_at, updated_at
+		`SELECT uuid, recipe_step_uuid, ingredient_name_uuid, ingredient_type, quantity, quantity_max, quantity_text, preparation, optional, original_text, sub_recipe_uuid, created_at, updated_at
 		 FROM step_ingredients WHERE recipe_step_uuid = $1`, stepUUID)
 	if err != nil {
 		return nil, err
@@ -189,7 +194,7 @@ func GetStepIngredientsByStepUUID(stepUUID uuid.UUID) ([]models.StepIngredient,
 	var ingredients []models.StepIngredient
 	for rows.Next() {
 		var si models.StepIngredient
-		if err := rows.Scan(&si.UUID, &si.RecipeStepUUID, &si.IngredientNameUUID, &si.IngredientType, &si.Quantity, &si.CreatedAt, &si.UpdatedAt); err != nil {
+		if err := rows.Scan(&si.UUID, &si.RecipeStepUUID, &si.IngredientNameUUID, &si.IngredientType, &si.Quantity, &si.QuantityMax, &si.QuantityText, &si.Preparation, &si.Optional, &si.OriginalText, &si.SubRecipeUUID, &si.CreatedAt, &si.UpdatedAt); err != nil {
 			return nil, err
 		}
 		ingredients = append(ingredients, si)
@@ -199,7 +204,7 @@ func GetStepIngredientsByStepUUID(stepUUID uuid.UUID) ([]models.StepIngredient,
 
 func GetStepIngredientsWithNamesByStepUUID(stepUUID uuid.UUID) ([]models.StepIngredientWithName, error) {
 	rows, err := db.Query(context.Background(),
-		`SELECT si.uuid, si.recipe_step_uuid, si.ingredient_name_uuid, si.ingredient_type, si.quantity, si.created_at, si.updated_at, n.name
+		`SELECT si.uuid, si.recipe_step_uuid, si.ingredient_name_uuid, si.ingredient_type, si.quantity, si.quantity_max, si.quantity_text, si.preparation, si.optional, si.original_text, si.sub_recipe_uuid, si.created_at, si.updated_at, COALESCE(si.name, n.name)
 		 FROM step_ingredients si
 		 JOIN ingredient_names n ON si.ingredient_name_uuid = n.uuid
 		 WHERE si.recipe_step_uuid = $1`, stepUUID)
@@ -211,7 +216,7 @@ func GetStepIngredientsWithNamesByStepUUID(stepUUID uuid.UUID) ([]models.StepIng
 	var ingredients []models.StepIngredientWithName
 	for rows.Next() {
 		var si models.StepIngredientWithName
-		if err := rows.Scan(&si.UUID, &si.RecipeStepUUID, &si.IngredientNameUUID, &si.IngredientType, &si.Quantity, &si.CreatedAt, &si.UpdatedAt, &si.IngredientName); err != nil {
+		if err := rows.Scan(&si.UUID, &si.RecipeStepUUID, &si.IngredientNameUUID, &si.IngredientType, &si.Quantity, &si.QuantityMax, &si.QuantityText, &si.Preparation, &si.Optional, &si.OriginalText, &si.SubRecipeUUID, &si.CreatedAt, &si.UpdatedAt, &si.IngredientName); err != nil {
 			return nil, err
 		}
 		ingredients = append(ingredients, si)
@@ -219,21 +224,23 @@ func GetStepIngredientsWithNamesByStepUUID(stepUUID uuid.UUID) ([]models.StepIng
 	return ingredients, rows.Err()
 }
 
-func CreateStepIngredient(stepUUID, ingredientNameUUID uuid.UUID, ingredientType models.IngredientUnit, quantity float64) (*models.StepIngredient, error) {
+// CreateStepIngredient adds an ingredient to a step. name is how the recipe
+// writes it; if empty, the catalog's name is shown.
+func CreateStepIngredient(stepUUID, ingredientNameUUID uuid.UUID, name string, ingredientType models.IngredientUnit, quantity float64) (*models.StepIngredient, error) {
 	var si models.StepIngredient
 	err := db.QueryRow(context.Background(),
-		`INSERT INTO step_ingredients (recipe_step_uuid, ingredient_name_uuid, ingredient_type, quantity)
-		 VALUES ($1, $2, $3, $4)
-		 RETURNING uuid, recipe_step_uuid, ingredient_name_uuid, ingredient_type, quantity, created_at, updated_at`,
-		stepUUID, ingredientNameUUID, ingredientType, quantity).Scan(
-		&si.UUID, &si.RecipeStepUUID, &si.IngredientNameUUID, &si.IngredientType, &si.Quantity, &si.CreatedAt, &si.UpdatedAt)
+		`INSERT INTO step_ingredients (recipe_step_uuid, ingredient_name_uuid, name, ingredient_type, quantity)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING uuid, recipe_step_uuid, ingredient_name_uuid, ingredient_type, quantity, quantity_max, quantity_text, preparation, optional, original_text, sub_recipe_uuid, created_at, updated_at`,
+		stepUUID, ingredientNameUUID, nullIfEmpty(name), ingredientType, quantity).Scan(
+		&si.UUID, &si.RecipeStepUUID, &si.IngredientNameUUID, &si.IngredientType, &si.Quantity, &si.QuantityMax, &si.QuantityText, &si.Preparation, &si.Optional, &si.OriginalText, &si.SubRecipeUUID, &si.CreatedAt, &si.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return &si, nil
 }
 
-func CreateStepIngredientWithUnit(stepUUID, ingredientNameUUID uuid.UUID, unit string, quantity float64) (*models.StepIngredient, error) {
+func CreateStepIngredientWithUnit(stepUUID, ingredientNameUUID uuid.UUID, name, unit string, quantity float64) (*models.StepIngredient, error) {
 	unitKey, category, err := units.ParseUnit(unit)
 	if err != nil {
 		return nil, fmt.Errorf("invalid unit %q: %w", unit, err)
@@ -256,7 +263,7 @@ func CreateStepIngredientWithUnit(stepUUID, ingredientNameUUID uuid.UUID, unit s
 		return nil, fmt.Errorf("unknown unit category: %s", category)
 	}
 
-	return CreateStepIngredient(stepUUID, ingredientNameUUID, ingredientType, baseValue)
+	return CreateStepIngredient(stepUUID, ingredientNameUUID, name, ingredientType, baseValue)
 }
 
 func UpdateStepIngredient(ingredientUUID uuid.UUID, ingredientType models.IngredientUnit, quantity float64) (*models.StepIngredient, error) {
@@ -264,9 +271,9 @@ func UpdateStepIngredient(ingredientUUID uuid.UUID, ingredientType models.Ingred
 	err := db.QueryRow(context.Background(),
 		`UPDATE step_ingredients SET ingredient_type = $1, quantity = $2, updated_at = NOW()
 		 WHERE uuid = $3
-		 RETURNING uuid, recipe_step_uuid, ingredient_name_uuid, ingredient_type, quantity, created_at, updated_at`,
+		 RETURNING uuid, recipe_step_uuid, ingredient_name_uuid, ingredient_type, quantity, quantity_max, quantity_text, preparation, optional, original_text, sub_recipe_uuid, created_at, updated_at`,
 		ingredientType, quantity, ingredientUUID).Scan(
-		&si.UUID, &si.RecipeStepUUID, &si.IngredientNameUUID, &si.IngredientType, &si.Quantity, &si.CreatedAt, &si.UpdatedAt)
+		&si.UUID, &si.RecipeStepUUID, &si.IngredientNameUUID, &si.IngredientType, &si.Quantity, &si.QuantityMax, &si.QuantityText, &si.Preparation, &si.Optional, &si.OriginalText, &si.SubRecipeUUID, &si.CreatedAt, &si.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -310,12 +317,12 @@ func GetRecipeStepsWithIngredients(recipeUUID uuid.UUID) ([]models.RecipeStepWit
 // GetAllIngredientsForRecipe returns all ingredients across all steps for a recipe
 func GetAllIngredientsForRecipe(recipeUUID uuid.UUID) ([]models.StepIngredientWithName, error) {
 	rows, err := db.Query(context.Background(),
-		`SELECT si.uuid, si.recipe_step_uuid, si.ingredient_name_uuid, si.ingredient_type, si.quantity, si.created_at, si.updated_at, n.name
+		`SELECT si.uuid, si.recipe_step_uuid, si.ingredient_name_uuid, si.ingredient_type, si.quantity, si.quantity_max, si.quantity_text, si.preparation, si.optional, si.original_text, si.sub_recipe_uuid, si.created_at, si.updated_at, COALESCE(si.name, n.name)
 		 FROM step_ingredients si
 		 JOIN ingredient_names n ON si.ingredient_name_uuid = n.uuid
 		 JOIN recipe_steps rs ON si.recipe_step_uuid = rs.uuid
 		 WHERE rs.recipe_uuid = $1
-		 ORDER BY rs.step_number, n.name`, recipeUUID)
+		 ORDER BY rs.step_number, COALESCE(si.name, n.name)`, recipeUUID)
 	if err != nil {
 		return nil, err
 	}
@@ -324,7 +331,7 @@ func GetAllIngredientsForRecipe(recipeUUID uuid.UUID) ([]models.StepIngredientWi
 	var ingredients []models.StepIngredientWithName
 	for rows.Next() {
 		var si models.StepIngredientWithName
-		if err := rows.Scan(&si.UUID, &si.RecipeStepUUID, &si.IngredientNameUUID, &si.IngredientType, &si.Quantity, &si.CreatedAt, &si.UpdatedAt, &si.IngredientName); err != nil {
+		if err := rows.Scan(&si.UUID, &si.RecipeStepUUID, &si.IngredientNameUUID, &si.IngredientType, &si.Quantity, &si.QuantityMax, &si.QuantityText, &si.Preparation, &si.Optional, &si.OriginalText, &si.SubRecipeUUID, &si.CreatedAt, &si.UpdatedAt, &si.IngredientName); err != nil {
 			return nil, err
 		}
 		ingredients = append(ingredients, si)
@@ -332,8 +339,15 @@ func GetAllIngredientsForRecipe(recipeUUID uuid.UUID) ([]models.StepIngredientWi
 	return ingredients, rows.Err()
 }
 
+// ErrSubRecipeCycle is returned when saving steps would make a recipe include
+// itself, directly or through its sub-recipes
+var ErrSubRecipeCycle = errors.New("sub-recipe cycle")
+
 type StepInput struct {
 	Instruction string
+	// GroupTitle names the section of the recipe the step belongs to, e.g.
+	// "For the dough"
+	GroupTitle  string
 	Ingredients []IngredientInput
 }
 
@@ -341,19 +355,37 @@ type IngredientInput struct {
 	Name     string
 	Unit     string
 	Quantity float64
+	// QuantityMax is the upper end of a range in Unit, or 0
+	QuantityMax  float64
+	QuantityText string
+	Preparation  string
+	Optional     bool
+	OriginalText string
+	// RecipeUUID makes the ingredient a sub-recipe, e.g. "1 batch pizza dough"
+	RecipeUUID *uuid.UUID
 }
 
 // ReplaceRecipeSteps deletes all existing steps and creates new ones
 func ReplaceRecipeSteps(recipeUUID uuid.UUID, steps []StepInput) error {
 	ctx := context.Background()
-	tx, err := db.Begin(ctx)
+	tx, err := BeginTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
+	if err := TxReplaceRecipeSteps(ctx, tx, recipeUUID, steps); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// TxReplaceRecipeSteps replaces a recipe's steps within a transaction
+func TxReplaceRecipeSteps(ctx context.Context, t *Tx, recipeUUID uuid.UUID, steps []StepInput) error {
+	tx := t.tx
+
 	// Delete existing steps (cascades to ingredients)
-	_, err = tx.Exec(ctx, `DELETE FROM recipe_steps WHERE recipe_uuid = $1`, recipeUUID)
+	_, err := tx.Exec(ctx, `DELETE FROM recipe_steps WHERE recipe_uuid = $1`, recipeUUID)
 	if err != nil {
 		return fmt.Errorf("failed to delete existing steps: %w", err)
 	}
@@ -362,22 +394,16 @@ func ReplaceRecipeSteps(recipeUUID uuid.UUID, steps []StepInput) error {
 	for i, step := range steps {
 		var stepUUID uuid.UUID
 		err := tx.QueryRow(ctx,
-			`INSERT INTO recipe_steps (recipe_uuid, step_number, instructions)
-			 VALUES ($1, $2, $3) RETURNING uuid`,
-			recipeUUID, i+1, step.Instruction).Scan(&stepUUID)
+			`INSERT INTO recipe_steps (recipe_uuid, step_number, instructions, group_title)
+			 VALUES ($1, $2, $3, $4) RETURNING uuid`,
+			recipeUUID, i+1, step.Instruction, nullIfEmpty(step.GroupTitle)).Scan(&stepUUID)
 		if err != nil {
 			return fmt.Errorf("failed to create step %d: %w", i+1, err)
 		}
 
 		// Create ingredients for this step
 		for _, ing := range step.Ingredients {
-			// Upsert ingredient name
-			var ingredientNameUUID uuid.UUID
-			err := tx.QueryRow(ctx,
-				`INSERT INTO ingredient_names (name)
-				 VALUES ($1)
-				 ON CONFLICT (name) DO UPDATE SET updated_at = NOW()
-				 RETURNING uuid`, ing.Name).Scan(&ingredientNameUUID)
+			ingredientNameUUID, err := resolveIngredientName(ctx, tx, ing.Name)
 			if err != nil {
 				return fmt.Errorf("failed to upsert ingredient name %q: %w", ing.Name, err)
 			}
@@ -398,15 +424,59 @@ func ReplaceRecipeSteps(recipeUUID uuid.UUID, steps []StepInput) error {
 				ingredientType = models.UnitCount
 			}
 
+			var baseMax *float64
+			if ing.QuantityMax > 0 {
+				max, _, err := units.ToBaseUnit(ing.QuantityMax, ing.Unit)
+				if err != nil {
+					return fmt.Errorf("failed to convert unit %q: %w", ing.Unit, err)
+				}
+				baseMax = &max
+			}
+
 			_, err = tx.Exec(ctx,
-				`INSERT INTO step_ingredients (recipe_step_uuid, ingredient_name_uuid, ingredient_type, quantity)
-				 VALUES ($1, $2, $3, $4)`,
-				stepUUID, ingredientNameUUID, ingredientType, baseValue)
+				`INSERT INTO step_ingredients (recipe_step_uuid, ingredient_name_uuid, name, ingredient_type, quantity,
+				     quantity_max, quantity_text, preparation, optional, original_text, sub_recipe_uuid)
+				 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, (SELECT uuid FROM recipes WHERE uuid = $11))`,
+				stepUUID, ingredientNameUUID, nullIfEmpty(ing.Name), ingredientType, baseValue,
+				baseMax, nullIfEmpty(ing.QuantityText), nullIfEmpty(ing.Preparation), ing.Optional, nullIfEmpty(ing.OriginalText),
+				ing.RecipeUUID)
 			if err != nil {
 				return fmt.Errorf("failed to create ingredient: %w", err)
 			}
 		}
 	}
 
-	return tx.Commit(ctx)
+	var cycle bool
+	if err := tx.QueryRow(ctx, querySubRecipeCycle, recipeUUID).Scan(&cycle); err != nil {
+		return fmt.Errorf("failed to check sub-recipes: %w", err)
+	}
+	if cycle {
+		return ErrSubRecipeCycle
+	}
+	return nil
+}
+
+// querySubRecipeCycle reports whether a recipe is among its own sub-recipes,
+// following references to any depth. UNION stops at cycles that don't
+// involve the recipe.
+const querySubRecipeCycle = `
+	WITH RECURSIVE sub_recipes(uuid) AS (
+		SELECT si.sub_recipe_uuid
+		FROM step_ingredients si
+		JOIN recipe_steps rs ON rs.uuid = si.recipe_step_uuid
+		WHERE rs.recipe_uuid = $1 AND si.sub_recipe_uuid IS NOT NULL
+		UNION
+		SELECT si.sub_recipe_uuid
+		FROM sub_recipes sr
+		JOIN recipe_steps rs ON rs.recipe_uuid = sr.uuid
+		JOIN step_ingredients si ON si.recipe_step_uuid = rs.uuid
+		WHERE si.sub_recipe_uuid IS NOT NULL
+	)
+	SELECT EXISTS (SELECT 1 FROM sub_recipes WHERE uuid = $1)`
+
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
 }
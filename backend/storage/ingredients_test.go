@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"errors"
 	"math"
 	"testing"
 
@@ -15,7 +16,7 @@ func TestCreateStepIngredientWithUnit(t *testing.T) {
 	if err != nil {
 		t.Fatalf("InsertRecipeByEmail failed: %v", err)
 	}
-	defer DeleteRecipe(recipe.UUID)
+	defer PurgeRecipe(recipe.UUID)
 
 	step, err := CreateRecipeStep(recipe.UUID, 1, "Mix ingredients")
 	if err != nil {
@@ -66,7 +67,7 @@ func TestCreateStepIngredientWithUnit(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			si, err := CreateStepIngredientWithUnit(step.UUID, ingredientName.UUID, tt.unit, tt.quantity)
+			si, err := CreateStepIngredientWithUnit(step.UUID, ingredientName.UUID, "flour", tt.unit, tt.quantity)
 			if err != nil {
 				t.Fatalf("CreateStepIngredientWithUnit(%q, %f) failed: %v", tt.unit, tt.quantity, err)
 			}
@@ -91,7 +92,7 @@ func TestCreateStepIngredientWithUnit_InvalidUnit(t *testing.T) {
 	if err != nil {
 		t.Fatalf("InsertRecipeByEmail failed: %v", err)
 	}
-	defer DeleteRecipe(recipe.UUID)
+	defer PurgeRecipe(recipe.UUID)
 
 	step, err := CreateRecipeStep(recipe.UUID, 1, "Mix")
 	if err != nil {
@@ -103,7 +104,7 @@ func TestCreateStepIngredientWithUnit_InvalidUnit(t *testing.T) {
 		t.Fatalf("UpsertIngredientName failed: %v", err)
 	}
 
-	_, err = CreateStepIngredientWithUnit(step.UUID, ingredientName.UUID, "bogusunit", 1)
+	_, err = CreateStepIngredientWithUnit(step.UUID, ingredientName.UUID, "sugar", "bogusunit", 1)
 	if err == nil {
 		t.Error("expected error for invalid unit, got nil")
 	}
@@ -116,7 +117,7 @@ func TestComplexRecipeWithIngredients(t *testing.T) {
 	if err != nil {
 		t.Fatalf("InsertRecipeByEmail failed: %v", err)
 	}
-	defer DeleteRecipe(recipe.UUID)
+	defer PurgeRecipe(recipe.UUID)
 
 	flour, _ := UpsertIngredientName("all-purpose flour")
 	sugar, _ := UpsertIngredientName("granulated sugar")
@@ -132,9 +133,9 @@ func TestComplexRecipeWithIngredients(t *testing.T) {
 		t.Fatalf("CreateRecipeStep failed: %v", err)
 	}
 
-	CreateStepIngredientWithUnit(step1.UUID, flour.UUID, "cups", 2)
-	CreateStepIngredientWithUnit(step1.UUID, sugar.UUID, "cup", 1)
-	CreateStepIngredientWithUnit(step1.UUID, salt.UUID, "tsp", 0.5)
+	CreateStepIngredientWithUnit(step1.UUID, flour.UUID, "all-purpose flour", "cups", 2)
+	CreateStepIngredientWithUnit(step1.UUID, sugar.UUID, "granulated sugar", "cup", 1)
+	CreateStepIngredientWithUnit(step1.UUID, salt.UUID, "salt", "tsp", 0.5)
 
 	// Step 2: Wet ingredients
 	step2, err := CreateRecipeStep(recipe.UUID, 2, "Cream butter and mix wet ingredients")
@@ -142,10 +143,10 @@ func TestComplexRecipeWithIngredients(t *testing.T) {
 		t.Fatalf("CreateRecipeStep failed: %v", err)
 	}
 
-	CreateStepIngredientWithUnit(step2.UUID, butter.UUID, "oz", 4)
-	CreateStepIngredientWithUnit(step2.UUID, eggs.UUID, "pieces", 2)
-	CreateStepIngredientWithUnit(step2.UUID, vanilla.UUID, "tsp", 1)
-	CreateStepIngredientWithUnit(step2.UUID, milk.UUID, "cup", 0.5)
+	CreateStepIngredientWithUnit(step2.UUID, butter.UUID, "unsalted butter", "oz", 4)
+	CreateStepIngredientWithUnit(step2.UUID, eggs.UUID, "large eggs", "pieces", 2)
+	CreateStepIngredientWithUnit(step2.UUID, vanilla.UUID, "vanilla extract", "tsp", 1)
+	CreateStepIngredientWithUnit(step2.UUID, milk.UUID, "whole milk", "cup", 0.5)
 
 	// Step 3: Combine and bake (no ingredients)
 	_, err = CreateRecipeStep(recipe.UUID, 3, "Fold wet into dry and bake at 350F for 30 minutes")
@@ -235,6 +236,65 @@ func TestComplexRecipeWithIngredients(t *testing.T) {
 	}
 }
 
+func TestReplaceRecipeSteps_SubRecipeCycle(t *testing.T) {
+	ensureTestUser(t)
+
+	var recipes [3]*models.Recipe
+	for i := range recipes {
+		recipe, err := InsertRecipeByEmail("sub-recipe-cycle-test", testEmail, nil)
+		if err != nil {
+			t.Fatalf("InsertRecipeByEmail failed: %v", err)
+		}
+		defer PurgeRecipe(recipe.UUID)
+		recipes[i] = recipe
+	}
+	a, b, c := recipes[0], recipes[1], recipes[2]
+
+	uses := func(sub *models.Recipe) []StepInput {
+		return []StepInput{{Instruction: "Mix", Ingredients: []IngredientInput{
+			{Name: "sub-recipe", Unit: "count", Quantity: 1, RecipeUUID: &sub.UUID},
+		}}}
+	}
+
+	// a uses b, and b uses c
+	if err := ReplaceRecipeSteps(a.UUID, uses(b)); err != nil {
+		t.Fatalf("ReplaceRecipeSteps failed: %v", err)
+	}
+	if err := ReplaceRecipeSteps(b.UUID, uses(c)); err != nil {
+		t.Fatalf("ReplaceRecipeSteps failed: %v", err)
+	}
+
+	tests := []struct {
+		desc    string
+		recipe  *models.Recipe
+		sub     *models.Recipe
+		wantErr error
+	}{
+		{"itself", a, a, ErrSubRecipeCycle},
+		{"direct cycle", b, a, ErrSubRecipeCycle},
+		{"indirect cycle", c, a, ErrSubRecipeCycle},
+		{"shared sub-recipe", a, c, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := ReplaceRecipeSteps(tt.recipe.UUID, uses(tt.sub))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReplaceRecipeSteps() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A rejected save leaves the steps as they were
+	steps, err := GetRecipeStepsWithIngredients(c.UUID)
+	if err != nil {
+		t.Fatalf("GetRecipeStepsWithIngredients failed: %v", err)
+	}
+	if len(steps) != 0 {
+		t.Errorf("Expected c to have no steps, got %d", len(steps))
+	}
+}
+
 func TestUnitConversionRoundTrip(t *testing.T) {
 	tests := []struct {
 		name     string
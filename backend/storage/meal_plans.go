@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	mealPlanColumns = `p.uuid, p.user_uuid, u.email, p.name, p.shared, p.created_at, p.updated_at`
+
+	queryCreateMealPlan = `
+		WITH p AS (
+			INSERT INTO meal_plans (user_uuid, name, shared)
+			VALUES ($1, $2, $3)
+			RETURNING *
+		)
+		SELECT ` + mealPlanColumns + `
+		FROM p JOIN users u ON u.uuid = p.user_uuid`
+
+	queryGetMealPlan = `
+		SELECT ` + mealPlanColumns + `
+		FROM meal_plans p JOIN users u ON u.uuid = p.user_uuid
+		WHERE p.uuid = $1`
+
+	// queryGetMealPlansForUser lists the user's own plans and the shared plans
+	// of users who have connected to them
+	queryGetMealPlansForUser = `
+		SELECT ` + mealPlanColumns + `
+		FROM meal_plans p JOIN users u ON u.uuid = p.user_uuid
+		WHERE p.user_uuid = $1
+			OR (p.shared AND EXISTS (
+				SELECT 1 FROM user_connections uc
+				WHERE uc.source_user_uuid = p.user_uuid
+					AND uc.target_user_uuid = $1
+			))
+		ORDER BY p.user_uuid <> $1, p.updated_at DESC`
+
+	queryUpdateMealPlan = `
+		WITH p AS (
+			UPDATE meal_plans
+			SET name = COALESCE($2, name), shared = COALESCE($3, shared), updated_at = NOW()
+			WHERE uuid = $1
+			RETURNING *
+		)
+		SELECT ` + mealPlanColumns + `
+		FROM p JOIN users u ON u.uuid = p.user_uuid`
+
+	mealPlanEntryColumns = `e.uuid, e.recipe_uuid, r.name, e.date::text, e.meal, e.servings, e.updated_at`
+
+	// queryGetMealPlanEntries lists a plan's entries between the dates $2 and
+	// $3, either of which may be NULL, by day and meal. Recipes in the trash
+	// are left out.
+	queryGetMealPlanEntries = `
+		SELECT ` + mealPlanEntryColumns + `
+		FROM meal_plan_entries e
+		JOIN recipes r ON r.uuid = e.recipe_uuid
+		WHERE e.meal_plan_uuid = $1
+			AND r.deleted_at IS NULL
+			AND ($2::date IS NULL OR e.date >= $2::date)
+			AND ($3::date IS NULL OR e.date <= $3::date)
+		ORDER BY e.date, array_position(ARRAY['breakfast', 'lunch', 'dinner'], e.meal), e.created_at`
+
+	queryAddMealPlanEntry = `
+		WITH e AS (
+			INSERT INTO meal_plan_entries (meal_plan_uuid, recipe_uuid, date, meal, servings)
+			VALUES ($1, $2, $3::date, $4, $5)
+			RETURNING *
+		)
+		SELECT ` + mealPlanEntryColumns + `
+		FROM e JOIN recipes r ON r.uuid = e.recipe_uuid`
+
+	queryUpdateMealPlanEntry = `
+		WITH e AS (
+			UPDATE meal_plan_entries
+			SET recipe_uuid = $3, date = $4::date, meal = $5, servings = $6, updated_at = NOW()
+			WHERE uuid = $2 AND meal_plan_uuid = $1
+			RETURNING *
+		)
+		SELECT ` + mealPlanEntryColumns + `
+		FROM e JOIN recipes r ON r.uuid = e.recipe_uuid`
+
+	queryTouchMealPlan = `UPDATE meal_plans SET updated_at = NOW() WHERE uuid = $1`
+)
+
+func scanMealPlan(row pgx.Row) (*models.MealPlan, error) {
+	var p models.MealPlan
+	if err := row.Scan(&p.UUID, &p.UserUUID, &p.OwnerEmail, &p.Name, &p.Shared, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func scanMealPlanEntry(row pgx.Row) (*models.MealPlanEntry, error) {
+	var e models.MealPlanEntry
+	if err := row.Scan(&e.UUID, &e.RecipeUUID, &e.RecipeName, &e.Date, &e.Meal, &e.Servings, &e.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// GetCalendarFeedKey returns the key the user's calendar feed URLs are signed
+// with
+func GetCalendarFeedKey(userUUID uuid.UUID) (uuid.UUID, error) {
+	var key uuid.UUID
+	err := db.QueryRow(context.Background(),
+		`SELECT calendar_feed_key FROM users WHERE uuid = $1`, userUUID).Scan(&key)
+	return key, err
+}
+
+// RegenerateCalendarFeedKey gives the user a new calendar feed key, so the
+// feed URLs signed with the old one stop working
+func RegenerateCalendarFeedKey(userUUID uuid.UUID) error {
+	_, err := db.Exec(context.Background(),
+		`UPDATE users SET calendar_feed_key = uuid_generate_v4() WHERE uuid = $1`, userUUID)
+	return err
+}
+
+func CreateMealPlan(userUUID uuid.UUID, name string, shared bool) (*models.MealPlan, error) {
+	return scanMealPlan(db.QueryRow(context.Background(), queryCreateMealPlan, userUUID, name, shared))
+}
+
+// GetMealPlan returns a plan whoever owns it; callers check access with authz
+func GetMealPlan(planUUID uuid.UUID) (*models.MealPlan, error) {
+	return scanMealPlan(db.QueryRow(context.Background(), queryGetMealPlan, planUUID))
+}
+
+// GetMealPlansForUser returns the user's own plans, most recently changed
+// first, followed by the plans shared with them
+func GetMealPlansForUser(userUUID uuid.UUID) ([]models.MealPlan, error) {
+	rows, err := db.Query(context.Background(), queryGetMealPlansForUser, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var plans []models.MealPlan
+	for rows.Next() {
+		p, err := scanMealPlan(rows)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, *p)
+	}
+	return plans, rows.Err()
+}
+
+// UpdateMealPlan renames a plan or changes whether it is shared, leaving nil
+// fields as they are
+func UpdateMealPlan(planUUID uuid.UUID, name *string, shared *bool) (*models.MealPlan, error) {
+	return scanMealPlan(db.QueryRow(context.Background(), queryUpdateMealPlan, planUUID, name, shared))
+}
+
+func DeleteMealPlan(planUUID uuid.UUID) error {
+	_, err := db.Exec(context.Background(), `DELETE FROM meal_plans WHERE uuid = $1`, planUUID)
+	return err
+}
+
+// GetMealPlanEntries returns a plan's entries from one date to another
+// (YYYY-MM-DD, inclusive), or without a bound where from or to is nil
+func GetMealPlanEntries(planUUID uuid.UUID, from, to *string) ([]models.MealPlanEntry, error) {
+	rows, err := db.Query(context.Background(), queryGetMealPlanEntries, planUUID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.MealPlanEntry
+	for rows.Next() {
+		e, err := scanMealPlanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *e)
+	}
+	return entries, rows.Err()
+}
+
+func AddMealPlanEntry(planUUID uuid.UUID, entry models.MealPlanEntry) (*models.MealPlanEntry, error) {
+	ctx := context.Background()
+	t, err := BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer t.Rollback(ctx)
+
+	added, err := scanMealPlanEntry(t.tx.QueryRow(ctx, queryAddMealPlanEntry,
+		planUUID, entry.RecipeUUID, entry.Date, entry.Meal, entry.Servings))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.tx.Exec(ctx, queryTouchMealPlan, planUUID); err != nil {
+		return nil, fmt.Errorf("failed to touch meal plan: %w", err)
+	}
+
+	if err := t.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return added, nil
+}
+
+// UpdateMealPlanEntry moves an entry to another recipe, day, meal or number of
+// servings, returning sql.ErrNoRows if the plan has no such entry
+func UpdateMealPlanEntry(planUUID, entryUUID uuid.UUID, entry models.MealPlanEntry) (*models.MealPlanEntry, error) {
+	ctx := context.Background()
+	t, err := BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer t.Rollback(ctx)
+
+	updated, err := scanMealPlanEntry(t.tx.QueryRow(ctx, queryUpdateMealPlanEntry,
+		planUUID, entryUUID, entry.RecipeUUID, entry.Date, entry.Meal, entry.Servings))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.tx.Exec(ctx, queryTouchMealPlan, planUUID); err != nil {
+		return nil, fmt.Errorf("failed to touch meal plan: %w", err)
+	}
+
+	if err := t.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return updated, nil
+}
+
+// DeleteMealPlanEntry removes an entry from a plan, reporting whether the plan
+// had it
+func DeleteMealPlanEntry(planUUID, entryUUID uuid.UUID) (bool, error) {
+	ctx := context.Background()
+	t, err := BeginTx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer t.Rollback(ctx)
+
+	tag, err := t.tx.Exec(ctx,
+		`DELETE FROM meal_plan_entries WHERE uuid = $2 AND meal_plan_uuid = $1`, planUUID, entryUUID)
+	if err != nil || tag.RowsAffected() == 0 {
+		return false, err
+	}
+	if _, err := t.tx.Exec(ctx, queryTouchMealPlan, planUUID); err != nil {
+		return false, fmt.Errorf("failed to touch meal plan: %w", err)
+	}
+
+	if err := t.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/gofrs/uuid"
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// RecipeSort is the order recipes are listed in
+type RecipeSort string
+
+const (
+	SortCreated    RecipeSort = "created"
+	SortName       RecipeSort = "name"
+	SortLastCooked RecipeSort = "last_cooked"
+	SortRating     RecipeSort = "rating"
+)
+
+type recipeSort struct {
+	expr    string
+	keyType string
+	dir     string
+}
+
+// compare returns the row comparison that selects rows after the cursor
+func (s recipeSort) compare() string {
+	if s.dir == "DESC" {
+		return "<"
+	}
+	return ">"
+}
+
+// Nullable columns are coalesced so keyset comparisons never see NULL; recipes
+// that were never cooked or rated sort last.
+var recipeSorts = map[RecipeSort]recipeSort{
+	SortCreated:    {expr: "r.created_at", keyType: "timestamptz", dir: "DESC"},
+	SortName:       {expr: "lower(r.name)", keyType: "text", dir: "ASC"},
+	SortLastCooked: {expr: "COALESCE(r.last_cooked_at, '-infinity')", keyType: "timestamptz", dir: "DESC"},
+	SortRating:     {expr: "COALESCE(r.rating, 0)", keyType: "int", dir: "DESC"},
+}
+
+// ParseRecipeSort validates a sort name; empty means SortCreated
+func ParseRecipeSort(s string) (RecipeSort, error) {
+	if s == "" {
+		return SortCreated, nil
+	}
+	if _, ok := recipeSorts[RecipeSort(s)]; !ok {
+		return "", ErrInvalidSort
+	}
+	return RecipeSort(s), nil
+}
+
+// RecipePage selects one page of a recipe listing
+type RecipePage struct {
+	Sort  RecipeSort
+	Tags  TagFilter
+	After *RecipeCursor
+	Limit int
+}
+
+// RecipeCursor is the position of the last recipe on a page. Clients only see
+// it encoded, and it is only valid for the sort it was issued for.
+type RecipeCursor struct {
+	Sort RecipeSort `json:"s"`
+	Key  string     `json:"k"`
+	UUID uuid.UUID  `json:"u"`
+}
+
+// Encode returns the opaque form of the cursor handed to clients
+func (c RecipeCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeRecipeCursor parses a cursor produced by Encode
+func DecodeRecipeCursor(s string) (*RecipeCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c RecipeCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if _, ok := recipeSorts[c.Sort]; !ok || c.UUID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
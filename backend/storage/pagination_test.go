@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/gofrs/uuid"
+)
+
+func TestRecipeCursorRoundTrip(t *testing.T) {
+	cursor := RecipeCursor{Sort: SortRating, Key: "4", UUID: uuid.Must(uuid.NewV4())}
+
+	decoded, err := DecodeRecipeCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("DecodeRecipeCursor failed: %v", err)
+	}
+	if *decoded != cursor {
+		t.Errorf("Expected %+v, got %+v", cursor, *decoded)
+	}
+}
+
+func TestDecodeRecipeCursor_Invalid(t *testing.T) {
+	unknownSort := RecipeCursor{Sort: "color", Key: "red", UUID: uuid.Must(uuid.NewV4())}
+	noUUID := RecipeCursor{Sort: SortName, Key: "soup"}
+
+	tests := []struct {
+		desc   string
+		cursor string
+	}{
+		{"empty", ""},
+		{"not base64", "!!!"},
+		{"not json", "bm90IGpzb24"},
+		{"unknown sort", unknownSort.Encode()},
+		{"missing uuid", noUUID.Encode()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if _, err := DecodeRecipeCursor(tt.cursor); err == nil {
+				t.Errorf("DecodeRecipeCursor(%q) expected error, got nil", tt.cursor)
+			}
+		})
+	}
+}
+
+func TestParseRecipeSort(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    RecipeSort
+		wantErr bool
+	}{
+		{"", SortCreated, false},
+		{"created", SortCreated, false},
+		{"name", SortName, false},
+		{"last_cooked", SortLastCooked, false},
+		{"rating", SortRating, false},
+		{"color", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseRecipeSort(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRecipeSort(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRecipeSort(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRecipesByUserEmail_Pagination(t *testing.T) {
+	ensureTestUser(t)
+
+	// Inserted oldest first; ratings and cooked state are deliberately not in
+	// insertion order
+	ratings := []int{3, 0, 5, 3, 1}
+	cooked := []bool{true, false, false, true, true}
+	var created []uuid.UUID
+	for i := range ratings {
+		recipe, err := InsertRecipeByEmail(fmt.Sprintf("pagination-test %d", i), testEmail, nil)
+		if err != nil {
+			t.Fatalf("InsertRecipeByEmail failed: %v", err)
+		}
+		defer PurgeRecipe(recipe.UUID)
+		created = append(created, recipe.UUID)
+
+		if ratings[i] > 0 {
+			if err := SetRecipeRating(recipe.UUID, &ratings[i]); err != nil {
+				t.Fatalf("SetRecipeRating failed: %v", err)
+			}
+		}
+		if cooked[i] {
+			if _, err := MarkRecipeCooked(recipe.UUID); err != nil {
+				t.Fatalf("MarkRecipeCooked failed: %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		sort RecipeSort
+		// want is the expected order of the test recipes, by insertion index
+		want []int
+	}{
+		{SortCreated, []int{4, 3, 2, 1, 0}},
+		{SortName, []int{0, 1, 2, 3, 4}},
+		{SortLastCooked, []int{4, 3, 0}},
+		{SortRating, []int{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.sort), func(t *testing.T) {
+			var seen []uuid.UUID
+			page := RecipePage{Sort: tt.sort, Limit: 2}
+			for {
+				recipes, next, err := GetRecipesByUserEmail(testEmail, page)
+				if err != nil {
+					t.Fatalf("GetRecipesByUserEmail failed: %v", err)
+				}
+				if len(recipes) > page.Limit {
+					t.Fatalf("Expected at most %d recipes, got %d", page.Limit, len(recipes))
+				}
+				for _, r := range recipes {
+					if slices.Contains(seen, r.UUID) {
+						t.Fatalf("Recipe %v returned on more than one page", r.UUID)
+					}
+					seen = append(seen, r.UUID)
+				}
+				if next == nil {
+					break
+				}
+				page.After = next
+			}
+
+			// Only compare the test recipes' relative order; other tests'
+			// recipes may be interleaved
+			var got []int
+			for _, id := range seen {
+				if i := slices.Index(created, id); i >= 0 {
+					got = append(got, i)
+				}
+			}
+			if len(got) != len(created) {
+				t.Fatalf("Expected all %d test recipes across pages, got %d", len(created), len(got))
+			}
+			if !slices.Equal(got[:len(tt.want)], tt.want) {
+				t.Errorf("Expected order %v to start with %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("cursor for another sort", func(t *testing.T) {
+		_, next, err := GetRecipesByUserEmail(testEmail, RecipePage{Sort: SortName, Limit: 1})
+		if err != nil || next == nil {
+			t.Fatalf("Expected a next cursor, got %v, %v", next, err)
+		}
+		_, _, err = GetRecipesByUserEmail(testEmail, RecipePage{Sort: SortRating, After: next, Limit: 1})
+		if err != ErrInvalidCursor {
+			t.Errorf("Expected ErrInvalidCursor, got %v", err)
+		}
+	})
+}
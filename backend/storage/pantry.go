@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cobyabrahams/hungr/ingredients"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/cobyabrahams/hungr/units"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	queryGetPantryItems = `
+		SELECT n.name, p.ingredient_type, p.base_quantity, p.updated_at
+		FROM pantry_items p
+		JOIN ingredient_names n ON n.uuid = p.ingredient_name_uuid
+		WHERE p.user_uuid = $1
+		ORDER BY n.name`
+
+	querySetPantryItem = `
+		INSERT INTO pantry_items (user_uuid, ingredient_name_uuid, ingredient_type, base_quantity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_uuid, ingredient_name_uuid)
+		DO UPDATE SET ingredient_type = EXCLUDED.ingredient_type, base_quantity = EXCLUDED.base_quantity, updated_at = NOW()`
+
+	queryDeletePantryItem = `
+		DELETE FROM pantry_items p
+		USING ingredient_names n
+		WHERE p.ingredient_name_uuid = n.uuid AND p.user_uuid = $1
+			AND (n.name = $2 OR n.uuid = (SELECT ingredient_name_uuid FROM ingredient_aliases WHERE alias = $3))`
+
+	// queryUseUpPantryItem removes an ingredient when a recipe uses all of
+	// it, and queryDeductPantryItem takes the amount used from what is left.
+	// Both only change quantities kept in the same unit type; a pantry item
+	// without a quantity is still on hand after cooking.
+	queryUseUpPantryItem = `
+		DELETE FROM pantry_items p
+		USING ingredient_names n
+		WHERE p.ingredient_name_uuid = n.uuid AND p.user_uuid = $1 AND n.name = $2
+			AND p.ingredient_type = $3 AND p.base_quantity <= $4`
+
+	queryDeductPantryItem = `
+		UPDATE pantry_items p
+		SET base_quantity = p.base_quantity - $4, updated_at = NOW()
+		FROM ingredient_names n
+		WHERE p.ingredient_name_uuid = n.uuid AND p.user_uuid = $1 AND n.name = $2
+			AND p.ingredient_type = $3`
+
+	// queryGetRecipeMatches ranks the recipes $1 can see (their own and those
+	// of users who have connected to them) by the share of their ingredients
+	// in $1's pantry. An ingredient is on hand when it is in the pantry
+	// without a quantity, in a different unit type, or with at least as much
+	// as the recipe uses. Optional ingredients and sub-recipes aren't counted.
+	queryGetRecipeMatches = `
+		WITH needs AS (
+			SELECT rs.recipe_uuid, si.ingredient_name_uuid, si.ingredient_type, SUM(si.quantity) AS quantity
+			FROM recipes r
+			JOIN recipe_steps rs ON rs.recipe_uuid = r.uuid
+			JOIN step_ingredients si ON si.recipe_step_uuid = rs.uuid
+			WHERE r.deleted_at IS NULL
+				AND (r.user_uuid = $1
+					OR EXISTS (
+						SELECT 1 FROM user_connections uc
+						WHERE uc.source_user_uuid = r.user_uuid
+							AND uc.target_user_uuid = $1
+					))
+				AND NOT si.optional
+				AND si.sub_recipe_uuid IS NULL
+			GROUP BY rs.recipe_uuid, si.ingredient_name_uuid, si.ingredient_type
+		),
+		coverage AS (
+			SELECT nd.recipe_uuid, nd.ingredient_name_uuid,
+			       BOOL_AND(p.user_uuid IS NOT NULL AND (p.base_quantity IS NULL
+			           OR p.ingredient_type <> nd.ingredient_type
+			           OR p.base_quantity >= nd.quantity)) AS have
+			FROM needs nd
+			LEFT JOIN pantry_items p ON p.user_uuid = $1 AND p.ingredient_name_uuid = nd.ingredient_name_uuid
+			GROUP BY nd.recipe_uuid, nd.ingredient_name_uuid
+		),
+		matches AS (
+			SELECT c.recipe_uuid, COUNT(*) AS total, COUNT(*) FILTER (WHERE c.have) AS have,
+			       COALESCE(ARRAY_AGG(n.name ORDER BY n.name) FILTER (WHERE NOT c.have), '{}') AS missing
+			FROM coverage c
+			JOIN ingredient_names n ON n.uuid = c.ingredient_name_uuid
+			GROUP BY c.recipe_uuid
+		)
+		SELECT r.uuid, r.name, r.user_uuid, r.source,
+		       COALESCE((
+		           SELECT STRING_AGG(t.name, ', ' ORDER BY rt.id)
+		           FROM recipe_tags rt JOIN tags t ON rt.tag_uuid = t.uuid
+		           WHERE rt.recipe_uuid = r.uuid), '') AS tag_string,
+		       r.created_at, u.email, r.is_public, r.last_cooked_at, r.rating,
+		       r.servings, r.yield_text,` + recipeForkColumns + `,
+		       m.total, m.have, m.missing
+		FROM matches m
+		JOIN recipes r ON r.uuid = m.recipe_uuid
+		JOIN users u ON u.uuid = r.user_uuid
+		ORDER BY m.have::float / m.total DESC, m.total - m.have, r.name, r.uuid
+		LIMIT $2`
+)
+
+// PantryDeduction is an amount of an ingredient used from the pantry, in the
+// ingredient's base unit
+type PantryDeduction struct {
+	IngredientName string
+	IngredientType models.IngredientUnit
+	BaseQuantity   float64
+}
+
+// GetPantryItems returns what the user has on hand, by name
+func GetPantryItems(userUUID uuid.UUID) ([]models.PantryItem, error) {
+	rows, err := db.Query(context.Background(), queryGetPantryItems, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.PantryItem
+	for rows.Next() {
+		var p models.PantryItem
+		var ingredientType *models.IngredientUnit
+		var baseQuantity *float64
+		if err := rows.Scan(&p.IngredientName, &ingredientType, &baseQuantity, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if ingredientType != nil && baseQuantity != nil {
+			p.Category = string(units.GetCategoryForIngredientUnit(*ingredientType))
+			p.BaseQuantity = *baseQuantity
+		}
+		items = append(items, p)
+	}
+	return items, rows.Err()
+}
+
+// SetPantryItem records that the user has an ingredient, and how much of it
+// in its base unit when ingredientType and baseQuantity aren't nil. The
+// ingredient must already be in the catalog; sql.ErrNoRows is returned if
+// there is none by that name.
+func SetPantryItem(userUUID uuid.UUID, ingredientName string, ingredientType *models.IngredientUnit, baseQuantity *float64) error {
+	ingredient, err := GetIngredientNameByName(ingredientName)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(context.Background(), querySetPantryItem, userUUID, ingredient.UUID, ingredientType, baseQuantity)
+	return err
+}
+
+// DeletePantryItem removes an ingredient from the user's pantry, reporting
+// whether it was there
+func DeletePantryItem(userUUID uuid.UUID, ingredientName string) (bool, error) {
+	tag, err := db.Exec(context.Background(), queryDeletePantryItem, userUUID, ingredientName, ingredients.Key(ingredientName))
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// DeductPantryItems takes the amounts used by a recipe out of the user's
+// pantry, removing whatever is used up
+func DeductPantryItems(userUUID uuid.UUID, deductions []PantryDeduction) error {
+	ctx := context.Background()
+	t, err := BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer t.Rollback(ctx)
+
+	for _, d := range deductions {
+		for _, query := range []string{queryUseUpPantryItem, queryDeductPantryItem} {
+			if _, err := t.tx.Exec(ctx, query, userUUID, d.IngredientName, d.IngredientType, d.BaseQuantity); err != nil {
+				return fmt.Errorf("failed to deduct %q: %w", d.IngredientName, err)
+			}
+		}
+	}
+	return t.Commit(ctx)
+}
+
+// GetRecipeMatches ranks the recipes the viewer can see by how many of their
+// ingredients are in the viewer's pantry, best first
+func GetRecipeMatches(viewerUUID uuid.UUID, limit int) ([]models.RecipeMatch, error) {
+	rows, err := db.Query(context.Background(), queryGetRecipeMatches, viewerUUID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := []models.RecipeMatch{}
+	for rows.Next() {
+		var m models.RecipeMatch
+		r := &m.Recipe
+		if err := rows.Scan(&r.UUID, &r.Name, &r.User, &r.Source, &r.TagString, &r.CreatedAt, &r.OwnerEmail, &r.IsPublic,
+			&r.LastCookedAt, &r.Rating, &r.Servings, &r.YieldText, &r.ForkedFrom, &r.ForkedFromEmail, &r.ForkCount,
+			&m.Total, &m.Have, &m.Missing); err != nil {
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/cobyabrahams/hungr/models"
+)
+
+func findPantryItem(items []models.PantryItem, name string) *models.PantryItem {
+	for i := range items {
+		if items[i].IngredientName == name {
+			return &items[i]
+		}
+	}
+	return nil
+}
+
+func TestDeductPantryItems(t *testing.T) {
+	ensureTestUser(t)
+	user, _ := GetUserByEmail(testEmail)
+	defer DeletePantryItem(user.UUID, "milk")
+	defer DeletePantryItem(user.UUID, "butter")
+
+	ml, mg := models.UnitML, models.UnitMG
+	milk, butter := 1000.0, 250000.0
+	if err := SetPantryItem(user.UUID, "milk", &ml, &milk); err != nil {
+		t.Fatalf("SetPantryItem failed: %v", err)
+	}
+	if err := SetPantryItem(user.UUID, "butter", &mg, &butter); err != nil {
+		t.Fatalf("SetPantryItem failed: %v", err)
+	}
+
+	err := DeductPantryItems(user.UUID, []PantryDeduction{
+		{IngredientName: "milk", IngredientType: models.UnitML, BaseQuantity: 250},
+		{IngredientName: "butter", IngredientType: models.UnitMG, BaseQuantity: 300000},
+		{IngredientName: "flour", IngredientType: models.UnitMG, BaseQuantity: 100000},
+	})
+	if err != nil {
+		t.Fatalf("DeductPantryItems failed: %v", err)
+	}
+
+	items, err := GetPantryItems(user.UUID)
+	if err != nil {
+		t.Fatalf("GetPantryItems failed: %v", err)
+	}
+	if item := findPantryItem(items, "milk"); item == nil || item.Category != "volume" || item.BaseQuantity != 750 {
+		t.Errorf("Expected 750 ml of milk left, got %+v", item)
+	}
+	if item := findPantryItem(items, "butter"); item != nil {
+		t.Errorf("Expected the butter to be used up, got %+v", item)
+	}
+}
@@ -2,89 +2,260 @@ package storage
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/cobyabrahams/hungr/models"
 	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 const (
+	// recipeForkColumns selects forked_from, the original's owner email and
+	// the fork count for recipe r
+	recipeForkColumns = `
+		       r.forked_from,
+		       (SELECT fu.email FROM recipes fr JOIN users fu ON fr.user_uuid = fu.uuid
+		        WHERE fr.uuid = r.forked_from) as forked_from_email,
+		       (SELECT COUNT(*) FROM recipes f
+		        WHERE f.forked_from = r.uuid AND f.deleted_at IS NULL) as fork_count`
+
 	queryGetRecipeByUUID = `
 		SELECT r.uuid, r.name, r.user_uuid, r.source,
 		       COALESCE(STRING_AGG(t.name, ', ' ORDER BY rt.id), '') as tag_string,
-		       r.created_at, u.email, r.is_public
+		       r.created_at, u.email, r.is_public, r.last_cooked_at, r.rating,
+		       r.servings, r.yield_text,` + recipeForkColumns + `
+		FROM recipes r
+		JOIN users u ON r.user_uuid = u.uuid
+		LEFT JOIN recipe_tags rt ON r.uuid = rt.recipe_uuid
+		LEFT JOIN tags t ON rt.tag_uuid = t.uuid
+		WHERE r.uuid = $1 AND r.deleted_at IS NULL
+		GROUP BY r.uuid, r.name, r.user_uuid, r.source, r.created_at, u.email, r.is_public, r.last_cooked_at, r.rating, r.servings, r.yield_text, r.forked_from`
+
+	queryGetDeletedRecipesByUser = `
+		SELECT r.uuid, r.name, r.user_uuid, r.source,
+		       COALESCE(STRING_AGG(t.name, ', ' ORDER BY rt.id), '') as tag_string,
+		       r.created_at, u.email, r.is_public, r.last_cooked_at, r.rating,
+		       r.servings, r.yield_text,` + recipeForkColumns + `,
+		       r.deleted_at
 		FROM recipes r
 		JOIN users u ON r.user_uuid = u.uuid
 		LEFT JOIN recipe_tags rt ON r.uuid = rt.recipe_uuid
 		LEFT JOIN tags t ON rt.tag_uuid = t.uuid
-		WHERE r.uuid = $1
-		GROUP BY r.uuid, r.name, r.user_uuid, r.source, r.created_at, u.email, r.is_public`
+		WHERE r.user_uuid = $1 AND r.deleted_at IS NOT NULL
+			AND ($2::uuid IS NULL OR r.uuid = $2)
+		GROUP BY r.uuid, r.name, r.user_uuid, r.source, r.created_at, u.email, r.is_public, r.last_cooked_at, r.rating, r.servings, r.yield_text, r.forked_from
+		ORDER BY r.deleted_at DESC`
 
+	// recipeListFilter restricts recipes r to those visible to viewer v (their
+	// own and those of users who have connected to them) that match a
+	// TagFilter: $2 tags to match, $3 how many of them must match and $4 tags
+	// to exclude, all lowercase
+	recipeListFilter = `
+		r.deleted_at IS NULL
+		AND (r.user_uuid = v.uuid
+			OR EXISTS (
+				SELECT 1 FROM user_connections uc
+				WHERE uc.source_user_uuid = r.user_uuid
+					AND uc.target_user_uuid = v.uuid
+			))
+		AND (cardinality($2::text[]) = 0 OR (
+			SELECT COUNT(DISTINCT lower(ft.name))
+			FROM recipe_tags frt JOIN tags ft ON frt.tag_uuid = ft.uuid
+			WHERE frt.recipe_uuid = r.uuid AND lower(ft.name) = ANY($2::text[])) >= $3)
+		AND NOT EXISTS (
+			SELECT 1
+			FROM recipe_tags frt JOIN tags ft ON frt.tag_uuid = ft.uuid
+			WHERE frt.recipe_uuid = r.uuid AND lower(ft.name) = ANY($4::text[]))`
+
+	// queryGetRecipesByUserEmail is formatted with a recipeSort: %[1]s is the
+	// sort expression, %[2]s the cursor comparison, %[3]s the cursor key type
+	// and %[4]s the direction
 	queryGetRecipesByUserEmail = `
 		WITH viewer AS (
 			SELECT uuid FROM users WHERE email = $1
 		)
 		SELECT r.uuid, r.name, r.user_uuid, r.source,
 		       COALESCE(STRING_AGG(t.name, ', ' ORDER BY rt.id), '') as tag_string,
-		       r.created_at, u.email, r.is_public
+		       r.created_at, u.email, r.is_public, r.last_cooked_at, r.rating,
+		       r.servings, r.yield_text,` + recipeForkColumns + `,
+		       (%[1]s)::text as sort_key
 		FROM recipes r
 		JOIN users u ON r.user_uuid = u.uuid
 		JOIN viewer v ON true
 		LEFT JOIN recipe_tags rt ON r.uuid = rt.recipe_uuid
 		LEFT JOIN tags t ON rt.tag_uuid = t.uuid
-		WHERE r.user_uuid = v.uuid
-			OR EXISTS (
-				SELECT 1 FROM user_connections uc
-				WHERE uc.source_user_uuid = r.user_uuid
-					AND uc.target_user_uuid = v.uuid
-			)
-		GROUP BY r.uuid, r.name, r.user_uuid, r.source, r.created_at, u.email, r.is_public
-		ORDER BY r.created_at DESC LIMIT 100`
+		WHERE` + recipeListFilter + `
+			AND ($5::text IS NULL OR ((%[1]s), r.uuid) %[2]s ($5::%[3]s, $6::uuid))
+		GROUP BY r.uuid, r.name, r.user_uuid, r.source, r.created_at, u.email, r.is_public, r.last_cooked_at, r.rating, r.servings, r.yield_text, r.forked_from
+		ORDER BY %[1]s %[4]s, r.uuid %[4]s
+		LIMIT $7`
+
+	queryGetTagCountsByUserEmail = `
+		WITH viewer AS (
+			SELECT uuid FROM users WHERE email = $1
+		),
+		filtered AS (
+			SELECT r.uuid
+			FROM recipes r
+			JOIN viewer v ON true
+			WHERE` + recipeListFilter + `
+		)
+		SELECT t.name, COUNT(*)
+		FROM filtered f
+		JOIN recipe_tags rt ON rt.recipe_uuid = f.uuid
+		JOIN tags t ON rt.tag_uuid = t.uuid
+		GROUP BY t.name
+		ORDER BY COUNT(*) DESC, t.name`
 
 	queryInsertRecipeByEmail = `
 		INSERT INTO recipes (name, user_uuid, source)
 		SELECT $1, u.uuid, $3
 		FROM users u WHERE u.email = $2
-		RETURNING uuid, name, user_uuid, $3 as source, '' as tag_string, created_at, $2 as owner_email, false as is_public`
+		RETURNING uuid, name, user_uuid, $3 as source, '' as tag_string, created_at, $2 as owner_email, false as is_public,
+		          last_cooked_at, rating, servings, yield_text, forked_from, NULL as forked_from_email, 0 as fork_count`
 
-	queryDeleteRecipeTags   = `DELETE FROM recipe_tags WHERE recipe_uuid = $1`
-	queryDeleteRecipeFiles  = `DELETE FROM files WHERE recipe_uuid = $1`
-	queryDeleteRecipe       = `DELETE FROM recipes WHERE uuid = $1`
-	queryUpdateRecipeSource = `UPDATE recipes SET source = $2 WHERE uuid = $1`
-	querySetRecipePublic    = `UPDATE recipes SET is_public = $2 WHERE uuid = $1`
+	queryDeleteRecipeTags      = `DELETE FROM recipe_tags WHERE recipe_uuid = $1`
+	queryDeleteRecipeFiles     = `DELETE FROM files WHERE recipe_uuid = $1`
+	queryDeleteRecipe          = `DELETE FROM recipes WHERE uuid = $1`
+	querySoftDeleteRecipe      = `UPDATE recipes SET deleted_at = NOW() WHERE uuid = $1 AND deleted_at IS NULL`
+	queryRestoreRecipe         = `UPDATE recipes SET deleted_at = NULL WHERE uuid = $1`
+	queryGetExpiredRecipes     = `SELECT uuid FROM recipes WHERE deleted_at < $1`
+	queryUpdateRecipeSource    = `UPDATE recipes SET source = $2 WHERE uuid = $1`
+	queryUpdateRecipeName      = `UPDATE recipes SET name = $2 WHERE uuid = $1`
+	queryUpdateRecipeServings  = `UPDATE recipes SET servings = $2 WHERE uuid = $1`
+	queryUpdateRecipeYieldText = `UPDATE recipes SET yield_text = $2 WHERE uuid = $1`
+	querySetRecipePublic       = `UPDATE recipes SET is_public = $2 WHERE uuid = $1`
+	querySetRecipeRating       = `UPDATE recipes SET rating = $2 WHERE uuid = $1`
+	queryMarkRecipeCooked      = `UPDATE recipes SET last_cooked_at = NOW() WHERE uuid = $1 RETURNING last_cooked_at`
+
+	queryForkRecipe = `
+		INSERT INTO recipes (name, user_uuid, source, servings, yield_text, forked_from)
+		SELECT name, $2, source, servings, yield_text, uuid FROM recipes WHERE uuid = $1
+		RETURNING uuid`
+
+	queryForkRecipeFiles = `
+		INSERT INTO files (recipe_uuid, data, content_type, url, page_number, image)
+		SELECT $2, data, content_type, '', page_number, image
+		FROM files WHERE recipe_uuid = $1`
+
+	queryForkRecipeFileURLs = `UPDATE files SET url = '/api/files/' || uuid WHERE recipe_uuid = $1`
+
+	queryForkRecipeTags = `
+		INSERT INTO recipe_tags (recipe_uuid, tag_uuid)
+		SELECT $2, tag_uuid FROM recipe_tags WHERE recipe_uuid = $1
+		ORDER BY id`
+
+	queryForkRecipeSteps = `
+		WITH new_steps AS (
+			INSERT INTO recipe_steps (recipe_uuid, step_number, instructions, group_title)
+			SELECT $2, step_number, instructions, group_title FROM recipe_steps WHERE recipe_uuid = $1
+			RETURNING uuid, step_number
+		)
+		INSERT INTO step_ingredients (recipe_step_uuid, ingredient_name_uuid, name, ingredient_type, quantity,
+		    quantity_max, quantity_text, preparation, optional, original_text, sub_recipe_uuid)
+		SELECT ns.uuid, si.ingredient_name_uuid, si.name, si.ingredient_type, si.quantity,
+		       si.quantity_max, si.quantity_text, si.preparation, si.optional, si.original_text, si.sub_recipe_uuid
+		FROM new_steps ns
+		JOIN recipe_steps os ON os.recipe_uuid = $1 AND os.step_number = ns.step_number
+		JOIN step_ingredients si ON si.recipe_step_uuid = os.uuid`
 )
 
 func GetRecipeByUUID(recipeUUID uuid.UUID) (*models.Recipe, error) {
 	var r models.Recipe
 	err := db.QueryRow(context.Background(), queryGetRecipeByUUID, recipeUUID).Scan(
-		&r.UUID, &r.Name, &r.User, &r.Source, &r.TagString, &r.CreatedAt, &r.OwnerEmail, &r.IsPublic)
+		&r.UUID, &r.Name, &r.User, &r.Source, &r.TagString, &r.CreatedAt, &r.OwnerEmail, &r.IsPublic,
+		&r.LastCookedAt, &r.Rating, &r.Servings, &r.YieldText, &r.ForkedFrom, &r.ForkedFromEmail, &r.ForkCount)
 	if err != nil {
 		return nil, err
 	}
 	return &r, nil
 }
 
-func GetRecipesByUserEmail(email string) ([]models.Recipe, error) {
-	rows, err := db.Query(context.Background(), queryGetRecipesByUserEmail, email)
+// GetRecipesByUserEmail returns one page of the recipes visible to the user
+// that match page.Tags: their own and those of users who have connected to
+// them. The returned cursor fetches the next page and is nil once there are no
+// more recipes.
+func GetRecipesByUserEmail(email string, page RecipePage) ([]models.Recipe, *RecipeCursor, error) {
+	sortBy := page.Sort
+	if sortBy == "" {
+		sortBy = SortCreated
+	}
+	order, ok := recipeSorts[sortBy]
+	if !ok {
+		return nil, nil, ErrInvalidSort
+	}
+
+	var afterKey *string
+	var afterUUID uuid.UUID
+	if page.After != nil {
+		if page.After.Sort != sortBy {
+			return nil, nil, ErrInvalidCursor
+		}
+		afterKey = &page.After.Key
+		afterUUID = page.After.UUID
+	}
+
+	query := fmt.Sprintf(queryGetRecipesByUserEmail, order.expr, order.compare(), order.keyType, order.dir)
+	// Fetch one extra row to tell whether there is another page
+	tags, minMatch, exclude := page.Tags.args()
+	rows, err := db.Query(context.Background(), query, email, tags, minMatch, exclude, afterKey, afterUUID, page.Limit+1)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
 	recipes := []models.Recipe{}
+	var sortKeys []string
 	for rows.Next() {
 		var r models.Recipe
-		if err := rows.Scan(&r.UUID, &r.Name, &r.User, &r.Source, &r.TagString, &r.CreatedAt, &r.OwnerEmail, &r.IsPublic); err != nil {
-			return nil, err
+		var sortKey string
+		if err := rows.Scan(&r.UUID, &r.Name, &r.User, &r.Source, &r.TagString, &r.CreatedAt, &r.OwnerEmail, &r.IsPublic,
+			&r.LastCookedAt, &r.Rating, &r.Servings, &r.YieldText, &r.ForkedFrom, &r.ForkedFromEmail, &r.ForkCount, &sortKey); err != nil {
+			return nil, nil, err
 		}
 		recipes = append(recipes, r)
+		sortKeys = append(sortKeys, sortKey)
 	}
-	return recipes, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(recipes) <= page.Limit {
+		return recipes, nil, nil
+	}
+	recipes = recipes[:page.Limit]
+	last := len(recipes) - 1
+	next := &RecipeCursor{Sort: sortBy, Key: sortKeys[last], UUID: recipes[last].UUID}
+	return recipes, next, nil
+}
+
+// GetTagCountsByUserEmail counts how many of the recipes visible to the user
+// that match filter carry each tag, most used first
+func GetTagCountsByUserEmail(email string, filter TagFilter) ([]models.TagCount, error) {
+	tags, minMatch, exclude := filter.args()
+	rows, err := db.Query(context.Background(), queryGetTagCountsByUserEmail, email, tags, minMatch, exclude)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []models.TagCount{}
+	for rows.Next() {
+		var c models.TagCount
+		if err := rows.Scan(&c.Name, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
 }
 
 func InsertRecipeByEmail(name string, email string, source *string) (*models.Recipe, error) {
 	var r models.Recipe
 	err := db.QueryRow(context.Background(), queryInsertRecipeByEmail,
-		name, email, source).Scan(&r.UUID, &r.Name, &r.User, &r.Source, &r.TagString, &r.CreatedAt, &r.OwnerEmail, &r.IsPublic)
+		name, email, source).Scan(&r.UUID, &r.Name, &r.User, &r.Source, &r.TagString, &r.CreatedAt, &r.OwnerEmail, &r.IsPublic,
+		&r.LastCookedAt, &r.Rating, &r.Servings, &r.YieldText, &r.ForkedFrom, &r.ForkedFromEmail, &r.ForkCount)
 	if err != nil {
 		return nil, err
 	}
@@ -95,14 +266,68 @@ func InsertRecipeByEmail(name string, email string, source *string) (*models.Rec
 func TxInsertRecipeByEmail(ctx context.Context, tx *Tx, name string, email string, source *string) (*models.Recipe, error) {
 	var r models.Recipe
 	err := tx.tx.QueryRow(ctx, queryInsertRecipeByEmail,
-		name, email, source).Scan(&r.UUID, &r.Name, &r.User, &r.Source, &r.TagString, &r.CreatedAt, &r.OwnerEmail, &r.IsPublic)
+		name, email, source).Scan(&r.UUID, &r.Name, &r.User, &r.Source, &r.TagString, &r.CreatedAt, &r.OwnerEmail, &r.IsPublic,
+		&r.LastCookedAt, &r.Rating, &r.Servings, &r.YieldText, &r.ForkedFrom, &r.ForkedFromEmail, &r.ForkCount)
 	if err != nil {
 		return nil, err
 	}
 	return &r, nil
 }
 
+// DeleteRecipe moves a recipe to the trash. It disappears from every listing
+// and lookup but can be restored until it is purged.
 func DeleteRecipe(recipeUUID uuid.UUID) error {
+	_, err := db.Exec(context.Background(), querySoftDeleteRecipe, recipeUUID)
+	return err
+}
+
+// RestoreRecipe takes a recipe back out of the trash
+func RestoreRecipe(recipeUUID uuid.UUID) error {
+	_, err := db.Exec(context.Background(), queryRestoreRecipe, recipeUUID)
+	return err
+}
+
+// GetDeletedRecipesByUser returns the user's recipes in the trash, most
+// recently deleted first
+func GetDeletedRecipesByUser(userUUID uuid.UUID) ([]models.TrashedRecipe, error) {
+	return getDeletedRecipes(userUUID, nil)
+}
+
+// GetDeletedRecipe returns one of the user's recipes from the trash
+func GetDeletedRecipe(userUUID, recipeUUID uuid.UUID) (*models.TrashedRecipe, error) {
+	recipes, err := getDeletedRecipes(userUUID, &recipeUUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(recipes) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &recipes[0], nil
+}
+
+func getDeletedRecipes(userUUID uuid.UUID, recipeUUID *uuid.UUID) ([]models.TrashedRecipe, error) {
+	rows, err := db.Query(context.Background(), queryGetDeletedRecipesByUser, userUUID, recipeUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipes := []models.TrashedRecipe{}
+	for rows.Next() {
+		var tr models.TrashedRecipe
+		r := &tr.Recipe
+		if err := rows.Scan(&r.UUID, &r.Name, &r.User, &r.Source, &r.TagString, &r.CreatedAt, &r.OwnerEmail, &r.IsPublic,
+			&r.LastCookedAt, &r.Rating, &r.Servings, &r.YieldText, &r.ForkedFrom, &r.ForkedFromEmail, &r.ForkCount, &tr.DeletedAt); err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, tr)
+	}
+	return recipes, rows.Err()
+}
+
+// PurgeRecipe permanently deletes a recipe with its tags, files, steps and
+// revisions
+func PurgeRecipe(recipeUUID uuid.UUID) error {
 	tx, err := db.Begin(context.Background())
 	if err != nil {
 		return err
@@ -124,12 +349,103 @@ func DeleteRecipe(recipeUUID uuid.UUID) error {
 	return tx.Commit(context.Background())
 }
 
+// PurgeDeletedRecipes permanently deletes recipes that were moved to the trash
+// before the cutoff and returns how many were purged
+func PurgeDeletedRecipes(before time.Time) (int, error) {
+	rows, err := db.Query(context.Background(), queryGetExpiredRecipes, before)
+	if err != nil {
+		return 0, err
+	}
+	var expired []uuid.UUID
+	for rows.Next() {
+		var recipeUUID uuid.UUID
+		if err := rows.Scan(&recipeUUID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		expired = append(expired, recipeUUID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i, recipeUUID := range expired {
+		if err := PurgeRecipe(recipeUUID); err != nil {
+			return i, fmt.Errorf("failed to purge recipe %s: %w", recipeUUID, err)
+		}
+	}
+	return len(expired), nil
+}
+
 func TxUpdateRecipeSource(ctx context.Context, tx *Tx, recipeUUID uuid.UUID, source *string) error {
 	_, err := tx.tx.Exec(ctx, queryUpdateRecipeSource, recipeUUID, source)
 	return err
 }
 
+func TxUpdateRecipeName(ctx context.Context, tx *Tx, recipeUUID uuid.UUID, name string) error {
+	_, err := tx.tx.Exec(ctx, queryUpdateRecipeName, recipeUUID, name)
+	return err
+}
+
+// TxUpdateRecipeServings sets how many servings a recipe makes, or clears it
+// when servings is nil
+func TxUpdateRecipeServings(ctx context.Context, tx *Tx, recipeUUID uuid.UUID, servings *int) error {
+	_, err := tx.tx.Exec(ctx, queryUpdateRecipeServings, recipeUUID, servings)
+	return err
+}
+
+// TxUpdateRecipeYieldText sets a recipe's free-form yield, or clears it when
+// yieldText is nil
+func TxUpdateRecipeYieldText(ctx context.Context, tx *Tx, recipeUUID uuid.UUID, yieldText *string) error {
+	_, err := tx.tx.Exec(ctx, queryUpdateRecipeYieldText, recipeUUID, yieldText)
+	return err
+}
+
 func SetRecipePublic(recipeUUID uuid.UUID, isPublic bool) error {
 	_, err := db.Exec(context.Background(), querySetRecipePublic, recipeUUID, isPublic)
 	return err
 }
+
+// SetRecipeRating sets a recipe's 1-5 rating, or clears it when rating is nil
+func SetRecipeRating(recipeUUID uuid.UUID, rating *int) error {
+	_, err := db.Exec(context.Background(), querySetRecipeRating, recipeUUID, rating)
+	return err
+}
+
+// MarkRecipeCooked records that the recipe was cooked now
+func MarkRecipeCooked(recipeUUID uuid.UUID) (time.Time, error) {
+	var cookedAt time.Time
+	err := db.QueryRow(context.Background(), queryMarkRecipeCooked, recipeUUID).Scan(&cookedAt)
+	return cookedAt, err
+}
+
+// TxForkRecipe copies a recipe with its files, tags, steps and ingredients
+// into ownerUUID's collection and returns the copy's UUID. The copy starts
+// private, unrated and uncooked, and points back at the original through
+// forked_from.
+func TxForkRecipe(ctx context.Context, tx *Tx, recipeUUID, ownerUUID uuid.UUID) (uuid.UUID, error) {
+	var forkUUID uuid.UUID
+	if err := tx.tx.QueryRow(ctx, queryForkRecipe, recipeUUID, ownerUUID).Scan(&forkUUID); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to copy recipe: %w", err)
+	}
+
+	copies := []struct {
+		what  string
+		query string
+	}{
+		{"files", queryForkRecipeFiles},
+		{"tags", queryForkRecipeTags},
+		{"steps", queryForkRecipeSteps},
+	}
+	for _, c := range copies {
+		if _, err := tx.tx.Exec(ctx, c.query, recipeUUID, forkUUID); err != nil {
+			return uuid.Nil, fmt.Errorf("failed to copy %s: %w", c.what, err)
+		}
+	}
+
+	if _, err := tx.tx.Exec(ctx, queryForkRecipeFileURLs, forkUUID); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to set file urls: %w", err)
+	}
+	return forkUUID, nil
+}
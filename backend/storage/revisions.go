@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	queryRecordRecipeRevision = `SELECT record_recipe_revision($1, $2)`
+
+	queryGetRecipeRevisions = `
+		SELECT uuid, recipe_uuid, revision_number, author_uuid, created_at, snapshot
+		FROM recipe_revisions
+		WHERE recipe_uuid = $1
+		ORDER BY revision_number DESC`
+
+	queryGetRecipeRevision = `
+		SELECT uuid, recipe_uuid, revision_number, author_uuid, created_at, snapshot
+		FROM recipe_revisions
+		WHERE recipe_uuid = $1 AND revision_number = $2`
+)
+
+// RecordRevision snapshots the recipe's current state and returns the new
+// revision number
+func RecordRevision(recipeUUID, authorUUID uuid.UUID) (int, error) {
+	var number int
+	err := db.QueryRow(context.Background(), queryRecordRecipeRevision, recipeUUID, authorUUID).Scan(&number)
+	return number, err
+}
+
+// TxRecordRevision snapshots the recipe as it stands in the transaction and
+// returns the new revision number. Call it after every change to a recipe's
+// name, source, tags or steps.
+func TxRecordRevision(ctx context.Context, tx *Tx, recipeUUID, authorUUID uuid.UUID) (int, error) {
+	var number int
+	err := tx.tx.QueryRow(ctx, queryRecordRecipeRevision, recipeUUID, authorUUID).Scan(&number)
+	return number, err
+}
+
+// GetRecipeRevisions returns a recipe's revisions, newest first
+func GetRecipeRevisions(recipeUUID uuid.UUID) ([]models.RecipeRevision, error) {
+	rows, err := db.Query(context.Background(), queryGetRecipeRevisions, recipeUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.RecipeRevision{}
+	for rows.Next() {
+		var rev models.RecipeRevision
+		if err := rows.Scan(&rev.UUID, &rev.RecipeUUID, &rev.Number, &rev.AuthorUUID, &rev.CreatedAt, &rev.Snapshot); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+func GetRecipeRevision(recipeUUID uuid.UUID, number int) (*models.RecipeRevision, error) {
+	var rev models.RecipeRevision
+	err := db.QueryRow(context.Background(), queryGetRecipeRevision, recipeUUID, number).Scan(
+		&rev.UUID, &rev.RecipeUUID, &rev.Number, &rev.AuthorUUID, &rev.CreatedAt, &rev.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// TxApplySnapshot overwrites a recipe's name, source, tags and steps with
+// those of snapshot
+func TxApplySnapshot(ctx context.Context, tx *Tx, recipeUUID uuid.UUID, snapshot models.RecipeSnapshot) error {
+	if err := TxUpdateRecipeName(ctx, tx, recipeUUID, snapshot.Name); err != nil {
+		return fmt.Errorf("failed to update name: %w", err)
+	}
+	if err := TxUpdateRecipeSource(ctx, tx, recipeUUID, snapshot.Source); err != nil {
+		return fmt.Errorf("failed to update source: %w", err)
+	}
+
+	if err := TxDeleteRecipeTags(ctx, tx, recipeUUID); err != nil {
+		return fmt.Errorf("failed to delete tags: %w", err)
+	}
+	for _, name := range snapshot.Tags {
+		tagUUID := CreateTagUUID(name)
+		if _, err := TxUpsertTag(ctx, tx, tagUUID, name); err != nil {
+			return fmt.Errorf("failed to upsert tag %q: %w", name, err)
+		}
+		if err := TxInsertRecipeTag(ctx, tx, recipeUUID, tagUUID); err != nil {
+			return fmt.Errorf("failed to link tag %q: %w", name, err)
+		}
+	}
+
+	steps := make([]StepInput, len(snapshot.Steps))
+	for i, step := range snapshot.Steps {
+		ingredients := make([]IngredientInput, len(step.Ingredients))
+		for j, ing := range step.Ingredients {
+			// Snapshot quantities are already in the ml/mg/count base units
+			ingredients[j] = IngredientInput{
+				Name:         ing.Name,
+				Unit:         string(ing.Unit),
+				Quantity:     ing.Quantity,
+				QuantityMax:  ing.QuantityMax,
+				QuantityText: ing.QuantityText,
+				Preparation:  ing.Preparation,
+				Optional:     ing.Optional,
+				OriginalText: ing.OriginalText,
+			}
+			// The sub-recipe may have been deleted since; the insert drops
+			// references to recipes that no longer exist
+			if recipeUUID, err := uuid.FromString(ing.RecipeUUID); err == nil {
+				ingredients[j].RecipeUUID = &recipeUUID
+			}
+		}
+		steps[i] = StepInput{Instruction: step.Instruction, GroupTitle: step.GroupTitle, Ingredients: ingredients}
+	}
+	return TxReplaceRecipeSteps(ctx, tx, recipeUUID, steps)
+}
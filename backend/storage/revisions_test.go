@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRecipeRevisions(t *testing.T) {
+	ensureTestUser(t)
+	user, _ := GetUserByEmail(testEmail)
+	ctx := context.Background()
+
+	recipe, err := InsertRecipeByEmail("revision-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("InsertRecipeByEmail failed: %v", err)
+	}
+	defer PurgeRecipe(recipe.UUID)
+
+	record := func() int {
+		tx, err := BeginTx(ctx)
+		if err != nil {
+			t.Fatalf("BeginTx failed: %v", err)
+		}
+		defer tx.Rollback(ctx)
+		number, err := TxRecordRevision(ctx, tx, recipe.UUID, user.UUID)
+		if err != nil {
+			t.Fatalf("TxRecordRevision failed: %v", err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+		return number
+	}
+
+	if got := record(); got != 1 {
+		t.Errorf("Expected first revision number 1, got %d", got)
+	}
+
+	tagUUID := CreateTagUUID("revision-tag")
+	UpsertTag(tagUUID, "revision-tag")
+	if err := InsertRecipeTag(recipe.UUID, tagUUID); err != nil {
+		t.Fatalf("InsertRecipeTag failed: %v", err)
+	}
+	err = ReplaceRecipeSteps(recipe.UUID, []StepInput{
+		{Instruction: "Mix", Ingredients: []IngredientInput{{Name: "flour", Unit: "g", Quantity: 200}}},
+	})
+	if err != nil {
+		t.Fatalf("ReplaceRecipeSteps failed: %v", err)
+	}
+	if got := record(); got != 2 {
+		t.Errorf("Expected second revision number 2, got %d", got)
+	}
+
+	revisions, err := GetRecipeRevisions(recipe.UUID)
+	if err != nil {
+		t.Fatalf("GetRecipeRevisions failed: %v", err)
+	}
+	if len(revisions) != 2 || revisions[0].Number != 2 {
+		t.Fatalf("Expected 2 revisions newest first, got %+v", revisions)
+	}
+	if *revisions[0].AuthorUUID != user.UUID {
+		t.Errorf("Expected author %v, got %v", user.UUID, *revisions[0].AuthorUUID)
+	}
+
+	latest := revisions[0].Snapshot
+	if latest.Name != "revision-test" || len(latest.Tags) != 1 || latest.Tags[0] != "revision-tag" {
+		t.Errorf("Unexpected snapshot: %+v", latest)
+	}
+	if len(latest.Steps) != 1 || len(latest.Steps[0].Ingredients) != 1 {
+		t.Fatalf("Expected one step with one ingredient, got %+v", latest.Steps)
+	}
+	if ing := latest.Steps[0].Ingredients[0]; ing.Name != "flour" || ing.Unit != "mg" || ing.Quantity != 200000 {
+		t.Errorf("Expected 200000 mg flour in base units, got %+v", ing)
+	}
+	if len(revisions[1].Snapshot.Steps) != 0 || len(revisions[1].Snapshot.Tags) != 0 {
+		t.Errorf("Expected first revision to have no steps or tags, got %+v", revisions[1].Snapshot)
+	}
+
+	t.Run("apply snapshot", func(t *testing.T) {
+		tx, err := BeginTx(ctx)
+		if err != nil {
+			t.Fatalf("BeginTx failed: %v", err)
+		}
+		defer tx.Rollback(ctx)
+		if err := TxApplySnapshot(ctx, tx, recipe.UUID, revisions[1].Snapshot); err != nil {
+			t.Fatalf("TxApplySnapshot failed: %v", err)
+		}
+		if err := TxApplySnapshot(ctx, tx, recipe.UUID, latest); err != nil {
+			t.Fatalf("TxApplySnapshot failed: %v", err)
+		}
+		if _, err := TxRecordRevision(ctx, tx, recipe.UUID, user.UUID); err != nil {
+			t.Fatalf("TxRecordRevision failed: %v", err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+
+		restored, err := GetRecipeRevision(recipe.UUID, 3)
+		if err != nil {
+			t.Fatalf("GetRecipeRevision failed: %v", err)
+		}
+		if len(restored.Snapshot.Steps) != 1 || restored.Snapshot.Steps[0].Ingredients[0] != latest.Steps[0].Ingredients[0] {
+			t.Errorf("Expected round trip through a snapshot to preserve steps, got %+v", restored.Snapshot)
+		}
+	})
+
+	t.Run("revisions are append-only", func(t *testing.T) {
+		_, err := db.Exec(ctx, `UPDATE recipe_revisions SET revision_number = 99 WHERE recipe_uuid = $1`, recipe.UUID)
+		if err == nil {
+			t.Error("Expected updating a revision to fail")
+		}
+	})
+}
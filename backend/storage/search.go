@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"html"
+	"strings"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/gofrs/uuid"
+)
+
+// Markers ts_headline puts around matches. They are stripped from the recipe
+// text first, so the snippet can be HTML-escaped before they are swapped for
+// <mark> tags.
+const (
+	headlineStart = "\x01"
+	headlineStop  = "\x02"
+)
+
+const querySearchRecipes = `
+	WITH query AS (
+		SELECT websearch_to_tsquery('english', $2) AS q
+	),
+	matches AS (
+		SELECT r.uuid, ts_rank(r.search_vector, query.q) AS rank
+		FROM recipes r, query
+		WHERE r.search_vector @@ query.q
+			AND r.deleted_at IS NULL
+			AND (r.user_uuid = $1
+				OR EXISTS (
+					SELECT 1 FROM user_connections uc
+					WHERE uc.source_user_uuid = r.user_uuid
+						AND uc.target_user_uuid = $1
+				))
+		ORDER BY rank DESC, r.created_at DESC
+		LIMIT $3
+	)
+	SELECT r.uuid, r.name, r.user_uuid, r.source,
+	       COALESCE((
+	           SELECT STRING_AGG(t.name, ', ' ORDER BY rt.id)
+	           FROM recipe_tags rt JOIN tags t ON rt.tag_uuid = t.uuid
+	           WHERE rt.recipe_uuid = r.uuid), '') AS tag_string,
+	       r.created_at, u.email, r.is_public, r.last_cooked_at, r.rating,
+	       r.servings, r.yield_text,` + recipeForkColumns + `,
+	       m.rank,
+	       ts_headline('english',
+	           translate(r.name || '. ' || COALESCE((
+	               SELECT STRING_AGG(instructions, ' ' ORDER BY step_number)
+	               FROM recipe_steps WHERE recipe_uuid = r.uuid), ''), chr(1) || chr(2), ''),
+	           query.q,
+	           'StartSel=' || chr(1) || ', StopSel=' || chr(2) || ', MaxWords=25, MinWords=8, MaxFragments=2, FragmentDelimiter=" … "')
+	FROM matches m
+	JOIN recipes r ON r.uuid = m.uuid
+	JOIN users u ON u.uuid = r.user_uuid
+	CROSS JOIN query
+	ORDER BY m.rank DESC, r.created_at DESC`
+
+// SearchRecipes runs a full-text search over the recipes viewer can see: their
+// own and those of users who have connected to them. q uses web search syntax
+// ("quoted phrases", -excluded, or). Results are ordered by rank.
+func SearchRecipes(viewerUUID uuid.UUID, q string, limit int) ([]models.SearchResult, error) {
+	rows, err := db.Query(context.Background(), querySearchRecipes, viewerUUID, q, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.SearchResult{}
+	for rows.Next() {
+		var res models.SearchResult
+		var headline string
+		r := &res.Recipe
+		if err := rows.Scan(&r.UUID, &r.Name, &r.User, &r.Source, &r.TagString, &r.CreatedAt, &r.OwnerEmail, &r.IsPublic,
+			&r.LastCookedAt, &r.Rating, &r.Servings, &r.YieldText, &r.ForkedFrom, &r.ForkedFromEmail, &r.ForkCount, &res.Rank, &headline); err != nil {
+			return nil, err
+		}
+		res.Snippet = highlightSnippet(headline)
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
+
+// highlightSnippet HTML-escapes a ts_headline result and wraps its matches in
+// <mark> tags
+func highlightSnippet(headline string) string {
+	escaped := html.EscapeString(headline)
+	return strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>").Replace(escaped)
+}
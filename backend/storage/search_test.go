@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlightSnippet(t *testing.T) {
+	tests := []struct {
+		desc     string
+		headline string
+		want     string
+	}{
+		{"no matches", "Boil water", "Boil water"},
+		{"one match", "Boil \x01pasta\x02 until tender", "Boil <mark>pasta</mark> until tender"},
+		{"escapes html", "Add <b>\x01salt\x02</b> & pepper", "Add &lt;b&gt;<mark>salt</mark>&lt;/b&gt; &amp; pepper"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := highlightSnippet(tt.headline); got != tt.want {
+				t.Errorf("highlightSnippet(%q) = %q, want %q", tt.headline, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchRecipes(t *testing.T) {
+	ensureTestUser(t)
+	ensureTestUser2(t)
+	owner, _ := GetUserByEmail(testEmail)
+	other, _ := GetUserByEmail(testEmail2)
+
+	recipe, err := InsertRecipeByEmail("Zanzibar pilau", testEmail, nil)
+	if err != nil {
+		t.Fatalf("InsertRecipeByEmail failed: %v", err)
+	}
+	defer PurgeRecipe(recipe.UUID)
+
+	tagUUID := CreateTagUUID("searchtestspice")
+	if _, err := UpsertTag(tagUUID, "searchtestspice"); err != nil {
+		t.Fatalf("UpsertTag failed: %v", err)
+	}
+	if err := InsertRecipeTag(recipe.UUID, tagUUID); err != nil {
+		t.Fatalf("InsertRecipeTag failed: %v", err)
+	}
+
+	err = ReplaceRecipeSteps(recipe.UUID, []StepInput{
+		{Instruction: "Toast the cardamom pods until fragrant", Ingredients: []IngredientInput{
+			{Name: "quuxberries", Unit: "count", Quantity: 3},
+		}},
+		{Instruction: "Simmer the rice with stock", Ingredients: nil},
+	})
+	if err != nil {
+		t.Fatalf("ReplaceRecipeSteps failed: %v", err)
+	}
+
+	tests := []struct {
+		desc string
+		q    string
+	}{
+		{"name", "zanzibar"},
+		{"tag", "searchtestspice"},
+		{"ingredient", "quuxberries"},
+		{"instructions", "cardamom"},
+		{"stemmed", "simmering"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			results, err := SearchRecipes(owner.UUID, tt.q, 10)
+			if err != nil {
+				t.Fatalf("SearchRecipes failed: %v", err)
+			}
+			found := false
+			for _, res := range results {
+				if res.Recipe.UUID == recipe.UUID {
+					found = true
+					if res.Rank <= 0 {
+						t.Errorf("Expected positive rank, got %v", res.Rank)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("Expected %q to find the recipe", tt.q)
+			}
+		})
+	}
+
+	t.Run("snippet highlights instructions", func(t *testing.T) {
+		results, err := SearchRecipes(owner.UUID, "cardamom", 10)
+		if err != nil {
+			t.Fatalf("SearchRecipes failed: %v", err)
+		}
+		for _, res := range results {
+			if res.Recipe.UUID == recipe.UUID && !strings.Contains(res.Snippet, "<mark>cardamom</mark>") {
+				t.Errorf("Expected highlighted snippet, got %q", res.Snippet)
+			}
+		}
+	})
+
+	t.Run("name outranks instructions", func(t *testing.T) {
+		other, err := InsertRecipeByEmail("Plain rice", testEmail, nil)
+		if err != nil {
+			t.Fatalf("InsertRecipeByEmail failed: %v", err)
+		}
+		defer PurgeRecipe(other.UUID)
+		ReplaceRecipeSteps(other.UUID, []StepInput{{Instruction: "Zanzibar style: rinse well"}})
+
+		results, err := SearchRecipes(owner.UUID, "zanzibar", 10)
+		if err != nil {
+			t.Fatalf("SearchRecipes failed: %v", err)
+		}
+		if len(results) < 2 || results[0].Recipe.UUID != recipe.UUID {
+			t.Errorf("Expected the recipe named Zanzibar to rank first, got %+v", results)
+		}
+	})
+
+	t.Run("not visible to unconnected users", func(t *testing.T) {
+		DeleteConnection(owner.UUID, other.UUID)
+		results, err := SearchRecipes(other.UUID, "zanzibar", 10)
+		if err != nil {
+			t.Fatalf("SearchRecipes failed: %v", err)
+		}
+		for _, res := range results {
+			if res.Recipe.UUID == recipe.UUID {
+				t.Error("Expected recipe to be hidden from an unconnected user")
+			}
+		}
+	})
+
+	t.Run("visible to connected users", func(t *testing.T) {
+		if err := CreateConnection(owner.UUID, other.UUID); err != nil {
+			t.Fatalf("CreateConnection failed: %v", err)
+		}
+		defer DeleteConnection(owner.UUID, other.UUID)
+
+		results, err := SearchRecipes(other.UUID, "zanzibar", 10)
+		if err != nil {
+			t.Fatalf("SearchRecipes failed: %v", err)
+		}
+		found := false
+		for _, res := range results {
+			found = found || res.Recipe.UUID == recipe.UUID
+		}
+		if !found {
+			t.Error("Expected recipe to be visible to a connected user")
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	queryCreateSession = `
+		INSERT INTO sessions (user_uuid, user_agent, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING uuid, user_uuid, user_agent, created_at, expires_at, last_used_at`
+
+	queryGetUserBySession = `
+		UPDATE sessions s SET last_used_at = NOW()
+		FROM users u
+		WHERE s.uuid = $1
+			AND u.uuid = s.user_uuid
+			AND s.revoked_at IS NULL
+			AND s.expires_at > NOW()
+		RETURNING u.uuid, u.email, u.name, u.created_at, u.email_verified_at IS NOT NULL, u.measurement_system`
+
+	queryGetSessionsByUser = `
+		SELECT uuid, user_uuid, user_agent, created_at, expires_at, last_used_at
+		FROM sessions
+		WHERE user_uuid = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at DESC`
+
+	queryRevokeSession = `
+		UPDATE sessions SET revoked_at = NOW()
+		WHERE uuid = $1 AND user_uuid = $2 AND revoked_at IS NULL`
+
+	queryRevokeUserSessions = `
+		UPDATE sessions SET revoked_at = NOW()
+		WHERE user_uuid = $1 AND revoked_at IS NULL`
+)
+
+func CreateSession(userUUID uuid.UUID, userAgent string, expiresAt time.Time) (*models.Session, error) {
+	var s models.Session
+	err := db.QueryRow(context.Background(), queryCreateSession, userUUID, userAgent, expiresAt).Scan(
+		&s.UUID, &s.UserUUID, &s.UserAgent, &s.CreatedAt, &s.ExpiresAt, &s.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetUserBySession returns the user for an active session and records its use.
+// Expired and revoked sessions return pgx.ErrNoRows.
+func GetUserBySession(sessionUUID uuid.UUID) (*models.User, error) {
+	var u models.User
+	err := db.QueryRow(context.Background(), queryGetUserBySession, sessionUUID).Scan(
+		&u.UUID, &u.Email, &u.Name, &u.CreatedAt, &u.EmailVerified, &u.MeasurementSystem)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func GetSessionsByUser(userUUID uuid.UUID) ([]models.Session, error) {
+	rows, err := db.Query(context.Background(), queryGetSessionsByUser, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var s models.Session
+		if err := rows.Scan(&s.UUID, &s.UserUUID, &s.UserAgent, &s.CreatedAt, &s.ExpiresAt, &s.LastUsedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession revokes one of a user's sessions. Returns false if the user
+// has no active session with that UUID.
+func RevokeSession(sessionUUID, userUUID uuid.UUID) (bool, error) {
+	tag, err := db.Exec(context.Background(), queryRevokeSession, sessionUUID, userUUID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// RevokeUserSessions revokes every active session belonging to a user
+func RevokeUserSessions(userUUID uuid.UUID) error {
+	_, err := db.Exec(context.Background(), queryRevokeUserSessions, userUUID)
+	return err
+}
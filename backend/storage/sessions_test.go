@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+func TestCreateSessionAndGetUser(t *testing.T) {
+	ensureTestUser(t)
+	user, _ := GetUserByEmail(testEmail)
+
+	session, err := CreateSession(user.UUID, "test-agent", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	defer RevokeSession(session.UUID, user.UUID)
+
+	found, err := GetUserBySession(session.UUID)
+	if err != nil {
+		t.Fatalf("GetUserBySession failed: %v", err)
+	}
+	if found.UUID != user.UUID {
+		t.Errorf("Expected user %v, got %v", user.UUID, found.UUID)
+	}
+}
+
+func TestGetUserBySession_Expired(t *testing.T) {
+	ensureTestUser(t)
+	user, _ := GetUserByEmail(testEmail)
+
+	session, err := CreateSession(user.UUID, "test-agent", time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	if _, err := GetUserBySession(session.UUID); err == nil {
+		t.Error("Expected expired session to be rejected")
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	ensureTestUser(t)
+	user, _ := GetUserByEmail(testEmail)
+
+	session, err := CreateSession(user.UUID, "test-agent", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	revoked, err := RevokeSession(session.UUID, user.UUID)
+	if err != nil {
+		t.Fatalf("RevokeSession failed: %v", err)
+	}
+	if !revoked {
+		t.Error("Expected session to be revoked")
+	}
+
+	if _, err := GetUserBySession(session.UUID); err == nil {
+		t.Error("Expected revoked session to be rejected")
+	}
+
+	revoked, err = RevokeSession(session.UUID, user.UUID)
+	if err != nil {
+		t.Fatalf("RevokeSession failed: %v", err)
+	}
+	if revoked {
+		t.Error("Expected second revoke to be a no-op")
+	}
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	ensureTestUser(t)
+	user, _ := GetUserByEmail(testEmail)
+
+	s1, _ := CreateSession(user.UUID, "agent-1", time.Now().Add(time.Hour))
+	s2, _ := CreateSession(user.UUID, "agent-2", time.Now().Add(time.Hour))
+
+	if err := RevokeUserSessions(user.UUID); err != nil {
+		t.Fatalf("RevokeUserSessions failed: %v", err)
+	}
+
+	for _, sessionUUID := range []uuid.UUID{s1.UUID, s2.UUID} {
+		if _, err := GetUserBySession(sessionUUID); err == nil {
+			t.Errorf("Expected session %v to be revoked", sessionUUID)
+		}
+	}
+
+	sessions, err := GetSessionsByUser(user.UUID)
+	if err != nil {
+		t.Fatalf("GetSessionsByUser failed: %v", err)
+	}
+	if len(sessions) != 0 {
+		t.Errorf("Expected no active sessions, got %d", len(sessions))
+	}
+}
@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cobyabrahams/hungr/ingredients"
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	queryShoppingListColumns = `SELECT uuid, user_uuid, name, created_at, updated_at FROM shopping_lists`
+
+	// queryInsertShoppingListItem links the item to the ingredient $3 is an
+	// alias of (by its key, $7), or else named $3 ignoring case, when there is
+	// one
+	queryInsertShoppingListItem = `
+		INSERT INTO shopping_list_items (shopping_list_uuid, ingredient_name_uuid, name, category, base_quantity, custom)
+		VALUES ($1, COALESCE(
+			(SELECT ingredient_name_uuid FROM ingredient_aliases WHERE alias = $7),
+			(SELECT uuid FROM ingredient_names WHERE lower(name) = lower($3) ORDER BY name LIMIT 1)), $2, $4, $5, $6)
+		RETURNING uuid`
+
+	// queryGetShoppingListItems finds each item's aisle from its ingredient,
+	// or else the last word of the ingredient's name, so "red onion" is in
+	// the same aisle as "onion"
+	queryGetShoppingListItems = `
+		SELECT i.uuid, i.name, i.category, i.base_quantity, COALESCE(n.aisle, h.aisle, 'other'), i.checked, i.custom
+		FROM shopping_list_items i
+		LEFT JOIN ingredient_names n ON n.uuid = i.ingredient_name_uuid
+		LEFT JOIN ingredient_names h ON h.name = regexp_replace(lower(COALESCE(n.name, i.name)), '^.*\s', '')
+		WHERE i.shopping_list_uuid = $1
+		ORDER BY lower(i.name), i.created_at`
+
+	queryGetShoppingListRecipes = `
+		SELECT r.uuid, r.name, s.scale
+		FROM shopping_list_recipes s
+		JOIN recipes r ON r.uuid = s.recipe_uuid
+		WHERE s.shopping_list_uuid = $1 AND r.deleted_at IS NULL
+		ORDER BY r.name`
+
+	queryTouchShoppingList = `UPDATE shopping_lists SET updated_at = NOW() WHERE uuid = $1`
+)
+
+// ShoppingListItemInput is an item to add to a shopping list
+type ShoppingListItemInput struct {
+	Name string
+	// IngredientName links the item to an ingredient, which decides its aisle
+	IngredientName string
+	// Category and BaseQuantity are empty for items without a quantity
+	Category     string
+	BaseQuantity float64
+	Custom       bool
+}
+
+// CreateShoppingList saves a user's shopping list with the recipes it was made
+// from and its items
+func CreateShoppingList(userUUID uuid.UUID, name string, recipes []models.ShoppingListRecipe, items []ShoppingListItemInput) (*models.ShoppingList, error) {
+	ctx := context.Background()
+	t, err := BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer t.Rollback(ctx)
+	tx := t.tx
+
+	var list models.ShoppingList
+	err = tx.QueryRow(ctx,
+		`INSERT INTO shopping_lists (user_uuid, name)
+		 VALUES ($1, $2)
+		 RETURNING uuid, user_uuid, name, created_at, updated_at`, userUUID, name).Scan(
+		&list.UUID, &list.UserUUID, &list.Name, &list.CreatedAt, &list.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shopping list: %w", err)
+	}
+
+	for _, recipe := range recipes {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO shopping_list_recipes (shopping_list_uuid, recipe_uuid, scale)
+			 VALUES ($1, $2, $3)`, list.UUID, recipe.RecipeUUID, recipe.Scale)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add recipe %s: %w", recipe.RecipeUUID, err)
+		}
+	}
+
+	for _, item := range items {
+		category, baseQuantity := itemQuantity(item)
+		_, err := tx.Exec(ctx, queryInsertShoppingListItem,
+			list.UUID, item.Name, item.IngredientName, category, baseQuantity, item.Custom, ingredients.Key(item.IngredientName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to add item %q: %w", item.Name, err)
+		}
+	}
+
+	if err := t.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// itemQuantity returns an item's category and base quantity, or nils for an
+// item without a quantity
+func itemQuantity(item ShoppingListItemInput) (*string, *float64) {
+	if item.Category == "" {
+		return nil, nil
+	}
+	return &item.Category, &item.BaseQuantity
+}
+
+// GetShoppingListsByUser returns the user's shopping lists, most recently
+// changed first
+func GetShoppingListsByUser(userUUID uuid.UUID) ([]models.ShoppingList, error) {
+	rows, err := db.Query(context.Background(),
+		queryShoppingListColumns+` WHERE user_uuid = $1 ORDER BY updated_at DESC`, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lists []models.ShoppingList
+	for rows.Next() {
+		var l models.ShoppingList
+		if err := rows.Scan(&l.UUID, &l.UserUUID, &l.Name, &l.CreatedAt, &l.UpdatedAt); err != nil {
+			return nil, err
+		}
+		lists = append(lists, l)
+	}
+	return lists, rows.Err()
+}
+
+// GetShoppingList returns one of the user's shopping lists, or sql.ErrNoRows
+// if they have no list with that uuid
+func GetShoppingList(userUUID, listUUID uuid.UUID) (*models.ShoppingList, error) {
+	var l models.ShoppingList
+	err := db.QueryRow(context.Background(),
+		queryShoppingListColumns+` WHERE uuid = $1 AND user_uuid = $2`, listUUID, userUUID).Scan(
+		&l.UUID, &l.UserUUID, &l.Name, &l.CreatedAt, &l.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// GetShoppingListRecipes returns the recipes a list was made from
+func GetShoppingListRecipes(listUUID uuid.UUID) ([]models.ShoppingListRecipe, error) {
+	rows, err := db.Query(context.Background(), queryGetShoppingListRecipes, listUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipes []models.ShoppingListRecipe
+	for rows.Next() {
+		var r models.ShoppingListRecipe
+		if err := rows.Scan(&r.RecipeUUID, &r.Name, &r.Scale); err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, r)
+	}
+	return recipes, rows.Err()
+}
+
+// GetShoppingListItems returns a list's items by name, with their aisles.
+// Quantity is left for the caller to format.
+func GetShoppingListItems(listUUID uuid.UUID) ([]models.ShoppingListItem, error) {
+	rows, err := db.Query(context.Background(), queryGetShoppingListItems, listUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.ShoppingListItem
+	for rows.Next() {
+		var i models.ShoppingListItem
+		var category *string
+		var baseQuantity *float64
+		if err := rows.Scan(&i.UUID, &i.Name, &category, &baseQuantity, &i.Aisle, &i.Checked, &i.Custom); err != nil {
+			return nil, err
+		}
+		if category != nil && baseQuantity != nil {
+			i.Category, i.BaseQuantity = *category, *baseQuantity
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+// AddShoppingListItem adds an item to a list
+func AddShoppingListItem(listUUID uuid.UUID, item ShoppingListItemInput) (uuid.UUID, error) {
+	ctx := context.Background()
+	category, baseQuantity := itemQuantity(item)
+	var itemUUID uuid.UUID
+	err := db.QueryRow(ctx, queryInsertShoppingListItem,
+		listUUID, item.Name, item.IngredientName, category, baseQuantity, item.Custom, ingredients.Key(item.IngredientName)).Scan(&itemUUID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	_, err = db.Exec(ctx, queryTouchShoppingList, listUUID)
+	return itemUUID, err
+}
+
+// SetShoppingListItemChecked checks an item off a list, or unchecks it,
+// reporting whether the list has the item
+func SetShoppingListItemChecked(listUUID, itemUUID uuid.UUID, checked bool) (bool, error) {
+	ctx := context.Background()
+	tag, err := db.Exec(ctx,
+		`UPDATE shopping_list_items SET checked = $3, updated_at = NOW()
+		 WHERE uuid = $2 AND shopping_list_uuid = $1`, listUUID, itemUUID, checked)
+	if err != nil || tag.RowsAffected() == 0 {
+		return false, err
+	}
+	_, err = db.Exec(ctx, queryTouchShoppingList, listUUID)
+	return true, err
+}
+
+// DeleteShoppingListItem removes an item from a list, reporting whether the
+// list had it
+func DeleteShoppingListItem(listUUID, itemUUID uuid.UUID) (bool, error) {
+	ctx := context.Background()
+	tag, err := db.Exec(ctx,
+		`DELETE FROM shopping_list_items WHERE uuid = $2 AND shopping_list_uuid = $1`, listUUID, itemUUID)
+	if err != nil || tag.RowsAffected() == 0 {
+		return false, err
+	}
+	_, err = db.Exec(ctx, queryTouchShoppingList, listUUID)
+	return true, err
+}
+
+// DeleteShoppingList deletes one of the user's lists and its items, reporting
+// whether they had it
+func DeleteShoppingList(userUUID, listUUID uuid.UUID) (bool, error) {
+	tag, err := db.Exec(context.Background(),
+		`DELETE FROM shopping_lists WHERE uuid = $1 AND user_uuid = $2`, listUUID, userUUID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/gofrs/uuid"
+)
+
+func TestShoppingListItems(t *testing.T) {
+	ensureTestUser(t)
+	user, _ := GetUserByEmail(testEmail)
+
+	list, err := CreateShoppingList(user.UUID, "aisles-test", nil, []ShoppingListItemInput{
+		{Name: "onion", IngredientName: "onion", Category: "count", BaseQuantity: 2},
+		{Name: "red onion", IngredientName: "red onion"},
+		{Name: "2 lemons", IngredientName: "lemon", Custom: true},
+		{Name: "paper towels", IngredientName: "paper towels", Custom: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateShoppingList failed: %v", err)
+	}
+	defer DeleteShoppingList(user.UUID, list.UUID)
+
+	items, err := GetShoppingListItems(list.UUID)
+	if err != nil {
+		t.Fatalf("GetShoppingListItems failed: %v", err)
+	}
+	wantAisles := map[string]string{"onion": "produce", "red onion": "produce", "2 lemons": "produce", "paper towels": "other"}
+	if len(items) != len(wantAisles) {
+		t.Fatalf("Expected %d items, got %+v", len(wantAisles), items)
+	}
+	for _, item := range items {
+		if item.Aisle != wantAisles[item.Name] {
+			t.Errorf("%s: expected aisle %q, got %q", item.Name, wantAisles[item.Name], item.Aisle)
+		}
+	}
+	if items[1].Name != "onion" || items[1].Category != "count" || items[1].BaseQuantity != 2 {
+		t.Errorf("Expected 2 onions, got %+v", items[1])
+	}
+
+	found, err := SetShoppingListItemChecked(list.UUID, items[0].UUID, true)
+	if err != nil || !found {
+		t.Fatalf("SetShoppingListItemChecked = %v, %v", found, err)
+	}
+	items, _ = GetShoppingListItems(list.UUID)
+	if !items[0].Checked || items[1].Checked {
+		t.Errorf("Expected only the first item checked, got %+v", items)
+	}
+
+	if _, err := GetShoppingList(uuid.Must(uuid.NewV4()), list.UUID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected another user's list to be not found, got %v", err)
+	}
+}
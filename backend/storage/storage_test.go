@@ -2,10 +2,13 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 const testEmail = "test@example.com"
@@ -67,7 +70,7 @@ func init() {
 func TestGetRecipesByUserEmail(t *testing.T) {
 	ensureTestUser(t)
 
-	recipes, err := GetRecipesByUserEmail(testEmail)
+	recipes, _, err := GetRecipesByUserEmail(testEmail, RecipePage{Limit: 100})
 	if err != nil {
 		t.Fatalf("GetRecipesByUserEmail failed: %v", err)
 	}
@@ -221,6 +224,7 @@ func TestDeleteRecipe(t *testing.T) {
 	if err != nil {
 		t.Fatalf("InsertRecipeByEmail failed: %v", err)
 	}
+	defer PurgeRecipe(recipe.UUID)
 
 	_, err = InsertFile(recipe.UUID, []byte("file data"), "image/jpeg", 0, true)
 	if err != nil {
@@ -232,22 +236,123 @@ func TestDeleteRecipe(t *testing.T) {
 		t.Fatalf("DeleteRecipe failed: %v", err)
 	}
 
-	recipes, err := GetRecipesByUserEmail(testEmail)
+	recipes, _, err := GetRecipesByUserEmail(testEmail, RecipePage{Limit: 100})
 	if err != nil {
 		t.Fatalf("GetRecipesByUserEmail failed: %v", err)
 	}
 	for _, r := range recipes {
 		if r.UUID == recipe.UUID {
-			t.Error("Recipe should have been deleted")
+			t.Error("Deleted recipe should not be listed")
 		}
 	}
+	if _, err := GetRecipeByUUID(recipe.UUID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("Expected ErrNoRows for deleted recipe, got %v", err)
+	}
 
+	trashed, err := GetDeletedRecipe(recipe.User, recipe.UUID)
+	if err != nil {
+		t.Fatalf("GetDeletedRecipe failed: %v", err)
+	}
+	if trashed.DeletedAt.IsZero() {
+		t.Error("Expected deleted_at to be set")
+	}
+
+	// Files are kept until the recipe is purged
+	files, err := GetFilesByRecipeUUIDs([]uuid.UUID{recipe.UUID})
+	if err != nil {
+		t.Fatalf("GetFilesByRecipeUUIDs failed: %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("Expected 1 file in trashed recipe, got %d", len(files))
+	}
+}
+
+func TestRestoreRecipe(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := InsertRecipeByEmail("restore-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("InsertRecipeByEmail failed: %v", err)
+	}
+	defer PurgeRecipe(recipe.UUID)
+
+	if err := DeleteRecipe(recipe.UUID); err != nil {
+		t.Fatalf("DeleteRecipe failed: %v", err)
+	}
+	if err := RestoreRecipe(recipe.UUID); err != nil {
+		t.Fatalf("RestoreRecipe failed: %v", err)
+	}
+
+	if _, err := GetRecipeByUUID(recipe.UUID); err != nil {
+		t.Errorf("Expected restored recipe to be found, got %v", err)
+	}
+	if _, err := GetDeletedRecipe(recipe.User, recipe.UUID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("Expected restored recipe to be out of the trash, got %v", err)
+	}
+}
+
+func TestPurgeRecipe(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := InsertRecipeByEmail("purge-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("InsertRecipeByEmail failed: %v", err)
+	}
+
+	_, err = InsertFile(recipe.UUID, []byte("file data"), "image/jpeg", 0, true)
+	if err != nil {
+		t.Fatalf("InsertFile failed: %v", err)
+	}
+
+	if err := DeleteRecipe(recipe.UUID); err != nil {
+		t.Fatalf("DeleteRecipe failed: %v", err)
+	}
+	if err := PurgeRecipe(recipe.UUID); err != nil {
+		t.Fatalf("PurgeRecipe failed: %v", err)
+	}
+
+	if _, err := GetDeletedRecipe(recipe.User, recipe.UUID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("Expected purged recipe to be gone, got %v", err)
+	}
 	files, err := GetFilesByRecipeUUIDs([]uuid.UUID{recipe.UUID})
 	if err != nil {
 		t.Fatalf("GetFilesByRecipeUUIDs failed: %v", err)
 	}
 	if len(files) != 0 {
-		t.Errorf("Expected 0 files after recipe deletion, got %d", len(files))
+		t.Errorf("Expected 0 files after purge, got %d", len(files))
+	}
+}
+
+func TestPurgeDeletedRecipes(t *testing.T) {
+	ensureTestUser(t)
+
+	recipe, err := InsertRecipeByEmail("purge-expired-test", testEmail, nil)
+	if err != nil {
+		t.Fatalf("InsertRecipeByEmail failed: %v", err)
+	}
+	defer PurgeRecipe(recipe.UUID)
+
+	if err := DeleteRecipe(recipe.UUID); err != nil {
+		t.Fatalf("DeleteRecipe failed: %v", err)
+	}
+
+	// Nothing was deleted before an hour ago
+	if _, err := PurgeDeletedRecipes(time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("PurgeDeletedRecipes failed: %v", err)
+	}
+	if _, err := GetDeletedRecipe(recipe.User, recipe.UUID); err != nil {
+		t.Fatalf("Expected recent recipe to stay in the trash, got %v", err)
+	}
+
+	purged, err := PurgeDeletedRecipes(time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("PurgeDeletedRecipes failed: %v", err)
+	}
+	if purged < 1 {
+		t.Errorf("Expected at least 1 purged recipe, got %d", purged)
+	}
+	if _, err := GetDeletedRecipe(recipe.User, recipe.UUID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("Expected expired recipe to be purged, got %v", err)
 	}
 }
 
@@ -313,7 +418,7 @@ func TestTransactionCommit(t *testing.T) {
 	}
 
 	// Cleanup
-	DeleteRecipe(recipe.UUID)
+	PurgeRecipe(recipe.UUID)
 }
 
 func TestTransactionRollback(t *testing.T) {
@@ -350,7 +455,7 @@ func TestTransactionRollback(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error getting recipe after rollback, but got nil")
 		// Cleanup if it somehow exists
-		DeleteRecipe(recipe.UUID)
+		PurgeRecipe(recipe.UUID)
 	}
 
 	// Verify no files exist for the rolled-back recipe
@@ -362,3 +467,97 @@ func TestTransactionRollback(t *testing.T) {
 		t.Errorf("Expected 0 files after rollback, got %d", len(files))
 	}
 }
+
+func TestTxForkRecipe(t *testing.T) {
+	ensureTestUser(t)
+	ensureTestUser2(t)
+	forker, _ := GetUserByEmail(testEmail2)
+	ctx := context.Background()
+
+	source := "https://example.com/original"
+	original, err := InsertRecipeByEmail("fork-original", testEmail, &source)
+	if err != nil {
+		t.Fatalf("InsertRecipeByEmail failed: %v", err)
+	}
+	defer PurgeRecipe(original.UUID)
+
+	if _, err := InsertFile(original.UUID, []byte("image data"), "image/png", 0, true); err != nil {
+		t.Fatalf("InsertFile failed: %v", err)
+	}
+	for _, tag := range []string{"fork-b", "fork-a"} {
+		tagUUID := CreateTagUUID(tag)
+		UpsertTag(tagUUID, tag)
+		if err := InsertRecipeTag(original.UUID, tagUUID); err != nil {
+			t.Fatalf("InsertRecipeTag failed: %v", err)
+		}
+	}
+	err = ReplaceRecipeSteps(original.UUID, []StepInput{
+		{Instruction: "Chop", Ingredients: []IngredientInput{{Name: "onion", Unit: "count", Quantity: 1}}},
+		{Instruction: "Fry", Ingredients: []IngredientInput{{Name: "oil", Unit: "tbsp", Quantity: 2}}},
+	})
+	if err != nil {
+		t.Fatalf("ReplaceRecipeSteps failed: %v", err)
+	}
+
+	tx, err := BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("BeginTx failed: %v", err)
+	}
+	defer tx.Rollback(ctx)
+	forkUUID, err := TxForkRecipe(ctx, tx, original.UUID, forker.UUID)
+	if err != nil {
+		t.Fatalf("TxForkRecipe failed: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	defer PurgeRecipe(forkUUID)
+
+	fork, err := GetRecipeByUUID(forkUUID)
+	if err != nil {
+		t.Fatalf("GetRecipeByUUID failed: %v", err)
+	}
+	if fork.User != forker.UUID || fork.Name != original.Name || *fork.Source != source {
+		t.Errorf("Fork has wrong owner, name or source: %+v", fork)
+	}
+	if fork.ForkedFrom == nil || *fork.ForkedFrom != original.UUID {
+		t.Errorf("Expected forked_from %v, got %v", original.UUID, fork.ForkedFrom)
+	}
+	if fork.ForkedFromEmail == nil || *fork.ForkedFromEmail != testEmail {
+		t.Errorf("Expected forked_from_email %s, got %v", testEmail, fork.ForkedFromEmail)
+	}
+	if fork.TagString != "fork-b, fork-a" {
+		t.Errorf("Expected tags in original order, got %q", fork.TagString)
+	}
+
+	original, err = GetRecipeByUUID(original.UUID)
+	if err != nil {
+		t.Fatalf("GetRecipeByUUID failed: %v", err)
+	}
+	if original.ForkCount != 1 {
+		t.Errorf("Expected fork_count 1 on the original, got %d", original.ForkCount)
+	}
+
+	files, err := GetFilesByRecipeUUIDs([]uuid.UUID{forkUUID})
+	if err != nil {
+		t.Fatalf("GetFilesByRecipeUUIDs failed: %v", err)
+	}
+	if len(files) != 1 || files[0].URL != "/api/files/"+files[0].UUID.String() {
+		t.Fatalf("Expected one copied file with its own URL, got %+v", files)
+	}
+	data, contentType, err := GetFileData(files[0].UUID)
+	if err != nil || string(data) != "image data" || contentType != "image/png" {
+		t.Errorf("Copied file data mismatch: %q %q %v", data, contentType, err)
+	}
+
+	steps, err := GetRecipeStepsWithIngredients(forkUUID)
+	if err != nil {
+		t.Fatalf("GetRecipeStepsWithIngredients failed: %v", err)
+	}
+	if len(steps) != 2 || steps[0].Instructions != "Chop" || steps[1].Instructions != "Fry" {
+		t.Fatalf("Expected copied steps Chop, Fry; got %+v", steps)
+	}
+	if len(steps[1].Ingredients) != 1 || steps[1].Ingredients[0].IngredientName != "oil" {
+		t.Errorf("Expected oil in the second step, got %+v", steps[1].Ingredients)
+	}
+}
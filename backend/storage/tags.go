@@ -2,11 +2,45 @@ package storage
 
 import (
 	"context"
+	"slices"
+	"strings"
 
 	"github.com/cobyabrahams/hungr/models"
 	"github.com/gofrs/uuid"
 )
 
+// TagFilter narrows a recipe listing by tag name, ignoring case. A recipe
+// matches when it has all of Tags (or any of them with MatchAny) and none of
+// Exclude. The zero value matches every recipe.
+type TagFilter struct {
+	Tags     []string
+	Exclude  []string
+	MatchAny bool
+}
+
+// args returns the lowercased, deduplicated query arguments for
+// recipeListFilter
+func (f TagFilter) args() (tags []string, minMatch int, exclude []string) {
+	tags = normalizeTagNames(f.Tags)
+	exclude = normalizeTagNames(f.Exclude)
+	minMatch = len(tags)
+	if f.MatchAny && minMatch > 0 {
+		minMatch = 1
+	}
+	return tags, minMatch, exclude
+}
+
+func normalizeTagNames(names []string) []string {
+	normalized := []string{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && !slices.Contains(normalized, name) {
+			normalized = append(normalized, name)
+		}
+	}
+	return normalized
+}
+
 const tagNamespace = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
 
 const (
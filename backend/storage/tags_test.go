@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/cobyabrahams/hungr/models"
+	"github.com/gofrs/uuid"
+)
+
+func TestTagFilterArgs(t *testing.T) {
+	tests := []struct {
+		desc         string
+		filter       TagFilter
+		wantTags     []string
+		wantMinMatch int
+		wantExclude  []string
+	}{
+		{"zero value", TagFilter{}, []string{}, 0, []string{}},
+		{"match all", TagFilter{Tags: []string{"Dinner", "quick"}}, []string{"dinner", "quick"}, 2, []string{}},
+		{"match any", TagFilter{Tags: []string{"dinner", "quick"}, MatchAny: true}, []string{"dinner", "quick"}, 1, []string{}},
+		{"any without tags", TagFilter{MatchAny: true}, []string{}, 0, []string{}},
+		{"duplicates and blanks", TagFilter{Tags: []string{"dinner", " DINNER ", ""}}, []string{"dinner"}, 1, []string{}},
+		{"exclude", TagFilter{Exclude: []string{"Spicy"}}, []string{}, 0, []string{"spicy"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			tags, minMatch, exclude := tt.filter.args()
+			if !slices.Equal(tags, tt.wantTags) {
+				t.Errorf("tags = %q, want %q", tags, tt.wantTags)
+			}
+			if minMatch != tt.wantMinMatch {
+				t.Errorf("minMatch = %d, want %d", minMatch, tt.wantMinMatch)
+			}
+			if !slices.Equal(exclude, tt.wantExclude) {
+				t.Errorf("exclude = %q, want %q", exclude, tt.wantExclude)
+			}
+		})
+	}
+}
+
+func TestGetRecipesByUserEmail_TagFilter(t *testing.T) {
+	ensureTestUser(t)
+
+	// Tag names are unique to this test so other recipes never match
+	recipeTags := map[string][]string{
+		"quick dinner":  {"tagfilter-dinner", "tagfilter-quick"},
+		"spicy dinner":  {"tagfilter-dinner", "tagfilter-spicy"},
+		"quick snack":   {"tagfilter-quick"},
+		"untagged dish": nil,
+	}
+	byName := map[string]uuid.UUID{}
+	for name, tags := range recipeTags {
+		recipe, err := InsertRecipeByEmail("tagfilter "+name, testEmail, nil)
+		if err != nil {
+			t.Fatalf("InsertRecipeByEmail failed: %v", err)
+		}
+		defer PurgeRecipe(recipe.UUID)
+		byName[name] = recipe.UUID
+
+		for _, tag := range tags {
+			tagUUID := CreateTagUUID(tag)
+			if _, err := UpsertTag(tagUUID, tag); err != nil {
+				t.Fatalf("UpsertTag failed: %v", err)
+			}
+			if err := InsertRecipeTag(recipe.UUID, tagUUID); err != nil {
+				t.Fatalf("InsertRecipeTag failed: %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		desc   string
+		filter TagFilter
+		want   []string
+	}{
+		{"all", TagFilter{Tags: []string{"tagfilter-dinner", "tagfilter-quick"}}, []string{"quick dinner"}},
+		{"any", TagFilter{Tags: []string{"tagfilter-dinner", "tagfilter-quick"}, MatchAny: true},
+			[]string{"quick dinner", "spicy dinner", "quick snack"}},
+		{"exclude", TagFilter{Tags: []string{"tagfilter-dinner"}, Exclude: []string{"tagfilter-spicy"}}, []string{"quick dinner"}},
+		{"case insensitive", TagFilter{Tags: []string{"TagFilter-Quick"}}, []string{"quick dinner", "quick snack"}},
+		{"exclude only", TagFilter{Exclude: []string{"tagfilter-quick", "tagfilter-spicy"}}, []string{"untagged dish"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			recipes, _, err := GetRecipesByUserEmail(testEmail, RecipePage{Tags: tt.filter, Limit: 1000})
+			if err != nil {
+				t.Fatalf("GetRecipesByUserEmail failed: %v", err)
+			}
+			for name, id := range byName {
+				found := slices.ContainsFunc(recipes, func(r models.Recipe) bool { return r.UUID == id })
+				if want := slices.Contains(tt.want, name); found != want {
+					t.Errorf("%q returned = %v, want %v", name, found, want)
+				}
+			}
+		})
+	}
+
+	t.Run("tag counts", func(t *testing.T) {
+		counts, err := GetTagCountsByUserEmail(testEmail, TagFilter{Tags: []string{"tagfilter-dinner"}})
+		if err != nil {
+			t.Fatalf("GetTagCountsByUserEmail failed: %v", err)
+		}
+		got := map[string]int{}
+		for _, c := range counts {
+			got[c.Name] = c.Count
+		}
+		want := map[string]int{"tagfilter-dinner": 2, "tagfilter-quick": 1, "tagfilter-spicy": 1}
+		for name, count := range want {
+			if got[name] != count {
+				t.Errorf("count for %q = %d, want %d", name, got[name], count)
+			}
+		}
+	})
+}
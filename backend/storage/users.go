@@ -9,28 +9,27 @@ import (
 
 const (
 	queryGetUserByUUID = `
-		SELECT uuid, email, name, created_at
+		SELECT uuid, email, name, created_at, email_verified_at IS NOT NULL, measurement_system
 		FROM users WHERE uuid = $1`
 
 	queryGetUserByEmail = `
-		SELECT uuid, email, name, created_at
+		SELECT uuid, email, name, created_at, email_verified_at IS NOT NULL, measurement_system
 		FROM users WHERE email = $1`
 
 	queryCreateUser = `
 		INSERT INTO users (email, name, last_seen)
 		VALUES ($1, $2, NOW())
-		RETURNING uuid, email, name, created_at`
-
-	queryUpsertUserOnLogin = `
-		INSERT INTO users (email, name, last_seen)
-		VALUES ($1, $1, NOW())
-		ON CONFLICT (email) DO UPDATE SET last_seen = NOW()
-		RETURNING uuid, email, name, created_at`
+		RETURNING uuid, email, name, created_at, email_verified_at IS NOT NULL, measurement_system`
 
 	queryUpdateUser = `
 		UPDATE users SET name = $1
 		WHERE uuid = $2
-		RETURNING uuid, email, name, created_at`
+		RETURNING uuid, email, name, created_at, email_verified_at IS NOT NULL, measurement_system`
+
+	queryUpdateUserMeasurementSystem = `
+		UPDATE users SET measurement_system = $1
+		WHERE uuid = $2
+		RETURNING uuid, email, name, created_at, email_verified_at IS NOT NULL, measurement_system`
 
 	queryDeleteUser = `DELETE FROM users WHERE uuid = $1`
 )
@@ -38,7 +37,7 @@ const (
 func GetUserByUUID(userUUID uuid.UUID) (*models.User, error) {
 	var u models.User
 	err := db.QueryRow(context.Background(), queryGetUserByUUID, userUUID).Scan(
-		&u.UUID, &u.Email, &u.Name, &u.CreatedAt)
+		&u.UUID, &u.Email, &u.Name, &u.CreatedAt, &u.EmailVerified, &u.MeasurementSystem)
 	if err != nil {
 		return nil, err
 	}
@@ -48,7 +47,7 @@ func GetUserByUUID(userUUID uuid.UUID) (*models.User, error) {
 func GetUserByEmail(email string) (*models.User, error) {
 	var u models.User
 	err := db.QueryRow(context.Background(), queryGetUserByEmail, email).Scan(
-		&u.UUID, &u.Email, &u.Name, &u.CreatedAt)
+		&u.UUID, &u.Email, &u.Name, &u.CreatedAt, &u.EmailVerified, &u.MeasurementSystem)
 	if err != nil {
 		return nil, err
 	}
@@ -58,27 +57,29 @@ func GetUserByEmail(email string) (*models.User, error) {
 func CreateUser(email, name string) (*models.User, error) {
 	var u models.User
 	err := db.QueryRow(context.Background(), queryCreateUser, email, name).Scan(
-		&u.UUID, &u.Email, &u.Name, &u.CreatedAt)
+		&u.UUID, &u.Email, &u.Name, &u.CreatedAt, &u.EmailVerified, &u.MeasurementSystem)
 	if err != nil {
 		return nil, err
 	}
 	return &u, nil
 }
 
-func UpsertUserOnLogin(email string) (*models.User, error) {
+func UpdateUser(userUUID uuid.UUID, name string) (*models.User, error) {
 	var u models.User
-	err := db.QueryRow(context.Background(), queryUpsertUserOnLogin, email).Scan(
-		&u.UUID, &u.Email, &u.Name, &u.CreatedAt)
+	err := db.QueryRow(context.Background(), queryUpdateUser, name, userUUID).Scan(
+		&u.UUID, &u.Email, &u.Name, &u.CreatedAt, &u.EmailVerified, &u.MeasurementSystem)
 	if err != nil {
 		return nil, err
 	}
 	return &u, nil
 }
 
-func UpdateUser(userUUID uuid.UUID, name string) (*models.User, error) {
+// UpdateUserMeasurementSystem sets the units the user sees recipes in; nil
+// clears the preference
+func UpdateUserMeasurementSystem(userUUID uuid.UUID, system *string) (*models.User, error) {
 	var u models.User
-	err := db.QueryRow(context.Background(), queryUpdateUser, name, userUUID).Scan(
-		&u.UUID, &u.Email, &u.Name, &u.CreatedAt)
+	err := db.QueryRow(context.Background(), queryUpdateUserMeasurementSystem, system, userUUID).Scan(
+		&u.UUID, &u.Email, &u.Name, &u.CreatedAt, &u.EmailVerified, &u.MeasurementSystem)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,76 @@
+package units
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Temperature units. Converting them needs an offset as well as a factor, so
+// unlike volume and mass they aren't DerivedUnits; Celsius plays the part of
+// the base unit.
+const (
+	Celsius    = "C"
+	Fahrenheit = "F"
+)
+
+var temperatureAliases = map[string]string{
+	"c":          Celsius,
+	"°c":         Celsius,
+	"celsius":    Celsius,
+	"centigrade": Celsius,
+	"f":          Fahrenheit,
+	"°f":         Fahrenheit,
+	"fahrenheit": Fahrenheit,
+}
+
+// ParseTemperatureUnit is ParseUnit for temperature units such as "°F", "c"
+// or "Celsius", returning Celsius or Fahrenheit and CategoryTemperature
+func ParseTemperatureUnit(s string) (string, UnitCategory, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.NewReplacer("º", "°", "˚", "°").Replace(s)
+	if unit, ok := temperatureAliases[s]; ok {
+		return unit, CategoryTemperature, nil
+	}
+	return "", "", fmt.Errorf("unknown temperature unit: %s", s)
+}
+
+// ConvertTemperature converts value from one temperature unit to another
+func ConvertTemperature(value float64, from, to string) (float64, error) {
+	celsius, category, err := ToBaseUnit(value, from)
+	if err != nil {
+		return 0, err
+	}
+	if category != CategoryTemperature {
+		return 0, fmt.Errorf("unknown temperature unit: %s", from)
+	}
+	return FromBaseUnit(celsius, category, to)
+}
+
+// FormatTemperature writes a CategoryTemperature quantity to the nearest
+// degree, e.g. "177°C"
+func FormatTemperature(q Quantity) string {
+	return fmt.Sprintf("%.0f°%s", math.Round(q.Value), q.Unit)
+}
+
+// toCelsius converts a temperature to the base unit for ToBaseUnit
+func toCelsius(value float64, unit string) (float64, bool) {
+	switch unit {
+	case Celsius:
+		return value, true
+	case Fahrenheit:
+		return (value - 32) * 5 / 9, true
+	}
+	return 0, false
+}
+
+// fromCelsius converts a temperature from the base unit for FromBaseUnit
+func fromCelsius(celsius float64, unit string) (float64, bool) {
+	switch unit {
+	case Celsius:
+		return celsius, true
+	case Fahrenheit:
+		return celsius*9/5 + 32, true
+	}
+	return 0, false
+}
@@ -3,8 +3,12 @@ package units
 import (
 	"fmt"
 	"math"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/cobyabrahams/hungr/models"
 )
@@ -17,6 +21,9 @@ const (
 	CategoryVolume UnitCategory = "volume"
 	CategoryMass   UnitCategory = "mass"
 	CategoryCount  UnitCategory = "count"
+	// CategoryTemperature is only used for temperatures in step instructions;
+	// see ConvertTemperature
+	CategoryTemperature UnitCategory = "temperature"
 )
 
 type DerivedUnit struct {
@@ -27,6 +34,8 @@ type DerivedUnit struct {
 	PluralAbbrev string
 	NoDisplay    bool   // If true, unit can be parsed but won't be chosen for display
 	ParentUnit   string // For fractional units, the parent unit (e.g., "tsp" for "half_tsp")
+	Imperial     bool   // If true, unit is only chosen for display in the imperial system
+	Inexact      bool   // If true, a measurement system only chooses it for a few of them (pinch, dash, can)
 }
 
 // VolumeUnits (base: ml)
@@ -50,20 +59,20 @@ var VolumeUnits = map[string]DerivedUnit{
 	//	"pt":     {ToBase: 473.176, Name: "pint", Abbrev: "pt", PluralName: "pints"},
 	"qt":  {ToBase: 946.353, Name: "quart", Abbrev: "qt", PluralName: "quarts"},
 	"gal": {ToBase: 3785.41, Name: "gallon", Abbrev: "gal", PluralName: "gallons"},
-	"can": {ToBase: 458, Name: "can", Abbrev: "can", PluralName: "cans"},
+	"can": {ToBase: 458, Name: "can", Abbrev: "can", PluralName: "cans", Inexact: true},
 
 	// "drop":    {ToBase: 0.05, Name: "drop", Abbrev: "drop", PluralName: "drops", NoDisplay: true},
 	"smidgen": {ToBase: 0.115522, Name: "smidgen", Abbrev: "smidgen", PluralName: "smidgens", NoDisplay: true},
-	"pinch":   {ToBase: 0.231043, Name: "pinch", Abbrev: "pinch", PluralName: "pinches"},
-	"dash":    {ToBase: 0.462086, Name: "dash", Abbrev: "dash", PluralName: "dashes"},
-
-	// "imp_tsp":   {ToBase: 5.91939, Name: "imperial teaspoon", Abbrev: "imp tsp", PluralName: "imperial teaspoons"},
-	// "imp_tbsp":  {ToBase: 17.7582, Name: "imperial tablespoon", Abbrev: "imp tbsp", PluralName: "imperial tablespoons"},
-	// "imp_fl_oz": {ToBase: 28.4131, Name: "imperial fluid ounce", Abbrev: "imp fl oz", PluralName: "imperial fluid ounces"},
-	// "imp_cup":   {ToBase: 284.131, Name: "imperial cup", Abbrev: "imp cup", PluralName: "imperial cups"},
-	// "imp_pt":    {ToBase: 568.261, Name: "imperial pint", Abbrev: "imp pt", PluralName: "imperial pints"},
-	// "imp_qt":    {ToBase: 1136.52, Name: "imperial quart", Abbrev: "imp qt", PluralName: "imperial quarts"},
-	// "imp_gal":   {ToBase: 4546.09, Name: "imperial gallon", Abbrev: "imp gal", PluralName: "imperial gallons"},
+	"pinch":   {ToBase: 0.231043, Name: "pinch", Abbrev: "pinch", PluralName: "pinches", Inexact: true},
+	"dash":    {ToBase: 0.462086, Name: "dash", Abbrev: "dash", PluralName: "dashes", Inexact: true},
+
+	"imp_tsp":   {ToBase: 5.91939, Name: "imperial teaspoon", Abbrev: "imp tsp", PluralName: "imperial teaspoons", Imperial: true},
+	"imp_tbsp":  {ToBase: 17.7582, Name: "imperial tablespoon", Abbrev: "imp tbsp", PluralName: "imperial tablespoons", Imperial: true},
+	"imp_fl_oz": {ToBase: 28.4131, Name: "imperial fluid ounce", Abbrev: "imp fl oz", PluralName: "imperial fluid ounces", Imperial: true},
+	"imp_cup":   {ToBase: 284.131, Name: "imperial cup", Abbrev: "imp cup", PluralName: "imperial cups", Imperial: true},
+	"imp_pt":    {ToBase: 568.261, Name: "imperial pint", Abbrev: "imp pt", PluralName: "imperial pints", Imperial: true},
+	"imp_qt":    {ToBase: 1136.52, Name: "imperial quart", Abbrev: "imp qt", PluralName: "imperial quarts", Imperial: true},
+	"imp_gal":   {ToBase: 4546.09, Name: "imperial gallon", Abbrev: "imp gal", PluralName: "imperial gallons", Imperial: true},
 }
 
 // MassUnits (base: mg)
@@ -83,6 +92,42 @@ var MassUnits = map[string]DerivedUnit{
 	//"dwt":  {ToBase: 1555.17, Name: "pennyweight", Abbrev: "dwt", PluralName: "pennyweights"},
 }
 
+// MeasurementSystem restricts the units quantities are displayed in
+type MeasurementSystem string
+
+const (
+	SystemMetric   MeasurementSystem = "metric"
+	SystemUS       MeasurementSystem = "us"
+	SystemImperial MeasurementSystem = "imperial"
+	// SystemAsWritten shows each ingredient in the unit it was entered in
+	SystemAsWritten MeasurementSystem = "as_written"
+)
+
+// systemUnits are the units each system displays, across categories
+var systemUnits = map[MeasurementSystem][]string{
+	SystemMetric: {"ml", "l", "pinch", "dash", "can", "mcg", "mg", "g", "kg"},
+	SystemUS: {"tsp", "half_tsp", "third_tsp", "qtr_tsp", "eighth_tsp", "tbsp", "cup", "half_cup", "third_cup", "qtr_cup",
+		"qt", "gal", "pinch", "dash", "can", "oz", "lb"},
+	SystemImperial: {"imp_tsp", "imp_tbsp", "imp_fl_oz", "imp_cup", "imp_pt", "imp_qt", "imp_gal", "pinch", "dash", "can", "oz", "lb"},
+}
+
+// ParseMeasurementSystem validates a measurement system name; "" means no
+// preference
+func ParseMeasurementSystem(s string) (MeasurementSystem, error) {
+	switch system := MeasurementSystem(s); system {
+	case "", SystemMetric, SystemUS, SystemImperial, SystemAsWritten:
+		return system, nil
+	default:
+		return "", fmt.Errorf("unknown measurement system %q", s)
+	}
+}
+
+// Units returns the units the system displays quantities in, or nil when any
+// unit may be used
+func (s MeasurementSystem) Units() []string {
+	return systemUnits[s]
+}
+
 type Quantity struct {
 	Value    float64
 	Unit     string
@@ -133,6 +178,9 @@ func ToBaseUnit(value float64, unit string) (float64, UnitCategory, error) {
 	if unit == "count" {
 		return value, CategoryCount, nil
 	}
+	if celsius, ok := toCelsius(value, unit); ok {
+		return celsius, CategoryTemperature, nil
+	}
 	return 0, "", fmt.Errorf("unknown unit: %s", unit)
 }
 
@@ -150,6 +198,11 @@ func FromBaseUnit(baseValue float64, category UnitCategory, targetUnit string) (
 		return 0, fmt.Errorf("unknown mass unit: %s", targetUnit)
 	case CategoryCount:
 		return baseValue, nil
+	case CategoryTemperature:
+		if value, ok := fromCelsius(baseValue, targetUnit); ok {
+			return value, nil
+		}
+		return 0, fmt.Errorf("unknown temperature unit: %s", targetUnit)
 	default:
 		return 0, fmt.Errorf("unknown category: %s", category)
 	}
@@ -177,6 +230,44 @@ func Convert(value float64, from, to string) (float64, error) {
 	return FromBaseUnit(baseValue, category, to)
 }
 
+// ConvertWithDensity is Convert for an ingredient, which can also convert
+// between volume and mass given its density in grams per ml
+func ConvertWithDensity(value float64, from, to string, gramsPerML float64) (float64, error) {
+	baseValue, category, err := ToBaseUnit(value, from)
+	if err != nil {
+		return 0, err
+	}
+	_, toCategory, err := ToBaseUnit(1, to)
+	if err != nil {
+		return 0, fmt.Errorf("unknown target unit: %s", to)
+	}
+
+	converted, err := ConvertBase(baseValue, category, toCategory, gramsPerML)
+	if err != nil {
+		return 0, fmt.Errorf("cannot convert between %s (%s) and %s (%s): %w", from, category, to, toCategory, err)
+	}
+	return FromBaseUnit(converted, toCategory, to)
+}
+
+// ConvertBase converts a quantity in base units between the volume (ml) and
+// mass (mg) categories using a density in grams per ml. A quantity already in
+// the target category is returned unchanged.
+func ConvertBase(baseValue float64, from, to UnitCategory, gramsPerML float64) (float64, error) {
+	mgPerML := gramsPerML * MassUnits["g"].ToBase
+	switch {
+	case from == to:
+		return baseValue, nil
+	case gramsPerML <= 0:
+		return 0, fmt.Errorf("density must be positive")
+	case from == CategoryVolume && to == CategoryMass:
+		return baseValue * mgPerML, nil
+	case from == CategoryMass && to == CategoryVolume:
+		return baseValue / mgPerML, nil
+	default:
+		return 0, fmt.Errorf("no density conversion from %s to %s", from, to)
+	}
+}
+
 func isNearInteger(value float64, tolerance float64) bool {
 	rounded := math.Round(value)
 	if rounded == 0 {
@@ -189,14 +280,27 @@ func isNearInteger(value float64, tolerance float64) bool {
 // FindBestIntegerUnit finds the largest unit where the value converts to within
 // IntegerTolerance of an integer. Returns base unit if no match found.
 func FindBestIntegerUnit(baseValue float64, category UnitCategory) Quantity {
-	return FindBestIntegerUnitWithTolerance(baseValue, category, IntegerTolerance)
+	return FindBestIntegerUnitWithTolerance(baseValue, category, IntegerTolerance, nil)
 }
 
 // MaxFractionalUnitQuantity is the maximum quantity for which fractional units
 // (like ½ tsp, ¼ cup) are used. Above this, we display as a decimal of the parent unit.
 const MaxFractionalUnitQuantity = 2
 
-func FindBestIntegerUnitWithTolerance(baseValue float64, category UnitCategory, tolerance float64) Quantity {
+// With a measurement system, a whole number of a unit is only preferred up to
+// MaxSystemIntegerQuantity (MaxInexactUnitQuantity for pinches and dashes).
+// Above that nearly any value is within tolerance of an integer, so it is shown
+// as a decimal of the largest unit instead, e.g. 1.06 qt rather than 68 tbsp.
+const (
+	MaxSystemIntegerQuantity = 10
+	MaxInexactUnitQuantity   = 4
+)
+
+// FindBestIntegerUnitWithTolerance is FindBestIntegerUnit with a tolerance,
+// choosing only from the allowed units when allowed isn't nil. When no allowed
+// unit gives an integer it uses the largest allowed unit the value is at least
+// one of, or the smallest allowed unit, rather than the base unit.
+func FindBestIntegerUnitWithTolerance(baseValue float64, category UnitCategory, tolerance float64, allowed []string) Quantity {
 	if category == CategoryCount {
 		return Quantity{Value: baseValue, Unit: "count", Category: CategoryCount}
 	}
@@ -220,12 +324,18 @@ func FindBestIntegerUnitWithTolerance(baseValue float64, category UnitCategory,
 
 	for _, unitKey := range sortedUnits {
 		unit := unitMap[unitKey]
-		if unit.NoDisplay {
+		if allowed != nil && !slices.Contains(allowed, unitKey) {
+			continue
+		}
+		if allowed == nil && (unit.NoDisplay || unit.Imperial) {
 			continue
 		}
 		converted := baseValue / unit.ToBase
 		if converted >= 1 && isNearInteger(converted, tolerance) {
 			roundedValue := math.Round(converted)
+			if allowed != nil && (roundedValue > MaxSystemIntegerQuantity || unit.Inexact && roundedValue > MaxInexactUnitQuantity) {
+				continue
+			}
 
 			// For fractional units (those with a ParentUnit), if the quantity is too large,
 			// display as a decimal of the parent unit instead
@@ -247,53 +357,205 @@ func FindBestIntegerUnitWithTolerance(baseValue float64, category UnitCategory,
 		}
 	}
 
+	if allowed != nil && baseValue > 0 {
+		fallback := ""
+		for _, unitKey := range sortedUnits {
+			if !slices.Contains(allowed, unitKey) || unitMap[unitKey].ParentUnit != "" || unitMap[unitKey].Inexact {
+				continue
+			}
+			fallback = unitKey
+			if baseValue/unitMap[unitKey].ToBase >= 1 {
+				break
+			}
+		}
+		if fallback != "" {
+			return Quantity{Value: baseValue / unitMap[fallback].ToBase, Unit: fallback, Category: category}
+		}
+	}
+
 	return Quantity{Value: baseValue, Unit: baseUnit, Category: category}
 }
 
+// FractionStyle is how Format writes quantities that aren't whole numbers
+type FractionStyle int
+
+const (
+	// FractionDefault uses FractionUnicode for US customary units and
+	// FractionDecimal for everything else
+	FractionDefault FractionStyle = iota
+	// FractionDecimal writes decimals: "1.50 tsp"
+	FractionDecimal
+	// FractionUnicode writes the nearest kitchen fraction: "1 ½ tsp"
+	FractionUnicode
+	// FractionASCII is FractionUnicode for plain text: "1 1/2 tsp"
+	FractionASCII
+)
+
+// FractionTolerance is how far, relative to the value, the nearest kitchen
+// fraction may be before Format falls back to a decimal
+const FractionTolerance = 0.02
+
+// kitchenDenominators are the fractions Format rounds to, simplest first
+var kitchenDenominators = []int{1, 2, 3, 4, 8}
+
+// fractionGlyphs are the unicode characters for the kitchen fractions
+var fractionGlyphs = map[[2]int]string{
+	{1, 2}: "½", {1, 3}: "⅓", {2, 3}: "⅔", {1, 4}: "¼", {3, 4}: "¾",
+	{1, 8}: "⅛", {3, 8}: "⅜", {5, 8}: "⅝", {7, 8}: "⅞",
+}
+
+// Format writes q in the default style for its unit
 func Format(q Quantity) string {
+	return FormatWith(q, FractionDefault)
+}
+
+// FormatWith writes q with the given fraction style. Fractional units such as
+// half_tsp are written as their parent unit when using fractions, so 2 half
+// teaspoons is "1 tsp".
+func FormatWith(q Quantity, style FractionStyle) string {
 	if q.Category == CategoryCount {
-		if q.Value == 1 {
-			return "1"
-		}
-		return fmt.Sprintf("%.0f", q.Value)
+		return formatCount(q.Value, style)
 	}
-
-	var unit DerivedUnit
-	var ok bool
-	switch q.Category {
-	case CategoryVolume:
-		unit, ok = VolumeUnits[q.Unit]
-	case CategoryMass:
-		unit, ok = MassUnits[q.Unit]
+	if q.Category == CategoryTemperature {
+		return FormatTemperature(q)
 	}
 
-	if !ok {
+	unit, _, err := GetDerivedUnit(q.Unit)
+	if err != nil {
 		return fmt.Sprintf("%.2f %s", q.Value, q.Unit)
 	}
 
-	var formatted string
-	if q.Value == float64(int(q.Value)) {
-		formatted = fmt.Sprintf("%d", int(q.Value))
-	} else if q.Value < 0.1 {
-		formatted = fmt.Sprintf("%.3f", q.Value)
-	} else if q.Value < 10 {
-		formatted = fmt.Sprintf("%.2f", q.Value)
-	} else {
-		formatted = fmt.Sprintf("%.1f", q.Value)
-	}
-
-	abbrev := unit.Abbrev
-	if q.Value != 1 && unit.PluralAbbrev != "" {
-		abbrev = unit.PluralAbbrev
+	style = resolveFractionStyle(style, q.Unit)
+	if style != FractionDecimal {
+		value, unit := q.Value, unit
+		if unit.ParentUnit != "" {
+			parent, _, _ := GetDerivedUnit(unit.ParentUnit)
+			value = value * unit.ToBase / parent.ToBase
+			unit = parent
+		}
+		return fmt.Sprintf("%s %s", formatFraction(value, style), unitAbbrev(unit, value))
 	}
 
 	// For fractional units (those with a ParentUnit), hide the "1" when quantity is 1
 	// e.g., "½ tsp" instead of "1 ½ tsp"
 	if q.Value == 1 && unit.ParentUnit != "" {
-		return abbrev
+		return unit.Abbrev
+	}
+
+	return fmt.Sprintf("%s %s", formatValue(q.Value), unitAbbrev(unit, q.Value))
+}
+
+// resolveFractionStyle picks the style FractionDefault means for unitKey
+func resolveFractionStyle(style FractionStyle, unitKey string) FractionStyle {
+	if style != FractionDefault {
+		return style
+	}
+	if isUSCustomary(unitKey) {
+		return FractionUnicode
+	}
+	return FractionDecimal
+}
+
+// isUSCustomary reports whether unitKey is a US customary unit, including the
+// ones that are parsed but never chosen for display
+func isUSCustomary(unitKey string) bool {
+	switch unitKey {
+	case "fl_oz", "jigger", "gill":
+		return true
+	}
+	return slices.Contains(systemUnits[SystemUS], unitKey)
+}
+
+func unitAbbrev(unit DerivedUnit, value float64) string {
+	if value != 1 && unit.PluralAbbrev != "" {
+		return unit.PluralAbbrev
+	}
+	return unit.Abbrev
+}
+
+func formatValue(value float64) string {
+	if value == float64(int(value)) {
+		return fmt.Sprintf("%d", int(value))
+	} else if value < 0.1 {
+		return fmt.Sprintf("%.3f", value)
+	} else if value < 10 {
+		return fmt.Sprintf("%.2f", value)
+	}
+	return fmt.Sprintf("%.1f", value)
+}
+
+// formatCount writes a unitless count, which uses kitchen fractions by
+// default since counts like "½ lemon" are written that way in any system
+func formatCount(value float64, style FractionStyle) string {
+	switch style {
+	case FractionDecimal:
+		return formatValue(value)
+	case FractionDefault:
+		style = FractionUnicode
+	}
+	return formatFraction(value, style)
+}
+
+// formatFraction writes value as a whole or mixed number using the simplest
+// kitchen fraction within FractionTolerance, e.g. "⅓" or "1 1/2", or as a
+// decimal if there isn't one
+func formatFraction(value float64, style FractionStyle) string {
+	for _, denom := range kitchenDenominators {
+		n := math.Round(value * float64(denom))
+		if n == 0 || math.Abs(value-n/float64(denom)) > FractionTolerance*value {
+			continue
+		}
+		whole, num := int(n)/denom, int(n)%denom
+		if num == 0 {
+			return strconv.Itoa(whole)
+		}
+
+		fraction := fmt.Sprintf("%d/%d", num, denom)
+		if style == FractionUnicode {
+			fraction = fractionGlyphs[[2]int{num, denom}]
+		}
+		if whole == 0 {
+			return fraction
+		}
+		return fmt.Sprintf("%d %s", whole, fraction)
+	}
+	return formatValue(value)
+}
+
+// FormatRange formats a range from q up to maxValue, which is in q's unit, e.g.
+// "2-3 tbsp", in the default style for its unit
+func FormatRange(q Quantity, maxValue float64) string {
+	return FormatRangeWith(q, maxValue, FractionDefault)
+}
+
+// FormatRangeWith is FormatRange with the given fraction style. Fractional
+// units are shown as their parent unit.
+func FormatRangeWith(q Quantity, maxValue float64, style FractionStyle) string {
+	if q.Category == CategoryCount {
+		return fmt.Sprintf("%s-%s", formatCount(q.Value, style), formatCount(maxValue, style))
 	}
 
-	return fmt.Sprintf("%s %s", formatted, abbrev)
+	unit, _, err := GetDerivedUnit(q.Unit)
+	if err != nil {
+		return fmt.Sprintf("%.2f-%.2f %s", q.Value, maxValue, q.Unit)
+	}
+	style = resolveFractionStyle(style, q.Unit)
+	if unit.ParentUnit != "" {
+		parent, _, _ := GetDerivedUnit(unit.ParentUnit)
+		q.Value = q.Value * unit.ToBase / parent.ToBase
+		maxValue = maxValue * unit.ToBase / parent.ToBase
+		unit = parent
+	}
+
+	abbrev := unit.Abbrev
+	if unit.PluralAbbrev != "" {
+		abbrev = unit.PluralAbbrev
+	}
+	format := formatValue
+	if style != FractionDecimal {
+		format = func(value float64) string { return formatFraction(value, style) }
+	}
+	return fmt.Sprintf("%s-%s %s", format(q.Value), format(maxValue), abbrev)
 }
 
 func FormatBest(baseValue float64, category UnitCategory) string {
@@ -400,6 +662,28 @@ func ParseUnit(s string) (string, UnitCategory, error) {
 		"shots":                 "jigger",
 		"gills":                 "gill",
 		"australian tablespoon": "au_tbsp",
+		"imperial teaspoon":     "imp_tsp",
+		"imperial teaspoons":    "imp_tsp",
+		"imp tsp":               "imp_tsp",
+		"imperial tablespoon":   "imp_tbsp",
+		"imperial tablespoons":  "imp_tbsp",
+		"imp tbsp":              "imp_tbsp",
+		"imperial fluid ounce":  "imp_fl_oz",
+		"imperial fluid ounces": "imp_fl_oz",
+		"imp fl oz":             "imp_fl_oz",
+		"imperial cup":          "imp_cup",
+		"imperial cups":         "imp_cup",
+		"imp cup":               "imp_cup",
+		"imp cups":              "imp_cup",
+		"imperial pint":         "imp_pt",
+		"imperial pints":        "imp_pt",
+		"imp pt":                "imp_pt",
+		"imperial quart":        "imp_qt",
+		"imperial quarts":       "imp_qt",
+		"imp qt":                "imp_qt",
+		"imperial gallon":       "imp_gal",
+		"imperial gallons":      "imp_gal",
+		"imp gal":               "imp_gal",
 		"cans":                  "can",
 	}
 
@@ -488,6 +772,43 @@ func ScaleQuantity(q Quantity, factor float64) Quantity {
 	}
 }
 
+// ScaleBase scales a quantity in base units by factor and finds the best unit
+// to display it in, so 3 tbsp doubled is 6 tbsp rather than 0.38 cup, choosing
+// from the allowed units when allowed isn't nil. Scaled counts are rounded with
+// RoundCount; unscaled counts are left as entered.
+func ScaleBase(baseValue float64, category UnitCategory, factor float64, allowed []string) Quantity {
+	q := FindBestIntegerUnitWithTolerance(baseValue*factor, category, IntegerTolerance, allowed)
+	if category == CategoryCount && factor != 1 {
+		q.Value = RoundCount(q.Value)
+	}
+	return q
+}
+
+// ScaleRangeMax scales the upper end of a range like ScaleBase, returning it
+// in the unit chosen for the scaled lower end q
+func ScaleRangeMax(maxBase float64, q Quantity, factor float64) float64 {
+	if q.Category == CategoryCount {
+		if factor != 1 {
+			return RoundCount(maxBase * factor)
+		}
+		return maxBase
+	}
+	maxValue, err := FromBaseUnit(maxBase*factor, q.Category, q.Unit)
+	if err != nil {
+		return 0
+	}
+	return maxValue
+}
+
+// RoundCount rounds a scaled count to a whole number, keeping at least 1 of
+// anything the recipe calls for: halving a recipe with 1 egg still needs 1 egg.
+func RoundCount(value float64) float64 {
+	if value <= 0 {
+		return 0
+	}
+	return math.Max(1, math.Round(value))
+}
+
 func SumQuantities(quantities []Quantity) (float64, UnitCategory, error) {
 	if len(quantities) == 0 {
 		return 0, "", fmt.Errorf("no quantities to sum")
@@ -527,80 +848,112 @@ func GetDerivedUnit(unitKey string) (DerivedUnit, UnitCategory, error) {
 }
 
 type ParsedIngredient struct {
-	Quantity       float64
+	Quantity float64
+	// QuantityMax is the upper end of a range such as "2-3 cloves", or 0
+	QuantityMax float64
+	// QuantityText is a quantity that isn't a number ("to taste"), or a size
+	// note such as the "14 oz" in "1 (14 oz) can tomatoes"
+	QuantityText   string
 	Unit           string
 	Category       UnitCategory
 	IngredientName string
+	// Preparation is whatever follows the first comma, e.g. "finely diced"
+	Preparation  string
+	Optional     bool
+	OriginalText string
 }
 
-// ParseIngredientString parses strings like "2 cups flour" or "1/2 tsp salt"
-// Also handles ingredients without quantities like "salt to taste" or "avocado oil"
-// Returns quantity, unit key, category, and ingredient name
+// freeTextQuantities stand in for a quantity at the end of an ingredient, as
+// in "salt to taste"
+var freeTextQuantities = []string{"to taste", "as needed", "as desired"}
+
+// IsFreeTextQuantity reports whether a ParsedIngredient's QuantityText stands
+// in for the quantity, rather than being a size note
+func IsFreeTextQuantity(s string) bool {
+	return slices.Contains(freeTextQuantities, s)
+}
+
+// ParseIngredientString parses strings like "2 cups flour", "1 ½ tsp salt" or
+// "one onion" (see parseQuantity for the quantities it understands).
+// Also handles ingredients without quantities like "salt to taste" or "avocado oil",
+// ranges like "2-3 cloves garlic", size notes like "1 (14 oz) can tomatoes",
+// a preparation after the first comma ("2 onions, finely diced") and optional
+// markers ("1 tsp chili flakes (optional)")
 func ParseIngredientString(s string) (ParsedIngredient, error) {
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return ParsedIngredient{}, fmt.Errorf("empty ingredient string")
 	}
+	result := ParsedIngredient{OriginalText: s}
 
-	parts := strings.Fields(s)
-	if len(parts) < 1 {
-		return ParsedIngredient{}, fmt.Errorf("ingredient string too short: %q", s)
-	}
-
-	// Try to parse quantity (first part) - handle fractions like "1/2"
-	// If the first word doesn't look like a number (no digits), treat whole string as ingredient
-	firstWord := parts[0]
-	hasDigit := false
-	for _, c := range firstWord {
-		if c >= '0' && c <= '9' {
-			hasDigit = true
-			break
-		}
+	s, result.Optional = stripOptional(s)
+	s, result.QuantityText = stripFreeTextQuantity(s)
+	if i := preparationComma(s); i >= 0 {
+		s, result.Preparation = s[:i], strings.TrimSpace(s[i+1:])
 	}
+	s = strings.TrimSpace(s)
 
-	if !hasDigit {
-		// First word isn't a number - treat entire string as ingredient name
-		// with quantity 1 and category count
-		return ParsedIngredient{
-			Quantity:       1,
-			Unit:           "count",
-			Category:       CategoryCount,
-			IngredientName: s,
-		}, nil
+	parts := strings.Fields(s)
+	if len(parts) < 1 {
+		return ParsedIngredient{}, fmt.Errorf("no ingredient name found in %q", result.OriginalText)
 	}
 
-	quantity, err := parseQuantity(firstWord)
+	quantity, quantityMax, n, err := takeQuantity(parts)
 	if err != nil {
-		return ParsedIngredient{}, fmt.Errorf("invalid quantity %q: %w", firstWord, err)
+		return ParsedIngredient{}, fmt.Errorf("invalid quantity %q: %w", parts[0], err)
 	}
+	if n == 0 {
+		// No quantity - treat entire string as ingredient name with quantity
+		// 1 and category count, or no quantity at all for "salt to taste"
+		result.Quantity = 1
+		if result.QuantityText != "" {
+			result.Quantity = 0
+		}
+		result.Unit = "count"
+		result.Category = CategoryCount
+		result.IngredientName = strings.Join(parts, " ")
+		return result, nil
+	}
+	result.Quantity, result.QuantityMax = quantity, quantityMax
 
-	// If only one part and it's a number, that's not valid
-	if len(parts) < 2 {
+	// If the string is only a number, that's not valid
+	if len(parts) <= n {
 		return ParsedIngredient{}, fmt.Errorf("ingredient string too short: %q", s)
 	}
 
-	// Try to find unit starting from second part
+	// A size note can come before or after the unit: "1 (14 oz) can" or
+	// "1 can (14 oz)"
+	idx := result.takeSizeNote(parts, n)
+
+	// Try to find unit
 	// Handle multi-word units like "fl oz"
-	unitEndIdx := 1
 	var unitKey string
 	var category UnitCategory
 
-	// Try two-word unit first (e.g., "fl oz")
-	if len(parts) >= 3 {
-		twoWord := parts[1] + " " + parts[2]
+	// Try three-word units (e.g., "imp fl oz"), then two-word (e.g., "fl oz")
+	if len(parts) >= idx+3 {
+		threeWord := strings.Join(parts[idx:idx+3], " ")
+		if key, cat, err := ParseUnit(threeWord); err == nil {
+			unitKey = key
+			category = cat
+			idx += 3
+		}
+	}
+	if unitKey == "" && len(parts) >= idx+2 {
+		twoWord := parts[idx] + " " + parts[idx+1]
 		if key, cat, err := ParseUnit(twoWord); err == nil {
 			unitKey = key
 			category = cat
-			unitEndIdx = 3
+			idx += 2
 		}
 	}
 
 	// Try single-word unit
-	if unitKey == "" {
-		if key, cat, err := ParseUnit(parts[1]); err == nil {
+	if unitKey == "" && len(parts) > idx {
+		if key, cat, err := ParseUnit(parts[idx]); err == nil {
 			unitKey = key
 			category = cat
-			unitEndIdx = 2
+			idx++
 		}
 	}
 
@@ -608,35 +961,263 @@ func ParseIngredientString(s string) (ParsedIngredient, error) {
 	if unitKey == "" {
 		unitKey = "count"
 		category = CategoryCount
-		unitEndIdx = 1
+	} else {
+		idx = result.takeSizeNote(parts, idx)
 	}
 
 	// Rest is ingredient name
-	ingredientName := strings.Join(parts[unitEndIdx:], " ")
+	ingredientName := strings.Join(parts[idx:], " ")
 	if ingredientName == "" {
-		return ParsedIngredient{}, fmt.Errorf("no ingredient name found in %q", s)
+		return ParsedIngredient{}, fmt.Errorf("no ingredient name found in %q", result.OriginalText)
+	}
+
+	result.Unit = unitKey
+	result.Category = category
+	result.IngredientName = ingredientName
+	return result, nil
+}
+
+// ParseYield parses the amount a recipe makes from its yield text, such as
+// "1 l", "500 g dough" or "12 muffins", returning it in base units. A yield
+// without a unit is a count, and a range like "4-6 servings" yields its lower
+// end.
+func ParseYield(s string) (float64, UnitCategory, error) {
+	parts := strings.Fields(s)
+	if len(parts) == 0 {
+		return 0, "", fmt.Errorf("empty yield")
+	}
+
+	quantity, _, n, err := takeQuantity(parts)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid yield quantity %q: %w", parts[0], err)
+	}
+	if n == 0 {
+		return 0, "", fmt.Errorf("yield %q has no quantity", s)
+	}
+	if quantity <= 0 {
+		return 0, "", fmt.Errorf("yield must be positive")
 	}
 
-	return ParsedIngredient{
-		Quantity:       quantity,
-		Unit:           unitKey,
-		Category:       category,
-		IngredientName: ingredientName,
-	}, nil
+	unitKey := "count"
+	if len(parts) >= n+2 {
+		if key, _, err := ParseUnit(parts[n] + " " + parts[n+1]); err == nil {
+			unitKey = key
+		}
+	}
+	if unitKey == "count" && len(parts) >= n+1 {
+		if key, _, err := ParseUnit(parts[n]); err == nil {
+			unitKey = key
+		}
+	}
+	return ToBaseUnit(quantity, unitKey)
+}
+
+// stripOptional removes "(optional)", a trailing ", optional" or a leading
+// "optional" from s
+func stripOptional(s string) (string, bool) {
+	lower := strings.ToLower(s)
+	if i := strings.Index(lower, "(optional)"); i >= 0 {
+		return strings.TrimSpace(s[:i] + s[i+len("(optional)"):]), true
+	}
+	if strings.HasSuffix(lower, ", optional") {
+		return strings.TrimSpace(s[:len(s)-len(", optional")]), true
+	}
+	if strings.HasPrefix(lower, "optional ") {
+		return strings.TrimSpace(s[len("optional "):]), true
+	}
+	return s, false
+}
+
+// stripFreeTextQuantity removes a trailing free-text quantity such as
+// "to taste" from s, along with any comma before it
+func stripFreeTextQuantity(s string) (string, string) {
+	lower := strings.ToLower(s)
+	for _, phrase := range freeTextQuantities {
+		if strings.HasSuffix(lower, " "+phrase) || strings.HasSuffix(lower, ","+phrase) {
+			rest := strings.TrimRight(s[:len(s)-len(phrase)], ", ")
+			return rest, phrase
+		}
+	}
+	return s, ""
 }
 
+// preparationComma returns the index of the first comma in s that isn't a
+// decimal comma or thousands separator between digits, or -1
+func preparationComma(s string) int {
+	for i := strings.IndexByte(s, ','); i >= 0; {
+		if i == 0 || i == len(s)-1 || !unicode.IsDigit(rune(s[i-1])) || !unicode.IsDigit(rune(s[i+1])) {
+			return i
+		}
+		next := strings.IndexByte(s[i+1:], ',')
+		if next < 0 {
+			return -1
+		}
+		i += next + 1
+	}
+	return -1
+}
+
+// takeSizeNote consumes a parenthesised size note such as "(14 oz)" starting
+// at parts[idx] into QuantityText, returning the index after it
+func (p *ParsedIngredient) takeSizeNote(parts []string, idx int) int {
+	if idx >= len(parts) || !strings.HasPrefix(parts[idx], "(") {
+		return idx
+	}
+	for end := idx; end < len(parts); end++ {
+		if strings.HasSuffix(parts[end], ")") {
+			note := strings.Join(parts[idx:end+1], " ")
+			if p.QuantityText == "" {
+				p.QuantityText = strings.TrimSpace(note[1 : len(note)-1])
+			}
+			return end + 1
+		}
+	}
+	return idx
+}
+
+// maxQuantityWords is the most words a quantity spans, as in "1 1/2 to 2"
+const maxQuantityWords = 4
+
+// takeQuantity parses the quantity at the start of parts, taking as many words
+// as form one so "1 1/2 cups" is 1.5 cups rather than 1 of "1/2 cups". It
+// returns how many words it used, or 0 when parts doesn't start with a
+// quantity.
+func takeQuantity(parts []string) (float64, float64, int, error) {
+	for n := min(len(parts), maxQuantityWords); n > 0; n-- {
+		quantity, quantityMax, err := ParseQuantityRange(strings.Join(parts[:n], " "))
+		if err == nil {
+			return quantity, quantityMax, n, nil
+		}
+	}
+	if len(parts) > 0 && startsWithNumber(parts[0]) {
+		_, _, err := ParseQuantityRange(parts[0])
+		return 0, 0, 0, err
+	}
+	return 0, 0, 0, nil
+}
+
+// startsWithNumber reports whether s begins with a digit or a vulgar fraction
+func startsWithNumber(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	_, fraction := vulgarFractions[r]
+	return unicode.IsDigit(r) || fraction
+}
+
+// ParseQuantityRange parses a quantity or a range such as "2-3", "1/2 to 1" or
+// "2 or 3", returning 0 as the maximum when s isn't a range. "1-1/2" is read as
+// the mixed number 1½, since a range can't end below its start.
+func ParseQuantityRange(s string) (float64, float64, error) {
+	s = strings.NewReplacer("–", "-", "—", "-").Replace(strings.ToLower(strings.TrimSpace(s)))
+
+	low, high, ok := strings.Cut(s, "-")
+	if !ok {
+		low, high, ok = strings.Cut(s, " to ")
+	}
+	if !ok {
+		low, high, ok = strings.Cut(s, " or ")
+	}
+	if !ok {
+		quantity, err := parseQuantity(s)
+		return quantity, 0, err
+	}
+	low, high = strings.TrimSpace(low), strings.TrimSpace(high)
+	if low == "" || high == "" {
+		return 0, 0, fmt.Errorf("incomplete range %q", s)
+	}
+
+	quantity, err := parseQuantity(low)
+	if err != nil {
+		return 0, 0, err
+	}
+	quantityMax, err := parseQuantity(high)
+	if err != nil {
+		return 0, 0, err
+	}
+	if quantityMax < quantity {
+		if isWholeNumber(low) && quantityMax < 1 && strings.Contains(high, "/") {
+			return quantity + quantityMax, 0, nil
+		}
+		return 0, 0, fmt.Errorf("range ends below its start")
+	}
+	if quantityMax == quantity {
+		quantityMax = 0
+	}
+	return quantity, quantityMax, nil
+}
+
+// vulgarFractions are the unicode fraction characters parseQuantity accepts
+var vulgarFractions = map[rune]float64{
+	'½': 1.0 / 2, '⅓': 1.0 / 3, '⅔': 2.0 / 3, '¼': 1.0 / 4, '¾': 3.0 / 4,
+	'⅕': 1.0 / 5, '⅖': 2.0 / 5, '⅗': 3.0 / 5, '⅘': 4.0 / 5, '⅙': 1.0 / 6,
+	'⅚': 5.0 / 6, '⅐': 1.0 / 7, '⅛': 1.0 / 8, '⅜': 3.0 / 8, '⅝': 5.0 / 8,
+	'⅞': 7.0 / 8, '⅑': 1.0 / 9, '⅒': 1.0 / 10,
+}
+
+// numberWords are the spelled-out numbers parseQuantity accepts
+var numberWords = map[string]float64{
+	"one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+	"seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+}
+
+// parseQuantity parses a single quantity: a number ("2", "0.5", or "0,5" with
+// a decimal comma), a fraction ("1/2", "½"), a mixed number ("1 1/2", "1 ½",
+// "1½") or a spelled-out number ("one")
 func parseQuantity(s string) (float64, error) {
-	// Handle fractions like "1/2"
+	s = strings.ToLower(strings.TrimSpace(s))
+	if value, ok := numberWords[s]; ok {
+		return value, nil
+	}
+
+	// Mixed numbers: a whole number followed by a fraction under one
+	if whole, fraction, ok := strings.Cut(s, " "); ok {
+		fraction = strings.TrimSpace(fraction)
+		if !isWholeNumber(whole) || !isFraction(fraction) {
+			return 0, fmt.Errorf("invalid quantity %q", s)
+		}
+		w, err := parseQuantity(whole)
+		if err != nil {
+			return 0, err
+		}
+		f, err := parseQuantity(fraction)
+		if err != nil {
+			return 0, err
+		}
+		if f >= 1 {
+			return 0, fmt.Errorf("invalid mixed number %q", s)
+		}
+		return w + f, nil
+	}
+
+	if r, size := utf8.DecodeLastRuneInString(s); size > 0 {
+		if fraction, ok := vulgarFractions[r]; ok {
+			whole := s[:len(s)-size]
+			if whole == "" {
+				return fraction, nil
+			}
+			if !isWholeNumber(whole) {
+				return 0, fmt.Errorf("invalid quantity %q", s)
+			}
+			w, err := parseQuantity(whole)
+			if err != nil {
+				return 0, err
+			}
+			return w + fraction, nil
+		}
+	}
+
+	// Handle fractions like "1/2", including the fraction slash in "1⁄2"
+	s = strings.ReplaceAll(s, "⁄", "/")
 	if strings.Contains(s, "/") {
 		parts := strings.Split(s, "/")
 		if len(parts) != 2 {
 			return 0, fmt.Errorf("invalid fraction")
 		}
-		var num, denom float64
-		if _, err := fmt.Sscanf(parts[0], "%f", &num); err != nil {
+		num, err := parseNumber(parts[0])
+		if err != nil {
 			return 0, err
 		}
-		if _, err := fmt.Sscanf(parts[1], "%f", &denom); err != nil {
+		denom, err := parseNumber(parts[1])
+		if err != nil {
 			return 0, err
 		}
 		if denom == 0 {
@@ -645,9 +1226,44 @@ func parseQuantity(s string) (float64, error) {
 		return num / denom, nil
 	}
 
-	var val float64
-	if _, err := fmt.Sscanf(s, "%f", &val); err != nil {
-		return 0, err
+	return parseNumber(s)
+}
+
+// parseNumber parses a non-negative decimal number. A comma before exactly
+// three digits separates thousands ("1,500"); any other comma is a decimal
+// comma ("1,5").
+func parseNumber(s string) (float64, error) {
+	if whole, rest, ok := strings.Cut(s, ","); ok {
+		if len(rest) == 3 && !strings.Contains(rest, ",") {
+			s = whole + rest
+		} else {
+			s = whole + "." + rest
+		}
+	}
+	if s == "" || !unicode.IsDigit(rune(s[0])) && s[0] != '.' {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return value, nil
+}
+
+// isWholeNumber reports whether s is written as a whole number, in digits or
+// as a word
+func isWholeNumber(s string) bool {
+	if _, ok := numberWords[s]; ok {
+		return true
+	}
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// isFraction reports whether s is written as a fraction, like "1/2" or "½"
+func isFraction(s string) bool {
+	if r, size := utf8.DecodeRuneInString(s); size == len(s) {
+		_, ok := vulgarFractions[r]
+		return ok
 	}
-	return val, nil
+	return strings.ContainsAny(s, "/⁄")
 }
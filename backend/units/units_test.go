@@ -91,6 +91,8 @@ func TestParseIngredientString_Errors(t *testing.T) {
 		{"", "empty string"},
 		{"1/0 cup flour", "division by zero"},
 		{"123", "number only"},
+		{"1 1/2", "mixed number only"},
+		{"1/2/3 cup flour", "malformed fraction"},
 	}
 
 	for _, tt := range tests {
@@ -111,8 +113,8 @@ func TestParseIngredientString_NoQuantity(t *testing.T) {
 		expectedUnit string
 	}{
 		{"flour", "flour", 1, "count"},
-		{"salt to taste", "salt to taste", 1, "count"},
-		{"avocado oil, for cooking", "avocado oil, for cooking", 1, "count"},
+		{"salt to taste", "salt", 0, "count"},
+		{"avocado oil, for cooking", "avocado oil", 1, "count"},
 		{"fresh parsley", "fresh parsley", 1, "count"},
 	}
 
@@ -136,6 +138,108 @@ func TestParseIngredientString_NoQuantity(t *testing.T) {
 	}
 }
 
+func TestParseIngredientString_Details(t *testing.T) {
+	tests := []struct {
+		input        string
+		quantity     float64
+		quantityMax  float64
+		quantityText string
+		unit         string
+		name         string
+		preparation  string
+		optional     bool
+	}{
+		{"2 onions, finely diced", 2, 0, "", "count", "onions", "finely diced", false},
+		{"salt to taste", 0, 0, "to taste", "count", "salt", "", false},
+		{"black pepper, to taste", 0, 0, "to taste", "count", "black pepper", "", false},
+		{"2 tbsp olive oil, as needed", 2, 0, "as needed", "tbsp", "olive oil", "", false},
+		{"1 (14 oz) can tomatoes", 1, 0, "14 oz", "can", "tomatoes", "", false},
+		{"1 can (14 oz) tomatoes, drained", 1, 0, "14 oz", "can", "tomatoes", "drained", false},
+		{"2-3 cloves garlic", 2, 3, "", "count", "cloves garlic", "", false},
+		{"1–2 cups stock", 1, 2, "", "cup", "stock", "", false},
+		{"1/2-1 tsp chili flakes (optional)", 0.5, 1, "", "tsp", "chili flakes", "", true},
+		{"1 cup walnuts, chopped, optional", 1, 0, "", "cup", "walnuts", "chopped", true},
+		{"Optional parmesan", 1, 0, "", "count", "parmesan", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseIngredientString(tt.input)
+			if err != nil {
+				t.Fatalf("ParseIngredientString(%q) returned error: %v", tt.input, err)
+			}
+
+			if result.Quantity != tt.quantity || result.QuantityMax != tt.quantityMax {
+				t.Errorf("Quantity: got %v-%v, want %v-%v", result.Quantity, result.QuantityMax, tt.quantity, tt.quantityMax)
+			}
+			if result.QuantityText != tt.quantityText {
+				t.Errorf("QuantityText: got %q, want %q", result.QuantityText, tt.quantityText)
+			}
+			if result.Unit != tt.unit {
+				t.Errorf("Unit: got %q, want %q", result.Unit, tt.unit)
+			}
+			if result.IngredientName != tt.name {
+				t.Errorf("IngredientName: got %q, want %q", result.IngredientName, tt.name)
+			}
+			if result.Preparation != tt.preparation {
+				t.Errorf("Preparation: got %q, want %q", result.Preparation, tt.preparation)
+			}
+			if result.Optional != tt.optional {
+				t.Errorf("Optional: got %v, want %v", result.Optional, tt.optional)
+			}
+			if result.OriginalText != tt.input {
+				t.Errorf("OriginalText: got %q, want %q", result.OriginalText, tt.input)
+			}
+		})
+	}
+}
+
+func TestParseIngredientString_InvalidRange(t *testing.T) {
+	if _, err := ParseIngredientString("3-2 cups flour"); err == nil {
+		t.Error("Expected error for a range that ends below its start")
+	}
+}
+
+func TestParseYield(t *testing.T) {
+	tests := []struct {
+		input        string
+		wantBase     float64
+		wantCategory UnitCategory
+		wantErr      bool
+	}{
+		{"1 l", 1000, CategoryVolume, false},
+		{"2 cups stock", 473.176, CategoryVolume, false},
+		{"1 fl oz", 29.5735, CategoryVolume, false},
+		{"500 g dough", 500000, CategoryMass, false},
+		{"12 muffins", 12, CategoryCount, false},
+		{"4-6 servings", 4, CategoryCount, false},
+		{"1 ½ l", 1500, CategoryVolume, false},
+		{"two loaves", 2, CategoryCount, false},
+		{"", 0, "", true},
+		{"a big pot", 0, "", true},
+		{"0 l", 0, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			base, category, err := ParseYield(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseYield(%q) expected error, got %v %s", tt.input, base, category)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseYield(%q) returned error: %v", tt.input, err)
+			}
+			delta := 0.001
+			if base < tt.wantBase-delta || base > tt.wantBase+delta || category != tt.wantCategory {
+				t.Errorf("ParseYield(%q) = %v %s, want %v %s", tt.input, base, category, tt.wantBase, tt.wantCategory)
+			}
+		})
+	}
+}
+
 func TestParseQuantity(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -149,6 +253,32 @@ func TestParseQuantity(t *testing.T) {
 		{"1/4", 0.25},
 		{"3/4", 0.75},
 		{"100", 100},
+		{".5", 0.5},
+		{"1,5", 1.5},
+		{"0,25", 0.25},
+		{"1,500", 1500},
+		{"½", 0.5},
+		{"⅓", 1.0 / 3},
+		{"⅔", 2.0 / 3},
+		{"¼", 0.25},
+		{"¾", 0.75},
+		{"⅛", 0.125},
+		{"⅜", 0.375},
+		{"⅝", 0.625},
+		{"⅞", 0.875},
+		{"⅕", 0.2},
+		{"⅙", 1.0 / 6},
+		{"1½", 1.5},
+		{"2¼", 2.25},
+		{"1 ½", 1.5},
+		{"1 1/2", 1.5},
+		{"2 3/4", 2.75},
+		{"1⁄2", 0.5},
+		{"1 1⁄2", 1.5},
+		{"one", 1},
+		{"Two", 2},
+		{"twelve", 12},
+		{"one ½", 1.5},
 	}
 
 	for _, tt := range tests {
@@ -171,6 +301,16 @@ func TestParseQuantity_Errors(t *testing.T) {
 		"abc",
 		"1/2/3",
 		"1/0",
+		"",
+		"1abc",
+		"1 2",
+		"1 3/2",
+		"1.5 1/2",
+		"½½",
+		"a½",
+		"thirteen",
+		"1 one",
+		"-1",
 	}
 
 	for _, input := range tests {
@@ -183,6 +323,110 @@ func TestParseQuantity_Errors(t *testing.T) {
 	}
 }
 
+func TestParseQuantityRange(t *testing.T) {
+	tests := []struct {
+		input   string
+		low     float64
+		high    float64
+		wantErr bool
+	}{
+		{"2", 2, 0, false},
+		{"2-3", 2, 3, false},
+		{"2 - 3", 2, 3, false},
+		{"2–3", 2, 3, false},
+		{"2—3", 2, 3, false},
+		{"2 to 3", 2, 3, false},
+		{"2 or 3", 2, 3, false},
+		{"two to three", 2, 3, false},
+		{"½-1", 0.5, 1, false},
+		{"1/2-1", 0.5, 1, false},
+		{"1½-2", 1.5, 2, false},
+		{"1 1/2-2", 1.5, 2, false},
+		{"1 1/2 to 2 1/2", 1.5, 2.5, false},
+		{"1,5-2", 1.5, 2, false},
+		{"2-2", 2, 0, false},
+		{"1-1/2", 1.5, 0, false},
+		{"3-2", 0, 0, true},
+		{"2-", 0, 0, true},
+		{"-2", 0, 0, true},
+		{"2 to", 0, 0, true},
+		{"2-3-4", 0, 0, true},
+		{"3-4/2", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			low, high, err := ParseQuantityRange(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseQuantityRange(%q) expected error, got %v-%v", tt.input, low, high)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseQuantityRange(%q) returned error: %v", tt.input, err)
+			}
+			delta := 0.0001
+			if low < tt.low-delta || low > tt.low+delta || high < tt.high-delta || high > tt.high+delta {
+				t.Errorf("got %v-%v, want %v-%v", low, high, tt.low, tt.high)
+			}
+		})
+	}
+}
+
+func TestParseIngredientString_QuantityGrammar(t *testing.T) {
+	tests := []struct {
+		input       string
+		quantity    float64
+		quantityMax float64
+		unit        string
+		name        string
+		preparation string
+	}{
+		{"1 ½ cups flour", 1.5, 0, "cup", "flour", ""},
+		{"1½ cups flour", 1.5, 0, "cup", "flour", ""},
+		{"1 1/2 cups flour", 1.5, 0, "cup", "flour", ""},
+		{"1-1/2 cups flour", 1.5, 0, "cup", "flour", ""},
+		{"⅓ cup sugar", 1.0 / 3, 0, "cup", "sugar", ""},
+		{"¾ tsp salt", 0.75, 0, "tsp", "salt", ""},
+		{"2 ¼ tsp yeast", 2.25, 0, "tsp", "yeast", ""},
+		{"2-3 cloves garlic", 2, 3, "count", "cloves garlic", ""},
+		{"2 to 3 tbsp honey", 2, 3, "tbsp", "honey", ""},
+		{"1 or 2 jalapeños, minced", 1, 2, "count", "jalapeños", "minced"},
+		{"one onion, diced", 1, 0, "count", "onion", "diced"},
+		{"Two eggs", 2, 0, "count", "eggs", ""},
+		{"1,5 kg potatoes, peeled", 1.5, 0, "kg", "potatoes", "peeled"},
+		{"0,5 l milk", 0.5, 0, "l", "milk", ""},
+		{"1,000 g flour", 1000, 0, "g", "flour", ""},
+		{"1 ½ (14 oz) cans tomatoes", 1.5, 0, "can", "tomatoes", ""},
+		{"2 onions or 3 shallots", 2, 0, "count", "onions or 3 shallots", ""},
+		{"12 3-inch pieces ginger", 12, 0, "count", "3-inch pieces ginger", ""},
+		{"oneonion", 1, 0, "count", "oneonion", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseIngredientString(tt.input)
+			if err != nil {
+				t.Fatalf("ParseIngredientString(%q) returned error: %v", tt.input, err)
+			}
+			delta := 0.0001
+			if result.Quantity < tt.quantity-delta || result.Quantity > tt.quantity+delta || result.QuantityMax != tt.quantityMax {
+				t.Errorf("Quantity: got %v-%v, want %v-%v", result.Quantity, result.QuantityMax, tt.quantity, tt.quantityMax)
+			}
+			if result.Unit != tt.unit {
+				t.Errorf("Unit: got %q, want %q", result.Unit, tt.unit)
+			}
+			if result.IngredientName != tt.name {
+				t.Errorf("IngredientName: got %q, want %q", result.IngredientName, tt.name)
+			}
+			if result.Preparation != tt.preparation {
+				t.Errorf("Preparation: got %q, want %q", result.Preparation, tt.preparation)
+			}
+		})
+	}
+}
+
 func TestFindBestIntegerUnit_FractionalUnits(t *testing.T) {
 	// Regression test: fractional units should display as fractional units,
 	// not convert to smaller units (e.g., 1/2 tsp was previously showing as 49 drops)
@@ -311,16 +555,16 @@ func TestFormat_FractionalUnits(t *testing.T) {
 		{"1 half cup", Quantity{Value: 1, Unit: "half_cup", Category: CategoryVolume}, "½ cup"},
 		{"1 third cup", Quantity{Value: 1, Unit: "third_cup", Category: CategoryVolume}, "⅓ cup"},
 		{"1 quarter cup", Quantity{Value: 1, Unit: "qtr_cup", Category: CategoryVolume}, "¼ cup"},
-		// Fractional units with quantity 2 should show the number
-		{"2 half tsp", Quantity{Value: 2, Unit: "half_tsp", Category: CategoryVolume}, "2 ½ tsp"},
-		{"2 third cup", Quantity{Value: 2, Unit: "third_cup", Category: CategoryVolume}, "2 ⅓ cup"},
+		// Fractional units with quantity 2 are written in their parent unit
+		{"2 half tsp", Quantity{Value: 2, Unit: "half_tsp", Category: CategoryVolume}, "1 tsp"},
+		{"2 third cup", Quantity{Value: 2, Unit: "third_cup", Category: CategoryVolume}, "⅔ cup"},
 		// Non-fractional units should always show the number
 		{"1 tsp", Quantity{Value: 1, Unit: "tsp", Category: CategoryVolume}, "1 tsp"},
 		{"1 cup", Quantity{Value: 1, Unit: "cup", Category: CategoryVolume}, "1 cup"},
 		{"2 tsp", Quantity{Value: 2, Unit: "tsp", Category: CategoryVolume}, "2 tsp"},
-		// Decimal values in parent units
-		{"1.5 tsp", Quantity{Value: 1.5, Unit: "tsp", Category: CategoryVolume}, "1.50 tsp"},
-		{"0.75 cup", Quantity{Value: 0.75, Unit: "cup", Category: CategoryVolume}, "0.75 cup"},
+		// Decimal values in parent units are written as fractions
+		{"1.5 tsp", Quantity{Value: 1.5, Unit: "tsp", Category: CategoryVolume}, "1 ½ tsp"},
+		{"0.75 cup", Quantity{Value: 0.75, Unit: "cup", Category: CategoryVolume}, "¾ cup"},
 	}
 
 	for _, tt := range tests {
@@ -332,3 +576,306 @@ func TestFormat_FractionalUnits(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatWith(t *testing.T) {
+	volume := func(value float64, unit string) Quantity {
+		return Quantity{Value: value, Unit: unit, Category: CategoryVolume}
+	}
+	mass := func(value float64, unit string) Quantity {
+		return Quantity{Value: value, Unit: unit, Category: CategoryMass}
+	}
+
+	tests := []struct {
+		name     string
+		quantity Quantity
+		style    FractionStyle
+		expected string
+	}{
+		{"half", volume(0.5, "cup"), FractionUnicode, "½ cup"},
+		{"third", volume(1.0/3, "cup"), FractionUnicode, "⅓ cup"},
+		{"rounded third", volume(0.33, "cup"), FractionUnicode, "⅓ cup"},
+		{"two thirds", volume(0.67, "cup"), FractionUnicode, "⅔ cup"},
+		{"quarter", volume(0.25, "tsp"), FractionUnicode, "¼ tsp"},
+		{"three quarters", volume(0.75, "tsp"), FractionUnicode, "¾ tsp"},
+		{"eighth", volume(0.125, "tsp"), FractionUnicode, "⅛ tsp"},
+		{"three eighths", volume(0.375, "tsp"), FractionUnicode, "⅜ tsp"},
+		{"five eighths", volume(0.625, "cup"), FractionUnicode, "⅝ cup"},
+		{"seven eighths", volume(0.875, "cup"), FractionUnicode, "⅞ cup"},
+		{"mixed number", volume(1.5, "tsp"), FractionUnicode, "1 ½ tsp"},
+		{"mixed third", volume(2.0+1.0/3, "cup"), FractionUnicode, "2 ⅓ cup"},
+		{"whole", volume(2, "tbsp"), FractionUnicode, "2 tbsp"},
+		{"near whole", volume(1.01, "tsp"), FractionUnicode, "1 tsp"},
+		{"no close fraction", volume(0.3, "cup"), FractionUnicode, "0.30 cup"},
+		{"tiny", volume(0.05, "tsp"), FractionUnicode, "0.050 tsp"},
+		{"fractional unit", volume(3, "qtr_cup"), FractionUnicode, "¾ cup"},
+		{"ascii half", volume(0.5, "tsp"), FractionASCII, "1/2 tsp"},
+		{"ascii mixed", volume(1.5, "cup"), FractionASCII, "1 1/2 cup"},
+		{"ascii fractional unit", volume(1, "third_cup"), FractionASCII, "1/3 cup"},
+		{"decimal", volume(1.5, "tsp"), FractionDecimal, "1.50 tsp"},
+		{"decimal fractional unit", volume(1, "half_cup"), FractionDecimal, "½ cup"},
+		{"default us volume", volume(2.75, "cup"), FractionDefault, "2 ¾ cup"},
+		{"default us mass", mass(1.5, "lb"), FractionDefault, "1 ½ lb"},
+		{"default fl oz", volume(0.5, "fl_oz"), FractionDefault, "½ fl oz"},
+		{"default metric", volume(1.5, "l"), FractionDefault, "1.50 l"},
+		{"default metric mass", mass(0.5, "kg"), FractionDefault, "0.50 kg"},
+		{"default imperial", volume(1.5, "imp_pt"), FractionDefault, "1.50 imp pt"},
+		{"count", Quantity{Value: 2, Unit: "count", Category: CategoryCount}, FractionUnicode, "2"},
+		{"half count", Quantity{Value: 0.5, Unit: "count", Category: CategoryCount}, FractionDefault, "½"},
+		{"mixed count", Quantity{Value: 1.5, Unit: "count", Category: CategoryCount}, FractionDefault, "1 ½"},
+		{"ascii mixed count", Quantity{Value: 1.5, Unit: "count", Category: CategoryCount}, FractionASCII, "1 1/2"},
+		{"decimal count", Quantity{Value: 1.5, Unit: "count", Category: CategoryCount}, FractionDecimal, "1.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatWith(tt.quantity, tt.style); got != tt.expected {
+				t.Errorf("FormatWith(%v, %d): got %q, want %q", tt.quantity, tt.style, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatRangeWith(t *testing.T) {
+	tests := []struct {
+		quantity Quantity
+		maxValue float64
+		style    FractionStyle
+		expected string
+	}{
+		{Quantity{Value: 0.5, Unit: "tsp", Category: CategoryVolume}, 1, FractionDefault, "½-1 tsp"},
+		{Quantity{Value: 1.5, Unit: "cup", Category: CategoryVolume}, 2, FractionASCII, "1 1/2-2 cup"},
+		{Quantity{Value: 1, Unit: "half_cup", Category: CategoryVolume}, 2, FractionDefault, "½-1 cup"},
+		{Quantity{Value: 1.5, Unit: "l", Category: CategoryVolume}, 2, FractionDefault, "1.50-2 l"},
+		{Quantity{Value: 2, Unit: "count", Category: CategoryCount}, 3, FractionDefault, "2-3"},
+		{Quantity{Value: 0.5, Unit: "count", Category: CategoryCount}, 1, FractionDefault, "½-1"},
+		{Quantity{Value: 1.5, Unit: "count", Category: CategoryCount}, 2.5, FractionASCII, "1 1/2-2 1/2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := FormatRangeWith(tt.quantity, tt.maxValue, tt.style); got != tt.expected {
+				t.Errorf("FormatRangeWith(%v, %v, %d): got %q, want %q", tt.quantity, tt.maxValue, tt.style, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRoundTripFractions(t *testing.T) {
+	for _, style := range []FractionStyle{FractionUnicode, FractionASCII} {
+		for _, value := range []float64{0.125, 0.25, 1.0 / 3, 0.5, 2.0 / 3, 0.75, 1.5, 2.25, 3.0 + 2.0/3} {
+			formatted := FormatWith(Quantity{Value: value, Unit: "cup", Category: CategoryVolume}, style) + " flour"
+			result, err := ParseIngredientString(formatted)
+			if err != nil {
+				t.Fatalf("ParseIngredientString(%q) returned error: %v", formatted, err)
+			}
+			delta := 0.0001
+			if result.Quantity < value-delta || result.Quantity > value+delta || result.Unit != "cup" {
+				t.Errorf("ParseIngredientString(%q) = %v %s, want %v cup", formatted, result.Quantity, result.Unit, value)
+			}
+		}
+	}
+}
+
+func TestScaleBase(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     float64
+		unit      string
+		factor    float64
+		want      string
+		wantValue float64
+	}{
+		{"3 tbsp doubled stays in tbsp", 3, "tbsp", 2, "tbsp", 6},
+		{"1 cup halved", 1, "cup", 0.5, "half_cup", 1},
+		{"200 g times 1.5", 200, "g", 1.5, "g", 300},
+		{"3 eggs halved rounds up", 3, "count", 0.5, "count", 2},
+		{"1 egg quartered keeps one", 1, "count", 0.25, "count", 1},
+		{"4 eggs times 1.4", 4, "count", 1.4, "count", 6},
+		{"unscaled count is unchanged", 0.5, "count", 1, "count", 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, category, err := ToBaseUnit(tt.value, tt.unit)
+			if err != nil {
+				t.Fatalf("ToBaseUnit failed: %v", err)
+			}
+			q := ScaleBase(base, category, tt.factor, nil)
+			if q.Unit != tt.want {
+				t.Errorf("Unit: got %q, want %q", q.Unit, tt.want)
+			}
+			delta := 0.0001
+			if q.Value < tt.wantValue-delta || q.Value > tt.wantValue+delta {
+				t.Errorf("Value: got %v, want %v", q.Value, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestFindBestIntegerUnit_MeasurementSystem(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     float64
+		unit      string
+		system    MeasurementSystem
+		want      string
+		wantValue float64
+	}{
+		{"1 cup in metric", 1, "cup", SystemMetric, "ml", 236.588},
+		{"1 l in us", 1, "l", SystemUS, "qt", 1.05669},
+		{"1 lb in metric", 1, "lb", SystemMetric, "g", 453.592},
+		{"250 g in us", 250, "g", SystemUS, "oz", 8.81850},
+		{"2 cups in imperial", 2, "cup", SystemImperial, "imp_cup", 1.66535},
+		{"1 l in imperial", 1, "l", SystemImperial, "imp_pt", 1.75975},
+		{"a can stays a can", 1, "can", SystemMetric, "can", 1},
+		{"a pinch stays a pinch", 1, "pinch", SystemMetric, "pinch", 1},
+		{"many dashes are measured", 20, "dash", SystemImperial, "imp_tsp", 1.56128},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, category, err := ToBaseUnit(tt.value, tt.unit)
+			if err != nil {
+				t.Fatalf("ToBaseUnit failed: %v", err)
+			}
+			q := FindBestIntegerUnitWithTolerance(base, category, IntegerTolerance, tt.system.Units())
+			if q.Unit != tt.want {
+				t.Errorf("Unit: got %q, want %q", q.Unit, tt.want)
+			}
+			delta := 0.001
+			if q.Value < tt.wantValue-delta || q.Value > tt.wantValue+delta {
+				t.Errorf("Value: got %v, want %v", q.Value, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestFindBestIntegerUnit_NoImperialByDefault(t *testing.T) {
+	for _, unit := range []string{"imp_tsp", "imp_tbsp", "imp_fl_oz", "imp_cup", "imp_pt", "imp_qt", "imp_gal"} {
+		q := FindBestIntegerUnit(VolumeUnits[unit].ToBase, CategoryVolume)
+		if VolumeUnits[q.Unit].Imperial {
+			t.Errorf("FindBestIntegerUnit(1 %s) chose %s", unit, q.Unit)
+		}
+	}
+}
+
+func TestParseMeasurementSystem(t *testing.T) {
+	for _, s := range []string{"", "metric", "us", "imperial", "as_written"} {
+		if _, err := ParseMeasurementSystem(s); err != nil {
+			t.Errorf("ParseMeasurementSystem(%q) failed: %v", s, err)
+		}
+	}
+	if _, err := ParseMeasurementSystem("cubits"); err == nil {
+		t.Error("Expected an error for an unknown system")
+	}
+}
+
+func TestRoundTripImperialUnits(t *testing.T) {
+	for _, unit := range []string{"imp_tsp", "imp_tbsp", "imp_fl_oz", "imp_cup", "imp_pt", "imp_qt", "imp_gal"} {
+		t.Run(unit, func(t *testing.T) {
+			display := Format(Quantity{Value: 2, Unit: unit, Category: CategoryVolume}) + " milk"
+			parsed, err := ParseIngredientString(display)
+			if err != nil {
+				t.Fatalf("ParseIngredientString(%q) failed: %v", display, err)
+			}
+			if parsed.Unit != unit || parsed.Quantity != 2 || parsed.IngredientName != "milk" {
+				t.Errorf("ParseIngredientString(%q) = %+v", display, parsed)
+			}
+		})
+	}
+}
+
+func TestConvertWithDensity(t *testing.T) {
+	tests := []struct {
+		desc       string
+		value      float64
+		from, to   string
+		gramsPerML float64
+		want       float64
+		wantErr    bool
+	}{
+		{"cup of flour to grams", 1, "cup", "g", 0.53, 125.39, false},
+		{"grams of butter to tbsp", 113, "g", "tbsp", 0.96, 7.96, false},
+		{"ml of water to kg", 500, "ml", "kg", 1, 0.5, false},
+		{"same category ignores density", 1, "lb", "oz", 0, 16, false},
+		{"no density", 1, "cup", "g", 0, 0, true},
+		{"count", 2, "count", "g", 1, 0, true},
+		{"unknown unit", 1, "cup", "bushel", 1, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := ConvertWithDensity(tt.value, tt.from, tt.to, tt.gramsPerML)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConvertWithDensity returned error: %v", err)
+			}
+			delta := 0.01
+			if got < tt.want-delta || got > tt.want+delta {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertTemperature(t *testing.T) {
+	tests := []struct {
+		value    float64
+		from, to string
+		want     float64
+	}{
+		{350, Fahrenheit, Celsius, 176.6667},
+		{180, Celsius, Fahrenheit, 356},
+		{-40, Celsius, Fahrenheit, -40},
+		{212, Fahrenheit, Fahrenheit, 212},
+	}
+
+	for _, tt := range tests {
+		got, err := ConvertTemperature(tt.value, tt.from, tt.to)
+		if err != nil {
+			t.Fatalf("ConvertTemperature(%v, %s, %s) returned error: %v", tt.value, tt.from, tt.to, err)
+		}
+		delta := 0.001
+		if got < tt.want-delta || got > tt.want+delta {
+			t.Errorf("ConvertTemperature(%v, %s, %s) = %v, want %v", tt.value, tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	if _, err := ConvertTemperature(1, "K", Celsius); err == nil {
+		t.Error("Expected an error for an unknown temperature unit")
+	}
+	if _, err := ConvertTemperature(1, "cup", Celsius); err == nil {
+		t.Error("Expected an error for a volume unit")
+	}
+}
+
+func TestFormatTemperature(t *testing.T) {
+	q := Quantity{Value: 176.67, Unit: Celsius, Category: CategoryTemperature}
+	if got := FormatTemperature(q); got != "177°C" {
+		t.Errorf("FormatTemperature(%v) = %q, want %q", q, got, "177°C")
+	}
+	if got := Format(q); got != "177°C" {
+		t.Errorf("Format(%v) = %q, want %q", q, got, "177°C")
+	}
+}
+
+func TestParseTemperatureUnit(t *testing.T) {
+	tests := map[string]string{
+		"F": Fahrenheit, "°f": Fahrenheit, "ºF": Fahrenheit, "Fahrenheit": Fahrenheit,
+		"c": Celsius, "°C": Celsius, "celsius": Celsius, "Centigrade": Celsius,
+	}
+	for input, want := range tests {
+		got, category, err := ParseTemperatureUnit(input)
+		if err != nil || got != want || category != CategoryTemperature {
+			t.Errorf("ParseTemperatureUnit(%q) = %q, %q, %v, want %q", input, got, category, err, want)
+		}
+	}
+	if _, _, err := ParseTemperatureUnit("kelvin"); err == nil {
+		t.Error("Expected an error for kelvin")
+	}
+}
@@ -7,10 +7,11 @@ test.describe('Authentication', () => {
     await expect(page.getByPlaceholder('Enter your email')).toBeVisible()
   })
 
-  test('can login with email', async ({ page }) => {
+  test('can login with email and password', async ({ page }) => {
     await page.goto('/')
     await page.getByPlaceholder('Enter your email').fill('test@example.com')
-    await page.getByRole('button', { name: 'Login' }).click()
+    await page.getByPlaceholder('Password').fill('test-password-123')
+    await page.getByRole('button', { name: 'Log in' }).click()
 
     // Should redirect to home page after login
     await expect(page.getByRole('heading', { name: 'Hungr' })).toBeVisible()
@@ -19,7 +20,8 @@ test.describe('Authentication', () => {
   test('persists login across page reloads', async ({ page }) => {
     await page.goto('/')
     await page.getByPlaceholder('Enter your email').fill('test@example.com')
-    await page.getByRole('button', { name: 'Login' }).click()
+    await page.getByPlaceholder('Password').fill('test-password-123')
+    await page.getByRole('button', { name: 'Log in' }).click()
 
     await expect(page.getByRole('heading', { name: 'Hungr' })).toBeVisible()
 
@@ -34,7 +36,8 @@ test.describe('Authentication', () => {
     // Login first
     await page.goto('/')
     await page.getByPlaceholder('Enter your email').fill('test@example.com')
-    await page.getByRole('button', { name: 'Login' }).click()
+    await page.getByPlaceholder('Password').fill('test-password-123')
+    await page.getByRole('button', { name: 'Log in' }).click()
 
     await expect(page.getByRole('heading', { name: 'Hungr' })).toBeVisible()
 
@@ -5,9 +5,10 @@ import { Browse } from './pages/Browse'
 import { Friends } from './pages/Friends'
 import { Login } from './pages/Login'
 import { Recipe } from './pages/Recipe'
-import { getEmail } from './auth'
-import type { Email } from './branded'
+import { Loading } from './components/Loading'
+import { asEmail } from './branded'
 import type { Page } from './types'
+import { useCurrentUser } from './hooks/useCurrentUser'
 import { usePopState } from './hooks/usePopState'
 import { useWakeServer } from './hooks/useWakeServer'
 
@@ -32,7 +33,7 @@ function getRecipeIdFromPath(): string | null {
 
 function App() {
   const [page, setPage] = useState<Page>(getPageFromPath)
-  const [email, setEmailState] = useState<Email | null>(getEmail)
+  const { user, loading, setUser } = useCurrentUser()
   const [recipeId, setRecipeId] = useState<string | null>(getRecipeIdFromPath)
 
   useWakeServer()
@@ -48,17 +49,19 @@ function App() {
     setPage(newPage)
   }
 
-  const handleLogin = () => {
-    setEmailState(getEmail())
-  }
+  const email = user === null ? null : asEmail(user.email)
 
   // Recipe page is publicly accessible (no auth required)
   if (page === 'recipe' && recipeId !== null) {
     return <Recipe recipeId={recipeId} email={email} onNavigate={navigate} />
   }
 
+  if (loading) {
+    return <Loading />
+  }
+
   if (email === null) {
-    return <Login onLogin={handleLogin} />
+    return <Login onLogin={setUser} />
   }
 
   switch (page) {
@@ -1,5 +1,6 @@
 import type {
   User,
+  LoginResponse,
   RecipesResponse,
   UploadResponse,
   RecipeStepsResponse,
@@ -12,6 +13,7 @@ import {
   getErrorMessage,
   isConnectionsResponse,
   isFileUploadResponse,
+  isLoginResponse,
   isPublicRecipeResponse,
   isRecipeStepsResponse,
   isRecipesResponse,
@@ -54,23 +56,69 @@ export function getFriendlyErrorMessage(err: unknown, fallback: string): string
   return message === 'Failed to fetch' ? FETCH_FAILURE_MESSAGE : message
 }
 
-export async function login(email: string): Promise<void> {
-  const response = await fetch(`${API_BASE}/api/auth/login`, {
+// apiFetch sends the session cookie with every request so the backend knows
+// who is signed in
+const apiFetch = (path: string, init: RequestInit = {}): Promise<Response> =>
+  fetch(`${API_BASE}${path}`, { ...init, credentials: 'include' })
+
+const readLoginResponse = async (response: Response, fallback: string): Promise<User> => {
+  if (!response.ok) {
+    throw new Error(await getErrorFromResponse(response, fallback))
+  }
+  const data = await readJson(response)
+  if (!isLoginResponse(data)) {
+    throw new Error('Unexpected login response from server.')
+  }
+  return data.user
+}
+
+export async function login(email: string, password: string): Promise<User> {
+  const response = await apiFetch('/api/auth/login', {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
-    body: JSON.stringify({ email }),
+    body: JSON.stringify({ email, password }),
   })
+  return readLoginResponse(response, `Failed to login: ${response.status.toString()}`)
+}
+
+export async function register(email: string, name: string, password: string): Promise<User> {
+  const response = await apiFetch('/api/auth/register', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ email, name, password }),
+  })
+  return readLoginResponse(response, `Failed to register: ${response.status.toString()}`)
+}
+
+export async function logout(): Promise<void> {
+  const response = await apiFetch('/api/auth/logout', { method: 'POST' })
+  // An expired session is already logged out
+  if (!response.ok && response.status !== 401) {
+    throw new Error(`Failed to logout: ${response.status.toString()}`)
+  }
+}
+
+export async function getCurrentUser(): Promise<User | null> {
+  const response = await apiFetch('/api/users')
+  if (response.status === 401) {
+    return null
+  }
   if (!response.ok) {
-    throw new Error(`Failed to login: ${response.status.toString()}`)
+    throw new Error(`Failed to fetch user: ${response.status.toString()}`)
+  }
+  const data = await readJson(response)
+  if (!isUserResponse(data)) {
+    throw new Error('Unexpected user response from server.')
   }
+  return data.user
 }
 
 export function getFileURL(path: string): string {
   return `${API_BASE}${path}`
 }
 
-export async function getRecipes(email: Email): Promise<RecipesResponse> {
-  const response = await fetch(`${API_BASE}/api/recipes?email=${encodeURIComponent(email)}`)
+export async function getRecipes(): Promise<RecipesResponse> {
+  const response = await apiFetch('/api/recipes')
   if (!response.ok) {
     throw new Error(`Failed to fetch recipes: ${response.status.toString()}`)
   }
@@ -82,7 +130,6 @@ export async function getRecipes(email: Email): Promise<RecipesResponse> {
 }
 
 export async function createRecipe(
-  email: Email,
   name: string,
   tagString: string,
   source: string | undefined,
@@ -94,7 +141,6 @@ export async function createRecipe(
   }
 
   const params = new URLSearchParams({
-    email,
     name,
     tagString,
   })
@@ -102,7 +148,7 @@ export async function createRecipe(
     params.set('source', source)
   }
 
-  const response = await fetch(`${API_BASE}/api/recipes?${params.toString()}`, {
+  const response = await apiFetch(`/api/recipes?${params.toString()}`, {
     method: 'POST',
     body: formData,
   })
@@ -126,7 +172,7 @@ export async function addRecipeFiles(
     formData.append('file', file)
   }
 
-  const response = await fetch(`${API_BASE}/api/recipes/${encodeURIComponent(recipeUUID)}/files`, {
+  const response = await apiFetch(`/api/recipes/${encodeURIComponent(recipeUUID)}/files`, {
     method: 'POST',
     body: formData,
   })
@@ -147,7 +193,7 @@ export async function addRecipeFiles(
 }
 
 export async function deleteRecipe(recipeUUID: UUID): Promise<void> {
-  const response = await fetch(`${API_BASE}/api/recipes?uuid=${encodeURIComponent(recipeUUID)}`, {
+  const response = await apiFetch(`/api/recipes?uuid=${encodeURIComponent(recipeUUID)}`, {
     method: 'DELETE',
   })
 
@@ -165,7 +211,7 @@ export async function patchRecipe(
   if (source !== undefined) {
     payload.source = source
   }
-  const response = await fetch(`${API_BASE}/api/recipes/${encodeURIComponent(recipeUUID)}`, {
+  const response = await apiFetch(`/api/recipes/${encodeURIComponent(recipeUUID)}`, {
     method: 'PATCH',
     headers: { 'Content-Type': 'application/json' },
     body: JSON.stringify(payload),
@@ -181,7 +227,7 @@ export async function patchRecipe(
 }
 
 export async function getRecipeSteps(recipeUUID: UUID): Promise<RecipeStepsResponse> {
-  const response = await fetch(`${API_BASE}/api/recipes/${encodeURIComponent(recipeUUID)}/steps`)
+  const response = await apiFetch(`/api/recipes/${encodeURIComponent(recipeUUID)}/steps`)
   if (!response.ok) {
     throw new Error(`Failed to fetch recipe steps: ${response.status.toString()}`)
   }
@@ -196,7 +242,7 @@ export async function updateRecipeSteps(
   recipeUUID: UUID,
   steps: RecipeStepsResponse['steps'],
 ): Promise<void> {
-  const response = await fetch(`${API_BASE}/api/recipes/${encodeURIComponent(recipeUUID)}/steps`, {
+  const response = await apiFetch(`/api/recipes/${encodeURIComponent(recipeUUID)}/steps`, {
     method: 'PUT',
     headers: { 'Content-Type': 'application/json' },
     body: JSON.stringify({ steps }),
@@ -211,7 +257,7 @@ export async function updateRecipeSteps(
 }
 
 export async function extractRecipeFromURL(url: string): Promise<RecipeStepsResponse> {
-  const response = await fetch(`${API_BASE}/api/extract-recipe`, {
+  const response = await apiFetch('/api/extract-recipe', {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
     body: JSON.stringify({ url }),
@@ -236,7 +282,7 @@ export async function extractRecipeFromImages(files: File[]): Promise<RecipeStep
     formData.append('images', file)
   }
 
-  const response = await fetch(`${API_BASE}/api/extract-recipe-image`, {
+  const response = await apiFetch('/api/extract-recipe-image', {
     method: 'POST',
     body: formData,
   })
@@ -255,7 +301,7 @@ export async function extractRecipeFromImages(files: File[]): Promise<RecipeStep
 }
 
 export async function extractRecipeFromText(text: string): Promise<RecipeStepsResponse> {
-  const response = await fetch(`${API_BASE}/api/extract-recipe-text`, {
+  const response = await apiFetch('/api/extract-recipe-text', {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
     body: JSON.stringify({ text }),
@@ -275,7 +321,7 @@ export async function extractRecipeFromText(text: string): Promise<RecipeStepsRe
 }
 
 export async function getTags(): Promise<Tag[]> {
-  const response = await fetch(`${API_BASE}/api/tags`)
+  const response = await apiFetch('/api/tags')
   if (!response.ok) {
     throw new Error(`Failed to fetch tags: ${response.status.toString()}`)
   }
@@ -287,7 +333,7 @@ export async function getTags(): Promise<Tag[]> {
 }
 
 export async function getUserByEmail(email: Email): Promise<User> {
-  const response = await fetch(`${API_BASE}/api/users?email=${encodeURIComponent(email)}`)
+  const response = await apiFetch(`/api/users?email=${encodeURIComponent(email)}`)
   if (!response.ok) {
     const message = await getErrorFromResponse(
       response,
@@ -302,12 +348,9 @@ export async function getUserByEmail(email: Email): Promise<User> {
   return data.user
 }
 
-export async function getConnections(
-  userUUID: UUID,
-  direction: 'outgoing' | 'incoming',
-): Promise<User[]> {
-  const params = new URLSearchParams({ user_uuid: userUUID, direction })
-  const response = await fetch(`${API_BASE}/api/connections?${params.toString()}`)
+export async function getConnections(direction: 'outgoing' | 'incoming'): Promise<User[]> {
+  const params = new URLSearchParams({ direction })
+  const response = await apiFetch(`/api/connections?${params.toString()}`)
   if (!response.ok) {
     const message = await getErrorFromResponse(
       response,
@@ -322,8 +365,8 @@ export async function getConnections(
   return data.connections
 }
 
-export async function createConnection(email: Email, targetUserUUID: UUID): Promise<void> {
-  const response = await fetch(`${API_BASE}/api/connections?email=${encodeURIComponent(email)}`, {
+export async function createConnection(targetUserUUID: UUID): Promise<void> {
+  const response = await apiFetch('/api/connections', {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
     body: JSON.stringify({ target_user_uuid: targetUserUUID }),
@@ -337,19 +380,12 @@ export async function createConnection(email: Email, targetUserUUID: UUID): Prom
   }
 }
 
-export async function deleteConnection(
-  email: Email,
-  targetUserUUID: UUID,
-  bidirectional = false,
-): Promise<void> {
-  const params = new URLSearchParams({
-    email,
-    target_user_uuid: targetUserUUID,
-  })
+export async function deleteConnection(targetUserUUID: UUID, bidirectional = false): Promise<void> {
+  const params = new URLSearchParams({ target_user_uuid: targetUserUUID })
   if (bidirectional) {
     params.set('bidirectional', 'true')
   }
-  const response = await fetch(`${API_BASE}/api/connections?${params.toString()}`, {
+  const response = await apiFetch(`/api/connections?${params.toString()}`, {
     method: 'DELETE',
   })
   if (!response.ok) {
@@ -362,7 +398,7 @@ export async function deleteConnection(
 }
 
 export async function getPublicRecipe(recipeUUID: UUID): Promise<PublicRecipeResponse> {
-  const response = await fetch(`${API_BASE}/api/recipes/${encodeURIComponent(recipeUUID)}/public`)
+  const response = await apiFetch(`/api/recipes/${encodeURIComponent(recipeUUID)}/public`)
   if (!response.ok) {
     if (response.status === 404) {
       throw new Error('Recipe not found')
@@ -381,7 +417,7 @@ export async function getPublicRecipe(recipeUUID: UUID): Promise<PublicRecipeRes
 }
 
 export async function setRecipePublic(recipeUUID: UUID, isPublic: boolean): Promise<void> {
-  const response = await fetch(`${API_BASE}/api/recipes/${encodeURIComponent(recipeUUID)}/public`, {
+  const response = await apiFetch(`/api/recipes/${encodeURIComponent(recipeUUID)}/public`, {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
     body: JSON.stringify({ is_public: isPublic }),
@@ -1,5 +1,6 @@
 import type {
   ConnectionsResponse,
+  LoginResponse,
   PublicRecipeResponse,
   RecipesResponse,
   RecipeStepsResponse,
@@ -90,6 +91,13 @@ export const isTagsResponse = (value: unknown): value is { tags: Tag[] } =>
 export const isUserResponse = (value: unknown): value is UserResponse =>
   isRecord(value) && isBoolean(value['success']) && isUser(value['user'])
 
+export const isLoginResponse = (value: unknown): value is LoginResponse =>
+  isRecord(value) &&
+  isBoolean(value['success']) &&
+  isUser(value['user']) &&
+  isString(value['token']) &&
+  isString(value['expires_at'])
+
 export const isConnectionsResponse = (value: unknown): value is ConnectionsResponse =>
   isRecord(value) &&
   isBoolean(value['success']) &&
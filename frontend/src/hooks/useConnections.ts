@@ -1,17 +1,15 @@
 import { useEffect, useEffectEvent } from 'react'
-import { getFriendlyErrorMessage, getUserByEmail } from '../api'
-import { asUUID, type Email, type UUID } from '../branded'
+import { getCurrentUser, getFriendlyErrorMessage } from '../api'
 import type { User } from '../types.gen'
 
 type Params = {
-  email: Email
-  loadConnections: (userUUID: UUID) => Promise<void>
+  loadConnections: () => Promise<void>
   setUser: (user: User | null) => void
   setLoading: (loading: boolean) => void
   setError: (message: string | null) => void
 }
 
-export function useConnections({ email, loadConnections, setUser, setLoading, setError }: Params) {
+export function useConnections({ loadConnections, setUser, setLoading, setError }: Params) {
   // useEffectEvent keeps the effect stable even if loadConnections changes identity.
   const loadConnectionsEvent = useEffectEvent(loadConnections)
 
@@ -19,10 +17,13 @@ export function useConnections({ email, loadConnections, setUser, setLoading, se
     setLoading(true)
     setError(null)
 
-    getUserByEmail(email)
+    getCurrentUser()
       .then((currentUser) => {
+        if (currentUser === null) {
+          throw new Error('You are not logged in')
+        }
         setUser(currentUser)
-        return loadConnectionsEvent(asUUID(currentUser.uuid))
+        return loadConnectionsEvent()
       })
       .catch((err: unknown) => {
         setError(getFriendlyErrorMessage(err, 'Failed to load connections'))
@@ -30,5 +31,5 @@ export function useConnections({ email, loadConnections, setUser, setLoading, se
       .finally(() => {
         setLoading(false)
       })
-  }, [email, setError, setLoading, setUser])
+  }, [setError, setLoading, setUser])
 }
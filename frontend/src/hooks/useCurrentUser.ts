@@ -0,0 +1,40 @@
+import { useEffect, useState } from 'react'
+import { getCurrentUser } from '../api'
+import type { User } from '../types.gen'
+
+type UseCurrentUserResult = {
+  user: User | null
+  loading: boolean
+  setUser: (user: User | null) => void
+}
+
+// Resolves the signed-in user from the session cookie; null means logged out
+export function useCurrentUser(): UseCurrentUserResult {
+  const [user, setUser] = useState<User | null>(null)
+  const [loading, setLoading] = useState(true)
+
+  useEffect(() => {
+    let cancelled = false
+
+    getCurrentUser()
+      .then((currentUser) => {
+        if (!cancelled) {
+          setUser(currentUser)
+        }
+      })
+      .catch(() => {
+        // Treat an unreachable server as logged out; login reports the error
+      })
+      .finally(() => {
+        if (!cancelled) {
+          setLoading(false)
+        }
+      })
+
+    return () => {
+      cancelled = true
+    }
+  }, [])
+
+  return { user, loading, setUser }
+}
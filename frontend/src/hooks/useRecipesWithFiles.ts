@@ -1,21 +1,19 @@
 import { useCallback, useEffect } from 'react'
 import { getRecipes, getFriendlyErrorMessage } from '../api'
-import type { Email } from '../branded'
 import type { File, Recipe } from '../types.gen'
 
 export type RecipeWithFiles = Recipe & { files: File[] }
 
 type Params = {
-  email: Email
   setRecipes: (recipes: RecipeWithFiles[]) => void
   setLoading: (loading: boolean) => void
   setError: (message: string | null) => void
 }
 
-export function useRecipesWithFiles({ email, setRecipes, setLoading, setError }: Params) {
+export function useRecipesWithFiles({ setRecipes, setLoading, setError }: Params) {
   const refetch = useCallback(() => {
     setLoading(true)
-    getRecipes(email)
+    getRecipes()
       .then((response) => {
         const fileData = response.fileData
         const recipesWithFiles = response.recipeData.map((recipe) => ({
@@ -32,7 +30,7 @@ export function useRecipesWithFiles({ email, setRecipes, setLoading, setError }:
       .finally(() => {
         setLoading(false)
       })
-  }, [email, setError, setLoading, setRecipes])
+  }, [setError, setLoading, setRecipes])
 
   useEffect(() => {
     refetch()
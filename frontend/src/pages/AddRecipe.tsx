@@ -21,7 +21,7 @@ import { Header } from '../components/Header'
 import type { RecipeStepResponse as RecipeStep, Recipe, RecipeStepsResponse } from '../types.gen'
 import { asUUID, type Email } from '../branded'
 import type { Page } from '../types'
-import { useRecipesForEmail } from '../hooks/useRecipesForEmail'
+import { useRecipes } from '../hooks/useRecipes'
 
 type Props = {
   email: Email
@@ -45,7 +45,7 @@ export function AddRecipe({ email, currentPage, onNavigate }: Props) {
   const [error, setError] = useState<string | null>(null)
   const [success, setSuccess] = useState(false)
 
-  useRecipesForEmail({ email, setRecipes })
+  useRecipes({ setRecipes })
 
   const handleImageFiles = (newFiles: File[]) => {
     if (newFiles.length === 0) return
@@ -143,7 +143,6 @@ export function AddRecipe({ email, currentPage, onNavigate }: Props) {
       const filesToUpload = inputMode === 'image' ? imageFiles : []
       const sourceParam = newRecipeSource.trim()
       const response = await createRecipe(
-        email,
         newRecipeName,
         newRecipeTags,
         sourceParam === '' ? undefined : sourceParam,
@@ -35,7 +35,7 @@ export function Browse({ email, currentPage, onNavigate }: BrowseProps) {
   const [tagFilter, setTagFilter] = useState<string[]>(initialParams.tags)
   const [deleting, setDeleting] = useState(false)
 
-  const { refetch } = useRecipesWithFiles({ email, setRecipes, setLoading, setError })
+  const { refetch } = useRecipesWithFiles({ setRecipes, setLoading, setError })
 
   const filteredRecipes =
     tagFilter.length === 0
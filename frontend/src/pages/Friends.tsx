@@ -9,7 +9,7 @@ import {
 import { Header } from '../components/Header'
 import { Button } from '../components/Button'
 import { FriendsList, FriendsSection, MutedText } from '../components/Friends'
-import { asEmail, asUUID, isEmail, type Email, type UUID } from '../branded'
+import { asEmail, asUUID, isEmail, type Email } from '../branded'
 import type { Page } from '../types'
 import type { User } from '../types.gen'
 import { useConnections } from '../hooks/useConnections'
@@ -32,17 +32,16 @@ export function Friends({ email, currentPage, onNavigate }: Props) {
   const [accepting, setAccepting] = useState<Record<string, boolean>>({})
   const [deleting, setDeleting] = useState<Record<string, boolean>>({})
 
-  const loadConnections = useCallback(async (userUUID: UUID) => {
+  const loadConnections = useCallback(async () => {
     const [outgoingConnections, incomingConnections] = await Promise.all([
-      getConnections(userUUID, 'outgoing'),
-      getConnections(userUUID, 'incoming'),
+      getConnections('outgoing'),
+      getConnections('incoming'),
     ])
     setOutgoing(outgoingConnections)
     setIncoming(incomingConnections)
   }, [])
 
   useConnections({
-    email,
     loadConnections,
     setUser,
     setLoading,
@@ -78,8 +77,8 @@ export function Friends({ email, currentPage, onNavigate }: Props) {
         if (targetUser.uuid === user.uuid) {
           throw new Error('You cannot connect to yourself')
         }
-        await createConnection(email, asUUID(targetUser.uuid))
-        await loadConnections(asUUID(user.uuid))
+        await createConnection(asUUID(targetUser.uuid))
+        await loadConnections()
         setInviteEmail('')
         setSuccess(`Added ${targetUser.email}`)
       } catch (err: unknown) {
@@ -100,9 +99,9 @@ export function Friends({ email, currentPage, onNavigate }: Props) {
 
     const targetUUID = asUUID(targetUser.uuid)
 
-    void createConnection(email, targetUUID)
+    void createConnection(targetUUID)
       .then(async () => {
-        await loadConnections(asUUID(user.uuid))
+        await loadConnections()
         setSuccess(`Added ${targetUser.email}`)
       })
       .catch((err: unknown) => {
@@ -125,9 +124,9 @@ export function Friends({ email, currentPage, onNavigate }: Props) {
     // For outgoing: delete only our outgoing connection
     const bidirectional = mode === 'mutual'
 
-    void deleteConnection(email, targetUUID, bidirectional)
+    void deleteConnection(targetUUID, bidirectional)
       .then(async () => {
-        await loadConnections(asUUID(user.uuid))
+        await loadConnections()
         setSuccess(`Removed ${targetUser.email}`)
       })
       .catch((err: unknown) => {
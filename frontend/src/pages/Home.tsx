@@ -1,4 +1,4 @@
-import { clearEmail } from '../auth'
+import { logout } from '../api'
 import { Button } from '../components/Button'
 import { Header } from '../components/Header'
 import type { Email } from '../branded'
@@ -12,8 +12,13 @@ type Props = {
 
 export function Home({ onNavigate, email, currentPage }: Props) {
   const handleLogout = () => {
-    clearEmail()
-    window.location.reload()
+    void logout()
+      .catch(() => {
+        // Reload anyway; the session expires on its own
+      })
+      .finally(() => {
+        window.location.reload()
+      })
   }
 
   return (
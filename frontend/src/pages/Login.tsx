@@ -1,15 +1,19 @@
 import { useState } from 'react'
-import { setEmail } from '../auth'
-import { getFriendlyErrorMessage, login } from '../api'
+import { getFriendlyErrorMessage, login, register } from '../api'
 import { Button } from '../components/Button'
-import { asEmail } from '../branded'
+import type { User } from '../types.gen'
 
 type Props = {
-  onLogin: () => void
+  onLogin: (user: User) => void
 }
 
+type Mode = 'login' | 'register'
+
 export function Login({ onLogin }: Props) {
+  const [mode, setMode] = useState<Mode>('login')
   const [email, setEmailValue] = useState('')
+  const [name, setName] = useState('')
+  const [password, setPassword] = useState('')
   const [error, setError] = useState<string | null>(null)
   const [loading, setLoading] = useState(false)
 
@@ -20,31 +24,48 @@ export function Login({ onLogin }: Props) {
       setError('Please enter a valid email address')
       return
     }
+    if (password === '') {
+      setError('Please enter your password')
+      return
+    }
 
     setLoading(true)
     setError(null)
 
-    login(trimmed)
-      .then(() => {
-        setEmail(asEmail(trimmed))
-        onLogin()
+    const request =
+      mode === 'login' ? login(trimmed, password) : register(trimmed, name.trim(), password)
+    request
+      .then((user) => {
+        onLogin(user)
       })
       .catch((err: unknown) => {
-        setError(getFriendlyErrorMessage(err, 'Login failed'))
+        setError(
+          getFriendlyErrorMessage(err, mode === 'login' ? 'Login failed' : 'Registration failed'),
+        )
       })
       .finally(() => {
         setLoading(false)
       })
   }
 
+  const toggleMode = () => {
+    setMode(mode === 'login' ? 'register' : 'login')
+    setError(null)
+  }
+
+  const submitLabel =
+    mode === 'login'
+      ? loading
+        ? 'Logging in...'
+        : 'Log in'
+      : loading
+        ? 'Creating account...'
+        : 'Create account'
+
   return (
     <div className="center">
       <div style={{ maxWidth: '400px', width: '100%', padding: '2rem' }}>
         <h1>Hungr</h1>
-        <p style={{ color: '#f59e0b', marginBottom: '1.5rem' }}>
-          This is not secure authentication. This email can be fake, it will be linked to your
-          recipes and anyone who enters this email can see whatever is associated with it.
-        </p>
         <form onSubmit={handleSubmit}>
           <input
             type="email"
@@ -54,13 +75,44 @@ export function Login({ onLogin }: Props) {
             onChange={(e) => {
               setEmailValue(e.target.value)
             }}
+            autoComplete="email"
             autoFocus
           />
+          {mode === 'register' && (
+            <input
+              type="text"
+              placeholder="Your name (optional)"
+              className="input"
+              value={name}
+              onChange={(e) => {
+                setName(e.target.value)
+              }}
+              autoComplete="name"
+            />
+          )}
+          <input
+            type="password"
+            placeholder="Password"
+            className="input"
+            value={password}
+            onChange={(e) => {
+              setPassword(e.target.value)
+            }}
+            autoComplete={mode === 'login' ? 'current-password' : 'new-password'}
+          />
           {error !== null && <p className="error">{error}</p>}
           <Button type="submit" style={{ width: '100%' }} disabled={loading}>
-            {loading ? 'Logging in...' : 'Continue'}
+            {submitLabel}
           </Button>
         </form>
+        <Button
+          type="button"
+          variant="secondary"
+          style={{ width: '100%', marginTop: '1rem' }}
+          onClick={toggleMode}
+        >
+          {mode === 'login' ? 'Need an account? Register' : 'Have an account? Log in'}
+        </Button>
       </div>
     </div>
   )
@@ -109,8 +109,16 @@ export interface TagsResponse {
   tags: Tag[]
 }
 export interface PatchRecipeRequest {
-  tagString: string
+  /**
+   * TagString replaces all of the recipe's tags when present; "" removes them
+   */
+  tagString?: string
   source?: string
+  /**
+   * Servings and YieldText are left unchanged when omitted; 0 or "" clears them
+   */
+  servings?: number /* int */
+  yield_text?: string
 }
 export interface SetPublicRequest {
   is_public: boolean
@@ -122,6 +130,58 @@ export interface PublicRecipeResponse {
   tags: string[]
 }
 
+//////////
+// source: session.go
+
+export interface Session {
+  uuid: string
+  user_uuid: string
+  user_agent: string
+  created_at: string
+  expires_at: string
+  last_used_at?: string
+  current: boolean
+}
+export interface LoginRequest {
+  email: string
+  password: string
+}
+export interface RegisterRequest {
+  email: string
+  name: string
+  password: string
+}
+/**
+ * EmailRequest starts an emailed flow (magic link, password reset, verification)
+ */
+export interface EmailRequest {
+  email: string
+}
+/**
+ * TokenRequest redeems a one-time token received by email
+ */
+export interface TokenRequest {
+  token: string
+}
+export interface PasswordResetRequest {
+  token: string
+  password: string
+}
+export interface ChangePasswordRequest {
+  current_password: string
+  new_password: string
+}
+export interface LoginResponse {
+  success: boolean
+  user: User
+  token: string
+  expires_at: string
+}
+export interface SessionsResponse {
+  success: boolean
+  sessions: Session[]
+}
+
 //////////
 // source: user.go
 
@@ -135,7 +195,3 @@ export interface UserResponse {
   success: boolean
   user: User
 }
-export interface CreateUserRequest {
-  email: string
-  name: string
-}